@@ -0,0 +1,335 @@
+package apiv1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"gin-doniai/caches"
+	"gin-doniai/config"
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const basePath = "/api/v1"
+
+// testUserHeader 测试中用于模拟登录的请求头，值为用户ID
+const testUserHeader = "X-Test-User"
+
+type fixtures struct {
+	author     models.User
+	other      models.User
+	unverified models.User
+	category   models.Category
+	post       models.Post
+	comment    models.Comment
+}
+
+// setupContract 使用内存SQLite初始化数据库和路由
+func setupContract(t *testing.T) (*gin.Engine, *Document, fixtures) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+
+	var f fixtures
+	f.author = models.User{Name: "author", Email: "author@example.com", Password: "x", Avatar: "/a.png"}
+	f.other = models.User{Name: "other", Email: "other@example.com", Password: "x", Avatar: "/b.png"}
+	f.unverified = models.User{Name: "new", Email: "new@example.com", Password: "x", Avatar: "/c.png", EmailStatus: models.EmailStatusUnverified}
+	for _, user := range []*models.User{&f.author, &f.other, &f.unverified} {
+		mustCreate(t, db, user)
+	}
+	f.category = models.Category{Name: "Go", Alias: "go", StatusCode: 1}
+	mustCreate(t, db, &f.category)
+	f.post = models.Post{Title: "Hello", UserId: int(f.author.ID), Author: f.author.Name, Category: "Go",
+		CategoryId: int(f.category.ID), Content: "<p>正文</p>", Tags: `["go"]`}
+	mustCreate(t, db, &f.post)
+	f.comment = models.Comment{Content: "<p>评论</p>", PostID: f.post.ID, UserID: f.other.ID}
+	mustCreate(t, db, &f.comment)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader(testUserHeader); id != "" {
+			var user models.User
+			if err := db.First(&user, id).Error; err == nil {
+				c.Set("user", &user)
+			}
+		}
+		c.Next()
+	})
+	api := New(services.New(repositories.NewGorm(db), caches.New(caches.NewMemoryStore(64)), func(string, interface{}) {}, config.Default))
+	Register(router.Group(basePath), api)
+
+	return router, BuildOpenAPI(basePath, api.Routes()), f
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("创建测试数据失败: %v", err)
+	}
+}
+
+// TestSpecMatchesRouter 路由表中的每个接口都必须出现在文档中，反之亦然
+func TestSpecMatchesRouter(t *testing.T) {
+	router, doc, _ := setupContract(t)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		path := strings.TrimPrefix(route.Path, basePath)
+		if path == "/openapi.json" {
+			continue
+		}
+		registered[strings.ToLower(route.Method)+" "+OpenAPIPath(path)] = true
+	}
+
+	documented := make(map[string]bool)
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for key := range registered {
+		if !documented[key] {
+			t.Errorf("接口 %s 未出现在OpenAPI文档中", key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("文档中的 %s 没有对应的路由", key)
+		}
+	}
+}
+
+// TestOpenAPIEndpoint 文档接口返回合法的JSON且引用的schema都存在
+func TestOpenAPIEndpoint(t *testing.T) {
+	router, _, _ := setupContract(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, basePath+"/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 %d", w.Code)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("文档不是合法JSON: %v", err)
+	}
+	if doc.OpenAPI == "" || len(doc.Paths) == 0 {
+		t.Fatalf("文档缺少 openapi 或 paths")
+	}
+
+	raw := w.Body.String()
+	for _, part := range strings.Split(raw, `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("引用的schema %s 不存在", name)
+		}
+	}
+}
+
+type contractCase struct {
+	name   string
+	method string
+	path   string // 实际请求路径（不含前缀）
+	route  string // 文档中的路径模板
+	user   uint
+	body   interface{}
+	status int
+}
+
+// TestResponsesMatchSpec 实际请求的状态码必须在文档中声明，响应体必须符合对应schema
+func TestResponsesMatchSpec(t *testing.T) {
+	router, doc, f := setupContract(t)
+	postPath := "/posts/" + strconv.Itoa(int(f.post.ID))
+
+	cases := []contractCase{
+		{"文章列表", "GET", "/posts?limit=1", "/posts", 0, nil, 200},
+		{"文章列表按标签过滤", "GET", "/posts?tag=go&sort=-views", "/posts", 0, nil, 200},
+		{"文章列表非法排序", "GET", "/posts?sort=password", "/posts", 0, nil, 400},
+		{"文章列表非法游标", "GET", "/posts?cursor=bad", "/posts", 0, nil, 400},
+		{"文章详情", "GET", postPath, "/posts/{id}", 0, nil, 200},
+		{"文章不存在", "GET", "/posts/9999", "/posts/{id}", 0, nil, 404},
+		{"未登录发文", "POST", "/posts", "/posts", 0, map[string]interface{}{"title": "t"}, 401},
+		{"未验证邮箱发文", "POST", "/posts", "/posts", f.unverified.ID,
+			map[string]interface{}{"title": "t", "category_id": f.category.ID, "content": "c"}, 403},
+		{"发文缺少参数", "POST", "/posts", "/posts", f.author.ID, map[string]interface{}{"title": "t"}, 400},
+		{"发文分类无效", "POST", "/posts", "/posts", f.author.ID,
+			map[string]interface{}{"title": "t", "category_id": 9999, "content": "c"}, 400},
+		{"发文", "POST", "/posts", "/posts", f.author.ID,
+			map[string]interface{}{"title": "新文章", "category_id": f.category.ID, "content": "<p>c</p>", "tags": "go,gin"}, 201},
+		{"评论列表", "GET", "/comments?post_id=" + strconv.Itoa(int(f.post.ID)), "/comments", 0, nil, 200},
+		{"评论文章不存在", "POST", "/comments", "/comments", f.other.ID,
+			map[string]interface{}{"content": "hi", "post_id": 9999}, 404},
+		{"发表评论", "POST", "/comments", "/comments", f.other.ID,
+			map[string]interface{}{"content": "hi", "post_id": f.post.ID}, 201},
+		{"用户列表", "GET", "/users", "/users", f.other.ID, nil, 200},
+		{"用户详情", "GET", "/users/" + strconv.Itoa(int(f.author.ID)), "/users/{id}", 0, nil, 200},
+		{"用户不存在", "GET", "/users/9999", "/users/{id}", 0, nil, 404},
+		{"当前用户", "GET", "/me", "/me", f.author.ID, nil, 200},
+		{"当前用户未登录", "GET", "/me", "/me", 0, nil, 401},
+		{"分类列表", "GET", "/categories", "/categories", 0, nil, 200},
+		{"社区统计", "GET", "/stats", "/stats", 0, nil, 200},
+		{"删除他人文章", "DELETE", postPath, "/posts/{id}", f.other.ID, nil, 403},
+		{"删除文章", "DELETE", postPath, "/posts/{id}", f.author.ID, nil, 204},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tc.body != nil {
+				json.NewEncoder(&body).Encode(tc.body)
+			}
+			req := httptest.NewRequest(tc.method, basePath+tc.path, &body)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.user != 0 {
+				req.Header.Set(testUserHeader, strconv.Itoa(int(tc.user)))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("期望状态码 %d，实际 %d: %s", tc.status, w.Code, w.Body.String())
+			}
+
+			op := doc.Paths[tc.route][strings.ToLower(tc.method)]
+			if op == nil {
+				t.Fatalf("文档中没有 %s %s", tc.method, tc.route)
+			}
+			resp, ok := op.Responses[strconv.Itoa(w.Code)]
+			if !ok {
+				t.Fatalf("状态码 %d 未在文档中声明", w.Code)
+			}
+
+			media, hasBody := resp.Content["application/json"]
+			if !hasBody {
+				if w.Body.Len() != 0 {
+					t.Fatalf("文档声明无响应体，实际返回: %s", w.Body.String())
+				}
+				return
+			}
+
+			var value interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &value); err != nil {
+				t.Fatalf("响应不是合法JSON: %v", err)
+			}
+			if errs := validate(doc, media.Schema, value, "$"); len(errs) > 0 {
+				t.Fatalf("响应不符合schema:\n%s\n%s", strings.Join(errs, "\n"), w.Body.String())
+			}
+		})
+	}
+}
+
+// TestPostSecretsNeverSerialized 用户相关响应中不能出现密码字段
+func TestPostSecretsNeverSerialized(t *testing.T) {
+	router, _, f := setupContract(t)
+
+	for _, path := range []string{"/users", "/users/" + strconv.Itoa(int(f.author.ID)), "/me", "/comments"} {
+		req := httptest.NewRequest(http.MethodGet, basePath+path, nil)
+		req.Header.Set(testUserHeader, strconv.Itoa(int(f.author.ID)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if strings.Contains(w.Body.String(), "password") {
+			t.Errorf("%s 的响应包含 password 字段: %s", path, w.Body.String())
+		}
+	}
+}
+
+// validate 按schema校验JSON值（支持本项目生成的schema子集），不允许出现未声明的字段
+func validate(doc *Document, schema *Schema, value interface{}, path string) []string {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := doc.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: 引用的schema %s 不存在", path, name)}
+		}
+		return validate(doc, resolved, value, path)
+	}
+
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return []string{path + ": 不能为null"}
+	}
+
+	var errs []string
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{path + ": 应为object"}
+		}
+		for _, name := range schema.Required {
+			if _, exists := obj[name]; !exists {
+				errs = append(errs, fmt.Sprintf("%s: 缺少必填字段 %s", path, name))
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prop, ok := schema.Properties[key]; ok {
+				errs = append(errs, validate(doc, prop, obj[key], path+"."+key)...)
+			} else if schema.AdditionalProperties != nil {
+				errs = append(errs, validate(doc, schema.AdditionalProperties, obj[key], path+"."+key)...)
+			} else {
+				errs = append(errs, fmt.Sprintf("%s: 未在文档中声明的字段 %s", path, key))
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{path + ": 应为array"}
+		}
+		for i, item := range items {
+			errs = append(errs, validate(doc, schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{path + ": 应为string"}
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			errs = append(errs, fmt.Sprintf("%s: %q 不在枚举值中", path, s))
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []string{path + ": 应为integer"}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{path + ": 应为number"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{path + ": 应为boolean"}
+		}
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
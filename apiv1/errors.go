@@ -0,0 +1,38 @@
+package apiv1
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// 错误码，客户端应根据 code 而不是 message 判断错误类型
+const (
+	CodeInvalidRequest    = "invalid_request"    // 参数缺失或格式错误
+	CodeUnauthorized      = "unauthorized"       // 未登录或令牌无效
+	CodeForbidden         = "forbidden"          // 无权操作该资源
+	CodeInsufficientScope = "insufficient_scope" // API令牌缺少所需权限
+	CodeEmailUnverified   = "email_unverified"   // 邮箱未验证
+	CodeNotFound          = "not_found"          // 资源不存在
+	CodeInternal          = "internal_error"     // 服务器内部错误
+)
+
+// ErrorCodes 全部错误码，用于生成OpenAPI文档
+var ErrorCodes = []string{
+	CodeInvalidRequest, CodeUnauthorized, CodeForbidden, CodeInsufficientScope,
+	CodeEmailUnverified, CodeNotFound, CodeInternal,
+}
+
+// Error 统一的错误信息
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// abortWithError 返回错误响应并终止后续处理
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: Error{Code: code, Message: message}})
+}
@@ -0,0 +1,193 @@
+package apiv1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"gin-doniai/dto"
+	"gin-doniai/handlers"
+	"gin-doniai/pagination"
+	"gin-doniai/services"
+	"gin-doniai/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// parseList 解析列表参数，参数错误时返回400
+func parseList(c *gin.Context, spec pagination.Spec) (*pagination.Query, bool) {
+	query, err := pagination.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return nil, false
+	}
+	return query, true
+}
+
+// bindJSON 解析请求体，格式错误时返回400
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		abortWithError(c, http.StatusBadRequest, CodeInvalidRequest, "请求参数错误: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// API v1接口，通过构造函数注入业务服务
+type API struct {
+	svc *services.Services
+}
+
+// New 创建v1接口
+func New(svc *services.Services) *API {
+	return &API{svc: svc}
+}
+
+// serviceError 业务错误对应的状态码和错误码，未知错误返回500和 fallback 消息
+func serviceError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrPostNotFound), errors.Is(err, services.ErrCommentNotFound), errors.Is(err, services.ErrUserNotFound):
+		abortWithError(c, http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidCategory):
+		abortWithError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		abortWithError(c, http.StatusForbidden, CodeForbidden, err.Error())
+	case errors.Is(err, services.ErrEmailNotVerified):
+		abortWithError(c, http.StatusForbidden, CodeEmailUnverified, err.Error())
+	default:
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, fallback)
+	}
+}
+
+// paramID 解析路径中的 :id，格式错误按不存在处理
+func paramID(c *gin.Context) uint {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	return uint(id)
+}
+
+func (a *API) listPosts(c *gin.Context) {
+	query, ok := parseList(c, services.PostListSpec)
+	if !ok {
+		return
+	}
+
+	posts, info, err := a.svc.Posts.List(query)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, "获取文章失败")
+		return
+	}
+	c.JSON(http.StatusOK, PostListResponse{Data: dto.NewPosts(posts), Pagination: info})
+}
+
+func (a *API) getPost(c *gin.Context) {
+	post, err := a.svc.Posts.Get(paramID(c))
+	if err != nil {
+		serviceError(c, err, "获取文章失败")
+		return
+	}
+	c.JSON(http.StatusOK, PostResponse{Data: dto.NewPost(*post, true)})
+}
+
+func (a *API) createPost(c *gin.Context) {
+	user := handlers.CurrentUserFromContext(c)
+	var input services.PostInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	post, err := a.svc.Posts.Create(user, input)
+	if err != nil {
+		serviceError(c, err, "文章创建失败")
+		return
+	}
+	c.JSON(http.StatusCreated, PostResponse{Data: dto.NewPost(*post, true)})
+}
+
+func (a *API) deletePost(c *gin.Context) {
+	err := a.svc.Posts.Delete(handlers.CurrentUserFromContext(c), paramID(c))
+	if errors.Is(err, services.ErrForbidden) {
+		abortWithError(c, http.StatusForbidden, CodeForbidden, "只能删除自己的文章")
+		return
+	}
+	if err != nil {
+		serviceError(c, err, "文章删除失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (a *API) listComments(c *gin.Context) {
+	query, ok := parseList(c, services.CommentListSpec)
+	if !ok {
+		return
+	}
+
+	comments, info, err := a.svc.Comments.List(query)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, "获取评论失败")
+		return
+	}
+	c.JSON(http.StatusOK, CommentListResponse{Data: dto.NewComments(comments), Pagination: info})
+}
+
+func (a *API) createComment(c *gin.Context) {
+	user := handlers.CurrentUserFromContext(c)
+	var input services.CommentInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	comment, err := a.svc.Comments.Create(user, input)
+	if err != nil {
+		serviceError(c, err, "评论创建失败")
+		return
+	}
+	comment.User = *user
+	c.JSON(http.StatusCreated, CommentResponse{Data: dto.NewComment(*comment)})
+}
+
+func (a *API) listUsers(c *gin.Context) {
+	current := handlers.CurrentUserFromContext(c)
+	query, ok := parseList(c, services.UserListSpecFor(current))
+	if !ok {
+		return
+	}
+
+	users, info, err := a.svc.Users.List(query)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, "获取用户失败")
+		return
+	}
+
+	result := make([]dto.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, dto.NewUser(user, services.CanSeePrivate(current, user.ID)))
+	}
+	c.JSON(http.StatusOK, UserListResponse{Data: result, Pagination: info})
+}
+
+func (a *API) getUser(c *gin.Context) {
+	user, err := a.svc.Users.Get(paramID(c))
+	if err != nil {
+		serviceError(c, err, "获取用户失败")
+		return
+	}
+	current := handlers.CurrentUserFromContext(c)
+	c.JSON(http.StatusOK, UserResponse{Data: dto.NewUser(*user, services.CanSeePrivate(current, user.ID))})
+}
+
+func (a *API) getCurrentUser(c *gin.Context) {
+	user := handlers.CurrentUserFromContext(c)
+	c.JSON(http.StatusOK, UserResponse{Data: dto.NewUser(*user, true)})
+}
+
+func (a *API) listCategories(c *gin.Context) {
+	categories, err := a.svc.Categories.CachedActive()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, "获取分类失败")
+		return
+	}
+	c.JSON(http.StatusOK, CategoryListResponse{Data: dto.NewCategories(categories)})
+}
+
+func (a *API) getStats(c *gin.Context) {
+	c.JSON(http.StatusOK, StatsResponse{Data: stats.Default().Snapshot()})
+}
@@ -0,0 +1,294 @@
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"gin-doniai/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// Schema OpenAPI 3 Schema对象（只包含本项目用到的字段）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Description          string             `json:"description,omitempty"`
+}
+
+// Parameter OpenAPI参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType OpenAPI媒体类型
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// RequestBody OpenAPI请求体
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response OpenAPI响应
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Operation OpenAPI操作
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Document OpenAPI文档
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       map[string]string                `json:"info"`
+	Servers    []map[string]string              `json:"servers"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas         map[string]*Schema           `json:"schemas"`
+		SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+var ginParamPattern = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// OpenAPIPath 把gin路由格式转换为OpenAPI路径格式（:id → {id}）
+func OpenAPIPath(path string) string {
+	return ginParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+// BuildOpenAPI 根据路由定义生成OpenAPI文档，basePath 为接口前缀（如 /api/v1）
+func BuildOpenAPI(basePath string, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: map[string]string{
+			"title":       "Doniai API",
+			"version":     "1.0.0",
+			"description": "错误响应统一为 {\"error\": {\"code\", \"message\"}}，code 取值见 Error 结构",
+		},
+		Servers: []map[string]string{{"url": basePath}},
+		Paths:   make(map[string]map[string]*Operation),
+	}
+	doc.Components.Schemas = make(map[string]*Schema)
+	doc.Components.SecuritySchemes = map[string]map[string]string{
+		"bearerAuth": {"type": "http", "scheme": "bearer", "description": "个人API令牌"},
+		"cookieAuth": {"type": "apiKey", "in": "cookie", "name": "mysession", "description": "网页登录会话，写操作需要 X-CSRF-Token 请求头"},
+	}
+
+	gen := &schemaGenerator{components: doc.Components.Schemas}
+	errorSchema := gen.schemaFor(reflect.TypeOf(ErrorResponse{}), false)
+	doc.Components.Schemas["Error"].Properties["code"].Enum = ErrorCodes
+
+	for _, route := range routes {
+		op := &Operation{
+			OperationID: route.OperationID,
+			Summary:     route.Summary,
+			Tags:        []string{route.Tag},
+			Responses:   make(map[string]Response),
+		}
+
+		for _, match := range ginParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: match[1], In: "path", Required: true,
+				Schema: &Schema{Type: "integer", Format: "int64"},
+			})
+		}
+		if route.List != nil {
+			op.Parameters = append(op.Parameters, listParameters(*route.List)...)
+		}
+
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: gen.schemaFor(reflect.TypeOf(route.Request), true)},
+				},
+			}
+		}
+
+		success := Response{Description: http.StatusText(route.Status)}
+		if route.Response != nil {
+			success.Content = map[string]MediaType{
+				"application/json": {Schema: gen.schemaFor(reflect.TypeOf(route.Response), false)},
+			}
+		}
+		op.Responses[strconv.Itoa(route.Status)] = success
+
+		for _, status := range route.Errors {
+			op.Responses[strconv.Itoa(status)] = errorResponse(status, errorSchema)
+		}
+		if route.Auth {
+			op.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+			for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+				op.Responses[strconv.Itoa(status)] = errorResponse(status, errorSchema)
+			}
+		}
+
+		path := OpenAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+func errorResponse(status int, schema *Schema) Response {
+	return Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// listParameters 根据分页规则生成 limit、cursor、sort 和过滤参数
+func listParameters(spec pagination.Spec) []Parameter {
+	sorts := make([]string, 0, len(spec.Sorts)*2)
+	for name := range spec.Sorts {
+		sorts = append(sorts, name, "-"+name)
+	}
+	sort.Strings(sorts)
+
+	params := []Parameter{
+		{Name: "limit", In: "query", Description: "每页数量，最大 " + strconv.Itoa(spec.MaxLimit), Schema: &Schema{Type: "integer"}},
+		{Name: "cursor", In: "query", Description: "上一页响应中的 next_cursor", Schema: &Schema{Type: "string"}},
+		{Name: "sort", In: "query", Description: "排序字段，- 前缀表示降序，默认 " + spec.DefaultSort, Schema: &Schema{Type: "string", Enum: sorts}},
+	}
+	for _, filter := range spec.Filters {
+		schema := &Schema{Type: "string"}
+		switch filter.Kind {
+		case pagination.KindInt:
+			schema = &Schema{Type: "integer"}
+		case pagination.KindBool:
+			schema = &Schema{Type: "boolean"}
+		case pagination.KindTime:
+			schema = &Schema{Type: "string", Description: "RFC3339 或 2006-01-02"}
+		}
+		description := "按 " + filter.Column + " 过滤"
+		if filter.Op == pagination.OpContains {
+			description = filter.Column + " 包含该值"
+		} else if filter.Op == pagination.OpElement {
+			description = filter.Column + " 中有与该值完全相同的一项（不区分大小写）"
+		} else if filter.Op != pagination.OpEq {
+			description = filter.Column + " " + filter.Op + " 该值"
+		}
+		params = append(params, Parameter{Name: filter.Param, In: "query", Description: description, Schema: schema})
+	}
+	return params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator 通过反射把Go类型转换为Schema，具名结构体放入components
+type schemaGenerator struct {
+	components map[string]*Schema
+}
+
+// schemaFor request 为 true 时按 binding:"required" 判断必填，否则非 omitempty 的字段均为必填
+func (g *schemaGenerator) schemaFor(t reflect.Type, request bool) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		name := t.Name()
+		if _, exists := g.components[name]; !exists {
+			// 先占位，避免递归类型无限展开
+			g.components[name] = &Schema{}
+			*g.components[name] = *g.structSchema(t, request)
+		}
+		// $ref 不能与其他字段并列，可空的结构体指针通过不列入 required 表示
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = &Schema{Type: "array", Items: g.schemaFor(t.Elem(), request)}
+	case t.Kind() == reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem(), request)}
+	case t.Kind() == reflect.Bool:
+		schema = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = &Schema{Type: "integer", Format: "int64"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = &Schema{Type: "number", Format: "double"}
+	case t.Kind() == reflect.String:
+		schema = &Schema{Type: "string"}
+	default:
+		schema = &Schema{}
+	}
+	schema.Nullable = nullable
+	return schema
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type, request bool) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = g.schemaFor(field.Type, request)
+
+		required := !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr
+		if request {
+			required = strings.Contains(field.Tag.Get("binding"), "required")
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  *Document
+)
+
+// serveOpenAPI 返回OpenAPI文档（首次请求时生成）
+func serveOpenAPI(basePath string, routes []Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		openAPIOnce.Do(func() {
+			openAPIDoc = BuildOpenAPI(basePath, routes)
+		})
+		c.JSON(http.StatusOK, openAPIDoc)
+	}
+}
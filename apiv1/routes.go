@@ -0,0 +1,134 @@
+package apiv1
+
+import (
+	"net/http"
+	"gin-doniai/handlers"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Route 一个v1接口的定义，同时用于注册路由和生成OpenAPI文档
+type Route struct {
+	Method      string
+	Path        string // gin路由格式，如 /posts/:id
+	OperationID string
+	Summary     string
+	Tag         string
+	Auth        bool             // 是否需要登录（session或API令牌）
+	Scope       string           // API令牌所需权限，为空时只要求 read
+	List        *pagination.Spec // 列表接口的分页、排序和过滤参数
+	Request     interface{}      // 请求体类型
+	Status      int              // 成功状态码
+	Response    interface{}      // 成功响应类型，nil表示无响应体
+	Errors      []int            // 可能返回的错误状态码
+	Handler     gin.HandlerFunc
+}
+
+// Routes 全部v1接口
+func (a *API) Routes() []Route {
+	return []Route{
+		{
+			Method: http.MethodGet, Path: "/posts", OperationID: "listPosts", Summary: "文章列表", Tag: "posts",
+			List: &services.PostListSpec, Status: http.StatusOK, Response: PostListResponse{},
+			Errors: []int{http.StatusBadRequest}, Handler: a.listPosts,
+		},
+		{
+			Method: http.MethodPost, Path: "/posts", OperationID: "createPost", Summary: "发表文章", Tag: "posts",
+			Auth: true, Scope: models.ScopeWritePosts, Request: services.PostInput{},
+			Status: http.StatusCreated, Response: PostResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}, Handler: a.createPost,
+		},
+		{
+			Method: http.MethodGet, Path: "/posts/:id", OperationID: "getPost", Summary: "文章详情", Tag: "posts",
+			Status: http.StatusOK, Response: PostResponse{},
+			Errors: []int{http.StatusNotFound}, Handler: a.getPost,
+		},
+		{
+			Method: http.MethodDelete, Path: "/posts/:id", OperationID: "deletePost", Summary: "删除文章（作者或管理员）", Tag: "posts",
+			Auth: true, Scope: models.ScopeWritePosts, Status: http.StatusNoContent,
+			Errors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}, Handler: a.deletePost,
+		},
+		{
+			Method: http.MethodGet, Path: "/comments", OperationID: "listComments", Summary: "评论列表", Tag: "comments",
+			List: &services.CommentListSpec, Status: http.StatusOK, Response: CommentListResponse{},
+			Errors: []int{http.StatusBadRequest}, Handler: a.listComments,
+		},
+		{
+			Method: http.MethodPost, Path: "/comments", OperationID: "createComment", Summary: "发表评论", Tag: "comments",
+			Auth: true, Scope: models.ScopeWriteComments, Request: services.CommentInput{},
+			Status: http.StatusCreated, Response: CommentResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}, Handler: a.createComment,
+		},
+		{
+			Method: http.MethodGet, Path: "/users", OperationID: "listUsers", Summary: "用户列表", Tag: "users",
+			List: &services.UserListSpec, Status: http.StatusOK, Response: UserListResponse{},
+			Errors: []int{http.StatusBadRequest}, Handler: a.listUsers,
+		},
+		{
+			Method: http.MethodGet, Path: "/users/:id", OperationID: "getUser", Summary: "用户详情", Tag: "users",
+			Status: http.StatusOK, Response: UserResponse{},
+			Errors: []int{http.StatusNotFound}, Handler: a.getUser,
+		},
+		{
+			Method: http.MethodGet, Path: "/me", OperationID: "getCurrentUser", Summary: "当前登录用户", Tag: "users",
+			Auth: true, Status: http.StatusOK, Response: UserResponse{},
+			Errors: []int{http.StatusUnauthorized, http.StatusForbidden}, Handler: a.getCurrentUser,
+		},
+		{
+			Method: http.MethodGet, Path: "/categories", OperationID: "listCategories", Summary: "分类列表", Tag: "categories",
+			Status: http.StatusOK, Response: CategoryListResponse{},
+			Errors: []int{http.StatusInternalServerError}, Handler: a.listCategories,
+		},
+		{
+			Method: http.MethodGet, Path: "/stats", OperationID: "getStats", Summary: "社区统计", Tag: "stats",
+			Status: http.StatusOK, Response: StatsResponse{}, Handler: a.getStats,
+		},
+	}
+}
+
+// Register 在 group（通常为 /api/v1）下注册全部接口和OpenAPI文档
+func Register(group *gin.RouterGroup, api *API) {
+	routes := api.Routes()
+	for _, route := range routes {
+		handlersChain := []gin.HandlerFunc{}
+		if route.Auth {
+			handlersChain = append(handlersChain, requireAuth(route.Scope))
+		}
+		handlersChain = append(handlersChain, route.Handler)
+		group.Handle(route.Method, route.Path, handlersChain...)
+	}
+	group.GET("/openapi.json", serveOpenAPI(group.BasePath(), routes))
+}
+
+// requireAuth 要求已登录；使用API令牌时，GET请求需要read权限，其他请求需要 scope 权限
+func requireAuth(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handlers.CurrentUserFromContext(c) == nil {
+			abortWithError(c, http.StatusUnauthorized, CodeUnauthorized, "请先登录或提供API令牌")
+			return
+		}
+
+		tokenObj, exists := c.Get("api_token")
+		if !exists {
+			c.Next()
+			return
+		}
+		token, ok := tokenObj.(*models.APIToken)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		required := models.ScopeRead
+		if c.Request.Method != http.MethodGet && scope != "" {
+			required = scope
+		}
+		if !token.HasScope(required) {
+			abortWithError(c, http.StatusForbidden, CodeInsufficientScope, "API令牌缺少权限: "+required)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package apiv1
+
+import (
+	"gin-doniai/dto"
+	"gin-doniai/pagination"
+	"gin-doniai/stats"
+)
+
+// 成功响应统一为 {"data": ...}，列表额外带 pagination
+
+// PostResponse 单篇文章
+type PostResponse struct {
+	Data dto.Post `json:"data"`
+}
+
+// PostListResponse 文章列表
+type PostListResponse struct {
+	Data       []dto.Post          `json:"data"`
+	Pagination pagination.PageInfo `json:"pagination"`
+}
+
+// CommentResponse 单条评论
+type CommentResponse struct {
+	Data dto.Comment `json:"data"`
+}
+
+// CommentListResponse 评论列表
+type CommentListResponse struct {
+	Data       []dto.Comment       `json:"data"`
+	Pagination pagination.PageInfo `json:"pagination"`
+}
+
+// UserResponse 单个用户
+type UserResponse struct {
+	Data dto.User `json:"data"`
+}
+
+// UserListResponse 用户列表
+type UserListResponse struct {
+	Data       []dto.User          `json:"data"`
+	Pagination pagination.PageInfo `json:"pagination"`
+}
+
+// CategoryListResponse 分类列表
+type CategoryListResponse struct {
+	Data []dto.Category `json:"data"`
+}
+
+// StatsResponse 社区统计
+type StatsResponse struct {
+	Data stats.Snapshot `json:"data"`
+}
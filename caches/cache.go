@@ -0,0 +1,153 @@
+package caches
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Store 缓存存储后端，值统一为序列化后的字节，便于替换为进程外存储
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	DeletePrefix(prefix string) error
+}
+
+// Cache 在Store之上提供回源加载、并发合并（防击穿）和命中率统计
+type Cache struct {
+	store Store
+	group singleflight.Group
+
+	mu      sync.Mutex
+	metrics map[string]*namespaceMetrics
+}
+
+type namespaceMetrics struct {
+	hits       atomic.Int64
+	misses     atomic.Int64
+	loadErrors atomic.Int64
+}
+
+// Stats 某个键空间（键名第一个冒号前的部分）的命中统计
+type Stats struct {
+	Namespace  string  `json:"namespace"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	LoadErrors int64   `json:"load_errors"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// New 创建缓存
+func New(store Store) *Cache {
+	return &Cache{
+		store:   store,
+		metrics: make(map[string]*namespaceMetrics),
+	}
+}
+
+var defaultCache = New(NewMemoryStore(1024))
+
+// Init 设置全局默认缓存的存储后端
+func Init(store Store) {
+	defaultCache = New(store)
+}
+
+// Default 返回全局默认缓存
+func Default() *Cache {
+	return defaultCache
+}
+
+func (c *Cache) namespace(key string) *namespaceMetrics {
+	ns, _, _ := strings.Cut(key, ":")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.metrics[ns]
+	if !ok {
+		m = &namespaceMetrics{}
+		c.metrics[ns] = m
+	}
+	return m
+}
+
+// Stats 返回各键空间的命中统计
+func (c *Cache) Stats() []Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]Stats, 0, len(c.metrics))
+	for ns, m := range c.metrics {
+		s := Stats{
+			Namespace:  ns,
+			Hits:       m.hits.Load(),
+			Misses:     m.misses.Load(),
+			LoadErrors: m.loadErrors.Load(),
+		}
+		if total := s.Hits + s.Misses; total > 0 {
+			s.HitRate = float64(s.Hits) / float64(total)
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Namespace < stats[j].Namespace })
+	return stats
+}
+
+// Invalidate 删除指定的缓存键
+func (c *Cache) Invalidate(keys ...string) {
+	if err := c.store.Delete(keys...); err != nil {
+		slog.Error("删除缓存失败", "keys", keys, "error", err)
+	}
+}
+
+// InvalidatePrefix 删除指定前缀的全部缓存键
+func (c *Cache) InvalidatePrefix(prefix string) {
+	if err := c.store.DeletePrefix(prefix); err != nil {
+		slog.Error("删除缓存失败", "prefix", prefix, "error", err)
+	}
+}
+
+// Remember 从缓存读取key，未命中时调用load回源并写入缓存
+// 同一个key的并发回源只会执行一次load，其余请求等待并共享结果
+func Remember[T any](c *Cache, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	m := c.namespace(key)
+
+	var value T
+	if data, ok, err := c.store.Get(key); err != nil {
+		slog.Error("读取缓存失败", "key", key, "error", err)
+	} else if ok {
+		if err := json.Unmarshal(data, &value); err == nil {
+			m.hits.Add(1)
+			return value, nil
+		}
+	}
+	m.misses.Add(1)
+
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		loaded, err := load()
+		if err != nil {
+			m.loadErrors.Add(1)
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.store.Set(key, data, ttl); err != nil {
+			slog.Error("写入缓存失败", "key", key, "error", err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return value, err
+	}
+
+	// 每个调用方各自反序列化，避免共享同一份切片/结构体
+	err = json.Unmarshal(result.([]byte), &value)
+	return value, err
+}
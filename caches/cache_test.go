@@ -0,0 +1,109 @@
+package caches
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRememberHitsAndStats(t *testing.T) {
+	c := New(NewMemoryStore(16))
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return 42, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := Remember(c, "posts:hot", time.Minute, load)
+		if err != nil || value != 42 {
+			t.Fatalf("第%d次读取 = %d, %v, 期望 42", i+1, value, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("回源次数 = %d, 期望 1", loads)
+	}
+
+	// 回源失败不写入缓存，下次继续回源
+	failure := errors.New("数据库不可用")
+	for i := 0; i < 2; i++ {
+		if _, err := Remember(c, "categories:active", time.Minute, func() ([]string, error) { return nil, failure }); !errors.Is(err, failure) {
+			t.Fatalf("回源失败时 err = %v, 期望 %v", err, failure)
+		}
+	}
+
+	want := []Stats{
+		{Namespace: "categories", Misses: 2, LoadErrors: 2},
+		{Namespace: "posts", Hits: 2, Misses: 1, HitRate: 2.0 / 3},
+	}
+	got := c.Stats()
+	if len(got) != len(want) {
+		t.Fatalf("Stats = %+v, 期望 %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Stats[%d] = %+v, 期望 %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// gatedStore 所有调用方都读过缓存（未命中）后才放行回源，保证并发请求同时进入回源阶段
+type gatedStore struct {
+	Store
+	misses sync.WaitGroup
+}
+
+func (s *gatedStore) Get(key string) ([]byte, bool, error) {
+	data, ok, err := s.Store.Get(key)
+	if !ok {
+		s.misses.Done()
+	}
+	return data, ok, err
+}
+
+func TestRememberDeduplicatesConcurrentLoads(t *testing.T) {
+	const callers = 20
+	store := &gatedStore{Store: NewMemoryStore(16)}
+	store.misses.Add(callers)
+	c := New(store)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() ([]int, error) {
+		loads.Add(1)
+		<-release
+		return []int{1, 2, 3}, nil
+	}
+
+	results := make([][]int, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := Remember(c, "posts:hot", time.Minute, load)
+			if err != nil {
+				t.Errorf("读取失败: %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	store.misses.Wait()
+	time.Sleep(20 * time.Millisecond) // 等待全部调用方进入 singleflight
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("并发回源次数 = %d, 期望 1", n)
+	}
+	// 每个调用方拿到各自的副本，修改一份不影响其他调用方
+	results[0][0] = 100
+	for i, value := range results[1:] {
+		if len(value) != 3 || value[0] != 1 {
+			t.Errorf("调用方%d的结果 = %v, 期望 [1 2 3]", i+1, value)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package caches
+
+import (
+	"gorm.io/gorm"
+)
+
+// 只更新这些计数字段时不触发失效：它们随浏览/点赞频繁变化，依靠TTL收敛即可
+var counterColumns = map[string]bool{
+	"views":     true,
+	"likes":     true,
+	"favorites": true,
+	"replies":   true,
+}
+
+// RegisterInvalidationHooks 注册GORM回调，在分类、文章、评论、用户表写入后失效相关缓存
+func RegisterInvalidationHooks(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("caches:invalidate_create", invalidateAfterWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("caches:invalidate_update", invalidateAfterWrite); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("caches:invalidate_delete", invalidateAfterWrite)
+}
+
+func invalidateAfterWrite(db *gorm.DB) {
+	if db.Error != nil || db.Statement == nil || db.RowsAffected == 0 {
+		return
+	}
+
+	prefixes, ok := tableInvalidations[db.Statement.Table]
+	if !ok || onlyCounterColumns(db) {
+		return
+	}
+
+	for _, prefix := range prefixes {
+		Default().InvalidatePrefix(prefix)
+	}
+}
+
+// onlyCounterColumns 判断本次更新是否只涉及计数字段
+func onlyCounterColumns(db *gorm.DB) bool {
+	updates, ok := db.Statement.Dest.(map[string]interface{})
+	if !ok || len(updates) == 0 {
+		return false
+	}
+	for column := range updates {
+		if !counterColumns[column] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package caches
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+func TestInvalidationHooks(t *testing.T) {
+	tests := []struct {
+		name      string
+		write     func(db *gorm.DB) error
+		remaining []string
+	}{
+		{
+			name:      "新建分类失效分类缓存",
+			write:     func(db *gorm.DB) error { return db.Create(&models.Category{Name: "新分类"}).Error },
+			remaining: []string{"posts:hot", "related:1", "users:1"},
+		},
+		{
+			name: "修改文章失效文章和相关文章缓存",
+			write: func(db *gorm.DB) error {
+				return db.Model(&models.Post{ID: 1}).Update("title", "新标题").Error
+			},
+			remaining: []string{"categories:active", "users:1"},
+		},
+		{
+			name:      "删除文章失效文章缓存",
+			write:     func(db *gorm.DB) error { return db.Delete(&models.Post{}, 1).Error },
+			remaining: []string{"categories:active", "users:1"},
+		},
+		{
+			name: "只更新计数字段不失效",
+			write: func(db *gorm.DB) error {
+				return db.Model(&models.Post{ID: 1}).UpdateColumn("views", gorm.Expr("views + ?", 1)).Error
+			},
+			remaining: []string{"categories:active", "posts:hot", "related:1", "users:1"},
+		},
+		{
+			name: "计数字段和其他字段一起更新时失效",
+			write: func(db *gorm.DB) error {
+				return db.Model(&models.Post{ID: 1}).Updates(map[string]interface{}{"views": 1, "title": "新标题"}).Error
+			},
+			remaining: []string{"categories:active", "users:1"},
+		},
+		{
+			name:      "没有影响任何行时不失效",
+			write:     func(db *gorm.DB) error { return db.Delete(&models.Post{}, 999).Error },
+			remaining: []string{"categories:active", "posts:hot", "related:1", "users:1"},
+		},
+		{
+			name: "没有关联缓存的表不失效",
+			write: func(db *gorm.DB) error {
+				return db.Model(&models.User{}).Where("id = ?", 1).Update("motto", "新格言").Error
+			},
+			remaining: []string{"categories:active", "posts:hot", "related:1", "users:1"},
+		},
+		{
+			name: "写入失败时不失效",
+			write: func(db *gorm.DB) error {
+				db.Create(&models.Category{ID: 1, Name: "重复主键"})
+				return nil
+			},
+			remaining: []string{"categories:active", "posts:hot", "related:1", "users:1"},
+		},
+	}
+
+	saved := Default()
+	t.Cleanup(func() { defaultCache = saved })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := database.OpenInMemory(strings.ReplaceAll(t.Name(), "/", "_"))
+			if err != nil {
+				t.Fatalf("打开SQLite失败: %v", err)
+			}
+			t.Cleanup(func() {
+				if sqlDB, err := db.DB(); err == nil {
+					sqlDB.Close()
+				}
+			})
+			user := models.User{Name: "author", Email: "author@example.com", Password: "x", Avatar: "a.png"}
+			category := models.Category{Name: "Go"}
+			for _, value := range []interface{}{&user, &category} {
+				if err := db.Create(value).Error; err != nil {
+					t.Fatalf("写入夹具失败: %v", err)
+				}
+			}
+			post := models.Post{Title: "标题", UserId: int(user.ID), Author: user.Name, Category: category.Name, CategoryId: int(category.ID), Content: "内容"}
+			if err := db.Create(&post).Error; err != nil {
+				t.Fatalf("写入夹具失败: %v", err)
+			}
+
+			if err := RegisterInvalidationHooks(db); err != nil {
+				t.Fatalf("注册失效钩子失败: %v", err)
+			}
+			store := NewMemoryStore(16)
+			Init(store)
+			keys := []string{"categories:active", "posts:hot", "related:1", "users:1"}
+			for _, key := range keys {
+				store.Set(key, []byte("1"), 0)
+			}
+
+			if err := tt.write(db); err != nil {
+				t.Fatalf("写入失败: %v", err)
+			}
+
+			var remaining []string
+			for _, key := range keys {
+				if _, ok, _ := store.Get(key); ok {
+					remaining = append(remaining, key)
+				}
+			}
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("剩余的缓存键 = %v, 期望 %v", remaining, tt.remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package caches
+
+import (
+	"strconv"
+)
+
+// 缓存键，第一个冒号前为键空间，用于命中率统计和按前缀失效
+const (
+	KeyRecommendedCategories = "categories:recommended" // 导航栏推荐分类
+	KeyActiveCategories      = "categories:active"      // 全部正常状态分类
+	KeyHotPosts              = "posts:hot"              // 热门文章
+	KeyRelatedPostsPrefix    = "related:"               // 相关文章，后接文章ID
+)
+
+// 各数据表写入时需要失效的键前缀
+var tableInvalidations = map[string][]string{
+	"categories": {"categories:"},
+	"posts":      {"posts:", KeyRelatedPostsPrefix},
+}
+
+// RelatedPostsKey 某篇文章的相关文章缓存键
+func RelatedPostsKey(postID uint) string {
+	return KeyRelatedPostsPrefix + strconv.FormatUint(uint64(postID), 10)
+}
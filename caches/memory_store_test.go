@@ -0,0 +1,95 @@
+package caches
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(s *MemoryStore)
+		present []string
+		missing []string
+	}{
+		{
+			name: "超出容量时淘汰最久未使用的条目",
+			run: func(s *MemoryStore) {
+				s.Set("a", []byte("1"), 0)
+				s.Set("b", []byte("2"), 0)
+				s.Get("a") // a 变为最近使用
+				s.Set("c", []byte("3"), 0)
+			},
+			present: []string{"a", "c"},
+			missing: []string{"b"},
+		},
+		{
+			name: "覆盖已有条目不占用新的容量",
+			run: func(s *MemoryStore) {
+				s.Set("a", []byte("1"), 0)
+				s.Set("b", []byte("2"), 0)
+				s.Set("a", []byte("3"), 0)
+			},
+			present: []string{"a", "b"},
+		},
+		{
+			name: "过期条目读取时删除",
+			run: func(s *MemoryStore) {
+				s.Set("a", []byte("1"), time.Millisecond)
+				s.Set("b", []byte("2"), time.Hour)
+				time.Sleep(5 * time.Millisecond)
+			},
+			present: []string{"b"},
+			missing: []string{"a"},
+		},
+		{
+			name: "覆盖时重新计算过期时间",
+			run: func(s *MemoryStore) {
+				s.Set("a", []byte("1"), time.Millisecond)
+				s.Set("a", []byte("2"), 0)
+				time.Sleep(5 * time.Millisecond)
+			},
+			present: []string{"a"},
+		},
+		{
+			name: "按键和前缀删除",
+			run: func(s *MemoryStore) {
+				s.Set("posts:hot", []byte("1"), 0)
+				s.Set("categories:active", []byte("2"), 0)
+				s.Delete("categories:active", "not-exist")
+				s.Set("posts:new", []byte("3"), 0)
+				s.DeletePrefix("posts:")
+			},
+			missing: []string{"posts:hot", "posts:new", "categories:active"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore(2)
+			tt.run(s)
+			for _, key := range tt.present {
+				if _, ok, _ := s.Get(key); !ok {
+					t.Errorf("%s 应该存在", key)
+				}
+			}
+			for _, key := range tt.missing {
+				if _, ok, _ := s.Get(key); ok {
+					t.Errorf("%s 应该已被删除", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReturnsLatestValue(t *testing.T) {
+	s := NewMemoryStore(0) // 容量不合法时使用默认值
+	s.Set("a", []byte("1"), 0)
+	s.Set("a", []byte("2"), 0)
+	if value, ok, err := s.Get("a"); !ok || err != nil || string(value) != "2" {
+		t.Errorf("Get = %q, %v, %v, 期望 \"2\"", value, ok, err)
+	}
+	if s.capacity != 1024 {
+		t.Errorf("默认容量 = %d, 期望 1024", s.capacity)
+	}
+}
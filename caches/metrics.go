@@ -0,0 +1,33 @@
+package caches
+
+import (
+	"gin-doniai/metrics"
+)
+
+// RegisterMetrics 注册默认缓存各键空间的命中、未命中、回源失败次数和命中率指标
+// 采集时读取 Default()，Init 替换存储后端后也不需要重新注册
+func RegisterMetrics() {
+	metrics.CounterFunc("cache_requests_total", "缓存读取次数，result 为 hit 或 miss", []string{"namespace", "result"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, s := range Default().Stats() {
+			samples = append(samples,
+				metrics.Sample{Labels: []string{s.Namespace, "hit"}, Value: float64(s.Hits)},
+				metrics.Sample{Labels: []string{s.Namespace, "miss"}, Value: float64(s.Misses)})
+		}
+		return samples
+	})
+	metrics.CounterFunc("cache_load_errors_total", "缓存未命中后回源失败次数", []string{"namespace"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, s := range Default().Stats() {
+			samples = append(samples, metrics.Sample{Labels: []string{s.Namespace}, Value: float64(s.LoadErrors)})
+		}
+		return samples
+	})
+	metrics.GaugeFunc("cache_hit_ratio", "进程启动以来的缓存命中率", []string{"namespace"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, s := range Default().Stats() {
+			samples = append(samples, metrics.Sample{Labels: []string{s.Namespace}, Value: s.HitRate})
+		}
+		return samples
+	})
+}
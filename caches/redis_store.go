@@ -0,0 +1,237 @@
+package caches
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisConn 执行一条Redis命令，返回解析后的RESP结果
+// （string / int64 / []interface{} / nil），测试时可以用本地替身实现
+type RedisConn interface {
+	Do(args ...string) (interface{}, error)
+}
+
+// RedisStore 基于Redis兼容服务（Redis、KeyDB、Dragonfly等）的缓存存储
+type RedisStore struct {
+	conn   RedisConn
+	prefix string // 所有键的统一前缀，避免与其他应用冲突
+}
+
+// NewRedisStore 使用给定连接创建Redis存储
+func NewRedisStore(conn RedisConn, prefix string) *RedisStore {
+	return &RedisStore{conn: conn, prefix: prefix}
+}
+
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	reply, err := s.conn.Do("GET", s.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	str, ok := reply.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("redis GET 返回了意外的类型 %T", reply)
+	}
+	return []byte(str), true, nil
+}
+
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", s.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := s.conn.Do(args...)
+	return err
+}
+
+func (s *RedisStore) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, s.prefix+key)
+	}
+	_, err := s.conn.Do(args...)
+	return err
+}
+
+// DeletePrefix 通过SCAN遍历匹配的键并删除，不会像KEYS一样阻塞服务端
+func (s *RedisStore) DeletePrefix(prefix string) error {
+	cursor := "0"
+	for {
+		reply, err := s.conn.Do("SCAN", cursor, "MATCH", s.prefix+prefix+"*", "COUNT", "100")
+		if err != nil {
+			return err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return fmt.Errorf("redis SCAN 返回了意外的结果")
+		}
+		cursor, _ = parts[0].(string)
+		keys, _ := parts[1].([]interface{})
+
+		args := []string{"DEL"}
+		for _, k := range keys {
+			if key, ok := k.(string); ok {
+				args = append(args, key)
+			}
+		}
+		if len(args) > 1 {
+			if _, err := s.conn.Do(args...); err != nil {
+				return err
+			}
+		}
+
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// redisClient 最小化的RESP协议客户端，单连接串行执行，断线后自动重连
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// DialRedis 创建Redis连接，password为空时不认证
+func DialRedis(addr, password string, db int) RedisConn {
+	return &redisClient{addr: addr, password: password, db: db, timeout: 3 * time.Second}
+}
+
+func (c *redisClient) Do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := c.roundTrip(args)
+	if err != nil {
+		var redisErr redisError
+		if !errors.As(err, &redisErr) {
+			// 网络错误时丢弃连接，下次调用重连
+			c.conn.Close()
+			c.conn = nil
+		}
+	}
+	return reply, err
+}
+
+func (c *redisClient) connect() error {
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return fmt.Errorf("连接Redis失败: %v", err)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	if c.password != "" {
+		if _, err := c.roundTrip([]string{"AUTH", c.password}); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+	if c.db != 0 {
+		if _, err := c.roundTrip([]string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *redisClient) roundTrip(args []string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(c.reader)
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// readRESP 解析一条RESP2回复
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("无效的RESP回复: %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("无效的RESP回复: %q", line)
+	}
+}
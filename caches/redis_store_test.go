@@ -0,0 +1,304 @@
+package caches
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 进程内的RESP服务端，只实现 RedisStore 用到的命令
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	ttls     map[string]string // SET ... PX 的毫秒数
+	order    []string          // 键的写入顺序，SCAN 的游标是它的下标
+	selected string            // SELECT 选择的库
+	accepted int
+	conns    []net.Conn
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动测试Redis失败: %v", err)
+	}
+	s := &fakeRedis{listener: listener, password: password, data: map[string]string{}, ttls: map[string]string{}}
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.dropConnections()
+	})
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.accepted++
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// dropConnections 断开全部客户端连接，模拟网络中断
+func (s *fakeRedis) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	for {
+		request, err := readRESP(reader)
+		if err != nil {
+			return
+		}
+		items, _ := request.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if _, err := conn.Write([]byte(s.exec(args, &authed))); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) exec(args []string, authed *bool) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	command := strings.ToUpper(args[0])
+	if !*authed && command != "AUTH" {
+		return "-NOAUTH Authentication required.\r\n"
+	}
+	switch command {
+	case "AUTH":
+		if len(args) != 2 || args[1] != s.password {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		*authed = true
+		return "+OK\r\n"
+	case "SELECT":
+		s.selected = args[1]
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulkString(value)
+	case "SET":
+		if _, ok := s.data[args[1]]; !ok {
+			s.order = append(s.order, args[1])
+		}
+		s.data[args[1]] = args[2]
+		delete(s.ttls, args[1])
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			s.ttls[args[1]] = args[4]
+		}
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "SCAN":
+		// 每次只检查一个键，迫使客户端按游标多次遍历（包括返回空结果的批次）
+		cursor, _ := strconv.Atoi(args[1])
+		prefix := strings.TrimSuffix(args[3], "*")
+		var keys []string
+		if cursor < len(s.order) {
+			key := s.order[cursor]
+			if _, ok := s.data[key]; ok && strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		next := "0"
+		if cursor+1 < len(s.order) {
+			next = strconv.Itoa(cursor + 1)
+		}
+		reply := "*2\r\n" + bulkString(next) + "*" + strconv.Itoa(len(keys)) + "\r\n"
+		for _, key := range keys {
+			reply += bulkString(key)
+		}
+		return reply
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func bulkString(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store := NewRedisStore(DialRedis(server.addr(), "secret", 2), "doniai:")
+
+	if err := store.Set("posts:hot", []byte(`[1,2]`), 1500*time.Millisecond); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	if err := store.Set("categories:active", []byte(`[]`), 0); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	if value, ok, err := store.Get("posts:hot"); err != nil || !ok || string(value) != `[1,2]` {
+		t.Errorf("Get = %q, %v, %v, 期望 [1,2]", value, ok, err)
+	}
+	if _, ok, err := store.Get("posts:none"); err != nil || ok {
+		t.Errorf("读取不存在的键 ok = %v, err = %v, 期望未命中", ok, err)
+	}
+
+	server.mu.Lock()
+	if server.selected != "2" {
+		t.Errorf("SELECT = %q, 期望 2", server.selected)
+	}
+	if server.ttls["doniai:posts:hot"] != "1500" {
+		t.Errorf("posts:hot 的过期时间 = %q, 期望 PX 1500", server.ttls["doniai:posts:hot"])
+	}
+	if _, ok := server.ttls["doniai:categories:active"]; ok {
+		t.Errorf("ttl 为0时不应设置过期时间")
+	}
+	server.mu.Unlock()
+
+	// 按前缀删除只影响本应用前缀下匹配的键
+	for _, key := range []string{"posts:new", "posts:top", "related:1"} {
+		store.Set(key, []byte("1"), 0)
+	}
+	server.mu.Lock()
+	server.data["other:posts:hot"] = "x"
+	server.order = append(server.order, "other:posts:hot")
+	server.mu.Unlock()
+
+	if err := store.DeletePrefix("posts:"); err != nil {
+		t.Fatalf("DeletePrefix 失败: %v", err)
+	}
+	if err := store.Delete("related:1", "missing"); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if err := store.Delete(); err != nil {
+		t.Fatalf("没有键时 Delete 失败: %v", err)
+	}
+
+	server.mu.Lock()
+	var remaining []string
+	for _, key := range server.order {
+		if _, ok := server.data[key]; ok {
+			remaining = append(remaining, key)
+		}
+	}
+	server.mu.Unlock()
+	want := []string{"doniai:categories:active", "other:posts:hot"}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("剩余的键 = %v, 期望 %v", remaining, want)
+	}
+}
+
+func TestRedisClientConnection(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	if _, err := DialRedis(server.addr(), "wrong", 0).Do("GET", "a"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("密码错误时 err = %v, 期望 WRONGPASS", err)
+	}
+
+	conn := DialRedis(server.addr(), "secret", 0)
+	server.mu.Lock()
+	before := server.accepted
+	server.mu.Unlock()
+
+	// 服务端返回的错误不是网络错误，连接继续使用
+	var redisErr redisError
+	if _, err := conn.Do("BOGUS"); !errors.As(err, &redisErr) {
+		t.Errorf("未知命令 err = %v, 期望 redisError", err)
+	}
+	if _, err := conn.Do("SET", "a", "1"); err != nil {
+		t.Fatalf("SET 失败: %v", err)
+	}
+
+	// 连接断开后，失败的那次调用丢弃连接，下一次调用重新连接
+	server.dropConnections()
+	var reply interface{}
+	var err error
+	for i := 0; i < 2; i++ {
+		if reply, err = conn.Do("GET", "a"); err == nil {
+			break
+		}
+	}
+	if err != nil || reply != "1" {
+		t.Errorf("重连后 GET = %v, %v, 期望 1", reply, err)
+	}
+
+	server.mu.Lock()
+	if connections := server.accepted - before; connections != 2 {
+		t.Errorf("建立连接 %d 次, 期望 2 次（首次连接和断线重连）", connections)
+	}
+	server.mu.Unlock()
+}
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "简单字符串", input: "+OK\r\n", want: "OK"},
+		{name: "整数", input: ":42\r\n", want: int64(42)},
+		{name: "负整数", input: ":-1\r\n", want: int64(-1)},
+		{name: "批量字符串", input: "$5\r\nhello\r\n", want: "hello"},
+		{name: "包含换行的批量字符串", input: "$4\r\na\r\nb\r\n", want: "a\r\nb"},
+		{name: "空批量字符串", input: "$0\r\n\r\n", want: ""},
+		{name: "空值", input: "$-1\r\n", want: nil},
+		{name: "空数组", input: "*-1\r\n", want: nil},
+		{name: "嵌套数组", input: "*2\r\n$1\r\n0\r\n*2\r\n$1\r\na\r\n:1\r\n", want: []interface{}{"0", []interface{}{"a", int64(1)}}},
+		{name: "错误回复", input: "-ERR wrong type\r\n", wantErr: true},
+		{name: "未知类型", input: "?1\r\n", wantErr: true},
+		{name: "行过短", input: "+\n", wantErr: true},
+		{name: "批量字符串不完整", input: "$5\r\nhel", wantErr: true},
+		{name: "长度不是数字", input: "*x\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRESP(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readRESP(%q) = %v, 期望返回错误", tt.input, got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readRESP(%q) = %#v, %v, 期望 %#v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Config 应用配置，加载顺序：默认值 < 配置文件 < 环境变量（含 .env）< 命令行参数
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Site     SiteConfig     `yaml:"site"`
+	Database DatabaseConfig `yaml:"database"`
+	Session  SessionConfig  `yaml:"session"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Security SecurityConfig `yaml:"security"`
+	SMTP     SMTPConfig     `yaml:"smtp"`
+	OAuth    OAuthConfig    `yaml:"oauth"`
+	Export   ExportConfig   `yaml:"export"`
+	Log      LogConfig      `yaml:"log"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+// ServerConfig HTTP服务配置（修改后需要重启）
+type ServerConfig struct {
+	Host string `yaml:"host"` // 监听地址，为空时监听所有网卡
+	Port int    `yaml:"port"`
+	Mode string `yaml:"mode"` // gin运行模式：debug / release / test
+}
+
+// Addr 监听地址，如 :8080
+func (s ServerConfig) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// SiteConfig 站点信息（SIGHUP时重新加载）
+type SiteConfig struct {
+	Name  string `yaml:"name"`
+	Theme string `yaml:"theme"`
+	URL   string `yaml:"url"` // 站点对外访问地址，如 https://example.com，邮件中的链接基于它生成
+}
+
+// Link 站点下某个路径的完整地址，如 Link("/verify-email?token=xxx")
+func (s SiteConfig) Link(path string) string {
+	return strings.TrimSuffix(s.URL, "/") + path
+}
+
+// DatabaseConfig 数据库连接配置（修改后需要重启）
+type DatabaseConfig struct {
+	Driver       string `yaml:"driver"` // mysql / postgres / sqlite
+	DSN          string `yaml:"dsn"`    // 完整连接串，设置后忽略 host 等字段；sqlite 为数据库文件路径
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"` // 0 表示驱动默认端口（MySQL 3306，PostgreSQL 5432）
+	User         string `yaml:"user"`
+	Password     string `yaml:"password"`
+	Name         string `yaml:"name"`
+	MaxIdleConns int    `yaml:"max_idle_conns"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
+	AutoMigrate  bool   `yaml:"auto_migrate"` // 启动时自动执行待执行的迁移，默认有待执行的迁移时拒绝启动
+}
+
+// SessionConfig 登录会话配置（修改后需要重启，更换密钥会使已登录用户退出）
+type SessionConfig struct {
+	Secret string `yaml:"secret"` // cookie签名密钥，release模式下必须设置且不少于32个字符
+	Secure bool   `yaml:"secure"` // 只通过HTTPS发送cookie
+
+	// SecretGenerated 未设置密钥时由 Load 临时生成，启动时在日志中提示
+	SecretGenerated bool `yaml:"-"`
+}
+
+// CacheConfig 缓存配置（修改后需要重启）
+type CacheConfig struct {
+	Driver string      `yaml:"driver"` // memory 或 redis
+	Size   int         `yaml:"size"`   // 进程内缓存的最大条目数
+	Redis  RedisConfig `yaml:"redis"`
+}
+
+// RedisConfig Redis兼容服务的连接配置
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// SecurityConfig 安全响应头配置（修改后需要重启）
+type SecurityConfig struct {
+	CSPMode string `yaml:"csp_mode"` // report 只上报不拦截，enforce 正式拦截
+}
+
+// SMTPConfig 发信配置，Host 为空时不发送邮件，只在debug日志中记录（SIGHUP时重新加载）
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// OAuthConfig 第三方登录配置（SIGHUP时重新加载）
+type OAuthConfig struct {
+	GitHub OAuthProvider `yaml:"github"`
+	Google OAuthProvider `yaml:"google"`
+}
+
+// OAuthProvider 单个第三方登录的应用信息，ClientID 为空表示未启用
+type OAuthProvider struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url"` // 如: http://localhost:8080/auth/github/callback
+}
+
+// Enabled 是否配置了该登录方式
+func (p OAuthProvider) Enabled() bool {
+	return p.ClientID != ""
+}
+
+// ExportConfig 用户数据导出配置（修改后需要重启）
+type ExportConfig struct {
+	Dir string `yaml:"dir"`
+}
+
+// LogConfig 日志配置（级别可热加载，格式修改后需要重启）
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug / info / warn / error
+	Format string `yaml:"format"` // json 或 text
+}
+
+// MetricsConfig Prometheus指标配置（修改后需要重启）
+type MetricsConfig struct {
+	Token string `yaml:"token"` // 设置后 /metrics 需要 Authorization: Bearer <token>
+}
+
+// TracingConfig OpenTelemetry链路追踪配置（修改后需要重启）
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`     // none 不导出，stdout 打印到标准输出，otlp 通过OTLP/HTTP发送到collector
+	Endpoint    string  `yaml:"endpoint"`     // OTLP地址，如 localhost:4318，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 或默认地址
+	Insecure    bool    `yaml:"insecure"`     // 使用HTTP而不是HTTPS连接collector
+	SampleRatio float64 `yaml:"sample_ratio"` // 新链路的采样比例（0-1），上游已采样的请求始终跟随上游
+	ServiceName string  `yaml:"service_name"`
+}
+
+// Default 默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: 8080, Mode: "debug"},
+		Site:   SiteConfig{Name: "Doniai", Theme: "light", URL: "http://localhost:8080"},
+		Database: DatabaseConfig{
+			Driver:       "mysql",
+			Host:         "127.0.0.1",
+			MaxIdleConns: 10,
+			MaxOpenConns: 100,
+		},
+		Cache:    CacheConfig{Driver: "memory", Size: 4096},
+		Security: SecurityConfig{CSPMode: "report"},
+		SMTP:     SMTPConfig{Port: 25},
+		Export:   ExportConfig{Dir: "exports"},
+		Log:      LogConfig{Level: "info", Format: "json"},
+		Tracing:  TracingConfig{Exporter: "none", SampleRatio: 1, ServiceName: "gin-doniai"},
+	}
+}
+
+// Validate 校验配置，一次返回所有问题
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port 必须在1-65535之间，当前为 %d", c.Server.Port)
+	check(oneOf(c.Server.Mode, "debug", "release", "test"), "server.mode 只能是 debug、release 或 test，当前为 %q", c.Server.Mode)
+	check(c.Site.Name != "", "site.name 不能为空")
+	check(isSiteURL(c.Site.URL), "site.url（SITE_URL）必须是 http:// 或 https:// 开头的完整地址，当前为 %q", c.Site.URL)
+
+	check(oneOf(c.Database.Driver, "mysql", "postgres", "sqlite"), "database.driver 只能是 mysql、postgres 或 sqlite，当前为 %q", c.Database.Driver)
+	check(c.Database.Port >= 0 && c.Database.Port < 65536, "database.port 必须在0-65535之间，当前为 %d", c.Database.Port)
+	if c.Database.DSN == "" {
+		check(c.Database.Name != "", "database.name 不能为空（DB_NAME）")
+		if c.Database.Driver != "sqlite" {
+			check(c.Database.Host != "", "database.host 不能为空（DB_HOST）")
+			check(c.Database.User != "", "database.user 不能为空（DB_USER）")
+		}
+	}
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns 必须大于0")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns 必须在0和max_open_conns之间")
+
+	if c.Server.Mode == "release" {
+		check(len(c.Session.Secret) >= 32, "release模式下 session.secret（SESSION_SECRET）必须设置且不少于32个字符")
+	}
+
+	check(oneOf(c.Cache.Driver, "memory", "redis"), "cache.driver 只能是 memory 或 redis，当前为 %q", c.Cache.Driver)
+	check(c.Cache.Size > 0, "cache.size 必须大于0")
+	if c.Cache.Driver == "redis" {
+		check(c.Cache.Redis.Addr != "", "cache.driver 为 redis 时必须设置 cache.redis.addr（REDIS_ADDR）")
+	}
+	check(oneOf(c.Security.CSPMode, "report", "enforce"), "security.csp_mode 只能是 report 或 enforce，当前为 %q", c.Security.CSPMode)
+
+	if c.SMTP.Host != "" {
+		check(c.SMTP.Port > 0 && c.SMTP.Port < 65536, "smtp.port 必须在1-65535之间，当前为 %d", c.SMTP.Port)
+	}
+	providers := []struct {
+		name     string
+		provider OAuthProvider
+	}{{"github", c.OAuth.GitHub}, {"google", c.OAuth.Google}}
+	for _, p := range providers {
+		if p.provider.Enabled() {
+			check(p.provider.ClientSecret != "", "oauth.%s.client_secret 不能为空", p.name)
+			check(strings.HasPrefix(p.provider.RedirectURL, "http://") || strings.HasPrefix(p.provider.RedirectURL, "https://"),
+				"oauth.%s.redirect_url 必须是 http 或 https 地址", p.name)
+		}
+	}
+	check(c.Export.Dir != "", "export.dir 不能为空")
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level 只能是 debug、info、warn 或 error，当前为 %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "json", "text"), "log.format 只能是 json 或 text，当前为 %q", c.Log.Format)
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "otlp"), "tracing.exporter 只能是 none、stdout 或 otlp，当前为 %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio 必须在0-1之间，当前为 %v", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "tracing.service_name 不能为空")
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("配置有误:\n  - " + strings.Join(problems, "\n  - "))
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isSiteURL 是否为带主机名的 http/https 地址
+func isSiteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validConfig 在默认配置的基础上补全必填项
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Name = "doniai"
+	cfg.Database.User = "root"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("有效配置校验失败: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{"端口超出范围", func(c *Config) { c.Server.Port = 70000 }, []string{"server.port"}},
+		{"运行模式", func(c *Config) { c.Server.Mode = "prod" }, []string{"server.mode"}},
+		{"站点地址缺少协议", func(c *Config) { c.Site.URL = "example.com" }, []string{"site.url"}},
+		{"数据库驱动", func(c *Config) { c.Database.Driver = "oracle" }, []string{"database.driver"}},
+		{"MySQL缺少连接信息", func(c *Config) { c.Database.Host, c.Database.User = "", "" }, []string{"database.host", "database.user"}},
+		{"空闲连接数超过最大连接数", func(c *Config) { c.Database.MaxIdleConns = 200 }, []string{"database.max_idle_conns"}},
+		{"release模式缺少会话密钥", func(c *Config) { c.Server.Mode = "release"; c.Session.Secret = "short" }, []string{"session.secret"}},
+		{"redis缺少地址", func(c *Config) { c.Cache.Driver = "redis" }, []string{"cache.redis.addr"}},
+		{"CSP模式", func(c *Config) { c.Security.CSPMode = "block" }, []string{"security.csp_mode"}},
+		{"SMTP端口", func(c *Config) { c.SMTP.Host, c.SMTP.Port = "smtp.example.com", 0 }, []string{"smtp.port"}},
+		{"OAuth缺少密钥和回调地址", func(c *Config) { c.OAuth.GitHub.ClientID = "id" }, []string{"oauth.github.client_secret", "oauth.github.redirect_url"}},
+		{"日志", func(c *Config) { c.Log.Level, c.Log.Format = "trace", "xml" }, []string{"log.level", "log.format"}},
+		{"采样比例", func(c *Config) { c.Tracing.SampleRatio = 1.5 }, []string{"tracing.sample_ratio"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatal("Validate() 期望返回错误")
+			}
+			// 一次返回所有问题，每个问题一行
+			if lines := strings.Count(err.Error(), "\n  - "); lines != len(tt.want) {
+				t.Errorf("Validate() 返回 %d 个问题, 期望 %d 个:\n%v", lines, len(tt.want), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, 期望包含 %s", err, want)
+				}
+			}
+		})
+	}
+
+	// sqlite 只需要数据库文件名，设置 DSN 后不再要求其他连接信息
+	sqlite := Default()
+	sqlite.Database.Driver, sqlite.Database.Name = "sqlite", "doniai.db"
+	dsn := Default()
+	dsn.Database.DSN = "root:secret@tcp(127.0.0.1:3306)/doniai"
+	for _, cfg := range []*Config{sqlite, dsn} {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", cfg.Database, err)
+		}
+	}
+}
+
+// clearEnv 清除测试涉及的环境变量，测试结束后恢复
+func clearEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// writeConfig 在临时目录中写入配置文件并切换到该目录（目录下没有 .env 文件）
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	return file
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t, "CONFIG_FILE", "PORT", "GIN_MODE", "SITE_NAME", "SITE_URL", "DB_DRIVER", "DB_NAME", "DB_DSN", "SESSION_SECRET", "LOG_LEVEL")
+	file := writeConfig(t, `
+server:
+  port: 9000
+site:
+  name: 文件中的站点
+  url: https://file.example.com
+database:
+  driver: sqlite
+  name: doniai.db
+log:
+  level: warn
+`)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		port     int
+		siteName string
+		mode     string
+		rest     []string
+	}{
+		{"默认值和配置文件", nil, []string{"-config", file}, 9000, "文件中的站点", "debug", []string{}},
+		{"环境变量覆盖配置文件", map[string]string{"PORT": "9100", "SITE_NAME": "环境变量中的站点"}, []string{"-config", file}, 9100, "环境变量中的站点", "debug", []string{}},
+		{"命令行参数覆盖环境变量", map[string]string{"PORT": "9100", "GIN_MODE": "test"}, []string{"-config", file, "-port", "9200", "-mode", "debug"}, 9200, "文件中的站点", "debug", []string{}},
+		{"CONFIG_FILE指定配置文件", map[string]string{"CONFIG_FILE": file}, []string{"migrate", "up"}, 9000, "文件中的站点", "debug", []string{"migrate", "up"}},
+		{"兼容旧的端口参数", map[string]string{"PORT": "9100"}, []string{"-config", file, "9300", "serve"}, 9300, "文件中的站点", "debug", []string{"serve"}},
+		{"-port优先于旧的端口参数", nil, []string{"-config", file, "-port", "9200", "9300"}, 9200, "文件中的站点", "debug", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, rest, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load(%q) 失败: %v", tt.args, err)
+			}
+			if cfg.Server.Port != tt.port || cfg.Site.Name != tt.siteName || cfg.Server.Mode != tt.mode {
+				t.Errorf("port=%d site=%q mode=%q, 期望 port=%d site=%q mode=%q", cfg.Server.Port, cfg.Site.Name, cfg.Server.Mode, tt.port, tt.siteName, tt.mode)
+			}
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("剩余参数 = %q, 期望 %q", rest, tt.rest)
+			}
+			// 配置文件中未设置的项保持默认值
+			if cfg.Site.URL != "https://file.example.com" || cfg.Log.Level != "warn" || cfg.Cache.Driver != "memory" {
+				t.Errorf("配置 = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultConfigFile(t *testing.T) {
+	clearEnv(t, "CONFIG_FILE", "PORT", "DB_DRIVER", "DB_NAME", "DB_DSN", "SESSION_SECRET")
+	writeConfig(t, "database:\n  driver: sqlite\n  name: doniai.db\n")
+	if err := os.Rename("app.yaml", defaultConfigFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() 失败: %v", err)
+	}
+	if cfg.Database.Driver != "sqlite" || cfg.Server.Port != 8080 {
+		t.Errorf("未读取工作目录下的 %s: %+v", defaultConfigFile, cfg.Database)
+	}
+	// 未设置会话密钥时临时生成
+	if !cfg.Session.SecretGenerated || len(cfg.Session.Secret) != 64 {
+		t.Errorf("会话密钥 = %q, generated=%v", cfg.Session.Secret, cfg.Session.SecretGenerated)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	clearEnv(t, "CONFIG_FILE", "PORT", "DB_DRIVER", "DB_NAME", "DB_DSN", "DB_AUTO_MIGRATE", "TRACING_SAMPLE_RATIO")
+
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		args    []string
+		want    string
+	}{
+		{"拼错的配置项", "server:\n  prot: 80\n", nil, nil, "prot"},
+		{"YAML格式错误", "server: [", nil, nil, "解析配置文件"},
+		{"环境变量不是整数", "database:\n  driver: sqlite\n  name: a.db\n", map[string]string{"PORT": "http"}, nil, "PORT 必须是整数"},
+		{"环境变量不是布尔值", "database:\n  driver: sqlite\n  name: a.db\n", map[string]string{"DB_AUTO_MIGRATE": "yes"}, nil, "DB_AUTO_MIGRATE 必须是 true 或 false"},
+		{"环境变量不是数字", "database:\n  driver: sqlite\n  name: a.db\n", map[string]string{"TRACING_SAMPLE_RATIO": "all"}, nil, "TRACING_SAMPLE_RATIO 必须是数字"},
+		{"校验失败", "database:\n  driver: sqlite\n  name: a.db\n", nil, []string{"-mode", "prod"}, "server.mode"},
+		{"未知的命令行参数", "", nil, []string{"-verbose"}, "verbose"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeConfig(t, tt.content)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, _, err := Load(append([]string{"-config", file}, tt.args...))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() 错误 = %v, 期望包含 %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("配置文件不存在时期望返回错误")
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	clearEnv(t, "CONFIG_FILE", "PORT", "SITE_NAME", "DB_DRIVER", "DB_NAME", "DB_DSN")
+	file := writeConfig(t, "site:\n  name: 文件\ndatabase:\n  driver: sqlite\n  name: a.db\n")
+	if err := os.WriteFile(".env", []byte("SITE_NAME=dotenv\nPORT=9400\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// .env 覆盖配置文件，真实的环境变量优先于 .env
+	t.Setenv("PORT", "9500")
+	cfg, _, err := Load([]string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Site.Name != "dotenv" || cfg.Server.Port != 9500 {
+		t.Errorf("site=%q port=%d, 期望 site=dotenv port=9500", cfg.Site.Name, cfg.Server.Port)
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// 未指定配置文件时，工作目录下存在该文件则自动加载
+const defaultConfigFile = "config.yaml"
+
+// source 记录启动时的配置来源，SIGHUP重新加载时按相同方式读取
+type source struct {
+	file string // 配置文件路径，为空表示不使用配置文件
+	port int    // 命令行指定的端口，0表示未指定
+	mode string // 命令行指定的运行模式
+}
+
+// Load 解析命令行参数并加载配置，返回剩余的参数（子命令及其参数）
+// 兼容旧的启动方式：第一个剩余参数是数字时作为端口，如 gin-doniai 8080
+func Load(args []string) (*Config, []string, error) {
+	flags := flag.NewFlagSet("gin-doniai", flag.ContinueOnError)
+	configFile := flags.String("config", "", "配置文件路径（YAML），默认读取 CONFIG_FILE 或工作目录下的 config.yaml")
+	port := flags.Int("port", 0, "监听端口，覆盖配置文件和 PORT 环境变量")
+	mode := flags.String("mode", "", "运行模式：debug / release / test")
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	src := source{file: *configFile, port: *port, mode: *mode}
+	rest := flags.Args()
+	if len(rest) > 0 {
+		if p, err := strconv.Atoi(rest[0]); err == nil {
+			if src.port == 0 {
+				src.port = p
+			}
+			rest = rest[1:]
+		}
+	}
+
+	if src.file == "" {
+		src.file = os.Getenv("CONFIG_FILE")
+	}
+	if src.file == "" {
+		if _, err := os.Stat(defaultConfigFile); err == nil {
+			src.file = defaultConfigFile
+		}
+	}
+
+	cfg, err := src.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 开发环境未设置会话密钥时临时生成一个，重启后已登录用户需要重新登录
+	if cfg.Session.Secret == "" {
+		cfg.Session.Secret = randomSecret()
+		cfg.Session.SecretGenerated = true
+	}
+
+	loaded = src
+	return cfg, rest, nil
+}
+
+// load 按 默认值 < 配置文件 < 环境变量 < 命令行参数 的顺序读取并校验配置
+func (src source) load() (*Config, error) {
+	cfg := Default()
+
+	if src.file != "" {
+		data, err := os.ReadFile(src.file)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true) // 拼错的配置项直接报错，而不是静默忽略
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %v", src.file, err)
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if src.port != 0 {
+		cfg.Server.Port = src.port
+	}
+	if src.mode != "" {
+		cfg.Server.Mode = src.mode
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv 用环境变量覆盖配置，.env 文件中的值优先级低于真实的环境变量
+// 每次都重新读取 .env，修改后发送SIGHUP即可生效（仅限可热加载的配置）
+func applyEnv(cfg *Config) error {
+	dotenv, err := godotenv.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取 .env 文件失败: %v", err)
+	}
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := dotenv[key]
+		return value, ok
+	}
+
+	var problems []error
+	str := func(target *string, key string) {
+		if value, ok := lookup(key); ok {
+			*target = value
+		}
+	}
+	num := func(target *int, key string) {
+		if value, ok := lookup(key); ok && value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("环境变量 %s 必须是整数，当前为 %q", key, value))
+				return
+			}
+			*target = n
+		}
+	}
+	decimal := func(target *float64, key string) {
+		if value, ok := lookup(key); ok && value != "" {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("环境变量 %s 必须是数字，当前为 %q", key, value))
+				return
+			}
+			*target = f
+		}
+	}
+	boolean := func(target *bool, key string) {
+		if value, ok := lookup(key); ok && value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("环境变量 %s 必须是 true 或 false，当前为 %q", key, value))
+				return
+			}
+			*target = b
+		}
+	}
+
+	str(&cfg.Server.Host, "SERVER_HOST")
+	num(&cfg.Server.Port, "PORT")
+	str(&cfg.Server.Mode, "GIN_MODE")
+	str(&cfg.Site.Name, "SITE_NAME")
+	str(&cfg.Site.Theme, "SITE_THEME")
+	str(&cfg.Site.URL, "SITE_URL")
+
+	str(&cfg.Database.Driver, "DB_DRIVER")
+	str(&cfg.Database.DSN, "DB_DSN")
+	str(&cfg.Database.Host, "DB_HOST")
+	num(&cfg.Database.Port, "DB_PORT")
+	str(&cfg.Database.User, "DB_USER")
+	str(&cfg.Database.Password, "DB_PASSWORD")
+	str(&cfg.Database.Name, "DB_NAME")
+	num(&cfg.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	num(&cfg.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	boolean(&cfg.Database.AutoMigrate, "DB_AUTO_MIGRATE")
+
+	str(&cfg.Session.Secret, "SESSION_SECRET")
+	boolean(&cfg.Session.Secure, "SESSION_SECURE")
+
+	str(&cfg.Cache.Driver, "CACHE_DRIVER")
+	num(&cfg.Cache.Size, "CACHE_SIZE")
+	str(&cfg.Cache.Redis.Addr, "REDIS_ADDR")
+	str(&cfg.Cache.Redis.Password, "REDIS_PASSWORD")
+	num(&cfg.Cache.Redis.DB, "REDIS_DB")
+
+	str(&cfg.Security.CSPMode, "CSP_MODE")
+
+	str(&cfg.SMTP.Host, "SMTP_HOST")
+	num(&cfg.SMTP.Port, "SMTP_PORT")
+	str(&cfg.SMTP.User, "SMTP_USER")
+	str(&cfg.SMTP.Password, "SMTP_PASSWORD")
+	str(&cfg.SMTP.From, "SMTP_FROM")
+
+	str(&cfg.OAuth.GitHub.ClientID, "GITHUB_CLIENT_ID")
+	str(&cfg.OAuth.GitHub.ClientSecret, "GITHUB_CLIENT_SECRET")
+	str(&cfg.OAuth.GitHub.RedirectURL, "GITHUB_REDIRECT_URL")
+	str(&cfg.OAuth.Google.ClientID, "GOOGLE_CLIENT_ID")
+	str(&cfg.OAuth.Google.ClientSecret, "GOOGLE_CLIENT_SECRET")
+	str(&cfg.OAuth.Google.RedirectURL, "GOOGLE_REDIRECT_URL")
+
+	str(&cfg.Export.Dir, "EXPORT_DIR")
+
+	str(&cfg.Log.Level, "LOG_LEVEL")
+	str(&cfg.Log.Format, "LOG_FORMAT")
+	str(&cfg.Metrics.Token, "METRICS_TOKEN")
+
+	str(&cfg.Tracing.Exporter, "TRACING_EXPORTER")
+	str(&cfg.Tracing.Endpoint, "TRACING_ENDPOINT")
+	boolean(&cfg.Tracing.Insecure, "TRACING_INSECURE")
+	decimal(&cfg.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+	str(&cfg.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+
+	return errors.Join(problems...)
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	current atomic.Pointer[Config]
+	loaded  source // 最近一次 Load 使用的配置来源
+
+	reloadMu  sync.Mutex
+	listeners []func(*Config)
+)
+
+// Set 设置当前生效的配置
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// Current 返回当前生效的配置，未设置时返回默认配置（如测试环境）
+// 配置在重新加载时整体替换，调用方不要修改返回值
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	return Default()
+}
+
+// OnReload 注册重新加载成功后的回调
+func OnReload(fn func(*Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Reload 按启动时的来源重新读取配置，只替换可以安全热加载的部分：
+// 站点信息、SMTP、第三方登录和日志级别；其余配置（端口、数据库、会话密钥等）需要重启才能生效
+// 新配置校验失败时保留原配置并返回错误
+func Reload() (*Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	fresh, err := loaded.load()
+	if err != nil {
+		return nil, err
+	}
+
+	next := *Current()
+	next.Site = fresh.Site
+	next.SMTP = fresh.SMTP
+	next.OAuth = fresh.OAuth
+	next.Log.Level = fresh.Log.Level
+	current.Store(&next)
+
+	for _, fn := range listeners {
+		fn(&next)
+	}
+	return &next, nil
+}
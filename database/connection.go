@@ -1,71 +1,63 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
 	"sync"
 	"time"
-	"gin-doniai/models"
-	"github.com/joho/godotenv"
-	"gorm.io/driver/mysql"
+	"gin-doniai/config"
 	"gorm.io/gorm"
 )
 
 var (
-	DB   *gorm.DB
-	once sync.Once
+	DB      *gorm.DB
+	once    sync.Once
+	initErr error
 )
 
-// GetInstance 获取数据库实例（单例模式）
-func GetInstance() *gorm.DB {
+// initDB 按配置连接数据库，表结构由 migrate 包的版本化迁移维护
+func initDB(cfg config.DatabaseConfig) error {
+	db, err := Open(cfg)
+	if err != nil {
+		return fmt.Errorf("数据库连接失败(%s): %v", cfg.Driver, err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetConnMaxLifetime(time.Hour) // 连接最大存活时间
+	if err := sqlDB.Ping(); err != nil {
+		return fmt.Errorf("数据库连接失败(%s): %v", cfg.Driver, err)
+	}
+	DB = db
+	return nil
+}
+
+// InitDB 初始化数据库连接（单例，重复调用返回第一次的结果）
+func InitDB(cfg config.DatabaseConfig) error {
 	once.Do(func() {
-		initDB()
+		initErr = initDB(cfg)
 	})
-	return DB
+	return initErr
 }
 
-// initDB 初始化数据库连接
-func initDB() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("警告: 未能加载 .env 文件")
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("数据库未初始化")
 	}
-
-	// 从环境变量获取数据库配置
-	username := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	dbname := os.Getenv("DB_NAME")
-
-	// 构建 DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		username, password, host, port, dbname)
-
-	var dbErr error
-	DB, dbErr = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if dbErr != nil {
-		log.Fatal("数据库连接失败:", dbErr)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
-
-	// 配置连接池
-	sqlDB, _ := DB.DB()
-	sqlDB.SetMaxIdleConns(10)                // 空闲连接数
-	sqlDB.SetMaxOpenConns(100)               // 最大连接数
-	sqlDB.SetConnMaxLifetime(time.Hour)      // 连接最大存活时间
-
-	// 自动迁移（创建表）
-	DB.AutoMigrate(&models.User{})
-    DB.AutoMigrate(&models.Category{})
-	DB.AutoMigrate(&models.Post{})
-	DB.AutoMigrate(&models.Comment{})
-	DB.AutoMigrate(&models.PostLike{})
-	DB.AutoMigrate(&models.PostFavorite{})
-	DB.AutoMigrate(&models.UserOnlineStatus{})
-    DB.AutoMigrate(&models.PasswordReset{})
+	return sqlDB.PingContext(ctx)
 }
 
-func InitDB() {
-	GetInstance()
+// Close 关闭数据库连接池，应在所有worker退出后调用
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"gin-doniai/config"
+	"gin-doniai/logging"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite" // 纯Go实现，不依赖CGO，适合开发和测试
+)
+
+// Dialector 按配置选择GORM驱动；设置了 DSN 时直接使用，否则由各字段拼接
+func Dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverMySQL, "":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+				cfg.User, cfg.Password, cfg.Host, portOrDefault(cfg.Port, 3306), cfg.Name)
+		}
+		return mysql.Open(dsn), nil
+
+	case DriverPostgres:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=Local",
+				cfg.Host, portOrDefault(cfg.Port, 5432), cfg.User, quoteKeyword(cfg.Password), cfg.Name)
+		}
+		return postgres.Open(dsn), nil
+
+	case DriverSQLite:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = cfg.Name + ".db"
+		}
+		return sqlite.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+}
+
+// Open 按配置连接数据库并设置连接池、记录SQL耗时和链路，不执行迁移
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// TranslateError 把各驱动的唯一键冲突等错误统一转换为 gorm.ErrDuplicatedKey 等
+	// SQL错误和慢查询写入结构化日志
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true, Logger: logging.GormLogger{}})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(QueryMetrics{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(QueryTracing{}); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Driver == DriverSQLite {
+		// SQLite 同一时间只允许一个写入，单连接可以避免 database is locked；
+		// 内存数据库每个连接都是独立的库，也必须只用一个连接
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns) // 空闲连接数
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns) // 最大连接数
+	}
+	return db, nil
+}
+
+func portOrDefault(port, fallback int) int {
+	if port == 0 {
+		return fallback
+	}
+	return port
+}
+
+// quoteKeyword 按 libpq 的 key=value 格式转义值，密码中可能包含空格或引号
+func quoteKeyword(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// LikeFold 不区分大小写的 LIKE 条件，PostgreSQL 的 LIKE 区分大小写，统一转为小写比较
+func LikeFold(column string) string {
+	return "LOWER(" + column + ") LIKE LOWER(?)"
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"gin-doniai/config"
+	"gin-doniai/migrate"
+	"gorm.io/gorm"
+)
+
+// OpenInMemory 打开一个内存SQLite数据库并执行全部迁移，供测试和本地试用，不需要MySQL
+// 相同 name 的连接共享同一个库，不同测试用不同 name 即可互相隔离
+func OpenInMemory(name string) (*gorm.DB, error) {
+	db, err := Open(config.DatabaseConfig{
+		Driver: DriverSQLite,
+		DSN:    "file:" + name + "?mode=memory&cache=shared",
+	})
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.New(db)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Up(0); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"errors"
+	"time"
+	"gin-doniai/metrics"
+	"gorm.io/gorm"
+)
+
+var (
+	queryDuration = metrics.NewHistogramVec("db_query_duration_seconds", "SQL执行耗时（秒）", nil, "operation", "table")
+	queryErrors   = metrics.NewCounterVec("db_query_errors_total", "SQL执行失败次数（不含找不到记录）", "operation", "table")
+)
+
+const queryStartKey = "metrics:query_start"
+
+// QueryMetrics 记录每条SQL耗时的GORM插件，按操作类型和表名统计，用法：db.Use(database.QueryMetrics{})
+type QueryMetrics struct{}
+
+// Name 插件名称
+func (QueryMetrics) Name() string {
+	return "metrics:query"
+}
+
+// Initialize 在各类操作前后注册计时回调
+func (QueryMetrics) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", startQuery),
+		cb.Create().After("gorm:create").Register("metrics:after_create", finishQuery("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", startQuery),
+		cb.Query().After("gorm:query").Register("metrics:after_query", finishQuery("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", startQuery),
+		cb.Update().After("gorm:update").Register("metrics:after_update", finishQuery("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", startQuery),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", finishQuery("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", startQuery),
+		cb.Row().After("gorm:row").Register("metrics:after_row", finishQuery("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", startQuery),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", finishQuery("raw")),
+	}
+	return errors.Join(errs...)
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func finishQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, _ := value.(time.Time)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown" // Raw/Exec 等没有解析出表名的语句
+		}
+		queryDuration.Observe(time.Since(start).Seconds(), operation, table)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			queryErrors.Inc(operation, table)
+		}
+	}
+}
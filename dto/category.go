@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"gin-doniai/models"
+)
+
+// Category 对外返回的分类信息
+type Category struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	Alias         string `json:"alias"`
+	IsRecommended bool   `json:"is_recommended"`
+}
+
+// NewCategories 转换分类列表
+func NewCategories(categories []models.Category) []Category {
+	result := make([]Category, 0, len(categories))
+	for _, category := range categories {
+		result = append(result, Category{
+			ID:            category.ID,
+			Name:          category.Name,
+			Alias:         category.Alias,
+			IsRecommended: category.IsRecommended,
+		})
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+	"gin-doniai/models"
+)
+
+// Comment 对外返回的评论信息
+type Comment struct {
+	ID            uint         `json:"id"`
+	PostID        uint         `json:"post_id"`
+	ParentID      uint         `json:"parent_id"`
+	Content       string       `json:"content"`
+	Author        *UserSummary `json:"author,omitempty"` // 未预加载用户时为空
+	UserID        uint         `json:"user_id"`
+	IsRecommended bool         `json:"is_recommended"`
+	StatusCode    int          `json:"status_code"`
+	LikeCount     int          `json:"like_count"`
+	ReplyCount    int          `json:"reply_count"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// NewComment 转换评论信息
+func NewComment(comment models.Comment) Comment {
+	c := Comment{
+		ID:            comment.ID,
+		PostID:        comment.PostID,
+		ParentID:      comment.ParentID,
+		Content:       comment.Content,
+		UserID:        comment.UserID,
+		IsRecommended: comment.IsRecommended,
+		StatusCode:    comment.StatusCode,
+		LikeCount:     comment.LikeCount,
+		ReplyCount:    comment.ReplyCount,
+		CreatedAt:     comment.CreatedAt,
+		UpdatedAt:     comment.UpdatedAt,
+	}
+	if comment.User.ID != 0 {
+		author := NewUserSummary(comment.User)
+		c.Author = &author
+	}
+	return c
+}
+
+// NewComments 转换评论列表
+func NewComments(comments []models.Comment) []Comment {
+	result := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		result = append(result, NewComment(comment))
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/utils"
+)
+
+// 列表中文章摘要的长度
+const summaryLength = 200
+
+// Post 对外返回的文章信息
+type Post struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	UserID     int       `json:"user_id"`
+	Author     string    `json:"author"`
+	Category   string    `json:"category"`
+	CategoryID int       `json:"category_id"`
+	Tags       []string  `json:"tags"`
+	Summary    string    `json:"summary"`
+	Content    string    `json:"content,omitempty"` // 列表中不返回正文
+	Views      int       `json:"views"`
+	Replies    int       `json:"replies"`
+	Favorites  int       `json:"favorites"`
+	Likes      int       `json:"likes"`
+	ReadLimit  int       `json:"read_limit"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// NewPost 转换文章信息，withContent 为 false 时只返回摘要
+func NewPost(post models.Post, withContent bool) Post {
+	p := Post{
+		ID:         post.ID,
+		Title:      post.Title,
+		UserID:     post.UserId,
+		Author:     post.Author,
+		Category:   post.Category,
+		CategoryID: post.CategoryId,
+		Tags:       utils.ParseTags(post.Tags),
+		Summary:    utils.Truncate(utils.StripHTML(post.Content), summaryLength),
+		Views:      post.Views,
+		Replies:    post.Replies,
+		Favorites:  post.Favorites,
+		Likes:      post.Likes,
+		ReadLimit:  post.ReadLimit,
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+	}
+	if withContent {
+		p.Content = post.Content
+	}
+	return p
+}
+
+// NewPosts 转换文章列表（不含正文）
+func NewPosts(posts []models.Post) []Post {
+	result := make([]Post, 0, len(posts))
+	for _, post := range posts {
+		result = append(result, NewPost(post, false))
+	}
+	return result
+}
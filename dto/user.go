@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+	"gin-doniai/models"
+)
+
+// User 对外返回的用户信息，不包含密码等敏感字段
+type User struct {
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	Avatar        string    `json:"avatar"`
+	Level         int       `json:"level"`
+	Motto         string    `json:"motto"`
+	Github        string    `json:"github"`
+	CreatedAt     time.Time `json:"created_at"`
+	Email         string    `json:"email,omitempty"`          // 仅本人和管理员可见
+	EmailVerified *bool     `json:"email_verified,omitempty"` // 仅本人和管理员可见
+	Role          int       `json:"role,omitempty"`           // 仅本人和管理员可见
+}
+
+// UserSummary 嵌套在文章、评论中的作者信息
+type UserSummary struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+// NewUser 转换用户信息，includePrivate 为 true 时附带邮箱和角色
+func NewUser(user models.User, includePrivate bool) User {
+	u := User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Avatar:    user.Avatar,
+		Level:     user.Level,
+		Motto:     user.Motto,
+		Github:    user.Github,
+		CreatedAt: user.CreatedAt,
+	}
+	if includePrivate {
+		verified := user.IsEmailVerified()
+		u.Email = user.Email
+		u.EmailVerified = &verified
+		u.Role = user.Role
+	}
+	return u
+}
+
+// NewUserSummary 转换作者信息
+func NewUserSummary(user models.User) UserSummary {
+	return UserSummary{ID: user.ID, Name: user.Name, Avatar: user.Avatar}
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+	"gorm.io/gorm"
+)
+
+// Command 命令行入口：gin-doniai export [选项]，base 提供模板函数和热门文章
+func Command(db *gorm.DB, args []string, out io.Writer, base Options) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(out)
+	format := flags.String("format", FormatMarkdown, "markdown（Markdown + JSON）或 html（静态站点镜像）")
+	output := flags.String("o", "", "输出路径，.zip 结尾时生成压缩包，否则写入目录（默认 site-<格式>-<时间>.zip）")
+	templates := flags.String("templates", "templates/**/*", "页面模板文件（html格式）")
+	static := flags.String("static", "static", "静态资源目录（html格式）")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "用法: gin-doniai export [选项]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != FormatMarkdown && *format != FormatHTML {
+		flags.Usage()
+		return fmt.Errorf("不支持的导出格式 %q", *format)
+	}
+	if *output == "" {
+		*output = fmt.Sprintf("site-%s-%s.zip", *format, time.Now().Format("20060102150405"))
+	}
+
+	sink, err := NewSink(*output)
+	if err != nil {
+		return err
+	}
+	opts := base
+	opts.Format, opts.Templates, opts.StaticDir = *format, *templates, *static
+	summary, err := Export(db, sink, opts)
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "已导出到 %s：文章 %d，评论 %d，分类 %d，标签 %d，作者 %d，共 %d 个文件\n",
+		*output, summary.Posts, summary.Comments, summary.Categories, summary.Tags, summary.Authors, summary.Files)
+	return nil
+}
@@ -0,0 +1,195 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"gin-doniai/utils"
+	"gorm.io/gorm"
+)
+
+// 导出格式
+const (
+	FormatMarkdown = "markdown" // 带front matter的Markdown + JSON数据
+	FormatHTML     = "html"     // 用页面模板渲染的静态站点镜像
+)
+
+// Options 导出选项
+type Options struct {
+	Format    string
+	Funcs     template.FuncMap // 页面模板函数，与路由中注册的保持一致（仅HTML格式需要）
+	Templates string           // 模板文件匹配规则，默认 templates/**/*
+	StaticDir string           // 静态资源目录，会复制到镜像的 /static 下，默认 static
+	// HotPosts 侧栏的热门文章（仅HTML格式需要），通常为 services.PostService.Hot
+	HotPosts func() ([]models.Post, error)
+	// RelatedPosts 详情页的相关文章（仅HTML格式需要），通常为 services.PostService.Related
+	RelatedPosts func(post *models.Post) ([]services.RelatedPost, error)
+}
+
+// Summary 导出结果统计
+type Summary struct {
+	Format     string    `json:"format"`
+	ExportedAt time.Time `json:"exported_at"`
+	Posts      int       `json:"posts"`
+	Comments   int       `json:"comments"`
+	Categories int       `json:"categories"`
+	Tags       int       `json:"tags"`
+	Authors    int       `json:"authors"`
+	Files      int       `json:"files,omitempty"` // manifest.json 写入时尚未统计
+}
+
+// TagCount 标签及使用次数
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Site 导出时读取的全站数据快照
+type Site struct {
+	ExportedAt time.Time
+	Posts      []models.Post    // 按发布时间倒序，已预加载作者
+	Comments   []models.Comment // 正常状态的评论，按发布时间正序，已预加载用户
+	Categories []models.Category
+	Tags       []TagCount
+	Authors    []models.User // 发表过文章或评论的用户
+	UserCount  int64         // 注册用户总数
+}
+
+// Load 读取未删除的文章及其评论、分类、标签和作者，publicOnly 为 true 时只包含公开文章
+func Load(db *gorm.DB, publicOnly bool) (*Site, error) {
+	site := &Site{ExportedAt: time.Now()}
+
+	query := db.Preload("User").Order("created_at DESC")
+	if publicOnly {
+		query = query.Where("read_limit = ?", 1)
+	}
+	if err := query.Find(&site.Posts).Error; err != nil {
+		return nil, fmt.Errorf("读取文章失败: %v", err)
+	}
+
+	postIDs := make([]uint, 0, len(site.Posts))
+	for _, post := range site.Posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	// 文章较多时分批查询评论，避免IN参数过多
+	for start := 0; start < len(postIDs); start += 500 {
+		end := start + 500
+		if end > len(postIDs) {
+			end = len(postIDs)
+		}
+		var comments []models.Comment
+		if err := db.Preload("User").
+			Where("post_id IN ? AND status_code = ?", postIDs[start:end], 1).
+			Order("created_at ASC").
+			Find(&comments).Error; err != nil {
+			return nil, fmt.Errorf("读取评论失败: %v", err)
+		}
+		site.Comments = append(site.Comments, comments...)
+	}
+	sort.SliceStable(site.Comments, func(i, j int) bool {
+		return site.Comments[i].CreatedAt.Before(site.Comments[j].CreatedAt)
+	})
+
+	if err := db.Where("status_code = ?", 1).Order("id ASC").Find(&site.Categories).Error; err != nil {
+		return nil, fmt.Errorf("读取分类失败: %v", err)
+	}
+
+	if err := db.Model(&models.User{}).Count(&site.UserCount).Error; err != nil {
+		return nil, fmt.Errorf("统计用户失败: %v", err)
+	}
+
+	site.Tags = countTags(site.Posts)
+	site.Authors = collectAuthors(site.Posts, site.Comments)
+	return site, nil
+}
+
+// countTags 统计标签使用次数，按次数倒序
+func countTags(posts []models.Post) []TagCount {
+	counts := make(map[string]int)
+	names := make(map[string]string) // 小写 -> 首次出现的写法
+	for _, post := range posts {
+		for _, tag := range utils.ParseTags(post.Tags) {
+			key := strings.ToLower(tag)
+			if _, ok := names[key]; !ok {
+				names[key] = tag
+			}
+			counts[key]++
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for key, count := range counts {
+		tags = append(tags, TagCount{Name: names[key], Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+func collectAuthors(posts []models.Post, comments []models.Comment) []models.User {
+	seen := make(map[uint]bool)
+	var authors []models.User
+	add := func(user models.User) {
+		if user.ID == 0 || seen[user.ID] {
+			return
+		}
+		seen[user.ID] = true
+		authors = append(authors, user)
+	}
+	for _, post := range posts {
+		add(post.User)
+	}
+	for _, comment := range comments {
+		add(comment.User)
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+	return authors
+}
+
+// Export 读取全站数据并按格式写入 out
+func Export(db *gorm.DB, out Sink, opts Options) (*Summary, error) {
+	if opts.Templates == "" {
+		opts.Templates = "templates/**/*"
+	}
+	if opts.StaticDir == "" {
+		opts.StaticDir = "static"
+	}
+
+	// 静态镜像可以公开托管，只包含公开文章
+	site, err := Load(db, opts.Format == FormatHTML)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Format:     opts.Format,
+		ExportedAt: site.ExportedAt,
+		Posts:      len(site.Posts),
+		Comments:   len(site.Comments),
+		Categories: len(site.Categories),
+		Tags:       len(site.Tags),
+		Authors:    len(site.Authors),
+	}
+	counter := &countingSink{Sink: out}
+	switch opts.Format {
+	case FormatMarkdown:
+		err = writeMarkdown(counter, site, summary)
+	case FormatHTML:
+		err = writeHTML(counter, site, opts)
+	default:
+		return nil, fmt.Errorf("不支持的导出格式 %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.Files = counter.files
+	return summary, nil
+}
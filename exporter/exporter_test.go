@@ -0,0 +1,132 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"gin-doniai/database"
+	"gin-doniai/models"
+)
+
+func TestEntryName(t *testing.T) {
+	valid := map[string]string{
+		"index.html":                  "index.html",
+		"categories/go/index.html":    "categories/go/index.html",
+		"categories/./go//index.html": "categories/go/index.html",
+		"categories/../x/index.html":  "x/index.html",
+		`static\css\style.css`:        "static/css/style.css",
+	}
+	for name, want := range valid {
+		if got, err := entryName(name); err != nil || got != want {
+			t.Errorf("entryName(%q) = %q, %v, 期望 %q", name, got, err, want)
+		}
+	}
+
+	for _, name := range []string{"", ".", "..", "../evil.html", "categories/../../evil/index.html", "/etc/passwd", `..\evil.html`} {
+		if got, err := entryName(name); err == nil {
+			t.Errorf("entryName(%q) = %q, 期望返回错误", name, got)
+		}
+	}
+}
+
+// zipEntries 读取zip中的全部文件名，按名称排序
+func zipEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("读取zip失败: %v", err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestZipSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewZipSink(&buf)
+	for _, name := range []string{"index.html", "categories/./go/index.html"} {
+		if err := sink.WriteFile(name, []byte("ok")); err != nil {
+			t.Fatalf("WriteFile(%q) 失败: %v", name, err)
+		}
+	}
+	if err := sink.WriteFile("categories/../../evil/index.html", []byte("x")); err == nil {
+		t.Error("跳出导出目录的路径期望返回错误")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := zipEntries(t, buf.Bytes()), []string{"categories/go/index.html", "index.html"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("zip中的文件 = %q, 期望 %q", got, want)
+	}
+}
+
+func TestDirSink(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "export")
+	sink, err := NewSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.WriteFile("posts/1.md", []byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "posts", "1.md")); err != nil || string(data) != "ok" {
+		t.Errorf("读取导出文件 = %q, %v", data, err)
+	}
+	if err := sink.WriteFile("../evil.html", []byte("x")); err == nil {
+		t.Error("跳出导出目录的路径期望返回错误")
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.html")); !os.IsNotExist(err) {
+		t.Errorf("导出目录之外出现了文件: %v", err)
+	}
+}
+
+func TestExportMarkdownZip(t *testing.T) {
+	db, err := database.OpenInMemory(strings.ReplaceAll(t.Name(), "/", "_"))
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	author := models.User{Name: "author", Email: "author@example.com", Password: "x", Avatar: ""}
+	db.Create(&author)
+	// 别名可能来自后台的任意输入，不能影响导出包中的路径
+	category := models.Category{Name: "逃逸", Alias: "../../evil", StatusCode: 1}
+	db.Create(&category)
+	posts := []models.Post{
+		{Title: "公开", UserId: int(author.ID), Author: author.Name, CategoryId: int(category.ID), Content: "<p>a</p>", Tags: `["go"]`, ReadLimit: 1},
+		{Title: "仅登录可见", UserId: int(author.ID), Author: author.Name, CategoryId: int(category.ID), Content: "<p>b</p>", Tags: `["go","gin"]`, ReadLimit: 2},
+	}
+	db.Create(&posts)
+
+	var buf bytes.Buffer
+	sink := NewZipSink(&buf)
+	summary, err := Export(db, sink, Options{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"authors.json", "categories.json", "manifest.json", "posts.json", "posts/1.md", "posts/2.md", "tags.json"}
+	if got := zipEntries(t, buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("zip中的文件 = %q, 期望 %q", got, want)
+	}
+	if summary.Posts != 2 || summary.Tags != 2 || summary.Files != len(want) {
+		t.Errorf("导出统计 = %+v", summary)
+	}
+}
@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Handler 管理员下载全站导出包：GET /api/admin/export?format=markdown|html
+// 先生成到临时文件，出错时还能返回JSON错误，成功后以zip附件下载；base 提供模板函数、热门文章和相关文章
+func Handler(db *gorm.DB, base Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", FormatMarkdown)
+		if format != FormatMarkdown && format != FormatHTML {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "format 只能是 markdown 或 html",
+			})
+			return
+		}
+
+		file, err := os.CreateTemp("", "doniai-export-*.zip")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "创建导出文件失败",
+			})
+			return
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		sink := NewZipSink(file)
+		opts := base
+		opts.Format = format
+		_, err = Export(db, sink, opts)
+		if closeErr := sink.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			slog.Error("全站导出失败", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "导出失败: " + err.Error(),
+			})
+			return
+		}
+
+		name := fmt.Sprintf("site-%s-%s.zip", format, time.Now().Format("20060102150405"))
+		c.FileAttachment(file.Name(), name)
+	}
+}
@@ -0,0 +1,262 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"gin-doniai/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 镜像中的时间显示为具体日期，相对时间（"3天前"）在静态页面里会过时
+const mirrorTimeLayout = "2006-01-02 15:04"
+
+// listPost 首页和分类页的文章条目，对应 handlers.PageHandler.Home 中的数据结构
+type listPost struct {
+	models.Post
+	TimeAgo string
+}
+
+// threadComment 详情页的评论，对应 handlers.PageHandler.Detail 中的数据结构
+type threadComment struct {
+	models.Comment
+	TimeAgo string
+	Replies []threadComment
+	Content template.HTML
+}
+
+// mirror 渲染静态镜像需要的数据，路径与线上路由一致：
+// /index.html、/categories/<alias>/index.html、/post-<id>-1/index.html、/static/...
+type mirror struct {
+	out       Sink
+	templates *template.Template
+	site      *Site
+	common    gin.H // 每个页面都需要的统计和侧边栏数据
+	exported  map[uint]bool
+	related   func(post *models.Post) ([]services.RelatedPost, error)
+}
+
+// writeHTML 用 templates/pages 中的模板渲染只读的静态站点
+func writeHTML(out Sink, site *Site, opts Options) error {
+	funcs := template.FuncMap{}
+	for name, fn := range opts.Funcs {
+		funcs[name] = fn
+	}
+	// 静态页面没有会话，不输出CSRF令牌
+	funcs["csrfMeta"] = func(string) template.HTML { return "" }
+	funcs["csrfField"] = func(string) template.HTML { return "" }
+	funcs["timeAgo"] = func(t time.Time) string { return t.Format(mirrorTimeLayout) }
+
+	templates, err := template.New("").Funcs(funcs).ParseGlob(opts.Templates)
+	if err != nil {
+		return fmt.Errorf("加载模板失败: %v", err)
+	}
+
+	m := &mirror{
+		out:       out,
+		templates: templates,
+		site:      site,
+		exported:  make(map[uint]bool, len(site.Posts)),
+		related:   opts.RelatedPosts,
+	}
+	for _, post := range site.Posts {
+		m.exported[post.ID] = true
+	}
+
+	var hotPosts []models.Post
+	if opts.HotPosts != nil {
+		if hotPosts, err = opts.HotPosts(); err != nil {
+			slog.Error("获取热门文章失败", "error", err)
+		}
+	}
+	m.common = gin.H{
+		"CurrentTime":  site.ExportedAt.Format("2006-01-02 15:04:05"),
+		"userCount":    site.UserCount,
+		"postCount":    len(site.Posts),
+		"commentCount": len(site.Comments),
+		"onlineCount":  0,
+		"categories":   site.Categories,
+		"hotPosts":     m.filterPosts(hotPosts),
+		"readOnly":     true,
+	}
+
+	if err := m.writeList("index.html", site.Posts); err != nil {
+		return err
+	}
+	for _, category := range site.Categories {
+		// 线上路由 /categories/:type 只匹配单个路径段，其他别名没有对应的页面
+		if !pathSegment(category.Alias) {
+			continue
+		}
+		var posts []models.Post
+		for _, post := range site.Posts {
+			if post.CategoryId == int(category.ID) {
+				posts = append(posts, post)
+			}
+		}
+		if err := m.writeList("categories/"+category.Alias+"/index.html", posts); err != nil {
+			return err
+		}
+	}
+
+	threads := m.commentThreads()
+	for _, post := range site.Posts {
+		if err := m.writeDetail(post, threads[post.ID]); err != nil {
+			return err
+		}
+	}
+
+	if err := m.render("404.html", "404.tmpl", gin.H{"Message": "页面未找到"}); err != nil {
+		return err
+	}
+	return copyStatic(out, opts.StaticDir)
+}
+
+func (m *mirror) render(name, tmpl string, data gin.H) error {
+	page := gin.H{"csrfToken": "", "cspNonce": ""}
+	for k, v := range m.common {
+		page[k] = v
+	}
+	for k, v := range data {
+		page[k] = v
+	}
+
+	var buf bytes.Buffer
+	if err := m.templates.ExecuteTemplate(&buf, tmpl, page); err != nil {
+		return fmt.Errorf("渲染 %s 失败: %v", name, err)
+	}
+	return m.out.WriteFile(name, buf.Bytes())
+}
+
+// writeList 渲染文章列表页，镜像中没有分页，全部文章按时间倒序列在一页
+func (m *mirror) writeList(name string, posts []models.Post) error {
+	items := make([]listPost, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, listPost{Post: post, TimeAgo: post.CreatedAt.Format(mirrorTimeLayout)})
+	}
+	return m.render(name, "home.tmpl", gin.H{
+		"posts":       items,
+		"sort":        "new",
+		"period":      "",
+		"nextCursor":  "",
+		"isFirstPage": true,
+	})
+}
+
+// writeDetail 渲染文章详情页，全部评论显示在一页
+func (m *mirror) writeDetail(post models.Post, comments []threadComment) error {
+	var postCount, replyCount, likeCount int
+	for _, p := range m.site.Posts {
+		if p.UserId == post.UserId {
+			postCount++
+			replyCount += p.Replies
+			likeCount += p.Likes
+		}
+	}
+
+	var related []services.RelatedPost
+	if m.related != nil {
+		var err error
+		if related, err = m.related(&post); err != nil {
+			slog.Error("获取相关文章失败", "error", err)
+		}
+	}
+	var relatedPosts []services.RelatedPost
+	for _, r := range related {
+		if m.exported[r.ID] && len(relatedPosts) < 3 {
+			relatedPosts = append(relatedPosts, r)
+		}
+	}
+
+	return m.render(fmt.Sprintf("post-%d-1/index.html", post.ID), "detail.tmpl", gin.H{
+		"Post":               post,
+		"User":               post.User,
+		"Content":            template.HTML(post.Content),
+		"Tags":               utils.ParseTags(post.Tags),
+		"Comments":           comments,
+		"commentCurrentPage": 1,
+		"commentTotalPages":  1,
+		"commentHasPrev":     false,
+		"commentHasNext":     false,
+		"commentPrevPage":    0,
+		"commentNextPage":    2,
+		"commentTotalCount":  len(comments),
+		"postCount":          postCount,
+		"replyCount":         replyCount,
+		"likeCount":          likeCount,
+		"RelatedPosts":       relatedPosts,
+	})
+}
+
+// commentThreads 按文章分组顶级评论（最新的在前，与详情页一致），回复挂到所属评论下
+func (m *mirror) commentThreads() map[uint][]threadComment {
+	replies := make(map[uint][]threadComment)
+	for _, comment := range m.site.Comments {
+		if comment.ParentID != 0 {
+			replies[comment.ParentID] = append(replies[comment.ParentID], threadComment{
+				Comment: comment,
+				TimeAgo: comment.CreatedAt.Format(mirrorTimeLayout),
+				Content: template.HTML(comment.Content),
+			})
+		}
+	}
+	threads := make(map[uint][]threadComment)
+	for i := len(m.site.Comments) - 1; i >= 0; i-- {
+		comment := m.site.Comments[i]
+		if comment.ParentID == 0 {
+			threads[comment.PostID] = append(threads[comment.PostID], threadComment{
+				Comment: comment,
+				TimeAgo: comment.CreatedAt.Format(mirrorTimeLayout),
+				Replies: replies[comment.ID],
+				Content: template.HTML(comment.Content),
+			})
+		}
+	}
+	return threads
+}
+
+// pathSegment 是否可以作为单个路径段（非空、不含分隔符、不是 . 或 ..）
+func pathSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
+
+// filterPosts 去掉没有导出的文章，避免镜像中出现失效链接
+func (m *mirror) filterPosts(posts []models.Post) []models.Post {
+	var result []models.Post
+	for _, post := range posts {
+		if m.exported[post.ID] {
+			result = append(result, post)
+		}
+	}
+	return result
+}
+
+// copyStatic 把CSS、JS和图标复制到镜像的 static 目录
+func copyStatic(out Sink, dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		slog.Warn("静态资源目录不存在，镜像中将不包含样式和脚本", "dir", dir)
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return out.WriteFile("static/"+filepath.ToSlash(rel), data)
+	})
+}
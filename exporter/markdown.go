@@ -0,0 +1,152 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+	"gin-doniai/dto"
+	"gin-doniai/models"
+	"gin-doniai/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter 导出的Markdown头部，字段与导入时读取的一致，导出包可以直接重新导入
+type frontMatter struct {
+	Title     string   `yaml:"title"`
+	Slug      string   `yaml:"slug"`
+	Author    string   `yaml:"author"`
+	Category  string   `yaml:"category"`
+	Tags      []string `yaml:"tags"`
+	Date      string   `yaml:"date"`
+	Updated   string   `yaml:"updated"`
+	ReadLimit int      `yaml:"read_limit"`
+	Comments  int      `yaml:"comments"`
+}
+
+// exportComment 导出的评论，回复嵌套在顶级评论下
+type exportComment struct {
+	dto.Comment
+	Replies []dto.Comment `json:"replies,omitempty"`
+}
+
+// exportPost 导出的文章，附带作者和评论
+type exportPost struct {
+	dto.Post
+	AuthorInfo *dto.UserSummary `json:"author_info,omitempty"`
+	Comments   []exportComment  `json:"comments"`
+}
+
+// writeMarkdown 每篇文章一个Markdown文件，另外输出完整数据的JSON文件
+func writeMarkdown(out Sink, site *Site, summary *Summary) error {
+	aliases := make(map[int]string, len(site.Categories))
+	for _, category := range site.Categories {
+		aliases[int(category.ID)] = category.Alias
+	}
+	threads := commentThreads(site.Comments)
+	commentCounts := make(map[uint]int)
+	for _, comment := range site.Comments {
+		commentCounts[comment.PostID]++
+	}
+
+	posts := make([]exportPost, 0, len(site.Posts))
+	for _, post := range site.Posts {
+		item := exportPost{Post: dto.NewPost(post, true), Comments: threads[post.ID]}
+		if post.User.ID != 0 {
+			author := dto.NewUserSummary(post.User)
+			item.AuthorInfo = &author
+		}
+		if item.Comments == nil {
+			item.Comments = []exportComment{}
+		}
+		posts = append(posts, item)
+
+		content, err := postMarkdown(post, aliases[post.CategoryId], commentCounts[post.ID])
+		if err != nil {
+			return err
+		}
+		if err := out.WriteFile(fmt.Sprintf("posts/%d.md", post.ID), content); err != nil {
+			return err
+		}
+	}
+
+	authors := make([]dto.User, 0, len(site.Authors))
+	for _, user := range site.Authors {
+		authors = append(authors, dto.NewUser(user, false))
+	}
+
+	jsonFiles := []struct {
+		name string
+		data interface{}
+	}{
+		{"posts.json", posts},
+		{"categories.json", dto.NewCategories(site.Categories)},
+		{"tags.json", site.Tags},
+		{"authors.json", authors},
+		{"manifest.json", summary},
+	}
+	for _, item := range jsonFiles {
+		content, err := json.MarshalIndent(item.data, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := out.WriteFile(item.name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commentThreads 按文章分组评论，回复挂到所属的顶级评论下
+func commentThreads(comments []models.Comment) map[uint][]exportComment {
+	replies := make(map[uint][]dto.Comment)
+	for _, comment := range comments {
+		if comment.ParentID != 0 {
+			replies[comment.ParentID] = append(replies[comment.ParentID], dto.NewComment(comment))
+		}
+	}
+	threads := make(map[uint][]exportComment)
+	for _, comment := range comments {
+		if comment.ParentID == 0 {
+			threads[comment.PostID] = append(threads[comment.PostID], exportComment{
+				Comment: dto.NewComment(comment),
+				Replies: replies[comment.ID],
+			})
+		}
+	}
+	return threads
+}
+
+// postMarkdown 生成带YAML front matter的Markdown，正文保留文章的HTML
+func postMarkdown(post models.Post, categoryAlias string, comments int) ([]byte, error) {
+	category := categoryAlias
+	if category == "" {
+		category = post.Category
+	}
+	author := post.Author
+	if post.User.Name != "" {
+		author = post.User.Name
+	}
+	head, err := yaml.Marshal(frontMatter{
+		Title:     post.Title,
+		Slug:      fmt.Sprintf("post-%d", post.ID),
+		Author:    author,
+		Category:  category,
+		Tags:      utils.ParseTags(post.Tags),
+		Date:      post.CreatedAt.Format(time.RFC3339),
+		Updated:   post.UpdatedAt.Format(time.RFC3339),
+		ReadLimit: post.ReadLimit,
+		Comments:  comments,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(head)
+	buf.WriteString("---\n\n")
+	buf.WriteString(post.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sink 导出文件的写入目标，name 使用 / 分隔的相对路径
+type Sink interface {
+	WriteFile(name string, data []byte) error
+	Close() error
+}
+
+// NewSink 按路径创建写入目标：.zip 结尾写入压缩包，否则写入目录
+func NewSink(target string) (Sink, error) {
+	if strings.EqualFold(filepath.Ext(target), ".zip") {
+		file, err := os.Create(target)
+		if err != nil {
+			return nil, err
+		}
+		return &zipSink{archive: zip.NewWriter(file), file: file}, nil
+	}
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return nil, err
+	}
+	return dirSink(target), nil
+}
+
+// NewZipSink 把导出内容写成zip压缩包
+func NewZipSink(w io.Writer) Sink {
+	return &zipSink{archive: zip.NewWriter(w)}
+}
+
+type zipSink struct {
+	archive *zip.Writer
+	file    *os.File // 由 NewSink 创建时需要关闭
+	now     time.Time
+}
+
+// entryName 规范化导出文件的相对路径，拒绝绝对路径和跳出导出目录的 ..，
+// 防止解压时写到目录之外（zip slip）
+func entryName(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("导出文件路径无效: %q", name)
+	}
+	return cleaned, nil
+}
+
+func (s *zipSink) WriteFile(name string, data []byte) error {
+	name, err := entryName(name)
+	if err != nil {
+		return err
+	}
+	if s.now.IsZero() {
+		s.now = time.Now()
+	}
+	// 设置修改时间，解压后的文件不会都是1980年
+	writer, err := s.archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: s.now})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func (s *zipSink) Close() error {
+	err := s.archive.Close()
+	if s.file != nil {
+		if closeErr := s.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+type dirSink string
+
+func (s dirSink) WriteFile(name string, data []byte) error {
+	name, err := entryName(name)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(string(s), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o644)
+}
+
+func (s dirSink) Close() error {
+	return nil
+}
+
+// countingSink 统计写入的文件数
+type countingSink struct {
+	Sink
+	files int
+}
+
+func (s *countingSink) WriteFile(name string, data []byte) error {
+	if err := s.Sink.WriteFile(name, data); err != nil {
+		return err
+	}
+	s.files++
+	return nil
+}
@@ -0,0 +1,64 @@
+package gql
+
+import (
+	"context"
+	"gin-doniai/apiv1"
+	"gin-doniai/models"
+)
+
+// GraphQL特有的错误码，其余错误码使用 /api/v1 的定义（apiv1.CodeXxx），都放在 errors[].extensions.code 中
+const (
+	CodeQueryTooDeep    = "query_too_deep"
+	CodeQueryTooComplex = "query_too_complex"
+)
+
+// Error 带错误码的GraphQL错误
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions 实现 gqlerrors.ExtendedError，错误码会输出到响应的 extensions 中
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+func newError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// requestState 一次GraphQL请求的登录信息和批量加载器
+type requestState struct {
+	user    *models.User
+	token   *models.APIToken
+	loaders *loaders
+}
+
+type stateKey struct{}
+
+func withState(ctx context.Context, state *requestState) context.Context {
+	return context.WithValue(ctx, stateKey{}, state)
+}
+
+// stateFrom 读取 Handler 保存的请求状态；不经过 Handler 执行schema时按未登录处理，且没有加载器
+func stateFrom(ctx context.Context) *requestState {
+	if state, ok := ctx.Value(stateKey{}).(*requestState); ok {
+		return state
+	}
+	return &requestState{}
+}
+
+// requireUser 写操作需要登录，使用API令牌时还需要对应权限
+func (s *requestState) requireUser(scope string) (*models.User, error) {
+	if s.user == nil {
+		return nil, newError(apiv1.CodeUnauthorized, "请先登录或提供API令牌")
+	}
+	if s.token != nil && !s.token.HasScope(scope) {
+		return nil, newError(apiv1.CodeInsufficientScope, "API令牌缺少权限: "+scope)
+	}
+	return s.user, nil
+}
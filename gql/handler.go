@@ -0,0 +1,98 @@
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"gin-doniai/apiv1"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// request GraphQL请求体
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler GraphQL接口，POST 接收JSON请求体，GET 通过 query/operationName/variables 参数只能执行查询
+func Handler(svc *services.Services) gin.HandlerFunc {
+	schema, err := NewSchema(svc)
+	if err != nil {
+		panic(fmt.Sprintf("GraphQL schema 构建失败: %v", err))
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if c.Request.Method == http.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					respondError(c, http.StatusBadRequest, apiv1.CodeInvalidRequest, "variables 不是合法的JSON")
+					return
+				}
+			}
+		} else if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, apiv1.CodeInvalidRequest, "请求体不是合法的JSON: "+err.Error())
+			return
+		}
+		if req.Query == "" {
+			respondError(c, http.StatusBadRequest, apiv1.CodeInvalidRequest, "缺少 query 参数")
+			return
+		}
+
+		// 先解析一次做深度和复杂度检查，语法错误交给执行器按GraphQL格式返回
+		doc, err := parser.Parse(parser.ParseParams{
+			Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+		})
+		if err == nil {
+			if cost, ok := analyzeQuery(&schema, doc, req.OperationName, req.Variables); ok {
+				if cost.Operation == ast.OperationTypeMutation && c.Request.Method == http.MethodGet {
+					respondError(c, http.StatusMethodNotAllowed, apiv1.CodeInvalidRequest, "mutation 只能通过 POST 请求执行")
+					return
+				}
+				if cost.Depth > MaxDepth {
+					respondError(c, http.StatusBadRequest, CodeQueryTooDeep, fmt.Sprintf("查询嵌套层数 %d 超过上限 %d", cost.Depth, MaxDepth))
+					return
+				}
+				if cost.Complexity > MaxComplexity {
+					respondError(c, http.StatusBadRequest, CodeQueryTooComplex, fmt.Sprintf("查询复杂度 %d 超过上限 %d", cost.Complexity, MaxComplexity))
+					return
+				}
+			}
+		}
+
+		state := &requestState{loaders: newLoaders(svc.WithContext(c.Request.Context()))}
+		if userObj, exists := c.Get("user"); exists {
+			state.user, _ = userObj.(*models.User)
+		}
+		if tokenObj, exists := c.Get("api_token"); exists {
+			state.token, _ = tokenObj.(*models.APIToken)
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        withState(c.Request.Context(), state),
+		})
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"errors": []gin.H{{
+			"message":    message,
+			"extensions": gin.H{"code": code},
+		}},
+	})
+}
@@ -0,0 +1,186 @@
+package gql
+
+import (
+	"strconv"
+	"strings"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// 查询限制
+const (
+	MaxDepth      = 8    // 字段最大嵌套层数
+	MaxComplexity = 5000 // 每个字段计1，列表字段的子字段按 first 参数放大
+
+	defaultListEstimate = 10 // 没有 first 参数的列表（如回复、分类）按此条数估算
+)
+
+// queryCost 一个操作的类型、嵌套深度和复杂度
+type queryCost struct {
+	Operation  string // query 或 mutation
+	Depth      int
+	Complexity int
+}
+
+// analyzeQuery 计算请求中要执行的操作的开销，内省字段（__开头）不计入
+// 找不到操作时返回 ok=false，交给执行器报告错误
+func analyzeQuery(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (queryCost, bool) {
+	a := &analyzer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+	var operation *ast.OperationDefinition
+	count := 0
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			count++
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil || (operationName == "" && count > 1) {
+		return queryCost{}, false
+	}
+
+	cost := queryCost{Operation: operation.Operation}
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	cost.Depth, cost.Complexity = a.selectionSet(operation.SelectionSet, root)
+	return cost, true
+}
+
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+// selectionSet 返回选择集的最大深度和复杂度
+func (a *analyzer) selectionSet(set *ast.SelectionSet, parent *graphql.Object) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = a.field(selection, parent)
+		case *ast.InlineFragment:
+			d, c = a.selectionSet(selection.SelectionSet, parent)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := a.fragments[name]
+			// 循环引用的片段由校验阶段报错，这里直接跳过
+			if !ok || a.visiting[name] {
+				continue
+			}
+			a.visiting[name] = true
+			d, c = a.selectionSet(fragment.SelectionSet, parent)
+			delete(a.visiting, name)
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (a *analyzer) field(field *ast.Field, parent *graphql.Object) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+
+	var definition *graphql.FieldDefinition
+	if parent != nil {
+		definition = parent.Fields()[field.Name.Value]
+	}
+	if definition == nil {
+		// 未知字段由校验阶段报错
+		return 1, 1
+	}
+
+	child, isList := unwrapType(definition.Type)
+	childObject, _ := child.(*graphql.Object)
+	depth, complexity := a.selectionSet(field.SelectionSet, childObject)
+	switch {
+	case hasArgument(definition, "first"):
+		complexity *= a.listSize(field, definition)
+	case isList && !strings.HasSuffix(parent.Name(), "Connection"):
+		// 连接类型的 nodes 已经按外层字段的 first 计算过
+		complexity *= defaultListEstimate
+	}
+	return depth + 1, complexity + 1
+}
+
+func hasArgument(definition *graphql.FieldDefinition, name string) bool {
+	for _, arg := range definition.Args {
+		if arg.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// listSize 列表字段预计返回的条数：请求中的 first 参数或其默认值
+func (a *analyzer) listSize(field *ast.Field, definition *graphql.FieldDefinition) int {
+	size := defaultListEstimate
+	for _, arg := range definition.Args {
+		if n, ok := arg.DefaultValue.(int); ok && arg.Name() == "first" {
+			size = n
+		}
+	}
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			switch n := a.variables[value.Name.Value].(type) {
+			case float64:
+				size = int(n)
+			case int:
+				size = n
+			}
+		}
+	}
+	return clamp(size)
+}
+
+func clamp(n int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > maxListSize {
+		return maxListSize
+	}
+	return n
+}
+
+// unwrapType 去掉非空和列表包装，返回具体类型以及是否为列表
+func unwrapType(t graphql.Type) (graphql.Type, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			isList = true
+			t = wrapped.OfType
+		default:
+			return t, isList
+		}
+	}
+}
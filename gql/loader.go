@@ -0,0 +1,163 @@
+package gql
+
+import (
+	"sync"
+	"gin-doniai/models"
+	"gin-doniai/services"
+)
+
+// Loader 请求内的批量加载器
+// 同一层字段解析时只登记key，执行器第一次取值时把已登记的key合并为一次查询，结果在本次请求内缓存
+type Loader[K comparable, V any] struct {
+	fetch   func(keys []K) (map[K]V, error)
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	loaded  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+// NewLoader 创建批量加载器，fetch 返回的map中不存在的key视为null
+func NewLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		loaded:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load 登记key并返回延迟取值函数，供resolver直接返回给graphql执行器
+func (l *Loader[K, V]) Load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.loaded[key] && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		value, ok, err := l.get(key)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+func (l *Loader[K, V]) get(key K) (V, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		results, err := l.fetch(keys)
+		for _, k := range keys {
+			delete(l.queued, k)
+			l.loaded[k] = true
+			if err != nil {
+				l.errs[k] = err
+				continue
+			}
+			if v, ok := results[k]; ok {
+				l.results[k] = v
+			}
+		}
+	}
+
+	value, ok := l.results[key]
+	return value, ok, l.errs[key]
+}
+
+// commentsKey 文章顶级评论的查询条件
+type commentsKey struct {
+	PostID uint
+	First  int
+}
+
+// loaders 一次请求使用的全部加载器
+type loaders struct {
+	users      *Loader[uint, models.User]
+	posts      *Loader[uint, models.Post]
+	categories *Loader[int, models.Category]
+	comments   *Loader[commentsKey, []models.Comment]
+	replies    *Loader[uint, []models.Comment]
+}
+
+// newLoaders 创建一次请求使用的加载器，数据通过 svc 读取，与REST接口共用缓存和业务规则
+func newLoaders(svc *services.Services) *loaders {
+	return &loaders{
+		users: NewLoader(func(ids []uint) (map[uint]models.User, error) {
+			users, err := svc.Users.ByIDs(ids)
+			return byKey(users, err, func(user models.User) uint { return user.ID })
+		}),
+		posts: NewLoader(func(ids []uint) (map[uint]models.Post, error) {
+			posts, err := svc.Posts.ByIDs(ids)
+			return byKey(posts, err, func(post models.Post) uint { return post.ID })
+		}),
+		categories: NewLoader(func(ids []int) (map[int]models.Category, error) {
+			keys := make([]uint, 0, len(ids))
+			for _, id := range ids {
+				keys = append(keys, uint(id))
+			}
+			categories, err := svc.Categories.ByIDs(keys)
+			return byKey(categories, err, func(category models.Category) int { return int(category.ID) })
+		}),
+		comments: NewLoader(func(keys []commentsKey) (map[commentsKey][]models.Comment, error) {
+			return loadTopComments(svc.Comments, keys)
+		}),
+		replies: NewLoader(func(parentIDs []uint) (map[uint][]models.Comment, error) {
+			replies, err := svc.Comments.Replies(parentIDs)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint][]models.Comment, len(parentIDs))
+			for _, reply := range replies {
+				result[reply.ParentID] = append(result[reply.ParentID], reply)
+			}
+			return result, nil
+		}),
+	}
+}
+
+// byKey 把批量查询结果按key转换为map
+func byKey[K comparable, V any](items []V, err error, key func(V) K) (map[K]V, error) {
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[K]V, len(items))
+	for _, item := range items {
+		result[key(item)] = item
+	}
+	return result, nil
+}
+
+// loadTopComments 一次查询多篇文章的顶级评论（最新在前），再按每个key的 first 截取
+func loadTopComments(comments *services.CommentService, keys []commentsKey) (map[commentsKey][]models.Comment, error) {
+	postIDs := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		postIDs = append(postIDs, key.PostID)
+	}
+
+	topLevel, err := comments.TopLevelOf(postIDs)
+	if err != nil {
+		return nil, err
+	}
+	byPost := make(map[uint][]models.Comment)
+	for _, comment := range topLevel {
+		byPost[comment.PostID] = append(byPost[comment.PostID], comment)
+	}
+
+	result := make(map[commentsKey][]models.Comment, len(keys))
+	for _, key := range keys {
+		list := byPost[key.PostID]
+		if len(list) > key.First {
+			list = list[:key.First]
+		}
+		result[key] = list
+	}
+	return result, nil
+}
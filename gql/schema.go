@@ -0,0 +1,495 @@
+package gql
+
+import (
+	"errors"
+	"strconv"
+	"gin-doniai/apiv1"
+	"gin-doniai/models"
+	"gin-doniai/ranking"
+	"gin-doniai/services"
+	"gin-doniai/utils"
+	"github.com/graphql-go/graphql"
+)
+
+// 列表字段 first 参数的上限
+const maxListSize = 100
+
+// fieldOf 从父对象读取一个字段，父对象类型不符时返回null
+func fieldOf[T any](t graphql.Output, description string, get func(T) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type:        t,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			source, ok := p.Source.(T)
+			if !ok {
+				return nil, nil
+			}
+			return get(source), nil
+		},
+	}
+}
+
+// orEmpty 加载器中没有记录时返回空列表，避免非空列表字段报错
+func orEmpty[V any](thunk func() (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		value, err := thunk()
+		if value == nil && err == nil {
+			return []V{}, nil
+		}
+		return value, err
+	}
+}
+
+// parseID 解析ID参数
+func parseID(value interface{}) (uint, error) {
+	s, _ := value.(string)
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, newError(apiv1.CodeInvalidRequest, "无效的ID: "+s)
+	}
+	return uint(id), nil
+}
+
+// listSize 读取 first 参数并限制在 1~maxListSize
+func listSize(args map[string]interface{}) int {
+	first, _ := args["first"].(int)
+	if first < 1 {
+		first = 1
+	}
+	if first > maxListSize {
+		first = maxListSize
+	}
+	return first
+}
+
+var idType = graphql.NewNonNull(graphql.ID)
+
+func nonNullList(t graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// resolver 需要业务服务的字段解析
+type resolver struct {
+	svc *services.Services
+}
+
+// NewSchema 构建GraphQL schema
+func NewSchema(svc *services.Services) (graphql.Schema, error) {
+	r := &resolver{svc: svc}
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "用户",
+		Fields: graphql.Fields{
+			"id":        fieldOf(idType, "", func(u models.User) interface{} { return u.ID }),
+			"name":      fieldOf(graphql.NewNonNull(graphql.String), "", func(u models.User) interface{} { return u.Name }),
+			"avatar":    fieldOf(graphql.NewNonNull(graphql.String), "", func(u models.User) interface{} { return u.Avatar }),
+			"level":     fieldOf(graphql.NewNonNull(graphql.Int), "", func(u models.User) interface{} { return u.Level }),
+			"motto":     fieldOf(graphql.NewNonNull(graphql.String), "个人格言", func(u models.User) interface{} { return u.Motto }),
+			"github":    fieldOf(graphql.NewNonNull(graphql.String), "", func(u models.User) interface{} { return u.Github }),
+			"createdAt": fieldOf(graphql.NewNonNull(graphql.DateTime), "", func(u models.User) interface{} { return u.CreatedAt }),
+			"email": {
+				Type:        graphql.String,
+				Description: "仅本人和管理员可见",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user, ok := p.Source.(models.User)
+					if !ok || !services.CanSeePrivate(stateFrom(p.Context).user, user.ID) {
+						return nil, nil
+					}
+					return user.Email, nil
+				},
+			},
+		},
+	})
+
+	categoryType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Category",
+		Description: "文章分类",
+		Fields: graphql.Fields{
+			"id":    fieldOf(idType, "", func(c models.Category) interface{} { return c.ID }),
+			"name":  fieldOf(graphql.NewNonNull(graphql.String), "", func(c models.Category) interface{} { return c.Name }),
+			"alias": fieldOf(graphql.NewNonNull(graphql.String), "", func(c models.Category) interface{} { return c.Alias }),
+		},
+	})
+
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Comment",
+		Description: "评论，顶级评论的 parentId 为 null",
+		Fields: graphql.Fields{
+			"id":        fieldOf(idType, "", func(c models.Comment) interface{} { return c.ID }),
+			"content":   fieldOf(graphql.NewNonNull(graphql.String), "HTML内容", func(c models.Comment) interface{} { return c.Content }),
+			"likeCount": fieldOf(graphql.NewNonNull(graphql.Int), "", func(c models.Comment) interface{} { return c.LikeCount }),
+			"createdAt": fieldOf(graphql.NewNonNull(graphql.DateTime), "", func(c models.Comment) interface{} { return c.CreatedAt }),
+			"updatedAt": fieldOf(graphql.NewNonNull(graphql.DateTime), "", func(c models.Comment) interface{} { return c.UpdatedAt }),
+			"parentId": fieldOf(graphql.ID, "", func(c models.Comment) interface{} {
+				if c.ParentID == 0 {
+					return nil
+				}
+				return c.ParentID
+			}),
+			"author": {
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					comment, _ := p.Source.(models.Comment)
+					return stateFrom(p.Context).loaders.users.Load(comment.UserID), nil
+				},
+			},
+		},
+	})
+
+	relatedPostType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "RelatedPost",
+		Description: "相关文章及推荐理由",
+		Fields: graphql.Fields{
+			"score":   fieldOf(graphql.NewNonNull(graphql.Float), "", func(related services.RelatedPost) interface{} { return related.Score }),
+			"reasons": fieldOf(nonNullList(graphql.String), "", func(related services.RelatedPost) interface{} { return related.Reasons }),
+		},
+	})
+
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Post",
+		Description: "文章",
+		Fields: graphql.Fields{
+			"id":        fieldOf(idType, "", func(p models.Post) interface{} { return p.ID }),
+			"title":     fieldOf(graphql.NewNonNull(graphql.String), "", func(p models.Post) interface{} { return p.Title }),
+			"content":   fieldOf(graphql.NewNonNull(graphql.String), "HTML正文", func(p models.Post) interface{} { return p.Content }),
+			"summary":   fieldOf(graphql.NewNonNull(graphql.String), "纯文本摘要", func(p models.Post) interface{} { return utils.Truncate(utils.StripHTML(p.Content), 200) }),
+			"tags":      fieldOf(nonNullList(graphql.String), "", func(p models.Post) interface{} { return utils.ParseTags(p.Tags) }),
+			"views":     fieldOf(graphql.NewNonNull(graphql.Int), "", func(p models.Post) interface{} { return p.Views }),
+			"likes":     fieldOf(graphql.NewNonNull(graphql.Int), "", func(p models.Post) interface{} { return p.Likes }),
+			"favorites": fieldOf(graphql.NewNonNull(graphql.Int), "", func(p models.Post) interface{} { return p.Favorites }),
+			"replies":   fieldOf(graphql.NewNonNull(graphql.Int), "评论数", func(p models.Post) interface{} { return p.Replies }),
+			"readLimit": fieldOf(graphql.NewNonNull(graphql.Int), "阅读限制: 1-公开, 2-Lv1, 3-Lv2, 4-私有", func(p models.Post) interface{} { return p.ReadLimit }),
+			"createdAt": fieldOf(graphql.NewNonNull(graphql.DateTime), "", func(p models.Post) interface{} { return p.CreatedAt }),
+			"updatedAt": fieldOf(graphql.NewNonNull(graphql.DateTime), "", func(p models.Post) interface{} { return p.UpdatedAt }),
+			"author": {
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					post, _ := p.Source.(models.Post)
+					return stateFrom(p.Context).loaders.users.Load(uint(post.UserId)), nil
+				},
+			},
+			"category": {
+				Type: categoryType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					post, _ := p.Source.(models.Post)
+					return stateFrom(p.Context).loaders.categories.Load(post.CategoryId), nil
+				},
+			},
+			"comments": {
+				Type:        nonNullList(commentType),
+				Description: "顶级评论，最新在前",
+				Args: graphql.FieldConfigArgument{
+					"first": {Type: graphql.Int, DefaultValue: 20},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					post, _ := p.Source.(models.Post)
+					key := commentsKey{PostID: post.ID, First: listSize(p.Args)}
+					return orEmpty[models.Comment](stateFrom(p.Context).loaders.comments.Load(key)), nil
+				},
+			},
+			"relatedPosts": {
+				Type:        nonNullList(relatedPostType),
+				Description: "相关文章（定时预先计算，结果有缓存）",
+				Args: graphql.FieldConfigArgument{
+					"first": {Type: graphql.Int, DefaultValue: 3},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					post, _ := p.Source.(models.Post)
+					related, err := r.svc.Posts.CachedRelated(&post)
+					if err != nil {
+						return nil, err
+					}
+					if first := listSize(p.Args); len(related) > first {
+						related = related[:first]
+					}
+					return related, nil
+				},
+			},
+		},
+	})
+
+	// 循环引用的字段在类型都创建后再添加
+	commentType.AddFieldConfig("post", &graphql.Field{
+		Type: postType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			comment, _ := p.Source.(models.Comment)
+			return stateFrom(p.Context).loaders.posts.Load(comment.PostID), nil
+		},
+	})
+	commentType.AddFieldConfig("replies", &graphql.Field{
+		Type:        nonNullList(commentType),
+		Description: "回复，按时间正序",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			comment, _ := p.Source.(models.Comment)
+			return orEmpty[models.Comment](stateFrom(p.Context).loaders.replies.Load(comment.ID)), nil
+		},
+	})
+	relatedPostType.AddFieldConfig("post", &graphql.Field{
+		Type: postType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			related, _ := p.Source.(services.RelatedPost)
+			return stateFrom(p.Context).loaders.posts.Load(related.ID), nil
+		},
+	})
+
+	postConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PostConnection",
+		Description: "一页文章，nextCursor 为空表示没有下一页",
+		Fields: graphql.Fields{
+			"nodes":      fieldOf(nonNullList(postType), "", func(page services.FeedPage) interface{} { return page.Posts }),
+			"nextCursor": fieldOf(graphql.String, "", func(page services.FeedPage) interface{} {
+				if page.NextCursor == "" {
+					return nil
+				}
+				return page.NextCursor
+			}),
+			"sort": fieldOf(graphql.NewNonNull(graphql.String), "实际使用的排序，榜单未就绪时为 new", func(page services.FeedPage) interface{} { return string(page.Sort) }),
+		},
+	})
+
+	feedSortEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "FeedSort",
+		Values: graphql.EnumValueConfigMap{
+			"HOT": {Value: string(ranking.SortHot), Description: "热门"},
+			"NEW": {Value: string(ranking.SortNew), Description: "最新"},
+			"TOP": {Value: string(ranking.SortTop), Description: "一段时间内互动最多"},
+		},
+	})
+	feedPeriodEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "FeedPeriod",
+		Values: graphql.EnumValueConfigMap{
+			"DAY":   {Value: string(ranking.PeriodDay)},
+			"WEEK":  {Value: string(ranking.PeriodWeek)},
+			"MONTH": {Value: string(ranking.PeriodMonth)},
+			"ALL":   {Value: string(ranking.PeriodAll)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": {
+				Type: postType,
+				Args: graphql.FieldConfigArgument{"id": {Type: idType}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					return stateFrom(p.Context).loaders.posts.Load(id), nil
+				},
+			},
+			"posts": {
+				Type:        graphql.NewNonNull(postConnectionType),
+				Description: "首页文章列表，与首页的热门/最新/Top排序一致",
+				Args: graphql.FieldConfigArgument{
+					"sort":       {Type: feedSortEnum, DefaultValue: string(ranking.SortHot)},
+					"period":     {Type: feedPeriodEnum, DefaultValue: string(ranking.PeriodWeek), Description: "仅 TOP 排序使用"},
+					"categoryId": {Type: graphql.ID},
+					"first":      {Type: graphql.Int, DefaultValue: 10},
+					"after":      {Type: graphql.String, Description: "上一页的 nextCursor"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					opts := services.FeedOptions{
+						Sort:   ranking.ParseSort(p.Args["sort"].(string)),
+						Period: ranking.ParsePeriod(p.Args["period"].(string)),
+						Limit:  listSize(p.Args),
+					}
+					if after, ok := p.Args["after"].(string); ok {
+						opts.Cursor = after
+					}
+					if categoryID, ok := p.Args["categoryId"]; ok {
+						id, err := parseID(categoryID)
+						if err != nil {
+							return nil, err
+						}
+						opts.CategoryID = int(id)
+					}
+					return r.svc.Posts.Feed(opts)
+				},
+			},
+			"comment": {
+				Type: commentType,
+				Args: graphql.FieldConfigArgument{"id": {Type: idType}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					comment, err := r.svc.Comments.Get(id)
+					if err != nil {
+						return nil, nil
+					}
+					return *comment, nil
+				},
+			},
+			"user": {
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"id": {Type: idType}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					return stateFrom(p.Context).loaders.users.Load(id), nil
+				},
+			},
+			"me": {
+				Type:        userType,
+				Description: "当前登录用户，未登录时为null",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					state := stateFrom(p.Context)
+					if state.user == nil {
+						return nil, nil
+					}
+					user, err := state.requireUser(models.ScopeRead)
+					if err != nil {
+						return nil, err
+					}
+					return *user, nil
+				},
+			},
+			"categories": {
+				Type:        nonNullList(categoryType),
+				Description: "启用中的分类",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.svc.Categories.CachedActive()
+				},
+			},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createComment": {
+				Type:        commentType,
+				Description: "发表评论或回复，需要已验证邮箱",
+				Args: graphql.FieldConfigArgument{
+					"postId":   {Type: idType},
+					"content":  {Type: graphql.NewNonNull(graphql.String)},
+					"parentId": {Type: graphql.ID},
+				},
+				Resolve: r.createComment,
+			},
+			"likePost": {
+				Type:        postType,
+				Description: "文章点赞，like 为 false 时取消点赞",
+				Args: graphql.FieldConfigArgument{
+					"id":   {Type: idType},
+					"like": {Type: graphql.Boolean, DefaultValue: true},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.postReaction(p, p.Args["like"].(bool), r.svc.Posts.SetLike)
+				},
+			},
+			"favoritePost": {
+				Type:        postType,
+				Description: "收藏文章，favorite 为 false 时取消收藏",
+				Args: graphql.FieldConfigArgument{
+					"id":       {Type: idType},
+					"favorite": {Type: graphql.Boolean, DefaultValue: true},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.postReaction(p, p.Args["favorite"].(bool), r.svc.Posts.SetFavorite)
+				},
+			},
+			"likeComment": {
+				Type:        commentType,
+				Description: "评论点赞，like 为 false 时取消点赞",
+				Args: graphql.FieldConfigArgument{
+					"id":   {Type: idType},
+					"like": {Type: graphql.Boolean, DefaultValue: true},
+				},
+				Resolve: r.likeComment,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+func (r *resolver) createComment(p graphql.ResolveParams) (interface{}, error) {
+	user, err := stateFrom(p.Context).requireUser(models.ScopeWriteComments)
+	if err != nil {
+		return nil, err
+	}
+	postID, err := parseID(p.Args["postId"])
+	if err != nil {
+		return nil, err
+	}
+	input := services.CommentInput{PostID: postID, Content: p.Args["content"].(string)}
+	if parent, ok := p.Args["parentId"]; ok {
+		if input.ParentID, err = parseID(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	comment, err := r.svc.Comments.Create(user, input)
+	if errors.Is(err, services.ErrPostNotFound) || errors.Is(err, services.ErrCommentNotFound) {
+		return nil, newError(apiv1.CodeNotFound, err.Error())
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		return nil, newError(apiv1.CodeEmailUnverified, err.Error())
+	}
+	if err != nil {
+		return nil, newError(apiv1.CodeInternal, "评论创建失败")
+	}
+	return *comment, nil
+}
+
+// postReaction 点赞/收藏文章后返回最新的文章数据
+func (r *resolver) postReaction(p graphql.ResolveParams, active bool, set func(*models.User, uint, bool) (int, error)) (interface{}, error) {
+	user, err := stateFrom(p.Context).requireUser(models.ScopeWritePosts)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := set(user, id, active); err != nil {
+		if errors.Is(err, services.ErrPostNotFound) {
+			return nil, newError(apiv1.CodeNotFound, err.Error())
+		}
+		return nil, newError(apiv1.CodeInternal, err.Error())
+	}
+
+	post, err := r.svc.Posts.Get(id)
+	if err != nil {
+		return nil, newError(apiv1.CodeNotFound, services.ErrPostNotFound.Error())
+	}
+	return *post, nil
+}
+
+func (r *resolver) likeComment(p graphql.ResolveParams) (interface{}, error) {
+	user, err := stateFrom(p.Context).requireUser(models.ScopeWriteComments)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.svc.Comments.SetLike(user, id, p.Args["like"].(bool)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrCommentNotFound):
+			return nil, newError(apiv1.CodeNotFound, err.Error())
+		case errors.Is(err, services.ErrSelfLike):
+			return nil, newError(apiv1.CodeForbidden, err.Error())
+		}
+		return nil, newError(apiv1.CodeInternal, err.Error())
+	}
+
+	comment, err := r.svc.Comments.Get(id)
+	if err != nil {
+		return nil, newError(apiv1.CodeNotFound, services.ErrCommentNotFound.Error())
+	}
+	return *comment, nil
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "os"
+    "time"
+    "gin-doniai/logging"
+    "gin-doniai/models"
+    "gin-doniai/services"
+    "gin-doniai/utils"
+    "github.com/gin-gonic/gin"
+)
+
+// AccountHandler 个人数据导出和账户注销（仅限网页登录）
+type AccountHandler struct {
+    accounts *services.AccountService
+    // exportQueue 数据导出任务队列，由后台worker生成导出包
+    exportQueue chan<- uint
+    // notify 发送通知邮件，通常为 (*services.EmailService).Notify
+    notify func(to, subject, body string) error
+}
+
+// NewAccountHandler 创建账户处理器
+func NewAccountHandler(accounts *services.AccountService, exportQueue chan<- uint, notify func(to, subject, body string) error) *AccountHandler {
+    return &AccountHandler{accounts: accounts, exportQueue: exportQueue, notify: notify}
+}
+
+// RequestDataExport 申请导出个人数据，导出包由后台worker异步生成
+func (h *AccountHandler) RequestDataExport(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    export, err := h.accounts.RequestExport(user.ID)
+    switch {
+    case errors.Is(err, services.ErrExportInProgress):
+        c.JSON(http.StatusConflict, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    case errors.Is(err, services.ErrExportTooSoon):
+        c.JSON(http.StatusTooManyRequests, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "创建导出任务失败: " + err.Error(),
+        })
+        return
+    }
+
+    select {
+    case h.exportQueue <- export.ID:
+    default:
+        // 队列满时保持排队状态，worker重启时会重新处理
+        logging.FromContext(c).Warn("数据导出队列已满，任务将稍后处理", "export_id", export.ID)
+    }
+
+    c.JSON(http.StatusAccepted, gin.H{
+        "success": true,
+        "message": "导出任务已创建，生成完成后可在设置页下载",
+        "data":    export,
+    })
+}
+
+// GetDataExports 获取当前用户的数据导出记录
+func (h *AccountHandler) GetDataExports(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    exports, err := h.accounts.Exports(user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "获取导出记录失败: " + err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "data":    exports,
+    })
+}
+
+// DownloadDataExport 下载数据导出包
+func (h *AccountHandler) DownloadDataExport(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    id, _ := paramID(c)
+    export, err := h.accounts.Export(id, user.ID)
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{
+            "success": false,
+            "message": "导出记录不存在",
+        })
+        return
+    }
+
+    if export.Status != models.ExportStatusDone || export.FilePath == "" {
+        c.JSON(http.StatusConflict, gin.H{
+            "success": false,
+            "message": "导出包尚未生成完成",
+        })
+        return
+    }
+
+    if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+        c.JSON(http.StatusGone, gin.H{
+            "success": false,
+            "message": "导出包已过期，请重新申请",
+        })
+        return
+    }
+
+    if _, err := os.Stat(export.FilePath); err != nil {
+        c.JSON(http.StatusGone, gin.H{
+            "success": false,
+            "message": "导出文件不存在，请重新申请",
+        })
+        return
+    }
+
+    filename := fmt.Sprintf("doniai-export-%s.zip", export.CreatedAt.Format("20060102"))
+    c.FileAttachment(export.FilePath, filename)
+}
+
+// RequestAccountDeletion 申请注销账户，冷静期结束后由worker执行删除
+// 需要输入当前密码，或在重新登录后 reauthWindow 内提交（第三方登录创建的账户没有可用的密码）
+func (h *AccountHandler) RequestAccountDeletion(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    var requestData struct {
+        Password string `json:"password"`
+    }
+
+    if err := c.ShouldBindJSON(&requestData); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": "请求数据格式错误",
+        })
+        return
+    }
+
+    if requestData.Password != "" {
+        if !utils.CheckPassword(requestData.Password, user.Password) {
+            c.JSON(http.StatusForbidden, gin.H{
+                "success": false,
+                "message": "密码错误",
+            })
+            return
+        }
+    } else if !recentlyAuthenticated(c) {
+        c.JSON(http.StatusForbidden, gin.H{
+            "success": false,
+            "message": "请输入当前密码；通过GitHub或Google登录的账户请重新登录后10分钟内提交",
+        })
+        return
+    }
+
+    deletion, err := h.accounts.RequestDeletion(user)
+    if errors.Is(err, services.ErrDeletionPending) {
+        c.JSON(http.StatusConflict, gin.H{
+            "success": false,
+            "message": err.Error(),
+            "data":    deletion,
+        })
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "提交注销申请失败: " + err.Error(),
+        })
+        return
+    }
+
+    notice := fmt.Sprintf("您的账户将于 %s 注销，届时个人数据将被永久删除，发布的内容将匿名保留。\n在此之前可以随时在设置页撤销。",
+        deletion.ScheduledAt.Format("2006-01-02 15:04"))
+    if err := h.notify(user.Email, "账户注销申请已提交", notice); err != nil {
+        logging.FromContext(c).Error("发送注销通知失败", "error", err)
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "注销申请已提交，冷静期结束后账户将被永久删除",
+        "data":    deletion,
+    })
+}
+
+// CancelAccountDeletion 撤销注销申请
+func (h *AccountHandler) CancelAccountDeletion(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    err := h.accounts.CancelDeletion(user.ID)
+    if errors.Is(err, services.ErrNoPendingDeletion) {
+        c.JSON(http.StatusNotFound, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "撤销失败: " + err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "已撤销注销申请",
+    })
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "gin-doniai/models"
+    "gin-doniai/services"
+    "github.com/gin-gonic/gin"
+)
+
+// sessionUserFromContext 获取通过session登录的用户，令牌管理不允许使用API令牌本身操作
+func sessionUserFromContext(c *gin.Context) *models.User {
+    if _, viaToken := c.Get("api_token"); viaToken {
+        c.JSON(http.StatusForbidden, gin.H{
+            "success": false,
+            "message": "请在网页中登录后管理API令牌",
+        })
+        return nil
+    }
+
+    userObj, exists := c.Get("user")
+    if !exists || userObj == nil {
+        c.JSON(http.StatusUnauthorized, gin.H{
+            "success": false,
+            "message": "用户未登录",
+        })
+        return nil
+    }
+    return userObj.(*models.User)
+}
+
+// APITokenHandler 个人API令牌管理（仅限网页登录）
+type APITokenHandler struct {
+    tokens *services.TokenService
+}
+
+// NewAPITokenHandler 创建API令牌管理处理器
+func NewAPITokenHandler(tokens *services.TokenService) *APITokenHandler {
+    return &APITokenHandler{tokens: tokens}
+}
+
+// List 获取当前用户的API令牌列表
+func (h *APITokenHandler) List(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    tokens, err := h.tokens.List(user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "获取令牌失败: " + err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "data":    tokens,
+    })
+}
+
+// Create 创建API令牌，明文令牌只在创建时返回一次
+func (h *APITokenHandler) Create(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    var input services.TokenInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": "请求参数错误: " + err.Error(),
+        })
+        return
+    }
+
+    raw, token, err := h.tokens.Create(user, input)
+    switch {
+    case errors.Is(err, services.ErrInvalidScope):
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    case errors.Is(err, services.ErrAdminScope):
+        c.JSON(http.StatusForbidden, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "令牌创建失败: " + err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "success": true,
+        "message": "令牌创建成功，请立即复制保存，关闭后将无法再次查看",
+        "token":   raw,
+        "data":    token,
+    })
+}
+
+// Delete 吊销API令牌
+func (h *APITokenHandler) Delete(c *gin.Context) {
+    user := sessionUserFromContext(c)
+    if user == nil {
+        return
+    }
+
+    id, _ := paramID(c)
+    err := h.tokens.Revoke(user, id)
+    if errors.Is(err, services.ErrTokenNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{
+            "success": false,
+            "message": "令牌不存在",
+        })
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "吊销令牌失败: " + err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "令牌已吊销",
+    })
+}
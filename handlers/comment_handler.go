@@ -1,23 +1,28 @@
 package handlers
 
-// 需要添加正确的导入
 import (
-	"fmt"
-	"gin-doniai/database"
-	"gin-doniai/models"
+	"errors"
+	"gin-doniai/dto"
+	"gin-doniai/services"
 	"net/http"
-	"regexp"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-// createComment 创建评论
-func CreateComment(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if !exists || userObj == nil {
+// CommentHandler 评论的JSON接口
+type CommentHandler struct {
+	comments *services.CommentService
+}
+
+// NewCommentHandler 创建评论接口
+func NewCommentHandler(comments *services.CommentService) *CommentHandler {
+	return &CommentHandler{comments: comments}
+}
+
+// Create 创建评论
+func (h *CommentHandler) Create(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
@@ -25,23 +30,8 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-    // 添加额外的类型检查
-    user, ok := userObj.(*models.User)
-    if !ok || user == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
-        return
-    }
-
 	// 解析请求数据
-	var requestData struct {
-		Content  string `json:"content" binding:"required"`
-		PostID   uint   `json:"post_id" binding:"required"`
-		ParentID uint   `json:"parent_id" binding:"omitempty"`
-	}
-
+	var requestData services.CommentInput
 	if err := c.ShouldBindJSON(&requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -50,19 +40,24 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 解析和转换评论内容
-	processedContent := processCommentContent(requestData.Content)
-
-	// 创建评论对象
-	comment := models.Comment{
-		Content:  processedContent,
-		PostID:   requestData.PostID,
-		UserID:   user.ID,
-		ParentID: requestData.ParentID,
+	comment, err := h.comments.Create(user, requestData)
+	// 文章不存在，或回复的评论不存在、不属于该文章
+	if errors.Is(err, services.ErrPostNotFound) || errors.Is(err, services.ErrCommentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
 	}
-
-	// 保存到数据库
-	if err := database.DB.Create(&comment).Error; err != nil {
+	// 未验证邮箱的用户不能评论
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "评论创建失败: " + err.Error(),
@@ -70,48 +65,36 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 更新帖子的回复数
-	database.DB.Model(&models.Post{}).Where("id = ?", requestData.PostID).UpdateColumn("replies", gorm.Expr("replies + ?", 1))
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "评论发表成功",
-		"data":    comment,
+		"data":    dto.NewComment(*comment),
 	})
 }
 
-// getComments 获取评论列表
-func GetComments(c *gin.Context) {
-	postID := c.Query("post_id")
-	if postID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "缺少post_id参数",
-		})
+// List 分页获取评论列表
+func (h *CommentHandler) List(c *gin.Context) {
+	query, ok := parseListQuery(c, services.CommentListSpec)
+	if !ok {
 		return
 	}
 
-	var comments []models.Comment
-	if err := database.DB.Where("post_id = ?", postID).Find(&comments).Error; err != nil {
+	comments, info, err := h.comments.List(query)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "获取评论失败: " + err.Error(),
 		})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    comments,
-	})
+	respondList(c, dto.NewComments(comments), info)
 }
 
-// getComment 获取单个评论
-func GetComment(c *gin.Context) {
-	id := c.Param("id")
-
-	var comment models.Comment
-	if err := database.DB.First(&comment, id).Error; err != nil {
+// Get 获取单个评论
+func (h *CommentHandler) Get(c *gin.Context) {
+	id, _ := paramID(c)
+	comment, err := h.comments.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"message": "评论未找到",
@@ -121,48 +104,24 @@ func GetComment(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    comment,
+		"data":    dto.NewComment(*comment),
 	})
 }
 
-// updateComment 更新评论
-func UpdateComment(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if !exists || userObj == nil {
+// Update 更新评论（仅评论作者）
+func (h *CommentHandler) Update(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
 		})
 		return
 	}
-	user = userObj.(*models.User)
-
-	id := c.Param("id")
-
-	var comment models.Comment
-	if err := database.DB.First(&comment, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
-		return
-	}
-
-	// 检查是否有权限更新评论（必须是评论作者）
-	if comment.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "无权限更新此评论",
-		})
-		return
-	}
 
 	var requestData struct {
 		Content string `json:"content" binding:"required"`
 	}
-
 	if err := c.ShouldBindJSON(&requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -171,61 +130,34 @@ func UpdateComment(c *gin.Context) {
 		return
 	}
 
-	comment.Content = requestData.Content
-	if err := database.DB.Save(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "更新评论失败: " + err.Error(),
-		})
+	id, _ := paramID(c)
+	comment, err := h.comments.Update(user, id, requestData.Content)
+	if err != nil {
+		respondCommentError(c, err, "无权限更新此评论", "更新评论失败: ")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "评论更新成功",
-		"data":    comment,
+		"data":    dto.NewComment(*comment),
 	})
 }
 
-// deleteComment 删除评论
-func DeleteComment(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if !exists || userObj == nil {
+// Delete 删除评论（评论作者或管理员）
+func (h *CommentHandler) Delete(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
 		})
 		return
 	}
-	user = userObj.(*models.User)
-
-	id := c.Param("id")
 
-	var comment models.Comment
-	if err := database.DB.First(&comment, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
-		return
-	}
-
-	// 检查是否有权限删除评论（必须是评论作者或管理员）
-	if comment.UserID != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "无权限删除此评论",
-		})
-		return
-	}
-
-	if err := database.DB.Delete(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "删除评论失败: " + err.Error(),
-		})
+	id, _ := paramID(c)
+	if err := h.comments.Delete(user, id); err != nil {
+		respondCommentError(c, err, "无权限删除此评论", "删除评论失败: ")
 		return
 	}
 
@@ -235,27 +167,30 @@ func DeleteComment(c *gin.Context) {
 	})
 }
 
-// LikeComment 评论点赞
-func LikeComment(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	if !exists || userObj == nil {
+// Like 评论点赞
+func (h *CommentHandler) Like(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
 		})
 		return
 	}
-	user := userObj.(*models.User)
 
-	// 获取评论ID
-	commentId := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "评论未找到",
+		})
+		return
+	}
 
 	// 解析请求数据
 	var requestData struct {
-		Action string `json:"action"` // like 或 unlike
+		Action string `json:"action" binding:"required,oneof=like unlike"` // like 或 unlike
 	}
-
 	if err := c.ShouldBindJSON(&requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -264,51 +199,39 @@ func LikeComment(c *gin.Context) {
 		return
 	}
 
-	// 查询评论
-	var comment models.Comment
-	if err := database.DB.First(&comment, commentId).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "评论未找到",
-		})
-		return
-	}
-
-	// 检查用户是否在给自己点赞
-	if comment.UserID == user.ID && requestData.Action == "like" {
+	likeCount, err := h.comments.SetLike(user, id, requestData.Action == "like")
+	if errors.Is(err, services.ErrSelfLike) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "不能给自己的评论点赞",
+			"message": err.Error(),
 		})
 		return
 	}
-
-	// 更新点赞数
-	if requestData.Action == "like" {
-		database.DB.Model(&comment).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1))
-	} else if requestData.Action == "unlike" {
-		database.DB.Model(&comment).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1))
+	if err != nil {
+		respondCommentError(c, err, err.Error(), "操作失败: ")
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "操作成功",
 		"data": gin.H{
-			"like_count": comment.LikeCount,
+			"like_count": likeCount,
 		},
 	})
 }
 
-func processCommentContent(content string) string {
-	// 使用正则表达式匹配 @用户名 和 #ID 模式
-	// 匹配 @用户名 (假设用户名不包含空格)
-	reUser := regexp.MustCompile(`@(\S+)`)
-	content = reUser.ReplaceAllString(content, `<a href="/user/$1" target="_blank">@$1</a>`)
-
-	// 匹配 #ID (数字)
-	reComment := regexp.MustCompile(`#(\d+)`)
-	content = reComment.ReplaceAllString(content, `<a href="#comment-$1">#$1</a>`)
-
-	// 将整个内容包装在 <p> 标签中
-	return fmt.Sprintf("<p>%s</p>", content)
+// respondCommentError 评论服务错误的响应：不存在返回404，无权限返回403，其他返回500
+func respondCommentError(c *gin.Context, err error, forbidden, failure string) {
+	status, message := http.StatusInternalServerError, failure+err.Error()
+	switch {
+	case errors.Is(err, services.ErrCommentNotFound):
+		status, message = http.StatusNotFound, "评论未找到"
+	case errors.Is(err, services.ErrForbidden):
+		status, message = http.StatusForbidden, forbidden
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message,
+	})
 }
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"gin-doniai/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cspReportMaxBody 单个违规报告的最大字节数
+const cspReportMaxBody = 64 * 1024
+
+// CSPReport 浏览器上报的CSP违规信息（report-uri 格式）
+type CSPReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ScriptSample       string `json:"script-sample"`
+}
+
+// ReportCSPViolation 收集CSP违规报告并写入日志
+func ReportCSPViolation(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, cspReportMaxBody))
+	if err != nil || len(body) == 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Report CSPReport `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	report := payload.Report
+	logging.FromContext(c).Warn("CSP违规报告",
+		"document_uri", report.DocumentURI, "directive", report.EffectiveDirective, "blocked_uri", report.BlockedURI,
+		"source_file", report.SourceFile, "line", report.LineNumber, "disposition", report.Disposition,
+		"user_agent", c.GetHeader("User-Agent"))
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "gin-doniai/logging"
+    "gin-doniai/models"
+    "gin-doniai/services"
+    "github.com/gin-gonic/gin"
+)
+
+// EmailHandler 邮箱验证和修改邮箱
+type EmailHandler struct {
+    emails *services.EmailService
+}
+
+// NewEmailHandler 创建邮箱验证处理器
+func NewEmailHandler(emails *services.EmailService) *EmailHandler {
+    return &EmailHandler{emails: emails}
+}
+
+// VerifyEmail 处理邮箱验证链接
+func (h *EmailHandler) VerifyEmail(c *gin.Context) {
+    user, _ := c.Get("user")
+    data := gin.H{
+        "user": user,
+    }
+    renderError := func(message string) {
+        data["error"] = message
+        RenderHTML(c, http.StatusBadRequest, "verify-email.tmpl", data)
+    }
+
+    token := c.Query("token")
+    if token == "" {
+        renderError("无效的验证链接")
+        return
+    }
+
+    change, err := h.emails.Verify(token)
+    switch {
+    case errors.Is(err, services.ErrVerifyLinkInvalid), errors.Is(err, services.ErrVerifyLinkExpired),
+        errors.Is(err, services.ErrVerifyLinkStale), errors.Is(err, services.ErrEmailInUse),
+        errors.Is(err, services.ErrUserNotFound):
+        renderError(err.Error())
+        return
+    case err != nil:
+        logging.FromContext(c).Error("邮箱验证失败", "error", err)
+        renderError("验证失败，请稍后重试")
+        return
+    }
+
+    if change.Purpose == models.EmailVerifyPurposeChange {
+        data["message"] = "邮箱修改成功，请使用新邮箱登录"
+        notice := fmt.Sprintf("您的账户邮箱已由 %s 修改为 %s。\n如非本人操作，请立即联系管理员。", change.OldEmail, change.NewEmail)
+        if err := h.emails.Notify(change.OldEmail, "您的账户邮箱已修改", notice); err != nil {
+            logging.FromContext(c).Error("发送邮箱变更通知失败", "error", err)
+        }
+    } else {
+        data["message"] = "邮箱验证成功"
+    }
+
+    RenderHTML(c, http.StatusOK, "verify-email.tmpl", data)
+}
+
+// ResendVerificationEmail 重新发送注册验证邮件
+func (h *EmailHandler) ResendVerificationEmail(c *gin.Context) {
+    userObj, exists := c.Get("user")
+    if !exists || userObj == nil {
+        c.JSON(http.StatusUnauthorized, gin.H{
+            "success": false,
+            "message": "用户未登录",
+        })
+        return
+    }
+    user := userObj.(*models.User)
+
+    if user.IsEmailVerified() {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": "邮箱已验证，无需重复发送",
+        })
+        return
+    }
+
+    if err := h.emails.CheckThrottle(user.ID, models.EmailVerifyPurposeRegister); err != nil {
+        h.throttled(c, err)
+        return
+    }
+
+    if err := h.emails.SendVerification(user.ID, user.Email, models.EmailVerifyPurposeRegister); err != nil {
+        logging.FromContext(c).Error("发送验证邮件失败", "error", err)
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "验证邮件发送失败，请稍后重试",
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "验证邮件已发送，请查收",
+    })
+}
+
+// ChangeUserEmail 申请修改邮箱，需要验证当前密码并确认新邮箱
+// 与注销账户相同，重新登录后 reauthWindow 内可以不输入密码（第三方登录创建的账户没有可用的密码）
+func (h *EmailHandler) ChangeUserEmail(c *gin.Context) {
+    userObj, exists := c.Get("user")
+    if !exists || userObj == nil {
+        c.JSON(http.StatusUnauthorized, gin.H{
+            "success": false,
+            "message": "用户未登录",
+        })
+        return
+    }
+    user := userObj.(*models.User)
+
+    var requestData struct {
+        NewEmail string `json:"new_email" binding:"required,email"`
+        Password string `json:"password"`
+    }
+
+    if err := c.ShouldBindJSON(&requestData); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": "请求数据格式错误",
+        })
+        return
+    }
+
+    // 第一重确认：当前密码或刚刚重新登录；第二重确认：新邮箱中的验证链接
+    err := h.emails.RequestChange(user, requestData.Password, requestData.NewEmail, recentlyAuthenticated(c))
+    switch {
+    case errors.Is(err, services.ErrWrongPassword):
+        c.JSON(http.StatusForbidden, gin.H{
+            "success": false,
+            "message": "当前密码错误；通过GitHub或Google登录的账户请重新登录后10分钟内提交",
+        })
+        return
+    case errors.Is(err, services.ErrSameEmail), errors.Is(err, services.ErrEmailTaken):
+        c.JSON(http.StatusBadRequest, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    case errors.Is(err, services.ErrSendTooFrequent), errors.Is(err, services.ErrSendTooMany):
+        h.throttled(c, err)
+        return
+    case err != nil:
+        logging.FromContext(c).Error("发送修改邮箱验证邮件失败", "error", err)
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "success": false,
+            "message": "验证邮件发送失败，请稍后重试",
+        })
+        return
+    }
+
+    // 通知旧邮箱
+    notice := fmt.Sprintf("有人申请将您的账户邮箱修改为 %s，确认链接已发送至新邮箱。\n如非本人操作，请立即修改密码。", requestData.NewEmail)
+    if err := h.emails.Notify(user.Email, "账户邮箱修改申请", notice); err != nil {
+        logging.FromContext(c).Error("发送邮箱变更通知失败", "error", err)
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "确认邮件已发送至新邮箱，完成验证后生效",
+    })
+}
+
+// throttled 验证邮件发送过于频繁时返回429，查询频率失败时返回500
+func (h *EmailHandler) throttled(c *gin.Context, err error) {
+    if errors.Is(err, services.ErrSendTooFrequent) || errors.Is(err, services.ErrSendTooMany) {
+        c.JSON(http.StatusTooManyRequests, gin.H{
+            "success": false,
+            "message": err.Error(),
+        })
+        return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{
+        "success": false,
+        "message": "验证邮件发送失败，请稍后重试",
+    })
+}
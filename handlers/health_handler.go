@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness 存活检查：进程能处理请求即返回200，不检查依赖
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness 就绪检查：服务启动完成、未在关闭中且数据库可用时返回200，否则返回503
+// ping 检查数据库连接，通常为绑定了连接的 database.Ping
+func Readiness(ready func() bool, ping func(ctx context.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "message": "服务正在启动或关闭"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		if err := ping(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "message": "数据库不可用: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"gin-doniai/importer"
+	"github.com/gin-gonic/gin"
+)
+
+// 上传的导入文件最大20MB
+const maxImportUploadSize = 20 << 20
+
+// ImportHandler 管理员批量导入文章
+type ImportHandler struct {
+	run func(items []importer.Item, opts importer.Options, report *importer.Report)
+}
+
+// NewImportHandler 创建导入接口，run 执行导入并把结果写入 report，通常为绑定了数据库的 importer.Run
+func NewImportHandler(run func(items []importer.Item, opts importer.Options, report *importer.Report)) *ImportHandler {
+	return &ImportHandler{run: run}
+}
+
+// ImportPosts 上传Markdown文件、Markdown压缩包（.zip）或WordPress导出文件批量导入文章（仅管理员）
+// 表单字段：file 文件，format 可选 markdown/wxr，category 找不到分类时使用的分类别名，dry_run=true 只返回报告
+// 来源中找不到作者的文章归到当前管理员名下
+func (h *ImportHandler) ImportPosts(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "用户未登录",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请上传导入文件（不超过20MB）",
+		})
+		return
+	}
+	if fileHeader.Size > maxImportUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"success": false,
+			"message": "导入文件不能超过20MB",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "读取上传文件失败",
+		})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "读取上传文件失败",
+		})
+		return
+	}
+
+	report := &importer.Report{}
+	items, err := importer.LoadFile(fileHeader.Filename, data, c.PostForm("format"), report)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	opts := importer.Options{
+		DryRun:          c.PostForm("dry_run") == "true" || c.PostForm("dry_run") == "1",
+		DefaultAuthor:   user,
+		DefaultCategory: c.PostForm("category"),
+	}
+	h.run(items, opts, report)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// parseListQuery 解析列表接口的分页、排序和过滤参数，参数错误时直接返回400
+func parseListQuery(c *gin.Context, spec pagination.Spec) (*pagination.Query, bool) {
+	query, err := pagination.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		var paramErr *pagination.Error
+		status := http.StatusInternalServerError
+		if errors.As(err, &paramErr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+	return query, true
+}
+
+// respondList 统一的列表响应格式
+func respondList(c *gin.Context, data interface{}, info pagination.PageInfo) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       data,
+		"pagination": info,
+	})
+}
+
+// CurrentUserFromContext 获取当前登录用户，未登录返回nil
+func CurrentUserFromContext(c *gin.Context) *models.User {
+	if userObj, exists := c.Get("user"); exists && userObj != nil {
+		if user, ok := userObj.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
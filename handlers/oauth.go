@@ -6,28 +6,27 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"net/url"
-	"os"
 
-	"gin-doniai/database"
-	"gin-doniai/models"
-	"gin-doniai/utils"
+	"gin-doniai/config"
+	"gin-doniai/services"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
-	"github.com/joho/godotenv"
 )
 
-// OAuth配置
+// session中保存OAuth state的键名
+var oauthStateString = "oauthstate"
+
+// 第三方登录的授权、令牌和用户信息地址（测试时替换为模拟服务）
 var (
-	githubOAuthConfig *oauth2.Config
-	googleOAuthConfig *oauth2.Config
-	oauthStateString  = "oauthstate"
+	GitHubEndpoint    = github.Endpoint
+	GitHubUserInfoURL = "https://api.github.com/user"
+	GoogleEndpoint    = google.Endpoint
+	GoogleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
 )
 
 // GitHub用户信息结构体
@@ -48,27 +47,36 @@ type GoogleUser struct {
 	Picture       string `json:"picture"`
 }
 
-func init() {
-    // 加载.env文件
-    if err := godotenv.Load(); err != nil {
-        fmt.Println("警告: 未能加载 .env 文件")
-    }
-	// GitHub OAuth配置
-	githubOAuthConfig = &oauth2.Config{
-		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
-		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"), // 如: http://localhost:8080/auth/github/callback
+// OAuthHandler GitHub和Google第三方登录
+type OAuthHandler struct {
+	users    *services.UserService
+	settings services.Settings
+}
+
+// NewOAuthHandler 创建第三方登录处理器，每次请求从 settings 读取应用信息，SIGHUP重新加载后立即生效
+func NewOAuthHandler(users *services.UserService, settings services.Settings) *OAuthHandler {
+	return &OAuthHandler{users: users, settings: settings}
+}
+
+// githubOAuthConfig 按当前配置生成GitHub OAuth配置
+func githubOAuthConfig(provider config.OAuthProvider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  provider.RedirectURL,
 		Scopes:       []string{"user:email"},
-		Endpoint:     github.Endpoint,
+		Endpoint:     GitHubEndpoint,
 	}
+}
 
-	// Google OAuth配置
-	googleOAuthConfig = &oauth2.Config{
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"), // 如: http://localhost:8080/auth/google/callback
+// googleOAuthConfig 按当前配置生成Google OAuth配置
+func googleOAuthConfig(provider config.OAuthProvider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  provider.RedirectURL,
 		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-		Endpoint:     google.Endpoint,
+		Endpoint:     GoogleEndpoint,
 	}
 }
 
@@ -80,7 +88,12 @@ func generateState() string {
 }
 
 // GitHub授权登录处理
-func GitHubLogin(c *gin.Context) {
+func (h *OAuthHandler) GitHubLogin(c *gin.Context) {
+	provider := h.settings().OAuth.GitHub
+	if !provider.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用GitHub登录"})
+		return
+	}
 	state := generateState()
 	session := sessions.Default(c)
 	session.Set(oauthStateString, state)
@@ -90,12 +103,12 @@ func GitHubLogin(c *gin.Context) {
 		return
 	}
 
-	url := githubOAuthConfig.AuthCodeURL(state)
+	url := githubOAuthConfig(provider).AuthCodeURL(state)
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
 // GitHub回调处理
-func GitHubCallback(c *gin.Context) {
+func (h *OAuthHandler) GitHubCallback(c *gin.Context) {
 	session := sessions.Default(c)
 
 	// 验证state参数
@@ -114,15 +127,16 @@ func GitHubCallback(c *gin.Context) {
 	}
 
 	// 交换access token
-	token, err := githubOAuthConfig.Exchange(context.Background(), code)
+	oauthConfig := githubOAuthConfig(h.settings().OAuth.GitHub)
+	token, err := oauthConfig.Exchange(context.Background(), code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
 		return
 	}
 
 	// 获取用户信息
-	client := githubOAuthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://api.github.com/user")
+	client := oauthConfig.Client(context.Background(), token)
+	resp, err := client.Get(GitHubUserInfoURL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
 		return
@@ -136,14 +150,14 @@ func GitHubCallback(c *gin.Context) {
 	}
 
 	// 处理用户登录/注册
-	user, err := handleOAuthUserLogin(c, githubUser.Login, githubUser.Email, githubUser.Name, githubUser.AvatarURL)
+	user, err := h.users.LoginOAuth(services.OAuthProfile{Identifier: githubUser.Login, Email: githubUser.Email, Name: githubUser.Name, AvatarURL: githubUser.AvatarURL})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user login"})
 		return
 	}
 
 	// 设置session
-	session.Set("user_id", user.ID)
+	setSessionUser(session, user.ID)
 	err = session.Save()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
@@ -155,7 +169,12 @@ func GitHubCallback(c *gin.Context) {
 }
 
 // Google授权登录处理
-func GoogleLogin(c *gin.Context) {
+func (h *OAuthHandler) GoogleLogin(c *gin.Context) {
+	provider := h.settings().OAuth.Google
+	if !provider.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用Google登录"})
+		return
+	}
 	state := generateState()
 	session := sessions.Default(c)
 	session.Set(oauthStateString, state)
@@ -165,12 +184,12 @@ func GoogleLogin(c *gin.Context) {
 		return
 	}
 
-	url := googleOAuthConfig.AuthCodeURL(state)
+	url := googleOAuthConfig(provider).AuthCodeURL(state)
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
 // Google回调处理
-func GoogleCallback(c *gin.Context) {
+func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
 	session := sessions.Default(c)
 
 	// 验证state参数
@@ -189,15 +208,16 @@ func GoogleCallback(c *gin.Context) {
 	}
 
 	// 交换access token
-	token, err := googleOAuthConfig.Exchange(context.Background(), code)
+	oauthConfig := googleOAuthConfig(h.settings().OAuth.Google)
+	token, err := oauthConfig.Exchange(context.Background(), code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
 		return
 	}
 
 	// 获取用户信息
-	client := googleOAuthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	client := oauthConfig.Client(context.Background(), token)
+	resp, err := client.Get(GoogleUserInfoURL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
 		return
@@ -211,14 +231,14 @@ func GoogleCallback(c *gin.Context) {
 	}
 
 	// 处理用户登录/注册
-	user, err := handleOAuthUserLogin(c, googleUser.Email, googleUser.Email, googleUser.Name, googleUser.Picture)
+	user, err := h.users.LoginOAuth(services.OAuthProfile{Identifier: googleUser.Email, Email: googleUser.Email, Name: googleUser.Name, AvatarURL: googleUser.Picture})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user login"})
 		return
 	}
 
 	// 设置session
-	session.Set("user_id", user.ID)
+	setSessionUser(session, user.ID)
 	err = session.Save()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
@@ -228,56 +248,3 @@ func GoogleCallback(c *gin.Context) {
 	// 重定向到首页
 	c.Redirect(http.StatusTemporaryRedirect, "/")
 }
-
-// 处理OAuth用户登录/注册的通用函数
-func handleOAuthUserLogin(c *gin.Context, identifier, email, name, avatarURL string) (*models.User, error) {
-	var user models.User
-
-	// 尝试查找现有用户
-	if email != "" {
-		// 优先通过邮箱查找
-		database.DB.Where("email = ?", email).First(&user)
-	}
-
-	if user.ID == 0 && identifier != "" {
-		// 通过标识符查找
-		database.DB.Where("name = ?", identifier).First(&user)
-	}
-
-	// 如果用户不存在，则创建新用户
-	if user.ID == 0 {
-		// 生成随机密码
-		passwordBytes := make([]byte, 32)
-		rand.Read(passwordBytes)
-		randomPassword := base64.URLEncoding.EncodeToString(passwordBytes)
-
-		hashedPassword, err := utils.HashPassword(randomPassword)
-		if err != nil {
-			return nil, err
-		}
-
-		// 如果没有名字，使用标识符
-		if name == "" {
-			name = identifier
-		}
-
-		// 如果没有头像，生成默认头像
-		if avatarURL == "" {
-			avatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", url.QueryEscape(name))
-		}
-
-		user = models.User{
-			Name:       name,
-			Email:      email,
-			Password:   hashedPassword,
-			AgreeTerms: true, // OAuth用户默认同意条款
-			Avatar:     avatarURL,
-		}
-
-		if err := database.DB.Create(&user).Error; err != nil {
-			return nil, err
-		}
-	}
-
-	return &user, nil
-}
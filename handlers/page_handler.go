@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-doniai/logging"
+	"gin-doniai/models"
+	"gin-doniai/ranking"
+	"gin-doniai/services"
+	"gin-doniai/stats"
+	"gin-doniai/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 各页面每页显示的数量
+const (
+	feedPageSize        = 10
+	detailCommentsLimit = 4
+	articlesPageSize    = 5
+	searchPageSize      = 10
+	rssItemsLimit       = 20
+)
+
+// PageHandler 首页、文章详情、个人文章、发布、搜索和RSS等HTML页面
+type PageHandler struct {
+	posts      *services.PostService
+	comments   *services.CommentService
+	users      *services.UserService
+	categories *services.CategoryService
+	// onView 文章详情页被访问时调用（发送浏览事件）
+	onView func(c *gin.Context, postID uint)
+
+	svc *services.Services
+}
+
+// NewPageHandler 创建页面处理器
+func NewPageHandler(svc *services.Services, onView func(c *gin.Context, postID uint)) *PageHandler {
+	return &PageHandler{
+		posts:      svc.Posts,
+		comments:   svc.Comments,
+		users:      svc.Users,
+		categories: svc.Categories,
+		onView:     onView,
+		svc:        svc,
+	}
+}
+
+// scoped 返回查询时使用请求context的处理器，页面的SQL记录为请求span的子span
+func (h *PageHandler) scoped(c *gin.Context) *PageHandler {
+	svc := h.svc.WithContext(c.Request.Context())
+	return &PageHandler{
+		posts:      svc.Posts,
+		comments:   svc.Comments,
+		users:      svc.Users,
+		categories: svc.Categories,
+		onView:     h.onView,
+		svc:        svc,
+	}
+}
+
+// PostWithFriendlyTime 带有友好时间的文章
+type PostWithFriendlyTime struct {
+	models.Post
+	TimeAgo string
+}
+
+func withTimeAgo(posts []models.Post) []PostWithFriendlyTime {
+	var result []PostWithFriendlyTime
+	for _, post := range posts {
+		result = append(result, PostWithFriendlyTime{
+			Post:    post,
+			TimeAgo: utils.GetTimeAgo(post.CreatedAt),
+		})
+	}
+	return result
+}
+
+// pageParam 读取 page 参数，默认为第1页
+func pageParam(c *gin.Context) int {
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		return p
+	}
+	return 1
+}
+
+// totalPages 总页数
+func totalPages(total int64, limit int) int {
+	return int((total + int64(limit) - 1) / int64(limit))
+}
+
+// sidebar 首页和搜索页侧栏：站点统计、全部分类和热门文章
+func (h *PageHandler) sidebar(c *gin.Context, data gin.H) gin.H {
+	// 获取站点统计信息（含在线用户数），由统计服务在内存中维护
+	siteStats := stats.Default().Snapshot()
+	data["userCount"] = siteStats.UserCount
+	data["postCount"] = siteStats.PostCount
+	data["commentCount"] = siteStats.CommentCount
+	data["onlineCount"] = siteStats.OnlineCount
+
+	categories, err := h.categories.CachedActive()
+	if err != nil {
+		logging.FromContext(c).Error("获取分类失败", "error", err)
+	}
+	data["categories"] = categories
+
+	hotPosts, err := h.posts.CachedHot()
+	if err != nil {
+		logging.FromContext(c).Error("获取热门文章失败", "error", err)
+	}
+	data["hotPosts"] = hotPosts
+	return data
+}
+
+// Home 首页和分类页（/categories/:type）
+func (h *PageHandler) Home(c *gin.Context) {
+	h = h.scoped(c)
+	var categoryID uint
+	if alias := c.Param("type"); alias != "" {
+		category, err := h.categories.ByAlias(alias)
+		if err != nil {
+			// 当找不到分类时，返回404页面而不是继续执行
+			RenderHTML(c, http.StatusNotFound, "404.tmpl", gin.H{
+				"Message": "分类未找到",
+			})
+			return
+		}
+		categoryID = category.ID
+	}
+
+	// 按热门/最新/Top排序读取一页帖子（游标分页）
+	feed, err := h.posts.Feed(services.FeedOptions{
+		Sort:       ranking.ParseSort(c.Query("sort")),
+		Period:     ranking.ParsePeriod(c.Query("t")),
+		CategoryID: int(categoryID),
+		Cursor:     c.Query("cursor"),
+		Limit:      feedPageSize,
+	})
+	if err != nil {
+		logging.FromContext(c).Error("获取帖子列表失败", "error", err)
+	}
+
+	data := h.sidebar(c, gin.H{
+		"CurrentTime": time.Now().Format("2006-01-02 15:04:05"),
+		"posts":       withTimeAgo(feed.Posts),
+		"sort":        string(feed.Sort),
+		"period":      string(feed.Period),
+		"nextCursor":  feed.NextCursor,
+		"isFirstPage": c.Query("cursor") == "",
+		"user":        CurrentUserFromContext(c),
+	})
+	RenderHTML(c, http.StatusOK, "home.tmpl", data)
+}
+
+// CommentWithReplies 带有友好时间和回复的评论
+type CommentWithReplies struct {
+	models.Comment
+	TimeAgo string
+	Replies []CommentWithReplies
+	Content template.HTML
+}
+
+func newCommentWithReplies(comment models.Comment, replies []models.Comment) CommentWithReplies {
+	result := CommentWithReplies{
+		Comment: comment,
+		TimeAgo: utils.GetTimeAgo(comment.CreatedAt),
+		Content: template.HTML(comment.Content),
+	}
+	for _, reply := range replies {
+		result.Replies = append(result.Replies, newCommentWithReplies(reply, nil))
+	}
+	return result
+}
+
+// Detail 文章详情页（/post-:id-1），包含分页的评论、作者统计和相关文章
+func (h *PageHandler) Detail(c *gin.Context) {
+	h = h.scoped(c)
+	// 路由参数为 "29-1" 形式，取第一段作为文章ID
+	idParts := strings.Split(c.Param("id-1"), "-")
+	postID, err := strconv.ParseUint(idParts[0], 10, 32)
+	if err != nil {
+		postID = 0
+	}
+	if h.onView != nil {
+		h.onView(c, uint(postID))
+	}
+
+	post, err := h.posts.GetWithAuthor(uint(postID))
+	if err != nil {
+		RenderHTML(c, http.StatusNotFound, "404.tmpl", gin.H{
+			"Message": "文章未找到",
+		})
+		return
+	}
+
+	// 顶级评论分页，每条带全部回复
+	commentPage := pageParam(c)
+	threads, totalComments, err := h.comments.Threads(post.ID, commentPage, detailCommentsLimit)
+	if err != nil {
+		logging.FromContext(c).Error("获取评论失败", "error", err)
+	}
+	var commentsWithReplies []CommentWithReplies
+	for _, thread := range threads {
+		commentsWithReplies = append(commentsWithReplies, newCommentWithReplies(thread.Comment, thread.Replies))
+	}
+	totalCommentPages := totalPages(totalComments, detailCommentsLimit)
+
+	authorStats, err := h.posts.AuthorStats(post.User.ID)
+	if err != nil {
+		logging.FromContext(c).Error("获取作者统计失败", "error", err)
+	}
+
+	// 相关文章（由worker按标签、内容相似度和共同互动预先计算）
+	relatedPosts, err := h.posts.CachedRelated(post)
+	if err != nil {
+		logging.FromContext(c).Error("获取相关文章失败", "error", err)
+	}
+	if len(relatedPosts) > 3 {
+		relatedPosts = relatedPosts[:3]
+	}
+
+	data := gin.H{
+		"Post":               *post,
+		"User":               post.User,
+		"Content":            template.HTML(post.Content),
+		"Tags":               utils.ParseTags(post.Tags),
+		"user":               CurrentUserFromContext(c),
+		"Comments":           commentsWithReplies,
+		"commentCurrentPage": commentPage,
+		"commentTotalPages":  totalCommentPages,
+		"commentHasPrev":     commentPage > 1,
+		"commentHasNext":     commentPage < totalCommentPages,
+		"commentPrevPage":    commentPage - 1,
+		"commentNextPage":    commentPage + 1,
+		"commentTotalCount":  totalComments,
+		"postCount":          authorStats.PostCount,
+		"replyCount":         authorStats.ReplyCount,
+		"likeCount":          authorStats.LikeCount,
+		"RelatedPosts":       relatedPosts,
+	}
+	RenderHTML(c, http.StatusOK, "detail.tmpl", data)
+}
+
+// Profile 个人主页，未登录时跳转到登录页
+func (h *PageHandler) Profile(c *gin.Context) {
+	h = h.scoped(c)
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	posts, err := h.posts.ListByUser(user.ID, 1, 1)
+	if err != nil {
+		logging.FromContext(c).Error("获取文章数失败", "error", err)
+	}
+
+	data := gin.H{
+		"user":        user,
+		"profileUser": user,
+		"postCount":   posts.Total,
+	}
+	RenderHTML(c, http.StatusOK, "profile.tmpl", data)
+}
+
+// Articles 我的文章、评论和收藏（/posts?tab=articles|comments|favorites），未登录时跳转到登录页
+func (h *PageHandler) Articles(c *gin.Context) {
+	h = h.scoped(c)
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	tab := c.DefaultQuery("tab", "articles")
+	page := pageParam(c)
+
+	userPosts, err := h.posts.ListByUser(user.ID, page, articlesPageSize)
+	if err != nil {
+		logging.FromContext(c).Error("获取用户文章失败", "error", err)
+	}
+
+	type CommentWithPostTitle struct {
+		models.Comment
+		PostTitle string
+		TimeAgo   string
+	}
+	userComments, err := h.comments.ListByUser(user.ID, page, articlesPageSize)
+	if err != nil {
+		logging.FromContext(c).Error("获取用户评论失败", "error", err)
+	}
+	var comments []CommentWithPostTitle
+	for _, comment := range userComments.Items {
+		comments = append(comments, CommentWithPostTitle{
+			Comment:   comment.Comment,
+			PostTitle: comment.PostTitle,
+			TimeAgo:   utils.GetTimeAgo(comment.CreatedAt),
+		})
+	}
+
+	favoritePosts, err := h.posts.Favorites(user.ID, page, articlesPageSize)
+	if err != nil {
+		logging.FromContext(c).Error("获取用户收藏失败", "error", err)
+	}
+
+	totalPostPages := totalPages(userPosts.Total, articlesPageSize)
+	totalCommentPages := totalPages(userComments.Total, articlesPageSize)
+	totalFavoritePages := totalPages(favoritePosts.Total, articlesPageSize)
+	tabPages := totalPostPages
+	switch tab {
+	case "comments":
+		tabPages = totalCommentPages
+	case "favorites":
+		tabPages = totalFavoritePages
+	}
+
+	data := gin.H{
+		"user":               user,
+		"profileUser":        user,
+		"articles":           userPosts.Items,
+		"comments":           comments,
+		"favorites":          withTimeAgo(favoritePosts.Items),
+		"currentPage":        page,
+		"currentTab":         tab,
+		"totalUserPosts":     userPosts.Total,
+		"totalUserComments":  userComments.Total,
+		"totalFavoritePosts": favoritePosts.Total,
+		"totalPostPages":     totalPostPages,
+		"totalCommentPages":  totalCommentPages,
+		"totalFavoritePages": totalFavoritePages,
+		"hasPrev":            page > 1,
+		"hasNext":            page < tabPages,
+		"prevPage":           page - 1,
+		"nextPage":           page + 1,
+	}
+	RenderHTML(c, http.StatusOK, "article-list.tmpl", data)
+}
+
+// Publish 发布文章页，未登录时跳转到登录页
+func (h *PageHandler) Publish(c *gin.Context) {
+	h = h.scoped(c)
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	categories, err := h.categories.CachedActive()
+	if err != nil {
+		logging.FromContext(c).Error("获取分类失败", "error", err)
+	}
+
+	data := gin.H{
+		"user":       user,
+		"categories": categories,
+	}
+	RenderHTML(c, http.StatusOK, "publish.tmpl", data)
+}
+
+// SearchPosts 按标题搜索文章（/search?q=）
+func (h *PageHandler) SearchPosts(c *gin.Context) {
+	h = h.scoped(c)
+	page := pageParam(c)
+	result, err := h.posts.Search(c.Query("q"), page, searchPageSize)
+	if err != nil {
+		logging.FromContext(c).Error("搜索文章失败", "error", err)
+	}
+	total := totalPages(result.Total, searchPageSize)
+
+	data := h.sidebar(c, gin.H{
+		"CurrentTime": time.Now().Format("2006-01-02 15:04:05"),
+		"posts":       withTimeAgo(result.Items),
+		"currentPage": page,
+		"totalPages":  total,
+		"hasPrev":     page > 1,
+		"hasNext":     page < total,
+		"prevPage":    page - 1,
+		"nextPage":    page + 1,
+		"user":        CurrentUserFromContext(c),
+	})
+	RenderHTML(c, http.StatusOK, "search.tmpl", data)
+}
+
+// SearchUsers 按用户名或邮箱搜索用户（/member?q=）
+func (h *PageHandler) SearchUsers(c *gin.Context) {
+	h = h.scoped(c)
+	keyword := c.Query("q")
+	page := pageParam(c)
+	result, err := h.users.Search(keyword, page, searchPageSize)
+	if err != nil {
+		logging.FromContext(c).Error("搜索用户失败", "error", err)
+	}
+	total := totalPages(result.Total, searchPageSize)
+
+	type UserWithFriendlyTime struct {
+		models.User
+		TimeAgo string
+	}
+	var users []UserWithFriendlyTime
+	for _, user := range result.Items {
+		users = append(users, UserWithFriendlyTime{
+			User:    user,
+			TimeAgo: utils.GetTimeAgo(user.CreatedAt),
+		})
+	}
+
+	data := gin.H{
+		"users":         users,
+		"currentPage":   page,
+		"totalPages":    total,
+		"hasPrev":       page > 1,
+		"hasNext":       page < total,
+		"prevPage":      page - 1,
+		"nextPage":      page + 1,
+		"user":          CurrentUserFromContext(c),
+		"searchKeyword": keyword,
+	}
+	RenderHTML(c, http.StatusOK, "member.tmpl", data)
+}
+
+// RSS 最新文章的RSS订阅
+func (h *PageHandler) RSS(c *gin.Context) {
+	h = h.scoped(c)
+	posts, err := h.posts.Latest(rssItemsLimit)
+	if err != nil {
+		logging.FromContext(c).Error("获取最新文章失败", "error", err)
+	}
+
+	// 获取当前域名
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	currentDomain := scheme + "://" + c.Request.Host
+
+	// 构造RSS数据结构
+	type RSSItem struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		Description string    `xml:"description"`
+		PubDate     time.Time `xml:"pubDate"`
+		GUID        string    `xml:"guid"`
+	}
+
+	type RSSChannel struct {
+		XMLName   xml.Name `xml:"rss"`
+		Version   string   `xml:"version,attr"`
+		NSDC      string   `xml:"xmlns:dc,attr"`
+		NSContent string   `xml:"xmlns:content,attr"`
+		NSAtom    string   `xml:"xmlns:atom,attr"`
+		Channel   struct {
+			Title         string    `xml:"title"`
+			Link          string    `xml:"link"`
+			Description   string    `xml:"description"`
+			Language      string    `xml:"language"`
+			LastBuildDate string    `xml:"lastBuildDate"`
+			Items         []RSSItem `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	rss := RSSChannel{
+		Version:   "2.0",
+		NSDC:      "http://purl.org/dc/elements/1.1/",
+		NSContent: "http://purl.org/rss/1.0/modules/content/",
+		NSAtom:    "http://www.w3.org/2005/Atom",
+	}
+
+	rss.Channel.Title = "Doniai技术社区"
+	rss.Channel.Link = currentDomain
+	rss.Channel.Description = "技术社区最新帖子"
+	rss.Channel.Language = "zh-CN"
+	rss.Channel.LastBuildDate = time.Now().Format(time.RFC1123Z)
+
+	// 添加帖子项目
+	for _, post := range posts {
+		postLink := currentDomain + "/post-" + fmt.Sprintf("%d", post.ID) + "-1"
+		rss.Channel.Items = append(rss.Channel.Items, RSSItem{
+			Title:       post.Title,
+			Link:        postLink,
+			Description: fmt.Sprintf("<![CDATA[%s]]>", post.Content),
+			PubDate:     post.CreatedAt,
+			GUID:        postLink,
+		})
+	}
+
+	c.XML(http.StatusOK, rss)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"github.com/gin-gonic/gin"
+)
+
+// analyticsDays 解析统计天数参数，默认30天，最多365天
+func analyticsDays(c *gin.Context) int {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		return 30
+	}
+	if days > 365 {
+		return 365
+	}
+	return days
+}
+
+// loadOwnPost 查询文章并校验当前用户是作者或管理员
+func loadOwnPost(c *gin.Context, posts *services.PostService) (*models.Post, int, string) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		return nil, http.StatusUnauthorized, "请先登录"
+	}
+
+	id, _ := paramID(c)
+	post, err := posts.GetOwn(user, id)
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return nil, http.StatusForbidden, "只有作者可以查看文章数据"
+	case err != nil:
+		return nil, http.StatusNotFound, "文章不存在"
+	}
+	return post, http.StatusOK, ""
+}
+
+// Analytics 获取文章浏览分析数据
+func (h *PostHandler) Analytics(c *gin.Context) {
+	post, code, message := loadOwnPost(c, h.posts)
+	if post == nil {
+		c.JSON(code, gin.H{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	analytics, err := h.posts.Analytics(post, analyticsDays(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取文章数据失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    analytics,
+	})
+}
+
+// PostAnalytics 文章数据分析页面（仅作者和管理员可见）
+func (h *PageHandler) PostAnalytics(c *gin.Context) {
+	h = h.scoped(c)
+	post, code, message := loadOwnPost(c, h.posts)
+	if post == nil {
+		if code == http.StatusUnauthorized {
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+		RenderHTML(c, code, "404.tmpl", gin.H{
+			"Message": message,
+		})
+		return
+	}
+
+	analytics, err := h.posts.Analytics(post, analyticsDays(c))
+	if err != nil {
+		RenderHTML(c, http.StatusInternalServerError, "404.tmpl", gin.H{
+			"Message": "获取文章数据失败",
+		})
+		return
+	}
+
+	user, _ := c.Get("user")
+	RenderHTML(c, http.StatusOK, "post-analytics.tmpl", gin.H{
+		"user":      user,
+		"Post":      post,
+		"analytics": analytics,
+	})
+}
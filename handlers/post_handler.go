@@ -1,195 +1,117 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
-	"gin-doniai/database"
+	"gin-doniai/dto"
 	"gin-doniai/models"
+	"gin-doniai/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CreatePost 创建文章
-func CreatePost(c *gin.Context) {
-    // 从上下文获取用户信息
-    userObj, exists := c.Get("user")
-    if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
-        return
-    }
-    user := userObj.(*models.User)
-
-    // 解析请求数据
-    var requestData struct {
-        Title      string `json:"title" binding:"required"`
-        CategoryId int    `json:"category_id" binding:"required"`
-        Content    string `json:"content" binding:"required"`
-        Tags       string `json:"tags"`
-        ReadLimit  int    `json:"read_limit"`
-    }
-
-    if err := c.ShouldBindJSON(&requestData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求参数错误: " + err.Error(),
-        })
-        return
-    }
-
-    // 根据 category_id 查询分类名称
-    var category models.Category
-    if err := database.DB.First(&category, requestData.CategoryId).Error; err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "无效的分类ID",
-        })
-        return
-    }
-
-    // 创建文章对象
-    post := models.Post{
-        Title:     requestData.Title,
-        Category:  category.Name, // 使用查询到的分类名称
-        CategoryId: requestData.CategoryId,
-        Content:   requestData.Content,
-        Tags:      requestData.Tags,
-        UserId:    int(user.ID),
-        Author:    user.Name,
-        ReadLimit: requestData.ReadLimit,
-    }
-
-    result := database.DB.Create(&post)
-    if result.Error != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "文章创建失败: " + result.Error.Error(),
-        })
-        return
-    }
+// PostHandler 文章的JSON接口
+type PostHandler struct {
+	posts *services.PostService
+}
 
-    c.JSON(http.StatusCreated, gin.H{
-        "success": true,
-        "message": "文章创建成功",
-        "post":    post,
-    })
+// NewPostHandler 创建文章接口
+func NewPostHandler(posts *services.PostService) *PostHandler {
+	return &PostHandler{posts: posts}
 }
 
+// paramID 解析路由中的 :id 参数，格式错误时返回false
+func paramID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
 
-// LikePost 文章点赞功能
-func LikePost(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	if !exists || userObj == nil {
+// Create 创建文章
+func (h *PostHandler) Create(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
 		})
 		return
 	}
-	user := userObj.(*models.User)
-
-	// 获取文章ID
-	id := c.Param("id")
-	var post models.Post
 
-	// 查找文章
-	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
+	// 解析请求数据
+	var requestData services.PostInput
+	if err := c.ShouldBindJSON(&requestData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "文章不存在",
+			"message": "请求参数错误: " + err.Error(),
 		})
 		return
 	}
 
-	// 解析请求数据
-	var requestData struct {
-		Action string `json:"action" binding:"required,oneof=like unlike"` // 点赞或取消点赞
+	post, err := h.posts.Create(user, requestData)
+	if errors.Is(err, services.ErrInvalidCategory) || errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(postErrorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
 	}
-
-	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "请求参数错误: " + err.Error(),
+			"message": "文章创建失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 检查用户是否已经点赞过（可以通过关联表实现）
-	var postLike models.PostLike
-	err := database.DB.Where("user_id = ? AND post_id = ?", user.ID, post.ID).First(&postLike).Error
-
-	if requestData.Action == "like" {
-		// 点赞操作
-		if err != nil {
-			// 用户尚未点赞，创建点赞记录
-			postLike = models.PostLike{
-				UserID: int(user.ID),
-				PostID: int(post.ID),
-			}
-
-			if err := database.DB.Create(&postLike).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "点赞失败: " + err.Error(),
-				})
-				return
-			}
-
-			// 增加文章点赞数
-			database.DB.Model(&post).Update("likes", post.Likes+1)
-		}
-	} else {
-		// 取消点赞操作
-		if err == nil {
-			// 用户已点赞，删除点赞记录
-			if err := database.DB.Delete(&postLike).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "取消点赞失败: " + err.Error(),
-				})
-				return
-			}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "文章创建成功",
+		"post":    dto.NewPost(*post, true),
+	})
+}
 
-			// 减少文章点赞数
-			if post.Likes > 0 {
-				database.DB.Model(&post).Update("likes", post.Likes-1)
-			}
-		}
+// Like 文章点赞功能
+func (h *PostHandler) Like(c *gin.Context) {
+	// 解析请求数据
+	var requestData struct {
+		Action string `json:"action" binding:"required,oneof=like unlike"` // 点赞或取消点赞
 	}
+	h.react(c, &requestData, func(user *models.User, id uint) (gin.H, error) {
+		likes, err := h.posts.SetLike(user, id, requestData.Action == "like")
+		return gin.H{"likes": likes}, err
+	})
+}
 
-	// 获取最新的点赞数
-	var updatedPost models.Post
-	database.DB.First(&updatedPost, id)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "操作成功",
-		"likes":   updatedPost.Likes,
+// Favorite 文章收藏功能
+func (h *PostHandler) Favorite(c *gin.Context) {
+	// 解析请求数据
+	var requestData struct {
+		Action string `json:"action" binding:"required,oneof=favorite unfavorite"`
+	}
+	h.react(c, &requestData, func(user *models.User, id uint) (gin.H, error) {
+		favorites, err := h.posts.SetFavorite(user, id, requestData.Action == "favorite")
+		return gin.H{"favorites": favorites}, err
 	})
 }
 
-// FavoritePost 文章收藏功能
-func FavoritePost(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	if !exists || userObj == nil {
+// react 点赞和收藏共用的登录检查、参数解析和响应
+func (h *PostHandler) react(c *gin.Context, requestData interface{}, set func(*models.User, uint) (gin.H, error)) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "用户未登录",
 		})
 		return
 	}
-	user := userObj.(*models.User)
-
-	// 获取文章ID
-	id := c.Param("id")
-	var post models.Post
 
-	// 查找文章
-	if err := database.DB.First(&post, id).Error; err != nil {
+	id, ok := paramID(c)
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"message": "文章不存在",
@@ -197,12 +119,7 @@ func FavoritePost(c *gin.Context) {
 		return
 	}
 
-	// 解析请求数据
-	var requestData struct {
-		Action string `json:"action" binding:"required,oneof=favorite unfavorite"`
-	}
-
-	if err := c.ShouldBindJSON(&requestData); err != nil {
+	if err := c.ShouldBindJSON(requestData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "请求参数错误: " + err.Error(),
@@ -210,152 +127,122 @@ func FavoritePost(c *gin.Context) {
 		return
 	}
 
-	// 检查用户是否已经收藏过
-	var postFavorite models.PostFavorite
-	err := database.DB.Where("user_id = ? AND post_id = ?", user.ID, post.ID).First(&postFavorite).Error
-
-	if requestData.Action == "favorite" {
-		// 收藏操作
-		if err != nil {
-			// 用户尚未收藏，创建收藏记录
-			postFavorite = models.PostFavorite{
-				UserID: int(user.ID),
-				PostID: int(post.ID),
-			}
-
-			if err := database.DB.Create(&postFavorite).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "收藏失败: " + err.Error(),
-				})
-				return
-			}
-
-			// 增加文章收藏数
-			database.DB.Model(&post).Update("favorites", post.Favorites+1)
-		}
-	} else {
-		// 取消收藏操作
-		if err == nil {
-			// 用户已收藏，删除收藏记录
-			if err := database.DB.Delete(&postFavorite).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "取消收藏失败: " + err.Error(),
-				})
-				return
-			}
-
-			// 减少文章收藏数
-			if post.Favorites > 0 {
-				database.DB.Model(&post).Update("favorites", post.Favorites-1)
-			}
-		}
+	result, err := set(user, id)
+	if errors.Is(err, services.ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "文章不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
 	}
 
-	// 获取最新的收藏数
-	var updatedPost models.Post
-	database.DB.First(&updatedPost, id)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"message":   "操作成功",
-		"favorites": updatedPost.Favorites,
-	})
+	result["success"] = true
+	result["message"] = "操作成功"
+	c.JSON(http.StatusOK, result)
 }
 
-// GetPosts 获取所有用户
-func GetPosts(c *gin.Context) {
-	var posts []models.Post
-
-	result := database.DB.Find(&posts)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+// List 分页获取文章列表
+func (h *PostHandler) List(c *gin.Context) {
+	query, ok := parseListQuery(c, services.PostListSpec)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"count": len(posts),
-	})
+	posts, info, err := h.posts.List(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取文章失败: " + err.Error(),
+		})
+		return
+	}
+	respondList(c, dto.NewPosts(posts), info)
 }
 
-// GetPost 获取单个文章
-func GetPost(c *gin.Context) {
-	id := c.Param("id")
-	var post models.Post
-
-	result := database.DB.First(&post, id)
-	if result.Error != nil {
+// Get 获取单个文章
+func (h *PostHandler) Get(c *gin.Context) {
+	id, _ := paramID(c)
+	post, err := h.posts.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+	c.JSON(http.StatusOK, gin.H{"post": dto.NewPost(*post, true)})
 }
 
-// UpdatePost 更新文章
-func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
-	var post models.Post
-
-	// 先查找文章是否存在
-	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+// Update 更新文章（作者或管理员）
+func (h *PostHandler) Update(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未登录"})
 		return
 	}
 
 	// 绑定更新数据
-	var updateData models.Post
+	var updateData services.PostUpdate
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 更新文章
-	result := database.DB.Model(&post).Updates(updateData)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	id, _ := paramID(c)
+	post, err := h.posts.Update(user, id, updateData)
+	if err != nil {
+		c.JSON(postErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "文章更新成功",
-		"post":    post,
+		"post":    dto.NewPost(*post, true),
 	})
 }
 
-// DeletePost 删除文章（软删除）
-func DeletePost(c *gin.Context) {
-	id := c.Param("id")
-	var post models.Post
-
-	// 先查找用户是否存在
-	if err := database.DB.First(&post, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+// Delete 删除文章（软删除，作者或管理员）
+func (h *PostHandler) Delete(c *gin.Context) {
+	user := CurrentUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未登录"})
 		return
 	}
 
-	// 软删除
-	result := database.DB.Delete(&post)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	id, _ := paramID(c)
+	if err := h.posts.Delete(user, id); err != nil {
+		c.JSON(postErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
 }
 
-// 硬删除（永久删除）
-func ForceDeletePost(c *gin.Context) {
-	id := c.Param("id")
-	var post models.Post
-
-	result := database.DB.Unscoped().Delete(&post, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+// ForceDelete 硬删除（永久删除，仅管理员）
+func (h *PostHandler) ForceDelete(c *gin.Context) {
+	id, _ := paramID(c)
+	if err := h.posts.ForceDelete(CurrentUserFromContext(c), id); err != nil {
+		c.JSON(postErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "文章永久删除成功"})
 }
+
+// postErrorStatus 文章服务错误对应的HTTP状态码
+func postErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPostNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidCategory):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"github.com/gin-gonic/gin"
+)
+
+// Related 获取文章的相关文章及推荐理由
+func (h *PostHandler) Related(c *gin.Context) {
+	id, _ := paramID(c)
+	post, err := h.posts.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "文章不存在",
+		})
+		return
+	}
+
+	related, err := h.posts.CachedRelated(post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取相关文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    related,
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"gin-doniai/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RenderHTML 渲染页面模板，并注入每个请求都需要的公共模板数据（CSRF令牌、CSP nonce）
+// 模板执行记录为请求span下的子span，便于区分慢在查询还是渲染
+func RenderHTML(c *gin.Context, code int, name string, data gin.H) {
+	if data == nil {
+		data = gin.H{}
+	}
+	data["csrfToken"] = c.GetString("csrf_token")
+	data["cspNonce"] = c.GetString("csp_nonce")
+
+	_, span := tracing.Start(c.Request.Context(), "render "+name, trace.WithAttributes(attribute.String("template.name", name)))
+	before := len(c.Errors)
+	c.HTML(code, name, data)
+	// 模板执行出错时gin把错误记录在 c.Errors 中
+	var err error
+	if len(c.Errors) > before {
+		err = c.Errors.Last().Err
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gin-doniai/logging"
+	"gin-doniai/models"
+	"gin-doniai/services"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// session中记录最近一次登录时间的键名，敏感操作据此判断用户是否刚刚重新认证
+const sessionAuthAtKey = "auth_at"
+
+// reauthWindow 重新登录（密码或第三方登录）后多长时间内可以不输入密码执行敏感操作，如注销账户
+const reauthWindow = 10 * time.Minute
+
+// setSessionUser 登录成功后在session中记录用户和登录时间
+func setSessionUser(session sessions.Session, userID uint) {
+	session.Set("user_id", userID)
+	session.Set(sessionAuthAtKey, time.Now().Unix())
+}
+
+// recentlyAuthenticated 当前session是否在 reauthWindow 内完成过登录
+func recentlyAuthenticated(c *gin.Context) bool {
+	authAt, ok := sessions.Default(c).Get(sessionAuthAtKey).(int64)
+	return ok && time.Since(time.Unix(authAt, 0)) < reauthWindow
+}
+
+// SessionHandler 账号密码注册、登录和退出
+type SessionHandler struct {
+	users  *services.UserService
+	emails *services.EmailService
+	// options 返回session cookie选项，maxAge 为0时浏览器关闭后失效（middlewares.SessionOptions）
+	options func(maxAge int) sessions.Options
+}
+
+// NewSessionHandler 创建登录注册处理器
+func NewSessionHandler(users *services.UserService, emails *services.EmailService, options func(maxAge int) sessions.Options) *SessionHandler {
+	return &SessionHandler{users: users, emails: emails, options: options}
+}
+
+// RegisterPage 注册页
+func (h *SessionHandler) RegisterPage(c *gin.Context) {
+	RenderHTML(c, http.StatusOK, "auth.tmpl", gin.H{
+		"CurrentPath": "/register",
+	})
+}
+
+// LoginPage 登录页
+func (h *SessionHandler) LoginPage(c *gin.Context) {
+	RenderHTML(c, http.StatusOK, "auth.tmpl", gin.H{
+		"CurrentPath": "/login",
+	})
+}
+
+// Logout 清除session后回到首页
+func (h *SessionHandler) Logout(c *gin.Context) {
+	session := sessions.Default(c)
+	session.Clear()
+	if err := session.Save(); err != nil {
+		logging.FromContext(c).Error("登出时Session保存失败", "error", err)
+	} else {
+		logging.FromContext(c).Debug("用户已成功登出")
+	}
+
+	c.Redirect(http.StatusFound, "/")
+}
+
+// Login 登录表单提交，支持邮箱或用户名登录
+func (h *SessionHandler) Login(c *gin.Context) {
+	identifier := c.PostForm("email") // 可以是邮箱或用户名
+	password := c.PostForm("password")
+	remember := c.PostForm("remember")
+
+	// 基本验证
+	if identifier == "" || password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "用户名/邮箱和密码不能为空",
+		})
+		return
+	}
+
+	user, err := h.users.Authenticate(identifier, password)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "登录失败"
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			status, message = http.StatusUnauthorized, "用户不存在"
+		case errors.Is(err, services.ErrWrongPassword):
+			status, message = http.StatusUnauthorized, "密码错误"
+		}
+		c.JSON(status, gin.H{
+			"status":  "error",
+			"message": message,
+		})
+		return
+	}
+
+	session := sessions.Default(c)
+	setSessionUser(session, user.ID)
+	// 勾选"记住密码"时30天有效，否则浏览器关闭后失效
+	if remember == "on" {
+		session.Options(h.options(30 * 24 * 60 * 60))
+	} else {
+		session.Options(h.options(0))
+	}
+	if err := session.Save(); err != nil {
+		logging.FromContext(c).Error("Session保存失败", "error", err)
+	} else {
+		logging.FromContext(c).Debug("Session保存成功")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "登录成功",
+		"data": gin.H{
+			"user_id": user.ID,
+			"email":   user.Email,
+			"name":    user.Name,
+		},
+	})
+}
+
+// Register 注册表单提交，注册后发送邮箱验证邮件
+func (h *SessionHandler) Register(c *gin.Context) {
+	username := c.PostForm("username")
+	email := c.PostForm("email")
+	password := c.PostForm("password")
+	confirmPassword := c.PostForm("confirmPassword")
+
+	// 基本验证
+	if username == "" || email == "" || password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "用户名、邮箱和密码不能为空",
+		})
+		return
+	}
+	if password != confirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "两次输入的密码不一致",
+		})
+		return
+	}
+	if c.PostForm("agreeTerms") != "on" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "请同意用户协议",
+		})
+		return
+	}
+
+	newUser, err := h.users.Register(services.RegisterInput{Name: username, Email: email, Password: password})
+	if errors.Is(err, services.ErrEmailTaken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("用户注册失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "用户注册失败",
+		})
+		return
+	}
+
+	// 发送邮箱验证邮件，发送失败不影响注册，用户可在设置页重新发送
+	if err := h.emails.SendVerification(newUser.ID, newUser.Email, models.EmailVerifyPurposeRegister); err != nil {
+		logging.FromContext(c).Error("发送验证邮件失败", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "注册成功，验证邮件已发送到您的邮箱",
+		"data": gin.H{
+			"user_id": newUser.ID,
+			"email":   newUser.Email,
+		},
+	})
+}
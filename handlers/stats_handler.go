@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"gin-doniai/caches"
+	"gin-doniai/services"
+	"gin-doniai/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// StatsHandler 社区统计接口
+type StatsHandler struct {
+	stats *services.StatsService
+}
+
+// NewStatsHandler 创建社区统计接口
+func NewStatsHandler(stats *services.StatsService) *StatsHandler {
+	return &StatsHandler{stats: stats}
+}
+
+// GetSiteStats 获取社区统计，days>0 时附带最近若干天的每日快照用于趋势图
+func (h *StatsHandler) GetSiteStats(c *gin.Context) {
+	data := gin.H{
+		"current": stats.Default().Snapshot(),
+	}
+
+	if days, _ := strconv.Atoi(c.Query("days")); days > 0 {
+		if days > 365 {
+			days = 365
+		}
+		snapshots, err := h.stats.Daily(days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "获取统计趋势失败: " + err.Error(),
+			})
+			return
+		}
+		data["daily"] = snapshots
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetCacheStats 获取缓存命中率统计
+func GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    caches.Default().Stats(),
+	})
+}
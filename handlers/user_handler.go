@@ -1,263 +1,244 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
-	"gin-doniai/database"
+	"gin-doniai/dto"
+	"gin-doniai/logging"
 	"gin-doniai/models"
-    "gin-doniai/utils"
+	"gin-doniai/services"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateUser 创建用户
-func CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+// UserHandler 用户管理和个人资料的JSON接口
+type UserHandler struct {
+	users  *services.UserService
+	emails *services.EmailService
+}
+
+// NewUserHandler 创建用户接口
+func NewUserHandler(users *services.UserService, emails *services.EmailService) *UserHandler {
+	return &UserHandler{users: users, emails: emails}
+}
+
+// Create 创建用户（仅管理员），新用户为普通用户，需要自行验证邮箱
+func (h *UserHandler) Create(c *gin.Context) {
+	var input services.CreateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result := database.DB.Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	user, err := h.users.Create(input)
+	if errors.Is(err, services.ErrEmailTaken) || errors.Is(err, services.ErrPasswordTooShort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 发送邮箱验证邮件，发送失败不影响创建，用户可在设置页重新发送
+	if err := h.emails.SendVerification(user.ID, user.Email, models.EmailVerifyPurposeRegister); err != nil {
+		logging.FromContext(c).Error("发送验证邮件失败", "error", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "用户创建成功",
-		"user":    user,
+		"user":    dto.NewUser(*user, true),
 	})
 }
 
-// GetUsers 获取所有用户
-func GetUsers(c *gin.Context) {
-	var users []models.User
+// List 分页获取用户列表
+func (h *UserHandler) List(c *gin.Context) {
+	current := CurrentUserFromContext(c)
+	query, ok := parseListQuery(c, services.UserListSpecFor(current))
+	if !ok {
+		return
+	}
 
-	result := database.DB.Find(&users)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	users, info, err := h.users.List(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取用户失败: " + err.Error(),
+		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-		"count": len(users),
-	})
+	result := make([]dto.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, dto.NewUser(user, services.CanSeePrivate(current, user.ID)))
+	}
+	respondList(c, result, info)
 }
 
-// GetUser 获取单个用户
-func GetUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
-
-	result := database.DB.First(&user, id)
-	if result.Error != nil {
+// Get 获取单个用户
+func (h *UserHandler) Get(c *gin.Context) {
+	id, _ := paramID(c)
+	user, err := h.users.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{"user": dto.NewUser(*user, services.CanSeePrivate(CurrentUserFromContext(c), user.ID))})
 }
 
-// UpdateUser 更新用户
-func UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
+// Update 更新用户（仅管理员），邮箱和角色不能通过该接口修改
+func (h *UserHandler) Update(c *gin.Context) {
+	// 绑定更新数据
+	var updateData services.UpdateUserInput
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 先查找用户是否存在
-	if err := database.DB.First(&user, id).Error; err != nil {
+	id, _ := paramID(c)
+	user, err := h.users.Update(id, updateData)
+	if errors.Is(err, services.ErrUserNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
-
-	// 绑定更新数据
-	var updateData models.User
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	if errors.Is(err, services.ErrPasswordTooShort) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 更新用户
-	result := database.DB.Model(&user).Updates(updateData)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "用户更新成功",
-		"user":    user,
+		"user":    dto.NewUser(*user, services.CanSeePrivate(CurrentUserFromContext(c), user.ID)),
 	})
 }
 
-// DeleteUser 删除用户（软删除）
-func DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
-
-	// 先查找用户是否存在
-	if err := database.DB.First(&user, id).Error; err != nil {
+// Delete 删除用户（软删除）
+func (h *UserHandler) Delete(c *gin.Context) {
+	id, _ := paramID(c)
+	err := h.users.Delete(id)
+	if errors.Is(err, services.ErrUserNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
-
-	// 软删除
-	result := database.DB.Delete(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "用户删除成功"})
 }
 
-// 硬删除（永久删除）
-func ForceDeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
-
-	result := database.DB.Unscoped().Delete(&user, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+// ForceDelete 硬删除（永久删除）
+func (h *UserHandler) ForceDelete(c *gin.Context) {
+	id, _ := paramID(c)
+	if err := h.users.ForceDelete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "用户永久删除成功"})
 }
 
-// UpdateUserProfile 更新用户资料
-func UpdateUserProfile(c *gin.Context) {
-    // 从上下文获取当前用户
-    userObj, exists := c.Get("user")
-    if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
-        return
-    }
-
-    currentUser := userObj.(*models.User)
-
-    // 绑定请求数据
-    var updateData struct {
-        Motto         string `json:"motto"`
-        Github        string `json:"github"`
-        GoogleAccount string `json:"google_account"`
-    }
-
-    if err := c.ShouldBindJSON(&updateData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
-        return
-    }
+// UpdateProfile 更新用户资料
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	currentUser := CurrentUserFromContext(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "用户未登录",
+		})
+		return
+	}
 
-    // 更新用户信息
-    updates := make(map[string]interface{})
-    if updateData.Motto != "" {
-        updates["motto"] = updateData.Motto
-    }
-    if updateData.Github != "" {
-        updates["github"] = updateData.Github
-    }
-    if updateData.GoogleAccount != "" {
-        updates["google_account"] = updateData.GoogleAccount
-    }
+	// 绑定请求数据
+	var updateData services.ProfileInput
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据格式错误",
+		})
+		return
+	}
 
-    if err := database.DB.Model(&models.User{}).Where("id = ?", currentUser.ID).Updates(updates).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "更新失败: " + err.Error(),
-        })
-        return
-    }
+	if err := h.users.UpdateProfile(currentUser, updateData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "更新失败: " + err.Error(),
+		})
+		return
+	}
 
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "个人信息更新成功",
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "个人信息更新成功",
+	})
 }
 
-// UpdateUserPassword 修改用户密码
-func UpdateUserPassword(c *gin.Context) {
-    // 从上下文获取当前用户
-    userObj, exists := c.Get("user")
-    if !exists || userObj == nil {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "success": false,
-            "message": "用户未登录",
-        })
-        return
-    }
-
-    currentUser := userObj.(*models.User)
-
-    // 绑定请求数据
-    var passwordData struct {
-        CurrentPassword string `json:"current_password" binding:"required"`
-        NewPassword     string `json:"new_password" binding:"required"`
-    }
-
-    if err := c.ShouldBindJSON(&passwordData); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "请求数据格式错误",
-        })
-        return
-    }
-
-    // 验证当前密码是否正确
-    if !utils.CheckPassword(passwordData.CurrentPassword, currentUser.Password) {
-        c.JSON(http.StatusForbidden, gin.H{
-            "success": false,
-            "message": "当前密码错误",
-        })
-        return
-    }
+// UpdatePassword 修改用户密码
+func (h *UserHandler) UpdatePassword(c *gin.Context) {
+	currentUser := CurrentUserFromContext(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "用户未登录",
+		})
+		return
+	}
 
-    // 验证新密码长度
-    if len(passwordData.NewPassword) < 6 {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "success": false,
-            "message": "新密码长度至少6位",
-        })
-        return
-    }
+	// 绑定请求数据
+	var passwordData struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
 
-    // 加密新密码
-    hashedPassword, err := utils.HashPassword(passwordData.NewPassword)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "密码加密失败",
-        })
-        return
-    }
+	if err := c.ShouldBindJSON(&passwordData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求数据格式错误",
+		})
+		return
+	}
 
-    // 更新密码
-    if err := database.DB.Model(&models.User{}).Where("id = ?", currentUser.ID).Update("password", hashedPassword).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "success": false,
-            "message": "密码更新失败: " + err.Error(),
-        })
-        return
-    }
+	err := h.users.ChangePassword(currentUser, passwordData.CurrentPassword, passwordData.NewPassword)
+	switch {
+	case errors.Is(err, services.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "当前密码错误",
+		})
+		return
+	case errors.Is(err, services.ErrPasswordTooShort):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "密码更新失败: " + err.Error(),
+		})
+		return
+	}
 
-    c.JSON(http.StatusOK, gin.H{
-        "success": true,
-        "message": "密码修改成功",
-    })
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "密码修改成功",
+	})
 }
 
 // UserIDFromContext 从上下文中获取用户ID
 func UserIDFromContext(c *gin.Context) *uint {
-    userObj, exists := c.Get("user")
-    if exists && userObj != nil {
-        if user, ok := userObj.(*models.User); ok {
-            return &user.ID
-        }
-    }
-    return nil
+	userObj, exists := c.Get("user")
+	if exists && userObj != nil {
+		if user, ok := userObj.(*models.User); ok {
+			return &user.ID
+		}
+	}
+	return nil
 }
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/webhooks"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookInput 创建/修改webhook的参数，修改时未传的字段保持不变
+type WebhookInput struct {
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	Description  *string  `json:"description"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"` // 仅修改时有效，重新生成签名密钥
+}
+
+// validateWebhookInput 校验URL和事件列表，返回错误信息
+func validateWebhookInput(input WebhookInput, creating bool) string {
+	if creating || input.URL != "" {
+		parsed, err := url.Parse(input.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "URL必须是有效的 http 或 https 地址"
+		}
+		if len(input.URL) > 500 {
+			return "URL不能超过500个字符"
+		}
+	}
+	if creating && len(input.Events) == 0 {
+		return "至少订阅一个事件"
+	}
+	for _, event := range input.Events {
+		valid := false
+		for _, e := range models.AllWebhookEvents {
+			if event == e {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "无效的事件: " + event
+		}
+	}
+	if input.Description != nil && len([]rune(*input.Description)) > 255 {
+		return "描述不能超过255个字符"
+	}
+	return ""
+}
+
+// WebhookHandler webhook订阅管理和投递记录（仅管理员）
+type WebhookHandler struct {
+	hooks *webhooks.Service
+}
+
+// NewWebhookHandler 创建webhook管理处理器
+func NewWebhookHandler(hooks *webhooks.Service) *WebhookHandler {
+	return &WebhookHandler{hooks: hooks}
+}
+
+// webhookFromParam 按路由中的 :id 获取webhook，不存在时返回404
+func (h *WebhookHandler) webhookFromParam(c *gin.Context) (*models.Webhook, bool) {
+	id, _ := paramID(c)
+	hook, err := h.hooks.Get(id)
+	if errors.Is(err, webhooks.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取webhook失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	return hook, true
+}
+
+// GetWebhooks 获取全部webhook（仅管理员）
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	hooks, err := h.hooks.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hooks,
+		"events":  models.AllWebhookEvents,
+	})
+}
+
+// CreateWebhook 创建webhook，签名密钥只在创建时返回一次
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if message := validateWebhookInput(input, true); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	hook := models.Webhook{
+		URL:       input.URL,
+		Events:    strings.Join(input.Events, ","),
+		Active:    true,
+		CreatedBy: CurrentUserFromContext(c).ID,
+	}
+	if input.Description != nil {
+		hook.Description = *input.Description
+	}
+	if input.Active != nil {
+		hook.Active = *input.Active
+	}
+
+	if err := h.hooks.Create(&hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "webhook创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "webhook创建成功，请立即保存签名密钥，之后将无法再次查看",
+		"secret":  hook.Secret,
+		"data":    hook,
+	})
+}
+
+// UpdateWebhook 修改webhook，rotate_secret 为 true 时返回新的签名密钥
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	hook, ok := h.webhookFromParam(c)
+	if !ok {
+		return
+	}
+
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if message := validateWebhookInput(input, false); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.URL != "" {
+		updates["url"] = input.URL
+	}
+	if len(input.Events) > 0 {
+		updates["events"] = strings.Join(input.Events, ",")
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.Active != nil {
+		updates["active"] = *input.Active
+	}
+
+	if err := h.hooks.Update(hook, updates, input.RotateSecret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "webhook更新失败: " + err.Error(),
+		})
+		return
+	}
+
+	response := gin.H{
+		"success": true,
+		"message": "webhook更新成功",
+		"data":    hook,
+	}
+	if input.RotateSecret {
+		response["secret"] = hook.Secret
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook 删除webhook，未完成的投递不再重试
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	hook, ok := h.webhookFromParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.hooks.Delete(hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "删除webhook失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "webhook已删除",
+	})
+}
+
+// WebhookDeliveryListSpec 投递记录列表的排序和过滤规则
+var WebhookDeliveryListSpec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"id":         {Column: "id", Kind: pagination.KindInt},
+		"created_at": {Column: "created_at", Kind: pagination.KindTime},
+	},
+	DefaultSort: "-id",
+	Filters: append([]pagination.Filter{
+		{Param: "event", Column: "event", Kind: pagination.KindString, Op: pagination.OpEq},
+		{Param: "status", Column: "status", Kind: pagination.KindInt, Op: pagination.OpEq},
+	}, pagination.CreatedAtRange()...),
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// GetWebhookDeliveries 获取webhook的投递记录，最新的在前
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	query, ok := parseListQuery(c, WebhookDeliveryListSpec)
+	if !ok {
+		return
+	}
+
+	id, _ := paramID(c)
+	deliveries, err := h.hooks.Deliveries(id, query)
+	if errors.Is(err, webhooks.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "获取投递记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	deliveries, info := pagination.Paginate(query, deliveries, func(delivery models.WebhookDelivery) (interface{}, uint) {
+		if query.SortColumn() == "created_at" {
+			return delivery.CreatedAt, delivery.ID
+		}
+		return delivery.ID, delivery.ID
+	})
+	respondList(c, deliveries, info)
+}
+
+// RedeliverWebhookDelivery 重新投递一条记录（生成新的投递ID）
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	webhookID, _ := paramID(c)
+	deliveryID, _ := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	delivery, err := h.hooks.Redeliver(webhookID, uint(deliveryID))
+	if errors.Is(err, webhooks.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "重新投递失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "已加入投递队列",
+		"data":    delivery,
+	})
+}
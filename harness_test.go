@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/config"
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/services"
+	"gin-doniai/stats"
+	"gin-doniai/utils"
+	"gin-doniai/webhooks"
+	"gin-doniai/workers"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// go test . -update 重新生成 testdata/golden 下的页面快照
+var updateGolden = flag.Bool("update", false, "更新 testdata/golden 下的页面快照")
+
+// 测试站点地址，请求不经过网络，直接交给路由处理
+const testBaseURL = "http://doniai.test"
+
+// fixturePassword 所有夹具用户的密码
+const fixturePassword = "password123"
+
+// fixtures 每个测试开始时写入的数据
+type fixtures struct {
+	author     models.User // 文章作者
+	reader     models.User // 已验证邮箱的普通用户
+	unverified models.User // 未验证邮箱的用户
+	admin      models.User
+	category   models.Category
+	post       models.Post
+	comment    models.Comment // reader 对 post 的评论
+	reply      models.Comment // author 对 comment 的回复
+}
+
+// harness 使用内存SQLite启动与 main 相同的完整路由
+type harness struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+	svc    *services.Services
+	hooks  *webhooks.Service
+	fx     fixtures
+}
+
+// newHarness 初始化配置、数据库、缓存和路由，每个测试使用独立的数据库
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Server.Mode = gin.TestMode
+	cfg.Database.Driver = database.DriverSQLite
+	cfg.Session.Secret = "integration-test-session-secret-0123456789"
+	config.Set(cfg)
+
+	db, err := database.OpenInMemory(strings.ReplaceAll(t.Name(), "/", "_"))
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	caches.Init(caches.NewMemoryStore(256))
+	if err := caches.RegisterInvalidationHooks(db); err != nil {
+		t.Fatalf("注册缓存失效钩子失败: %v", err)
+	}
+	if err := stats.RegisterHooks(db, stats.Default()); err != nil {
+		t.Fatalf("注册统计钩子失败: %v", err)
+	}
+
+	h := &harness{t: t, db: db}
+	h.loadFixtures()
+	if err := stats.Default().Reconcile(db); err != nil {
+		t.Fatalf("加载社区统计失败: %v", err)
+	}
+
+	// 不启动worker，通道只需有足够缓冲
+	onlineStatusChan = make(chan workers.OnlineStatusUpdate, 1000)
+	viewEventChan = make(chan workers.ViewEvent, 1000)
+	dataExportChan = make(chan uint, 100)
+
+	repos := repositories.NewGorm(db)
+	h.hooks = webhooks.NewService(repos.Webhooks, repos.WebhookDeliveries)
+	h.svc = services.New(repos, caches.Default(), h.hooks.Dispatch, func() *config.Config { return cfg })
+	h.router = newRouter(cfg, db, h.svc, h.hooks, func() bool { return true })
+	return h
+}
+
+// loadFixtures 写入用户、分类、文章和评论，时间固定为相对当前时间，保证页面快照稳定
+func (h *harness) loadFixtures() {
+	h.t.Helper()
+	hashed, err := utils.HashPassword(fixturePassword)
+	if err != nil {
+		h.t.Fatalf("密码加密失败: %v", err)
+	}
+	created := time.Now().Add(-3 * time.Hour)
+
+	fx := &h.fx
+	fx.author = models.User{Name: "author", Email: "author@example.com", Password: hashed, Avatar: "/static/avatar/author.png", Motto: "写点东西", AgreeTerms: true}
+	fx.reader = models.User{Name: "reader", Email: "reader@example.com", Password: hashed, Avatar: "/static/avatar/reader.png", AgreeTerms: true}
+	fx.unverified = models.User{Name: "newbie", Email: "newbie@example.com", Password: hashed, Avatar: "/static/avatar/newbie.png", AgreeTerms: true, EmailStatus: models.EmailStatusUnverified}
+	fx.admin = models.User{Name: "admin", Email: "admin@example.com", Password: hashed, Avatar: "/static/avatar/admin.png", AgreeTerms: true, Role: models.RoleAdmin}
+	for _, user := range []*models.User{&fx.author, &fx.reader, &fx.unverified, &fx.admin} {
+		user.CreatedAt = created
+		h.create(user)
+	}
+
+	fx.category = models.Category{Name: "Go语言", Alias: "go", StatusCode: 1, IsRecommended: true}
+	h.create(&fx.category)
+
+	fx.post = models.Post{Title: "Gin 入门", UserId: int(fx.author.ID), Author: fx.author.Name, Category: fx.category.Name,
+		CategoryId: int(fx.category.ID), Content: "<p>第一篇文章</p>", Tags: `["go","gin"]`, Views: 12, Replies: 2, CreatedAt: created}
+	h.create(&fx.post)
+
+	fx.comment = models.Comment{Content: "<p>写得好</p>", PostID: fx.post.ID, UserID: fx.reader.ID, CreatedAt: created.Add(time.Hour)}
+	h.create(&fx.comment)
+	fx.reply = models.Comment{Content: "<p>谢谢</p>", PostID: fx.post.ID, ParentID: fx.comment.ID, UserID: fx.author.ID, CreatedAt: created.Add(2 * time.Hour)}
+	h.create(&fx.reply)
+}
+
+func (h *harness) create(value interface{}) {
+	h.t.Helper()
+	if err := h.db.Create(value).Error; err != nil {
+		h.t.Fatalf("写入夹具失败: %v", err)
+	}
+}
+
+// reload 从数据库重新读取记录
+func (h *harness) reload(value interface{}, id uint) {
+	h.t.Helper()
+	if err := h.db.First(value, id).Error; err != nil {
+		h.t.Fatalf("读取记录失败: %v", err)
+	}
+}
+
+// client 模拟浏览器：保存cookie、不自动跟随重定向，写请求自动带上CSRF令牌
+type client struct {
+	h    *harness
+	http *http.Client
+	csrf string
+}
+
+// routerTransport 直接用路由处理请求
+type routerTransport struct {
+	handler http.Handler
+}
+
+func (rt routerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	rt.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// anonymous 未登录的访客
+func (h *harness) anonymous() *client {
+	jar, _ := cookiejar.New(nil)
+	return &client{h: h, http: &http.Client{
+		Transport: routerTransport{handler: h.router},
+		Jar:       jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// login 通过登录表单登录，返回已登录的客户端
+func (h *harness) login(user models.User) *client {
+	h.t.Helper()
+	c := h.anonymous()
+	resp := c.postForm("/login", url.Values{"email": {user.Email}, "password": {fixturePassword}})
+	resp.expectStatus(http.StatusOK)
+	return c
+}
+
+// withToken 创建指定权限的API令牌，返回用 Authorization: Bearer 认证的客户端（不带cookie）
+func (h *harness) withToken(user models.User, scopes ...string) *client {
+	h.t.Helper()
+	raw := "dn_test_" + strconv.Itoa(int(user.ID)) + "_" + strings.Join(scopes, "_")
+	h.create(&models.APIToken{UserID: user.ID, Name: "test", TokenHash: utils.HashToken(raw), Prefix: raw[:9], Scopes: strings.Join(scopes, ",")})
+	c := h.anonymous()
+	c.http.Transport = bearerTransport{next: c.http.Transport, token: raw}
+	return c
+}
+
+// bearerTransport 给每个请求加上令牌头
+type bearerTransport struct {
+	next  http.RoundTripper
+	token string
+}
+
+func (bt bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+bt.token)
+	return bt.next.RoundTrip(req)
+}
+
+// response 读取完毕的响应
+type response struct {
+	t      *testing.T
+	Status int
+	Header http.Header
+	Body   string
+}
+
+func (c *client) do(req *http.Request) *response {
+	c.h.t.Helper()
+	if req.Method != http.MethodGet {
+		req.Header.Set("X-CSRF-Token", c.csrfToken())
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.h.t.Fatalf("%s %s 失败: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.h.t.Fatalf("读取响应失败: %v", err)
+	}
+	return &response{t: c.h.t, Status: resp.StatusCode, Header: resp.Header, Body: string(body)}
+}
+
+var csrfMetaPattern = regexp.MustCompile(`<meta name="csrf-token" content="([^"]*)">`)
+
+// csrfToken 首次写请求前打开登录页，从meta标签读取session中的CSRF令牌
+func (c *client) csrfToken() string {
+	c.h.t.Helper()
+	if c.csrf == "" {
+		page := c.get("/login")
+		match := csrfMetaPattern.FindStringSubmatch(page.Body)
+		if match == nil {
+			c.h.t.Fatalf("登录页中没有CSRF令牌")
+		}
+		c.csrf = match[1]
+	}
+	return c.csrf
+}
+
+func (c *client) get(path string) *response {
+	c.h.t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, testBaseURL+path, nil)
+	return c.do(req)
+}
+
+func (c *client) postForm(path string, form url.Values) *response {
+	c.h.t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, testBaseURL+path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req)
+}
+
+// sendJSON 发送JSON请求体
+func (c *client) sendJSON(method, path string, body interface{}) *response {
+	c.h.t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		c.h.t.Fatalf("序列化请求失败: %v", err)
+	}
+	req, _ := http.NewRequest(method, testBaseURL+path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req)
+}
+
+func (r *response) expectStatus(status int) *response {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", r.Status, status, truncate(r.Body, 500))
+	}
+	return r
+}
+
+// expectRedirect 检查重定向的目标地址
+func (r *response) expectRedirect(location string) *response {
+	r.t.Helper()
+	if r.Status < 300 || r.Status >= 400 || r.Header.Get("Location") != location {
+		r.t.Fatalf("期望重定向到 %s, 实际 %d %s", location, r.Status, r.Header.Get("Location"))
+	}
+	return r
+}
+
+func (r *response) expectContains(parts ...string) *response {
+	r.t.Helper()
+	for _, part := range parts {
+		if !strings.Contains(r.Body, part) {
+			r.t.Errorf("响应中没有 %q", part)
+		}
+	}
+	return r
+}
+
+// json 解析JSON响应
+func (r *response) json() map[string]interface{} {
+	r.t.Helper()
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(r.Body), &data); err != nil {
+		r.t.Fatalf("响应不是JSON: %v, 响应: %s", err, truncate(r.Body, 500))
+	}
+	return data
+}
+
+// number 读取JSON响应中的数字字段
+func (r *response) number(key string) int {
+	r.t.Helper()
+	value, ok := r.json()[key].(float64)
+	if !ok {
+		r.t.Fatalf("响应中没有数字字段 %s: %s", key, r.Body)
+	}
+	return int(value)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// 页面中每次请求都会变化的部分，对比快照前替换为固定文本
+var goldenVolatile = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{csrfMetaPattern, `<meta name="csrf-token" content="CSRF">`},
+	{regexp.MustCompile(`name="_csrf" value="[^"]*"`), `name="_csrf" value="CSRF"`},
+	{regexp.MustCompile(`- ` + strconv.Itoa(time.Now().Year()) + ` All rights`), `- YEAR All rights`},
+}
+
+// assertGolden 把页面与 testdata/golden/<name>.golden 对比，-update 时重新生成
+func assertGolden(t *testing.T, name, body string) {
+	t.Helper()
+	for _, v := range goldenVolatile {
+		body = v.pattern.ReplaceAllString(body, v.replacement)
+	}
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("创建快照目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("写入快照失败: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取快照失败（首次运行请加 -update）: %v", err)
+	}
+	if string(want) != body {
+		t.Errorf("%s 与快照不一致，确认改动无误后使用 go test . -update 更新\n%s", name, firstDiff(string(want), body))
+	}
+}
+
+// firstDiff 返回第一处不同的行，便于定位
+func firstDiff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return "第" + strconv.Itoa(i+1) + "行\n期望: " + strings.TrimSpace(w) + "\n实际: " + strings.TrimSpace(g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// Command 命令行入口：gin-doniai import [选项] <目录或文件>
+func Command(db *gorm.DB, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dryRun := flags.Bool("dry-run", false, "只输出导入报告，不写入数据库")
+	format := flags.String("format", "", "markdown 或 wxr，默认按路径判断（.xml 为 wxr）")
+	authorEmail := flags.String("author", "", "找不到作者时使用的用户邮箱")
+	category := flags.String("category", "", "找不到分类时使用的分类别名")
+	asJSON := flags.Bool("json", false, "以JSON格式输出报告")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "用法: gin-doniai import [选项] <Markdown目录|.md|.zip|WXR导出文件.xml>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("需要指定一个导入路径")
+	}
+
+	opts := Options{DryRun: *dryRun, DefaultCategory: *category}
+	if *authorEmail != "" {
+		var user models.User
+		if err := db.Where("email = ?", *authorEmail).First(&user).Error; err != nil {
+			return fmt.Errorf("找不到默认作者 %s", *authorEmail)
+		}
+		opts.DefaultAuthor = &user
+	}
+
+	report := &Report{}
+	items, err := LoadPath(flags.Arg(0), *format, report)
+	if err != nil {
+		return err
+	}
+	Run(db, items, opts, report)
+
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	PrintReport(out, report)
+	return nil
+}
+
+// PrintReport 以表格形式输出导入报告
+func PrintReport(out io.Writer, report *Report) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "结果\t文章ID\t来源\t标题\t作者\t分类\t说明")
+	for _, r := range report.Results {
+		postID := "-"
+		if r.PostID != 0 {
+			postID = fmt.Sprint(r.PostID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Action, postID, r.Key, r.Title, r.Author, r.Category, r.Message)
+	}
+	w.Flush()
+
+	mode := ""
+	if report.DryRun {
+		mode = "（预演，未写入数据库）"
+	}
+	fmt.Fprintf(out, "\n新建 %d，更新 %d，跳过 %d，失败 %d%s\n", report.Created, report.Updated, report.Skipped, report.Failed, mode)
+}
@@ -0,0 +1,314 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/utils"
+	"gorm.io/gorm"
+)
+
+// 导入来源
+const (
+	SourceMarkdown = "markdown"
+	SourceWXR      = "wxr"
+)
+
+// 每条记录的处理结果
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionSkip   = "skip"
+	ActionError  = "error"
+)
+
+// Author 来源中的作者信息，按邮箱、登录名/用户名依次匹配站内用户
+type Author struct {
+	Login string
+	Email string
+	Name  string
+}
+
+// Item 一篇待导入的文章，Content 已转换为HTML
+type Item struct {
+	Source   string
+	Key      string // 来源内的唯一标识，重复导入时用于识别同一篇文章
+	Title    string
+	Content  string
+	Tags     []string
+	Category string // 分类别名（也接受分类名称）
+	Date     time.Time
+	Author   Author
+}
+
+// Options 导入选项
+type Options struct {
+	DryRun          bool
+	DefaultAuthor   *models.User // 找不到作者时使用，为空则该条导入失败
+	DefaultCategory string       // 找不到分类时使用的分类别名，为空则该条导入失败
+}
+
+// Result 单篇文章的导入结果
+type Result struct {
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+	PostID   uint   `json:"post_id,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Category string `json:"category,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Report 导入报告，DryRun 为 true 时只是预演，数据库没有任何改动
+type Report struct {
+	DryRun  bool     `json:"dry_run"`
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+func (r *Report) add(result Result) {
+	switch result.Action {
+	case ActionCreate:
+		r.Created++
+	case ActionUpdate:
+		r.Updated++
+	case ActionSkip:
+		r.Skipped++
+	case ActionError:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// Fail 记录解析阶段就失败的条目
+func (r *Report) Fail(key, message string) {
+	r.add(Result{Key: key, Action: ActionError, Message: message})
+}
+
+// Run 导入文章：按来源标识判断新建、更新或跳过，每篇文章单独提交事务
+func Run(db *gorm.DB, items []Item, opts Options, report *Report) {
+	report.DryRun = opts.DryRun
+	resolver := newResolver(db)
+	seen := make(map[string]bool)
+
+	for _, item := range items {
+		result := Result{Key: item.Key, Title: item.Title}
+		id := item.Source + ":" + item.Key
+		if seen[id] {
+			result.Action = ActionError
+			result.Message = "同一批次中重复的来源标识"
+			report.add(result)
+			continue
+		}
+		seen[id] = true
+
+		if err := importItem(db, resolver, item, opts, &result); err != nil {
+			result.Action = ActionError
+			result.Message = err.Error()
+		}
+		report.add(result)
+	}
+}
+
+func importItem(db *gorm.DB, resolver *resolver, item Item, opts Options, result *Result) error {
+	if strings.TrimSpace(item.Title) == "" {
+		return errors.New("缺少标题")
+	}
+	if len([]rune(item.Title)) > 200 {
+		return errors.New("标题超过200个字符")
+	}
+
+	author := resolver.user(item.Author)
+	if author == nil {
+		author = opts.DefaultAuthor
+	}
+	if author == nil {
+		return fmt.Errorf("找不到作者 %s", describeAuthor(item.Author))
+	}
+	result.Author = author.Name
+
+	category := resolver.category(item.Category)
+	if category == nil && opts.DefaultCategory != "" {
+		category = resolver.category(opts.DefaultCategory)
+	}
+	if category == nil {
+		return fmt.Errorf("找不到分类 %q", item.Category)
+	}
+	result.Category = category.Name
+
+	// 来源中的标签已经是列表，直接编码，不经过逗号拼接以免拆开带逗号的标签
+	tagsJSON := utils.EncodeTags(uniqueTags(item.Tags))
+	hash := contentHash(item, author.ID, category.ID, tagsJSON)
+
+	// 用 Find 查询导入记录，首次导入时不会打印 record not found 日志
+	var record models.PostImport
+	query := db.Where("source = ? AND source_key = ?", item.Source, item.Key).Limit(1).Find(&record)
+	if query.Error != nil {
+		return query.Error
+	}
+	exists := query.RowsAffected > 0
+
+	if exists {
+		result.PostID = record.PostID
+		var count int64
+		db.Model(&models.Post{}).Where("id = ?", record.PostID).Count(&count)
+		switch {
+		case count == 0:
+			// 导入后被删除的文章不再恢复
+			result.Action = ActionSkip
+			result.Message = "文章已被删除"
+			return nil
+		case record.ContentHash == hash:
+			result.Action = ActionSkip
+			result.Message = "内容未变化"
+			return nil
+		}
+		result.Action = ActionUpdate
+	} else {
+		result.Action = ActionCreate
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if exists {
+			updates := map[string]interface{}{
+				"title":       item.Title,
+				"content":     item.Content,
+				"tags":        tagsJSON,
+				"category":    category.Name,
+				"category_id": category.ID,
+				"user_id":     author.ID,
+				"author":      author.Name,
+			}
+			if err := tx.Model(&models.Post{}).Where("id = ?", record.PostID).Updates(updates).Error; err != nil {
+				return err
+			}
+			return tx.Model(&record).Update("content_hash", hash).Error
+		}
+
+		post := models.Post{
+			Title:      item.Title,
+			UserId:     int(author.ID),
+			Author:     author.Name,
+			Category:   category.Name,
+			CategoryId: int(category.ID),
+			Content:    item.Content,
+			Tags:       tagsJSON,
+			ReadLimit:  1,
+		}
+		if !item.Date.IsZero() {
+			post.CreatedAt = item.Date
+			post.UpdatedAt = item.Date
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		result.PostID = post.ID
+		return tx.Create(&models.PostImport{
+			Source:      item.Source,
+			SourceKey:   item.Key,
+			PostID:      post.ID,
+			ContentHash: hash,
+		}).Error
+	})
+}
+
+// uniqueTags 去掉空标签和只有大小写不同的重复标签
+func uniqueTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+func contentHash(item Item, authorID, categoryID uint, tags string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00%d", item.Title, item.Content, tags, authorID, categoryID)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func describeAuthor(a Author) string {
+	for _, s := range []string{a.Email, a.Login, a.Name} {
+		if s != "" {
+			return s
+		}
+	}
+	return "(未指定)"
+}
+
+// resolver 缓存作者和分类的查询结果
+type resolver struct {
+	db         *gorm.DB
+	users      map[string]*models.User
+	categories map[string]*models.Category
+}
+
+func newResolver(db *gorm.DB) *resolver {
+	return &resolver{
+		db:         db,
+		users:      make(map[string]*models.User),
+		categories: make(map[string]*models.Category),
+	}
+}
+
+func (r *resolver) user(a Author) *models.User {
+	key := a.Email + "\x00" + a.Login + "\x00" + a.Name
+	if user, ok := r.users[key]; ok {
+		return user
+	}
+
+	var found *models.User
+	if a.Email != "" {
+		var user models.User
+		if r.db.Where("email = ?", a.Email).Limit(1).Find(&user).RowsAffected > 0 {
+			found = &user
+		}
+	}
+	for _, name := range []string{a.Login, a.Name} {
+		if found != nil || name == "" {
+			continue
+		}
+		var user models.User
+		if r.db.Where("name = ?", name).Limit(1).Find(&user).RowsAffected > 0 {
+			found = &user
+		}
+	}
+	r.users[key] = found
+	return found
+}
+
+// category 先按别名、再按名称查找分类
+func (r *resolver) category(value string) *models.Category {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if category, ok := r.categories[value]; ok {
+		return category
+	}
+
+	var found *models.Category
+	var category models.Category
+	if r.db.Where("LOWER(alias) = ?", strings.ToLower(value)).Limit(1).Find(&category).RowsAffected > 0 {
+		found = &category
+	} else if r.db.Where("name = ?", value).Limit(1).Find(&category).RowsAffected > 0 {
+		found = &category
+	}
+	r.categories[value] = found
+	return found
+}
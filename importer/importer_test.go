@@ -0,0 +1,321 @@
+package importer
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		data string
+		want Item
+	}{
+		{
+			name: "完整头部",
+			key:  "posts/a.md",
+			data: "---\ntitle: \" 标题 \"\nslug: my-post\ntags: [go, gin]\ncategory: go\ndate: 2024-03-01\nauthor: a@example.com\n---\n正文\n",
+			want: Item{Source: SourceMarkdown, Key: "my-post", Title: "标题", Tags: []string{"go", "gin"}, Category: "go",
+				Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), Author: Author{Email: "a@example.com"}, Content: "<p>正文</p>\n"},
+		},
+		{
+			name: "逗号分隔的标签和用户名作者",
+			key:  "b.md",
+			data: "\xef\xbb\xbf---\r\ntitle: B\r\ntags: go，gin, web\r\nauthor: alice\r\n---\r\n",
+			want: Item{Source: SourceMarkdown, Key: "b.md", Title: "B", Tags: []string{"go", "gin", "web"}, Author: Author{Name: "alice"}, Content: ""},
+		},
+		{
+			name: "没有头部",
+			key:  "c.md",
+			data: "# 只有正文\n",
+			want: Item{Source: SourceMarkdown, Key: "c.md", Content: "<h1>只有正文</h1>\n"},
+		},
+		{
+			name: "头部未结束时整体作为正文",
+			key:  "d.md",
+			data: "---\ntitle: D\n",
+			want: Item{Source: SourceMarkdown, Key: "d.md", Content: "<hr>\n<p>title: D</p>\n"},
+		},
+		{
+			name: "保留HTML",
+			key:  "e.md",
+			data: "---\ntitle: E\n---\n<div class=\"note\">提示</div>\n",
+			want: Item{Source: SourceMarkdown, Key: "e.md", Title: "E", Content: "<div class=\"note\">提示</div>\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMarkdown(tt.key, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseMarkdown 失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMarkdown() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"YAML格式错误": "---\ntitle: [未闭合\n---\n正文",
+		"日期无法识别":   "---\ntitle: A\ndate: 昨天\n---\n正文",
+	} {
+		if _, err := ParseMarkdown("a.md", []byte(data)); err == nil {
+			t.Errorf("%s: ParseMarkdown 期望返回错误", name)
+		}
+	}
+}
+
+func TestReadMarkdownDirectory(t *testing.T) {
+	report := &Report{}
+	items, err := ReadMarkdown(os.DirFS("testdata/markdown"), report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 隐藏目录和非Markdown文件被忽略，日期错误的文件记入报告
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"hello-world", "notes/plain.markdown"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("导入的文章 = %v, 期望 %v", keys, want)
+	}
+	if report.Failed != 1 || report.Results[0].Key != "notes/bad-date.md" {
+		t.Errorf("报告 = %+v, 期望 notes/bad-date.md 解析失败", report)
+	}
+
+	hello := items[0]
+	if hello.Title != "你好，世界" || hello.Category != "go" || hello.Author.Email != "author@example.com" {
+		t.Errorf("hello.md = %+v", hello)
+	}
+	if want := []string{"go", "R&D", "a, b", "Go"}; !reflect.DeepEqual(hello.Tags, want) {
+		t.Errorf("hello.md 标签 = %q, 期望 %q", hello.Tags, want)
+	}
+	if !hello.Date.Equal(time.Date(2024, 3, 1, 12, 30, 0, 0, time.Local)) {
+		t.Errorf("hello.md 日期 = %v", hello.Date)
+	}
+	if !strings.Contains(hello.Content, "<h1>标题</h1>") || !strings.Contains(hello.Content, "<strong>加粗</strong>") {
+		t.Errorf("hello.md 正文 = %q", hello.Content)
+	}
+}
+
+func TestParseWXR(t *testing.T) {
+	file, err := os.Open("testdata/export.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	report := &Report{}
+	items, err := ParseWXR(file, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("解析出 %d 篇文章, 期望 2", len(items))
+	}
+
+	first := items[0]
+	want := Item{
+		Source:   SourceWXR,
+		Key:      "https://blog.example.com#11",
+		Title:    "第一篇文章",
+		Content:  "<p>第一段<br>\n第二行</p>\n<h2>小标题</h2>\n<p>最后一段</p>",
+		Tags:     []string{"gin", "Web"},
+		Category: "GO",
+		Date:     time.Date(2023, 5, 6, 7, 8, 9, 0, time.Local),
+		Author:   Author{Login: "wpadmin", Email: "author@example.com", Name: "站长"},
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("第一篇文章 = %+v\n期望 %+v", first, want)
+	}
+
+	// 不在作者列表中的作者只有登录名，零值日期被忽略
+	second := items[1]
+	if second.Author != (Author{Login: "reader"}) || !second.Date.IsZero() || second.Content != "<p>已有段落</p>" || second.Category != "unknown" {
+		t.Errorf("第二篇文章 = %+v", second)
+	}
+
+	// 草稿记为跳过，页面直接忽略
+	if report.Skipped != 1 || report.Results[0].Key != "https://blog.example.com#13" {
+		t.Errorf("报告 = %+v, 期望草稿被跳过", report)
+	}
+}
+
+func TestParseWXRInvalid(t *testing.T) {
+	if _, err := ParseWXR(strings.NewReader("<rss><channel>"), &Report{}); err == nil {
+		t.Error("ParseWXR 期望返回错误")
+	}
+}
+
+// openDB 打开迁移好的内存数据库，写入作者和分类
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenInMemory(strings.ReplaceAll(t.Name(), "/", "_"))
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	users := []models.User{
+		{Name: "author", Email: "author@example.com", Password: "x", Avatar: ""},
+		{Name: "reader", Email: "reader@example.com", Password: "x", Avatar: ""},
+	}
+	categories := []models.Category{
+		{Name: "Go语言", Alias: "go", StatusCode: 1},
+		{Name: "随笔", Alias: "notes", StatusCode: 1},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&categories).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func loadWXR(t *testing.T) []Item {
+	t.Helper()
+	data, err := os.ReadFile("testdata/export.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := LoadFile("export.xml", data, "", &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestRunResolvesAuthorsAndCategories(t *testing.T) {
+	db := openDB(t)
+	items := loadWXR(t)
+
+	// 分类按别名忽略大小写匹配，找不到的分类没有默认值时导入失败
+	report := &Report{}
+	Run(db, items, Options{}, report)
+	if report.Created != 1 || report.Failed != 1 {
+		t.Fatalf("报告 = %+v, 期望新建1篇、失败1篇", report)
+	}
+	created := report.Results[0]
+	if created.Author != "author" || created.Category != "Go语言" {
+		t.Errorf("导入结果 = %+v, 期望按邮箱匹配作者、按别名匹配分类", created)
+	}
+	var post models.Post
+	db.First(&post, created.PostID)
+	if post.Tags != `["gin","Web"]` || post.CategoryId == 0 || !post.CreatedAt.Equal(items[0].Date) {
+		t.Errorf("导入的文章 = %+v", post)
+	}
+	if failed := report.Results[1]; !strings.Contains(failed.Message, "unknown") {
+		t.Errorf("失败原因 = %q, 期望提示找不到分类", failed.Message)
+	}
+
+	// 使用默认分类（按名称匹配）后可以导入，作者按登录名匹配站内用户名
+	report = &Report{}
+	Run(db, items, Options{DefaultCategory: "随笔"}, report)
+	if report.Created != 1 || report.Skipped != 1 {
+		t.Fatalf("报告 = %+v, 期望新建1篇、跳过1篇", report)
+	}
+	if created := report.Results[1]; created.Author != "reader" || created.Category != "随笔" {
+		t.Errorf("导入结果 = %+v, 期望作者 reader、分类 随笔", created)
+	}
+}
+
+func TestRunMissingAuthor(t *testing.T) {
+	db := openDB(t)
+	items := []Item{{Source: SourceMarkdown, Key: "a.md", Title: "A", Category: "go", Author: Author{Name: "nobody"}}}
+
+	report := &Report{}
+	Run(db, items, Options{}, report)
+	if report.Failed != 1 || !strings.Contains(report.Results[0].Message, "nobody") {
+		t.Fatalf("报告 = %+v, 期望找不到作者", report)
+	}
+
+	var fallback models.User
+	db.Where("name = ?", "reader").First(&fallback)
+	report = &Report{}
+	Run(db, items, Options{DefaultAuthor: &fallback}, report)
+	if report.Created != 1 || report.Results[0].Author != "reader" {
+		t.Errorf("报告 = %+v, 期望使用默认作者", report)
+	}
+}
+
+func TestRunIdempotent(t *testing.T) {
+	db := openDB(t)
+	items, err := ReadMarkdown(os.DirFS("testdata/markdown"), &Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{DefaultCategory: "notes"}
+	countPosts := func() int64 {
+		var count int64
+		db.Model(&models.Post{}).Count(&count)
+		return count
+	}
+
+	// 预演不写数据库
+	report := &Report{}
+	Run(db, items, Options{DryRun: true, DefaultCategory: "notes"}, report)
+	if report.Created != 2 || countPosts() != 0 {
+		t.Fatalf("预演报告 = %+v, 文章数 %d", report, countPosts())
+	}
+
+	report = &Report{}
+	Run(db, items, opts, report)
+	if report.Created != 2 || countPosts() != 2 {
+		t.Fatalf("首次导入报告 = %+v", report)
+	}
+	var hello models.Post
+	db.First(&hello, report.Results[0].PostID)
+	if hello.Tags != `["go","R&D","a, b"]` {
+		t.Errorf("标签 = %s, 期望去重且不拆分带逗号的标签、不转义HTML字符", hello.Tags)
+	}
+
+	// 内容未变化时跳过
+	report = &Report{}
+	Run(db, items, opts, report)
+	if report.Skipped != 2 || report.Results[0].Message != "内容未变化" || countPosts() != 2 {
+		t.Fatalf("重复导入报告 = %+v", report)
+	}
+
+	// 修改后更新同一篇文章
+	changed := append([]Item(nil), items...)
+	changed[0].Content = "<p>新的正文</p>"
+	report = &Report{}
+	Run(db, changed, opts, report)
+	if report.Updated != 1 || report.Skipped != 1 || report.Results[0].PostID != hello.ID || countPosts() != 2 {
+		t.Fatalf("修改后导入报告 = %+v", report)
+	}
+	db.First(&hello, hello.ID)
+	if hello.Content != "<p>新的正文</p>" {
+		t.Errorf("更新后的正文 = %q", hello.Content)
+	}
+
+	// 导入后被删除的文章不再恢复
+	db.Delete(&models.Post{}, hello.ID)
+	report = &Report{}
+	Run(db, items, opts, report)
+	if report.Results[0].Action != ActionSkip || report.Results[0].Message != "文章已被删除" || countPosts() != 1 {
+		t.Errorf("删除后导入报告 = %+v", report)
+	}
+
+	// 同一批次中重复的来源标识
+	report = &Report{}
+	Run(db, []Item{items[1], items[1]}, opts, report)
+	if report.Skipped != 1 || report.Failed != 1 {
+		t.Errorf("重复标识报告 = %+v", report)
+	}
+}
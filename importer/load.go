@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DetectFormat 根据文件名判断格式：.xml 为WXR，其余（目录、.md、.zip）为Markdown
+func DetectFormat(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".xml") {
+		return SourceWXR
+	}
+	return SourceMarkdown
+}
+
+// LoadPath 读取本地目录或文件，format 为空时自动判断
+func LoadPath(path, format string, report *Report) ([]Item, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		if format == SourceWXR {
+			return nil, fmt.Errorf("WXR格式需要指定导出的XML文件")
+		}
+		return ReadMarkdown(os.DirFS(path), report)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(filepath.Base(path), data, format, report)
+}
+
+// LoadFile 读取单个文件（上传的文件或命令行指定的文件），format 为空时按文件名判断
+func LoadFile(name string, data []byte, format string, report *Report) ([]Item, error) {
+	if format == "" {
+		format = DetectFormat(name)
+	}
+
+	switch {
+	case format == SourceWXR:
+		return ParseWXR(bytes.NewReader(data), report)
+	case format != SourceMarkdown:
+		return nil, fmt.Errorf("不支持的格式 %q", format)
+	case strings.EqualFold(filepath.Ext(name), ".zip"):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("zip文件解析失败: %v", err)
+		}
+		return ReadMarkdown(archive, report)
+	default:
+		// 单个Markdown文件
+		item, err := ParseMarkdown(name, data)
+		if err != nil {
+			report.Fail(name, err.Error())
+			return nil, nil
+		}
+		return []Item{item}, nil
+	}
+}
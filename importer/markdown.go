@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+	"gin-doniai/utils"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter Markdown文件头部的YAML元数据
+type frontMatter struct {
+	Title    string      `yaml:"title"`
+	Slug     string      `yaml:"slug"` // 设置后作为来源标识，文件改名也不会重复导入
+	Tags     interface{} `yaml:"tags"` // 列表或逗号分隔的字符串
+	Category string      `yaml:"category"`
+	Date     string      `yaml:"date"`
+	Author   string      `yaml:"author"` // 邮箱或用户名
+}
+
+// 导入的是站点管理员提供的内容，保留Markdown中的HTML
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %q", value)
+}
+
+// splitFrontMatter 拆分 --- 包围的YAML头部和正文，没有头部时返回空头部
+func splitFrontMatter(data []byte) ([]byte, []byte) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return nil, data
+	}
+	rest := data[4:]
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, data
+	}
+	body := rest[end+4:]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+	return rest[:end], body
+}
+
+// ParseMarkdown 解析一篇带YAML头部的Markdown，key 为来源标识（通常是相对路径）
+func ParseMarkdown(key string, data []byte) (Item, error) {
+	head, body := splitFrontMatter(data)
+
+	var meta frontMatter
+	if len(head) > 0 {
+		if err := yaml.Unmarshal(head, &meta); err != nil {
+			return Item{}, fmt.Errorf("YAML头部解析失败: %v", err)
+		}
+	}
+
+	item := Item{
+		Source:   SourceMarkdown,
+		Key:      key,
+		Title:    strings.TrimSpace(meta.Title),
+		Category: meta.Category,
+		Author:   Author{Name: meta.Author},
+	}
+	if meta.Slug != "" {
+		item.Key = meta.Slug
+	}
+	if strings.Contains(meta.Author, "@") {
+		item.Author = Author{Email: meta.Author}
+	}
+
+	switch tags := meta.Tags.(type) {
+	case string:
+		// 字符串形式按逗号（含全角逗号）拆分
+		item.Tags = utils.ParseTags(tags)
+	case []interface{}:
+		for _, tag := range tags {
+			item.Tags = append(item.Tags, fmt.Sprint(tag))
+		}
+	}
+
+	date, err := parseDate(meta.Date)
+	if err != nil {
+		return Item{}, err
+	}
+	item.Date = date
+
+	var buf bytes.Buffer
+	if err := markdown.Convert(body, &buf); err != nil {
+		return Item{}, fmt.Errorf("Markdown转换失败: %v", err)
+	}
+	item.Content = buf.String()
+	return item, nil
+}
+
+// ReadMarkdown 读取目录（或zip包）中的全部 .md/.markdown 文件，解析失败的文件记入报告
+func ReadMarkdown(fsys fs.FS, report *Report) ([]Item, error) {
+	var items []Item
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// 跳过 .git 等隐藏目录
+			if p != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(path.Ext(p))
+		if ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			report.Fail(p, err.Error())
+			return nil
+		}
+		item, err := ParseMarkdown(p, data)
+		if err != nil {
+			report.Fail(p, err.Error())
+			return nil
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WXR（WordPress导出文件）中用到的元素，命名空间按本地名匹配以兼容不同版本
+type wxrDocument struct {
+	Channel struct {
+		Link        string      `xml:"link"`
+		BaseSiteURL string      `xml:"base_site_url"`
+		Authors     []wxrAuthor `xml:"author"`
+		Items       []wxrItem   `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAuthor struct {
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrItem struct {
+	Title      string        `xml:"title"`
+	Creator    string        `xml:"creator"`
+	Content    string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID     string        `xml:"post_id"`
+	PostDate   string        `xml:"post_date"`
+	Status     string        `xml:"status"`
+	PostType   string        `xml:"post_type"`
+	Categories []wxrCategory `xml:"category"`
+}
+
+// ParseWXR 解析WordPress导出文件，只导入已发布的文章，其余条目记为跳过
+func ParseWXR(r io.Reader, report *Report) ([]Item, error) {
+	var doc wxrDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("WXR解析失败: %v", err)
+	}
+
+	authors := make(map[string]wxrAuthor, len(doc.Channel.Authors))
+	for _, author := range doc.Channel.Authors {
+		authors[author.Login] = author
+	}
+	// 来源标识为 站点地址#文章ID，不同站点的导出文件不会冲突
+	site := strings.TrimRight(firstNonEmpty(doc.Channel.BaseSiteURL, doc.Channel.Link), "/")
+
+	var items []Item
+	for _, entry := range doc.Channel.Items {
+		key := site + "#" + strings.TrimSpace(entry.PostID)
+		if entry.PostType != "post" {
+			continue
+		}
+		if entry.Status != "publish" {
+			report.add(Result{Key: key, Title: entry.Title, Action: ActionSkip, Message: "未发布的文章（" + entry.Status + "）"})
+			continue
+		}
+
+		item := Item{
+			Source:  SourceWXR,
+			Key:     key,
+			Title:   strings.TrimSpace(entry.Title),
+			Content: autop(entry.Content),
+		}
+		if author, ok := authors[entry.Creator]; ok {
+			item.Author = Author{Login: author.Login, Email: author.Email, Name: author.DisplayName}
+		} else {
+			item.Author = Author{Login: entry.Creator}
+		}
+
+		var tags []string
+		for _, category := range entry.Categories {
+			switch category.Domain {
+			case "category":
+				// WordPress文章可以有多个分类，这里取第一个
+				if item.Category == "" {
+					item.Category = firstNonEmpty(category.Nicename, category.Name)
+				}
+			case "post_tag":
+				tags = append(tags, strings.TrimSpace(category.Name))
+			}
+		}
+		item.Tags = tags
+
+		if entry.PostDate != "" && !strings.HasPrefix(entry.PostDate, "0000") {
+			if date, err := time.ParseInLocation("2006-01-02 15:04:05", entry.PostDate, time.Local); err == nil {
+				item.Date = date
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var blockTagPattern = regexp.MustCompile(`(?i)^<(p|div|h[1-6]|ul|ol|li|blockquote|pre|table|figure|hr|!--)`)
+
+// autop WordPress正文用空行分段、不带<p>标签，这里按空行补上段落，已是块级元素的段落保持不变
+func autop(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	var out []string
+	for _, block := range strings.Split(content, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if blockTagPattern.MatchString(block) {
+			out = append(out, block)
+			continue
+		}
+		out = append(out, "<p>"+strings.ReplaceAll(block, "\n", "<br>\n")+"</p>")
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,827 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"gin-doniai/caches"
+	"gin-doniai/config"
+	"gin-doniai/handlers"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/services"
+	"gin-doniai/utils"
+	"gin-doniai/workers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/oauth2"
+)
+
+func TestPagesMatchGolden(t *testing.T) {
+	h := newHarness(t)
+	visitor := h.anonymous()
+
+	home := visitor.get("/").expectStatus(http.StatusOK)
+	assertGolden(t, "home", home.Body)
+
+	detail := visitor.get(fmt.Sprintf("/post-%d-1", h.fx.post.ID)).expectStatus(http.StatusOK)
+	assertGolden(t, "detail", detail.Body)
+
+	category := visitor.get("/categories/" + h.fx.category.Alias).expectStatus(http.StatusOK)
+	category.expectContains(h.fx.post.Title)
+
+	visitor.get("/post-9999-1").expectStatus(http.StatusNotFound)
+	notFound := visitor.get("/no-such-page").expectStatus(http.StatusNotFound)
+	// CSP不允许 javascript: 链接，内联脚本必须带本次请求的nonce
+	if strings.Contains(notFound.Body, "javascript:") {
+		t.Errorf("404页面包含会被CSP拦截的 javascript: 链接")
+	}
+	policy := notFound.Header.Get("Content-Security-Policy") + notFound.Header.Get("Content-Security-Policy-Report-Only")
+	// 模板会把属性值中的 + 转义为 &#43;，比较前先还原
+	nonce, script := cspNoncePattern.FindStringSubmatch(policy), scriptNoncePattern.FindStringSubmatch(notFound.Body)
+	if nonce == nil || script == nil || html.UnescapeString(script[1]) != nonce[1] {
+		t.Errorf("404页面的内联脚本没有使用CSP nonce, 策略: %s", policy)
+	}
+}
+
+var (
+	cspNoncePattern    = regexp.MustCompile(`'nonce-([^']+)'`)
+	scriptNoncePattern = regexp.MustCompile(`<script nonce="([^"]+)">`)
+)
+
+func TestLoginAndLogout(t *testing.T) {
+	h := newHarness(t)
+
+	visitor := h.anonymous()
+	visitor.get("/profile").expectRedirect("/login")
+	visitor.postForm("/login", url.Values{"email": {h.fx.reader.Email}, "password": {"wrong-password"}}).
+		expectStatus(http.StatusUnauthorized)
+	visitor.postForm("/login", url.Values{"email": {"nobody@example.com"}, "password": {fixturePassword}}).
+		expectStatus(http.StatusUnauthorized)
+	visitor.postForm("/login", url.Values{"email": {h.fx.reader.Email}}).expectStatus(http.StatusBadRequest)
+
+	// 写请求缺少CSRF令牌时被拒绝
+	noToken, _ := http.NewRequest(http.MethodPost, testBaseURL+"/login", strings.NewReader("email=reader%40example.com"))
+	noToken.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := visitor.http.Do(noToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("缺少CSRF令牌的状态码 = %d, 期望 403", resp.StatusCode)
+	}
+
+	// 用户名同样可以登录
+	reader := h.anonymous()
+	reader.postForm("/login", url.Values{"email": {h.fx.reader.Name}, "password": {fixturePassword}}).expectStatus(http.StatusOK)
+	reader.get("/profile").expectStatus(http.StatusOK).expectContains(h.fx.reader.Name)
+
+	reader.get("/logout").expectRedirect("/")
+	reader.get("/profile").expectRedirect("/login")
+}
+
+func TestRegister(t *testing.T) {
+	h := newHarness(t)
+	visitor := h.anonymous()
+
+	form := url.Values{
+		"username":        {"alice"},
+		"email":           {"alice@example.com"},
+		"password":        {"secret123"},
+		"confirmPassword": {"secret123"},
+		"agreeTerms":      {"on"},
+	}
+	visitor.postForm("/register", form).expectStatus(http.StatusOK)
+
+	var user models.User
+	if err := h.db.Where("email = ?", "alice@example.com").First(&user).Error; err != nil {
+		t.Fatalf("注册后找不到用户: %v", err)
+	}
+	if user.IsEmailVerified() {
+		t.Error("新注册用户的邮箱应为未验证")
+	}
+	if !utils.CheckPassword("secret123", user.Password) {
+		t.Error("密码没有正确加密保存")
+	}
+	var verifications int64
+	h.db.Model(&models.EmailVerification{}).Where("user_id = ?", user.ID).Count(&verifications)
+	if verifications != 1 {
+		t.Errorf("验证邮件记录数 = %d, 期望 1", verifications)
+	}
+
+	var verification models.EmailVerification
+	h.db.Where("user_id = ?", user.ID).First(&verification)
+	visitor.get("/verify-email?token=" + url.QueryEscape(verification.Token)).expectStatus(http.StatusOK)
+	visitor.get("/verify-email?token=" + url.QueryEscape(verification.Token)).expectStatus(http.StatusBadRequest)
+	// 并发请求查到的是同一条未使用的记录，只有先标记的请求能继续
+	if err := repositories.NewEmailVerificationRepository(h.db).MarkUsed(&verification); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("重复标记验证令牌 = %v, 期望 ErrNotFound", err)
+	}
+
+	// 重复邮箱、密码不一致、未同意协议
+	visitor.postForm("/register", form).expectStatus(http.StatusBadRequest)
+	mismatch := cloneForm(form)
+	mismatch.Set("email", "bob@example.com")
+	mismatch.Set("confirmPassword", "other")
+	visitor.postForm("/register", mismatch).expectStatus(http.StatusBadRequest)
+	noTerms := cloneForm(form)
+	noTerms.Set("email", "bob@example.com")
+	noTerms.Del("agreeTerms")
+	visitor.postForm("/register", noTerms).expectStatus(http.StatusBadRequest)
+
+	// 新用户可以直接登录
+	alice := h.anonymous()
+	alice.postForm("/login", url.Values{"email": {"alice@example.com"}, "password": {"secret123"}}).expectStatus(http.StatusOK)
+}
+
+func cloneForm(form url.Values) url.Values {
+	copied := url.Values{}
+	for key, values := range form {
+		copied[key] = append([]string(nil), values...)
+	}
+	return copied
+}
+
+func TestPublishPost(t *testing.T) {
+	h := newHarness(t)
+	post := map[string]interface{}{
+		"title":       "新文章",
+		"category_id": h.fx.category.ID,
+		"content":     "<p>内容</p>",
+		"tags":        `["test"]`,
+	}
+
+	h.anonymous().sendJSON(http.MethodPost, "/api/posts/", post).expectStatus(http.StatusUnauthorized)
+	h.login(h.fx.unverified).sendJSON(http.MethodPost, "/api/posts/", post).expectStatus(http.StatusForbidden)
+
+	author := h.login(h.fx.author)
+	invalid := map[string]interface{}{"title": "x", "category_id": 9999, "content": "x"}
+	author.sendJSON(http.MethodPost, "/api/posts/", invalid).expectStatus(http.StatusBadRequest)
+
+	created := author.sendJSON(http.MethodPost, "/api/posts/", post).expectStatus(http.StatusCreated)
+	id := uint(created.json()["post"].(map[string]interface{})["id"].(float64))
+
+	var saved models.Post
+	h.reload(&saved, id)
+	if saved.UserId != int(h.fx.author.ID) || saved.Category != h.fx.category.Name {
+		t.Errorf("文章作者或分类不正确: %+v", saved)
+	}
+
+	h.anonymous().get(fmt.Sprintf("/post-%d-1", id)).expectStatus(http.StatusOK).expectContains("新文章", "<p>内容</p>")
+
+	// 只有作者或管理员可以修改和删除
+	path := fmt.Sprintf("/api/posts/%d", id)
+	h.login(h.fx.reader).sendJSON(http.MethodPut, path, map[string]interface{}{"title": "改标题"}).expectStatus(http.StatusForbidden)
+	author.sendJSON(http.MethodPut, path, map[string]interface{}{"title": "改标题"}).expectStatus(http.StatusOK)
+	h.reload(&saved, id)
+	if saved.Title != "改标题" {
+		t.Errorf("标题 = %q, 期望 改标题", saved.Title)
+	}
+	h.login(h.fx.reader).sendJSON(http.MethodDelete, path, nil).expectStatus(http.StatusForbidden)
+	h.login(h.fx.admin).sendJSON(http.MethodDelete, path, nil).expectStatus(http.StatusOK)
+	h.anonymous().get(fmt.Sprintf("/post-%d-1", id)).expectStatus(http.StatusNotFound)
+}
+
+func TestComments(t *testing.T) {
+	h := newHarness(t)
+	comment := map[string]interface{}{"post_id": h.fx.post.ID, "content": "第二条评论"}
+
+	h.anonymous().sendJSON(http.MethodPost, "/api/comments/", comment).expectStatus(http.StatusUnauthorized)
+	h.login(h.fx.unverified).sendJSON(http.MethodPost, "/api/comments/", comment).expectStatus(http.StatusForbidden)
+
+	reader := h.login(h.fx.reader)
+	missing := map[string]interface{}{"post_id": 9999, "content": "x"}
+	reader.sendJSON(http.MethodPost, "/api/comments/", missing).expectStatus(http.StatusNotFound)
+
+	created := reader.sendJSON(http.MethodPost, "/api/comments/", comment).expectStatus(http.StatusOK)
+	id := uint(created.json()["data"].(map[string]interface{})["id"].(float64))
+
+	var post models.Post
+	h.reload(&post, h.fx.post.ID)
+	if post.Replies != h.fx.post.Replies+1 {
+		t.Errorf("回复数 = %d, 期望 %d", post.Replies, h.fx.post.Replies+1)
+	}
+	h.anonymous().get(fmt.Sprintf("/post-%d-1", h.fx.post.ID)).expectStatus(http.StatusOK).expectContains("第二条评论")
+
+	// 回复
+	reply := map[string]interface{}{"post_id": h.fx.post.ID, "parent_id": id, "content": "回复一下"}
+	h.login(h.fx.author).sendJSON(http.MethodPost, "/api/comments/", reply).expectStatus(http.StatusOK)
+
+	// 只有作者可以修改，作者或管理员可以删除
+	path := fmt.Sprintf("/api/comments/%d", id)
+	h.login(h.fx.author).sendJSON(http.MethodPut, path, map[string]interface{}{"content": "改"}).expectStatus(http.StatusForbidden)
+	reader.sendJSON(http.MethodPut, path, map[string]interface{}{"content": "改过了"}).expectStatus(http.StatusOK)
+	h.login(h.fx.author).sendJSON(http.MethodDelete, path, nil).expectStatus(http.StatusForbidden)
+	h.login(h.fx.admin).sendJSON(http.MethodDelete, path, nil).expectStatus(http.StatusOK)
+	reader.get(path).expectStatus(http.StatusNotFound)
+	if err := repositories.NewGorm(h.db).Comments.Delete(&models.Comment{ID: id}); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("删除已删除的评论 err = %v, 期望 ErrNotFound", err)
+	}
+
+	// 删除评论后回复数同步减少（回复本身保留）
+	h.reload(&post, h.fx.post.ID)
+	if post.Replies != h.fx.post.Replies+1 {
+		t.Errorf("删除后回复数 = %d, 期望 %d", post.Replies, h.fx.post.Replies+1)
+	}
+	if report, err := h.svc.Counters.Reconcile(); err != nil || report.Posts != 0 {
+		t.Errorf("回复数与评论记录不一致: 校准结果 %+v, %v", report, err)
+	}
+}
+
+func TestLikesAndFavorites(t *testing.T) {
+	h := newHarness(t)
+	likePath := fmt.Sprintf("/api/posts/%d/like", h.fx.post.ID)
+	favoritePath := fmt.Sprintf("/api/posts/%d/favorite", h.fx.post.ID)
+	like := map[string]string{"action": "like"}
+
+	h.anonymous().sendJSON(http.MethodPost, likePath, like).expectStatus(http.StatusUnauthorized)
+
+	reader := h.login(h.fx.reader)
+	admin := h.login(h.fx.admin)
+	steps := []struct {
+		c      *client
+		path   string
+		action string
+		field  string
+		want   int
+	}{
+		{reader, likePath, "like", "likes", 1},
+		{reader, likePath, "like", "likes", 1}, // 重复点赞不计数
+		{admin, likePath, "like", "likes", 2},
+		{reader, likePath, "unlike", "likes", 1},
+		{reader, likePath, "unlike", "likes", 1},
+		{reader, favoritePath, "favorite", "favorites", 1},
+		{reader, favoritePath, "favorite", "favorites", 1},
+	}
+	for i, step := range steps {
+		resp := step.c.sendJSON(http.MethodPost, step.path, map[string]string{"action": step.action}).expectStatus(http.StatusOK)
+		if got := resp.number(step.field); got != step.want {
+			t.Errorf("第%d步 %s = %d, 期望 %d", i+1, step.field, got, step.want)
+		}
+	}
+	reader.sendJSON(http.MethodPost, likePath, map[string]string{"action": "bogus"}).expectStatus(http.StatusBadRequest)
+	reader.sendJSON(http.MethodPost, "/api/posts/9999/like", like).expectStatus(http.StatusNotFound)
+
+	var post models.Post
+	h.reload(&post, h.fx.post.ID)
+	if post.Likes != 1 || post.Favorites != 1 {
+		t.Errorf("点赞/收藏数 = %d/%d, 期望 1/1", post.Likes, post.Favorites)
+	}
+	reader.get("/posts?tab=favorites").expectStatus(http.StatusOK).expectContains(h.fx.post.Title)
+
+	reader.sendJSON(http.MethodPost, favoritePath, map[string]string{"action": "unfavorite"}).expectStatus(http.StatusOK)
+	h.reload(&post, h.fx.post.ID)
+	if post.Favorites != 0 {
+		t.Errorf("取消收藏后收藏数 = %d, 期望 0", post.Favorites)
+	}
+
+	// 评论点赞：不能给自己点赞，重复点赞不计数
+	commentLike := fmt.Sprintf("/api/comments/%d/like", h.fx.comment.ID)
+	reader.sendJSON(http.MethodPost, commentLike, like).expectStatus(http.StatusBadRequest)
+	author := h.login(h.fx.author)
+	for i := 0; i < 2; i++ {
+		resp := author.sendJSON(http.MethodPost, commentLike, like).expectStatus(http.StatusOK)
+		if got := int(resp.json()["data"].(map[string]interface{})["like_count"].(float64)); got != 1 {
+			t.Errorf("第%d次评论点赞后点赞数 = %d, 期望 1", i+1, got)
+		}
+	}
+}
+
+func TestConcurrentReactions(t *testing.T) {
+	h := newHarness(t)
+	users := []models.User{h.fx.author, h.fx.reader, h.fx.unverified, h.fx.admin}
+
+	// 每个用户并发重复点赞、收藏，每人只能计一次
+	var wg sync.WaitGroup
+	errs := make(chan error, len(users)*10)
+	for i := range users {
+		for n := 0; n < 5; n++ {
+			wg.Add(2)
+			go func(user *models.User) {
+				defer wg.Done()
+				_, err := h.svc.Posts.SetLike(user, h.fx.post.ID, true)
+				errs <- err
+			}(&users[i])
+			go func(user *models.User) {
+				defer wg.Done()
+				_, err := h.svc.Posts.SetFavorite(user, h.fx.post.ID, true)
+				errs <- err
+			}(&users[i])
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("并发点赞失败: %v", err)
+		}
+	}
+
+	var post models.Post
+	h.reload(&post, h.fx.post.ID)
+	var likes, favorites int64
+	h.db.Model(&models.PostLike{}).Where("post_id = ?", h.fx.post.ID).Count(&likes)
+	h.db.Model(&models.PostFavorite{}).Where("post_id = ?", h.fx.post.ID).Count(&favorites)
+	if post.Likes != len(users) || likes != int64(len(users)) {
+		t.Errorf("点赞数/记录数 = %d/%d, 期望 %d", post.Likes, likes, len(users))
+	}
+	if post.Favorites != len(users) || favorites != int64(len(users)) {
+		t.Errorf("收藏数/记录数 = %d/%d, 期望 %d", post.Favorites, favorites, len(users))
+	}
+}
+
+func TestCounterReconciliation(t *testing.T) {
+	h := newHarness(t)
+	if _, err := h.svc.Posts.SetLike(&h.fx.reader, h.fx.post.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 直接改库造成计数偏差
+	h.db.Model(&models.Post{}).Where("id = ?", h.fx.post.ID).
+		UpdateColumns(map[string]interface{}{"likes": 7, "favorites": 3, "replies": 0})
+	h.db.Model(&models.Comment{}).Where("id = ?", h.fx.comment.ID).UpdateColumn("reply_count", 5)
+
+	report, err := h.svc.Counters.Reconcile()
+	if err != nil {
+		t.Fatalf("校准失败: %v", err)
+	}
+	if report.Posts != 3 || report.Comments != 1 {
+		t.Errorf("校准结果 = %+v, 期望修正文章计数3个、评论1条", report)
+	}
+
+	var post models.Post
+	h.reload(&post, h.fx.post.ID)
+	if post.Likes != 1 || post.Favorites != 0 || post.Replies != 2 {
+		t.Errorf("校准后点赞/收藏/回复 = %d/%d/%d, 期望 1/0/2", post.Likes, post.Favorites, post.Replies)
+	}
+	var comment models.Comment
+	h.reload(&comment, h.fx.comment.ID)
+	if comment.ReplyCount != 1 {
+		t.Errorf("校准后评论回复数 = %d, 期望 1", comment.ReplyCount)
+	}
+
+	// 再次校准没有需要修正的
+	if report, err := h.svc.Counters.Reconcile(); err != nil || report != (services.CounterReport{}) {
+		t.Errorf("重复校准结果 = %+v, %v, 期望没有修正", report, err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	h := newHarness(t)
+	visitor := h.anonymous()
+
+	// 不存在的邮箱同样返回成功，避免被用来探测注册邮箱
+	visitor.sendJSON(http.MethodPost, "/api/auth/forgot-password", map[string]string{"email": "nobody@example.com"}).
+		expectStatus(http.StatusOK)
+	visitor.sendJSON(http.MethodPost, "/api/auth/forgot-password", map[string]string{"email": h.fx.reader.Email}).
+		expectStatus(http.StatusOK)
+
+	var reset models.PasswordReset
+	if err := h.db.Where("email = ?", h.fx.reader.Email).First(&reset).Error; err != nil {
+		t.Fatalf("没有生成重置记录: %v", err)
+	}
+
+	visitor.get("/reset-password?token=bogus").expectStatus(http.StatusBadRequest)
+	visitor.get("/reset-password?token=" + url.QueryEscape(reset.Token)).expectStatus(http.StatusOK)
+
+	submit := map[string]string{"token": reset.Token, "password": "newpass456"}
+	visitor.sendJSON(http.MethodPost, "/api/auth/reset-password", map[string]string{"token": reset.Token, "password": "123"}).
+		expectStatus(http.StatusBadRequest)
+	visitor.sendJSON(http.MethodPost, "/api/auth/reset-password", submit).expectStatus(http.StatusOK)
+	// 令牌只能使用一次
+	visitor.sendJSON(http.MethodPost, "/api/auth/reset-password", submit).expectStatus(http.StatusBadRequest)
+	if err := repositories.NewPasswordResetRepository(h.db).MarkUsed(&reset); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("重复标记重置令牌 = %v, 期望 ErrNotFound", err)
+	}
+
+	h.anonymous().postForm("/login", url.Values{"email": {h.fx.reader.Email}, "password": {fixturePassword}}).
+		expectStatus(http.StatusUnauthorized)
+	h.anonymous().postForm("/login", url.Values{"email": {h.fx.reader.Email}, "password": {"newpass456"}}).
+		expectStatus(http.StatusOK)
+}
+
+func TestUserAdminRoutes(t *testing.T) {
+	h := newHarness(t)
+	input := map[string]interface{}{"name": "carol", "email": "carol@example.com", "password": "secret123", "role": models.RoleAdmin, "email_status": models.EmailStatusVerified}
+
+	// 创建、修改用户只允许管理员
+	h.anonymous().sendJSON(http.MethodPost, "/api/users/", input).expectStatus(http.StatusUnauthorized)
+	reader := h.login(h.fx.reader)
+	reader.sendJSON(http.MethodPost, "/api/users/", input).expectStatus(http.StatusForbidden)
+	reader.sendJSON(http.MethodPut, fmt.Sprintf("/api/users/%d", h.fx.author.ID), map[string]string{"password": "hijacked"}).
+		expectStatus(http.StatusForbidden)
+
+	admin := h.login(h.fx.admin)
+	admin.sendJSON(http.MethodPost, "/api/users/", map[string]string{"name": "carol", "email": "carol@example.com", "password": "123"}).
+		expectStatus(http.StatusBadRequest)
+	admin.sendJSON(http.MethodPost, "/api/users/", input).expectStatus(http.StatusCreated)
+
+	// 请求中的角色和邮箱状态被忽略，密码加密保存
+	var carol models.User
+	if err := h.db.Where("email = ?", "carol@example.com").First(&carol).Error; err != nil {
+		t.Fatalf("用户未创建: %v", err)
+	}
+	if carol.IsAdmin() || carol.IsEmailVerified() {
+		t.Errorf("新用户 role = %d, email_status = %d, 期望普通用户且邮箱待验证", carol.Role, carol.EmailStatus)
+	}
+	if !utils.CheckPassword("secret123", carol.Password) {
+		t.Errorf("新用户密码没有加密保存: %q", carol.Password)
+	}
+
+	admin.sendJSON(http.MethodPut, fmt.Sprintf("/api/users/%d", carol.ID), map[string]interface{}{"name": "carol2", "password": "changed456", "role": models.RoleAdmin}).
+		expectStatus(http.StatusOK)
+	h.reload(&carol, carol.ID)
+	if carol.Name != "carol2" || carol.IsAdmin() || !utils.CheckPassword("changed456", carol.Password) {
+		t.Errorf("修改后 name = %q, role = %d, 密码加密 = %v", carol.Name, carol.Role, utils.CheckPassword("changed456", carol.Password))
+	}
+
+	// 令牌：写权限可以修改自己的资料，管理操作需要 admin 权限
+	writer := h.withToken(h.fx.reader, models.ScopeWritePosts)
+	writer.sendJSON(http.MethodPut, "/api/users/profile", map[string]string{"motto": "令牌修改"}).expectStatus(http.StatusOK)
+	writer.get("/api/users/").expectStatus(http.StatusForbidden)
+	h.withToken(h.fx.reader, models.ScopeRead).sendJSON(http.MethodPut, "/api/users/profile", map[string]string{"motto": "只读"}).
+		expectStatus(http.StatusForbidden)
+	h.withToken(h.fx.admin, models.ScopeWriteComments).sendJSON(http.MethodPost, "/api/users/", input).
+		expectStatus(http.StatusForbidden)
+}
+
+func TestListFilters(t *testing.T) {
+	h := newHarness(t)
+	reader := h.login(h.fx.reader)
+
+	// 逗号分隔的标签保存为JSON数组，按标签过滤只匹配完整的标签
+	reader.sendJSON(http.MethodPost, "/api/posts/", map[string]interface{}{"title": "MongoDB", "category_id": h.fx.category.ID, "content": "<p>mongo</p>", "tags": "mongo, golang"}).
+		expectStatus(http.StatusCreated)
+	var created models.Post
+	if err := h.db.Where("title = ?", "MongoDB").First(&created).Error; err != nil {
+		t.Fatalf("文章未创建: %v", err)
+	}
+	if created.Tags != `["mongo","golang"]` {
+		t.Errorf("保存的标签 = %s, 期望 JSON 数组", created.Tags)
+	}
+
+	titles := func(c *client, path string) []string {
+		t.Helper()
+		var titles []string
+		for _, item := range c.get(path).expectStatus(http.StatusOK).json()["data"].([]interface{}) {
+			entry := item.(map[string]interface{})
+			if title, ok := entry["title"]; ok {
+				titles = append(titles, title.(string))
+			} else {
+				titles = append(titles, entry["name"].(string))
+			}
+		}
+		sort.Strings(titles)
+		return titles
+	}
+	for tag, want := range map[string][]string{"go": {h.fx.post.Title}, "GO": {h.fx.post.Title}, "golang": {"MongoDB"}, "o": nil} {
+		if got := titles(reader, "/api/posts/?tag="+tag); !reflect.DeepEqual(got, want) {
+			t.Errorf("tag=%s 的文章 = %v, 期望 %v", tag, got, want)
+		}
+	}
+
+	// 按角色过滤用户只对管理员生效，其他人的角色参数被忽略
+	if got := titles(h.login(h.fx.admin), fmt.Sprintf("/api/users/?role=%d", models.RoleAdmin)); !reflect.DeepEqual(got, []string{"admin"}) {
+		t.Errorf("管理员按角色过滤 = %v, 期望 [admin]", got)
+	}
+	if got := titles(h.anonymous(), fmt.Sprintf("/api/users/?role=%d", models.RoleAdmin)); len(got) != 4 {
+		t.Errorf("未登录按角色过滤 = %v, 期望忽略角色参数", got)
+	}
+}
+
+func TestGraphQLLoaders(t *testing.T) {
+	h := newHarness(t)
+	query := map[string]string{"query": fmt.Sprintf(`{ post(id: "%d") { title author { name email } category { name }
+		comments { content author { name } replies { content author { name } } } } }`, h.fx.post.ID)}
+
+	body := h.anonymous().sendJSON(http.MethodPost, "/graphql", query).expectStatus(http.StatusOK).
+		expectContains(`"title":"Gin 入门"`, `"category":{"name":"Go语言"}`, `"content":"\u003cp\u003e写得好\u003c/p\u003e"`,
+			`"author":{"name":"reader"}`, `"replies":[{"author":{"name":"author"}`).Body
+	if strings.Contains(body, "author@example.com") || strings.Contains(body, `"errors"`) {
+		t.Errorf("未登录时的响应 = %s, 期望不含邮箱和错误", body)
+	}
+	h.login(h.fx.author).sendJSON(http.MethodPost, "/graphql", query).expectStatus(http.StatusOK).
+		expectContains(`"author":{"email":"author@example.com","name":"author"}`)
+
+	// 错误码与 /api/v1 一致
+	h.anonymous().sendJSON(http.MethodPost, "/graphql", map[string]string{"query": `mutation { likePost(id: "1", like: true) { likes } }`}).
+		expectStatus(http.StatusOK).expectContains(`"code":"unauthorized"`)
+}
+
+// oauthProvider 模拟第三方登录服务：令牌接口接受任意授权码，用户信息接口返回固定用户
+func oauthProvider(t *testing.T, profile interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good-code" {
+			http.Error(w, `{"error":"bad_verification_code"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"mock-token","token_type":"bearer"}`)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mock-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profile)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// useOAuthProvider 把GitHub和Google登录都指向模拟服务，测试结束后恢复
+func useOAuthProvider(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	endpoint := oauth2.Endpoint{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token", AuthStyle: oauth2.AuthStyleInParams}
+	saved := []interface{}{handlers.GitHubEndpoint, handlers.GitHubUserInfoURL, handlers.GoogleEndpoint, handlers.GoogleUserInfoURL}
+	handlers.GitHubEndpoint, handlers.GitHubUserInfoURL = endpoint, server.URL+"/user"
+	handlers.GoogleEndpoint, handlers.GoogleUserInfoURL = endpoint, server.URL+"/user"
+	t.Cleanup(func() {
+		handlers.GitHubEndpoint = saved[0].(oauth2.Endpoint)
+		handlers.GitHubUserInfoURL = saved[1].(string)
+		handlers.GoogleEndpoint = saved[2].(oauth2.Endpoint)
+		handlers.GoogleUserInfoURL = saved[3].(string)
+	})
+}
+
+// authorize 打开授权入口，返回跳转到服务商时携带的state
+func authorize(t *testing.T, c *client, path string) string {
+	t.Helper()
+	resp := c.get(path).expectStatus(http.StatusTemporaryRedirect)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("授权跳转地址无效: %v", err)
+	}
+	state := location.Query().Get("state")
+	if state == "" {
+		t.Fatalf("授权跳转地址中没有state: %s", location)
+	}
+	return state
+}
+
+func TestOAuthCallbacks(t *testing.T) {
+	h := newHarness(t)
+
+	// 未配置时不可用
+	h.anonymous().get("/auth/github").expectStatus(http.StatusNotFound)
+
+	cfg := *config.Current()
+	cfg.OAuth.GitHub = config.OAuthProvider{ClientID: "github-id", ClientSecret: "github-secret", RedirectURL: testBaseURL + "/auth/github/callback"}
+	cfg.OAuth.Google = config.OAuthProvider{ClientID: "google-id", ClientSecret: "google-secret", RedirectURL: testBaseURL + "/auth/google/callback"}
+	config.Set(&cfg)
+
+	t.Run("GitHub新用户", func(t *testing.T) {
+		server := oauthProvider(t, handlers.GitHubUser{ID: 42, Login: "octocat", Email: "octocat@example.com", Name: "Octo Cat"})
+		useOAuthProvider(t, server)
+
+		visitor := h.anonymous()
+		state := authorize(t, visitor, "/auth/github")
+
+		visitor.get("/auth/github/callback?state=wrong&code=good-code").expectStatus(http.StatusBadRequest)
+		visitor.get("/auth/github/callback?state=" + url.QueryEscape(state) + "&code=bad-code").
+			expectStatus(http.StatusInternalServerError)
+		visitor.get("/auth/github/callback?state=" + url.QueryEscape(state) + "&code=good-code").expectRedirect("/")
+
+		var user models.User
+		if err := h.db.Where("email = ?", "octocat@example.com").First(&user).Error; err != nil {
+			t.Fatalf("没有创建GitHub用户: %v", err)
+		}
+		if user.Name != "Octo Cat" {
+			t.Errorf("用户名 = %q, 期望 Octo Cat", user.Name)
+		}
+		visitor.get("/profile").expectStatus(http.StatusOK).expectContains("Octo Cat")
+
+		// 第三方登录的账户没有可用的密码，刚登录过可以直接申请修改邮箱和注销
+		visitor.sendJSON(http.MethodPut, "/api/users/email", map[string]string{"new_email": "octo@example.org", "password": "guess"}).
+			expectStatus(http.StatusForbidden)
+		visitor.sendJSON(http.MethodPut, "/api/users/email", map[string]string{"new_email": "octo@example.org"}).expectStatus(http.StatusOK)
+		var changes int64
+		h.db.Model(&models.EmailVerification{}).Where("user_id = ? AND purpose = ? AND email = ?", user.ID, models.EmailVerifyPurposeChange, "octo@example.org").Count(&changes)
+		if changes != 1 {
+			t.Errorf("修改邮箱验证记录数 = %d, 期望 1", changes)
+		}
+
+		visitor.sendJSON(http.MethodPost, "/api/account/delete", map[string]string{"password": "guess"}).expectStatus(http.StatusForbidden)
+		visitor.sendJSON(http.MethodPost, "/api/account/delete", map[string]string{}).expectStatus(http.StatusOK)
+		var deletions int64
+		h.db.Model(&models.AccountDeletion{}).Where("user_id = ? AND status = ?", user.ID, models.DeletionStatusPending).Count(&deletions)
+		if deletions != 1 {
+			t.Errorf("注销申请数 = %d, 期望 1", deletions)
+		}
+	})
+
+	t.Run("Google已有用户", func(t *testing.T) {
+		server := oauthProvider(t, handlers.GoogleUser{ID: "g-1", Email: h.fx.reader.Email, VerifiedEmail: true, Name: "Reader G"})
+		useOAuthProvider(t, server)
+
+		var before int64
+		h.db.Model(&models.User{}).Count(&before)
+
+		visitor := h.anonymous()
+		state := authorize(t, visitor, "/auth/google")
+		visitor.get("/auth/google/callback?state=" + url.QueryEscape(state) + "&code=good-code").expectRedirect("/")
+
+		var after int64
+		h.db.Model(&models.User{}).Count(&after)
+		if after != before {
+			t.Errorf("邮箱已注册时不应创建新用户，用户数 %d -> %d", before, after)
+		}
+		visitor.get("/profile").expectStatus(http.StatusOK).expectContains(h.fx.reader.Name)
+	})
+}
+
+func TestMetricsAndRequestID(t *testing.T) {
+	h := newHarness(t)
+	caches.RegisterMetrics()
+	workers.RegisterQueueMetrics(onlineStatusChan, viewEventChan, dataExportChan)
+	visitor := h.anonymous()
+
+	// 沿用合法的 X-Request-ID，不合法时重新生成
+	req, _ := http.NewRequest(http.MethodGet, testBaseURL+"/", nil)
+	req.Header.Set("X-Request-ID", "trace-123")
+	if id := visitor.do(req).expectStatus(http.StatusOK).Header.Get("X-Request-ID"); id != "trace-123" {
+		t.Errorf("X-Request-ID = %q, 期望沿用 trace-123", id)
+	}
+	req, _ = http.NewRequest(http.MethodGet, testBaseURL+"/", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	if id := visitor.do(req).Header.Get("X-Request-ID"); id == "" || id == "bad id\n" {
+		t.Errorf("不合法的请求ID应重新生成，实际为 %q", id)
+	}
+
+	visitor.get(fmt.Sprintf("/post-%d-1", h.fx.post.ID)).expectStatus(http.StatusOK)
+	visitor.get("/no-such-page").expectStatus(http.StatusNotFound)
+
+	visitor.get("/metrics").expectStatus(http.StatusOK).expectContains(
+		`http_request_duration_seconds_count{method="GET",route="/",status="200"}`,
+		`http_request_duration_seconds_bucket{method="GET",route="/post-:id-1",status="200",le="+Inf"}`,
+		`route="unmatched",status="404"`,
+		`db_query_duration_seconds_count{operation="query",table="posts"}`,
+		`cache_requests_total{namespace="posts",result="miss"}`,
+		`worker_queue_capacity{queue="view_events"} 1000`,
+	)
+
+	// 配置了令牌时 /metrics 需要认证
+	cfg := *config.Current()
+	cfg.Metrics.Token = "scrape-token"
+	router := newRouter(&cfg, h.db, h.svc, h.hooks, func() bool { return true })
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("未带令牌访问 /metrics status = %d, 期望 401", rec.Code)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	h := newHarness(t)
+	visitor := h.anonymous()
+
+	visitor.get("/healthz").expectStatus(http.StatusOK).expectContains(`"status":"ok"`)
+	// 就绪检查使用注入的数据库连接，不依赖全局变量
+	visitor.get("/readyz").expectStatus(http.StatusOK).expectContains(`"status":"ok"`)
+
+	// 启动或关闭过程中返回503
+	router := newRouter(config.Current(), h.db, h.svc, h.hooks, func() bool { return false })
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("未就绪时 /readyz status = %d, 期望 503", rec.Code)
+	}
+}
+
+func TestAdminExportMirror(t *testing.T) {
+	h := newHarness(t)
+	// 别名不是单个路径段的分类没有线上页面，也不能在导出包中生成跳出目录的文件
+	evil := models.Category{Name: "逃逸", Alias: "../../evil", StatusCode: 1}
+	h.create(&evil)
+	private := models.Post{Title: "仅登录可见", UserId: int(h.fx.author.ID), Author: h.fx.author.Name, CategoryId: int(h.fx.category.ID),
+		Content: "<p>内部</p>", Tags: `[]`, ReadLimit: 2}
+	h.create(&private)
+
+	h.login(h.fx.reader).get("/api/admin/export?format=html").expectStatus(http.StatusForbidden)
+	resp := h.login(h.fx.admin).get("/api/admin/export?format=html").expectStatus(http.StatusOK)
+
+	archive, err := zip.NewReader(strings.NewReader(resp.Body), int64(len(resp.Body)))
+	if err != nil {
+		t.Fatalf("导出包不是zip: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, file := range archive.File {
+		names[file.Name] = true
+		if strings.HasPrefix(file.Name, "/") || strings.Contains(file.Name, "..") || strings.Contains(file.Name, "evil") {
+			t.Errorf("导出包中有不安全的路径 %q", file.Name)
+		}
+	}
+	postPage := fmt.Sprintf("post-%d-1/index.html", h.fx.post.ID)
+	for _, want := range []string{"index.html", "categories/go/index.html", postPage, "404.html", "static/css/app.css"} {
+		if !names[want] {
+			t.Errorf("导出包中没有 %s", want)
+		}
+	}
+	// 静态镜像只包含公开文章
+	if names[fmt.Sprintf("post-%d-1/index.html", private.ID)] {
+		t.Error("导出包中包含了非公开文章")
+	}
+}
+
+// useSpanRecorder 把全局TracerProvider替换为记录到内存的实现，测试结束后恢复
+func useSpanRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+	return exporter
+}
+
+func TestTracing(t *testing.T) {
+	h := newHarness(t)
+	exporter := useSpanRecorder(t)
+
+	// 上游传入的 traceparent 被沿用
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/post-%d-1", testBaseURL, h.fx.post.ID), nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0") // 爬虫的浏览不发送事件
+	h.anonymous().do(req).expectStatus(http.StatusOK)
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	server, ok := byName["GET /post-:id-1"]
+	if !ok {
+		t.Fatalf("没有请求span，实际记录了 %d 个span", len(spans))
+	}
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("请求span未沿用上游链路: trace=%s parent=%s", server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	for _, name := range []string{"render detail.tmpl", "db.query posts", "db.query comments"} {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("缺少span %q", name)
+			continue
+		}
+		if span.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("span %q 的父span不是请求span", name)
+		}
+	}
+
+	// 浏览事件携带请求的链路，批量写入的span通过link关联回请求
+	exporter.Reset()
+	var event workers.ViewEvent
+	select {
+	case event = <-viewEventChan:
+	default:
+		t.Fatal("详情页没有发送浏览事件")
+	}
+	if event.Trace.TraceID().String() != traceID {
+		t.Errorf("浏览事件的链路 = %s, 期望 %s", event.Trace.TraceID(), traceID)
+	}
+	viewEventChan <- event
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // 已取消时处理完通道中的事件后立即返回
+	workers.HandleViewNumUpdates(ctx, h.db, viewEventChan)
+
+	var flush *tracetest.SpanStub
+	writes := 0
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "view-count.flush" {
+			flush = &span
+		} else if strings.HasPrefix(span.Name, "db.") {
+			writes++
+		}
+	}
+	if flush == nil {
+		t.Fatal("没有批量写入浏览数的span")
+	}
+	if len(flush.Links) != 1 || flush.Links[0].SpanContext.TraceID().String() != traceID {
+		t.Errorf("批量写入span的links = %+v, 期望关联请求链路", flush.Links)
+	}
+	if writes == 0 {
+		t.Error("批量写入的SQL没有记录为子span")
+	}
+}
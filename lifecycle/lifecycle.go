@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager 管理后台worker的启动和退出，并记录服务是否可以接收流量
+// worker 收到 ctx.Done() 后应处理完通道中剩余的数据再返回
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	ready  atomic.Bool
+
+	mu      sync.Mutex
+	running map[string]int // 仍在运行的worker，按名称计数
+	wg      sync.WaitGroup
+}
+
+// New 创建生命周期管理器
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel, running: make(map[string]int)}
+}
+
+// Go 启动一个worker，Stop 时取消 ctx 并等待其返回
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+		}()
+		defer func() {
+			// 单个worker崩溃不影响其他worker退出
+			if r := recover(); r != nil {
+				slog.Error("worker异常退出", "worker", name, "panic", r)
+			}
+		}()
+		worker(m.ctx)
+	}()
+}
+
+// SetReady 设置是否可以接收流量（就绪检查）
+func (m *Manager) SetReady(ready bool) {
+	m.ready.Store(ready)
+}
+
+// Ready 服务已启动且没有在关闭中
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Stop 通知所有worker退出并等待，超时后返回仍未退出的worker
+func (m *Manager) Stop(timeout time.Duration) error {
+	m.SetReady(false)
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		m.mu.Lock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		m.mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("等待 %v 后仍有worker未退出: %s", timeout, strings.Join(names, ", "))
+	}
+}
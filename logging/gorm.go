@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold 超过该耗时的SQL记为慢查询
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger 把GORM的日志写入 slog：SQL出错记为error，慢查询记为warn，其余SQL只在debug级别输出
+// 找不到记录是正常的业务分支，不记为错误
+type GormLogger struct{}
+
+// LogMode 日志级别统一由 slog 控制
+func (l GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+}
+
+func (GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+}
+
+func (GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+}
+
+// Trace 每条SQL执行后调用
+func (GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	logger := FromContext(ctx)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.Error("SQL执行失败", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds(), "error", err)
+	case elapsed > SlowQueryThreshold:
+		sql, rows := fc()
+		logger.Warn("慢查询", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds())
+	case logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.Debug("SQL", "sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds())
+	}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDKey 请求ID在 gin.Context 中的键名，也是日志中的字段名
+const RequestIDKey = "request_id"
+
+// level 全局日志级别，SIGHUP重新加载配置时可以修改
+var level = new(slog.LevelVar)
+
+// ParseLevel 解析日志级别：debug / info / warn / error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("未知的日志级别: %s", s)
+}
+
+// Setup 按级别和格式（json / text）创建日志并设为 slog 的默认日志
+func Setup(w io.Writer, levelName, format string) error {
+	l, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel 修改全局日志级别，格式需要重启才能修改
+func SetLevel(levelName string) error {
+	l, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+type ctxKey struct{}
+
+// WithRequestID 把请求ID放入 context，供不持有 gin.Context 的下层代码记录日志
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, requestID)
+}
+
+// RequestID 返回 ctx 中的请求ID，支持 *gin.Context 和由 WithRequestID 生成的 context
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.GetString(RequestIDKey)
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// FromContext 返回带请求ID和链路ID（trace_id、span_id）字段的日志，都没有时返回默认日志
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx == nil {
+		return slog.Default()
+	}
+	var args []interface{}
+	if id := RequestID(ctx); id != "" {
+		args = append(args, RequestIDKey, id)
+	}
+	spanCtx := ctx
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		spanCtx = c.Request.Context()
+	}
+	if sc := trace.SpanContextFromContext(spanCtx); sc.IsValid() {
+		args = append(args, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	if len(args) == 0 {
+		return slog.Default()
+	}
+	return slog.Default().With(args...)
+}
@@ -1,34 +1,57 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"html/template"
+	"log/slog"
+	"net"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
-	"encoding/xml"
-    "gin-doniai/middlewares"
+
+	"gin-doniai/apiv1"
 	"gin-doniai/caches"
+	"gin-doniai/config"
 	"gin-doniai/database"
+	"gin-doniai/exporter"
+	"gin-doniai/gql"
 	"gin-doniai/handlers"
+	"gin-doniai/importer"
+	"gin-doniai/lifecycle"
+	"gin-doniai/logging"
+	"gin-doniai/metrics"
+	"gin-doniai/middlewares"
+	"gin-doniai/migrate"
 	"gin-doniai/models"
+	"gin-doniai/ranking"
+	"gin-doniai/repositories"
+	"gin-doniai/services"
+	"gin-doniai/stats"
+	"gin-doniai/tracing"
 	"gin-doniai/utils"
+	"gin-doniai/webhooks"
 	"gin-doniai/workers"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 // 在 main.go 顶部添加全局变量
 var (
-	onlineStatusChan      chan workers.OnlineStatusUpdate
-    viewEventChan chan workers.ViewEvent
-	globalConfig          GlobalConfig
-	recommendedCategories []models.Category
+	onlineStatusChan chan workers.OnlineStatusUpdate
+	viewEventChan    chan workers.ViewEvent
+	dataExportChan   chan uint
 )
 
+// 程序版本号
+const appVersion = "1.0.0"
+
 type GlobalConfig struct {
 	SiteName   string
 	Theme      string
@@ -36,44 +59,9 @@ type GlobalConfig struct {
 	Categories []models.Category
 }
 
-type OnlineStatusUpdate struct {
-	UserID    uint
-	IP        string
-	UserAgent string
-}
-
-func main() {
-	database.InitDB()
-
-	// 初始化全局配置
-	globalConfig = GlobalConfig{
-		SiteName: "Doniai",
-		Theme:    "light",
-		Version:  "1.0.0",
-	}
-
-	// 获取推荐分类并注入到全局配置
-	if categories, err := caches.CachedRecommendedCategories(); err == nil {
-		recommendedCategories = categories
-		globalConfig.Categories = categories
-	} else {
-		fmt.Printf("获取推荐分类失败: %v\n", err)
-	}
-
-	gin.SetMode(gin.DebugMode)
-    // gin.SetMode(gin.ReleaseMode)
-	// 初始化在线状态更新通道
-	onlineStatusChan = make(chan workers.OnlineStatusUpdate, 1000) // 缓冲1000个消息
-	// 启动在线状态更新处理器
-    go workers.HandleOnlineStatusUpdates(onlineStatusChan)
-
-    viewEventChan = make(chan workers.ViewEvent, 1000)  // 缓冲1000个消息
-
-    // 启动浏览事件处理器
-    go workers.HandleViewNumUpdates(viewEventChan)
-
-	router := gin.Default()
-	router.SetFuncMap(template.FuncMap{
+// templateFuncs 页面模板函数，路由和全站静态导出共用
+func templateFuncs(categories *services.CategoryService) template.FuncMap {
+	return template.FuncMap{
 		"add": func(a, b int) int {
 			return a + b
 		},
@@ -87,1026 +75,509 @@ func main() {
 			}
 			return result
 		},
-        "currentYear": func() int {
-            return time.Now().Year()
-        },
-        "timeAgo": func(t time.Time) string {
-            return utils.GetTimeAgo(t)
-        },
-		"global": func() GlobalConfig {
-			return globalConfig
+		"currentYear": func() int {
+			return time.Now().Year()
 		},
-	})
-	// 设置session存储
-	store := cookie.NewStore([]byte("secret"))
-	router.Use(sessions.Sessions("mysession", store))
-	// 在路由定义之前应用用户中间件
-	router.Use(middlewares.UserAndOnlineStatusMiddleware(onlineStatusChan))
-
-	// 加载模板文件
-	router.LoadHTMLGlob("templates/**/*")
-
-	// 静态文件服务
-	router.Static("/static", "./static")
-
-	// 路由定义
-	router.GET("/", homeHandler)
-	router.GET("/categories/:type", homeHandler)
-	router.GET("/about", aboutHandler)
-	router.GET("/post-:id-1", detailHandler)
-	router.GET("/register", registerHandler)
-	router.POST("/register", registerSubmit)
-	router.GET("/login", loginHandler)
-	router.POST("/login", loginSubmit)
-	router.GET("/logout", logoutHandler)
-	router.GET("/profile", profileHandler)
-	router.GET("/posts", articleHandler)
-	router.GET("/publish", publishHandler)
-	router.GET("/settings", settingsHandler)
-	router.GET("/rss", rssHandler)
-	// 添加搜索路由
-	router.GET("/search", searchPostsHandler)
-	router.GET("/member", searchUsersHandler)
-
-	// 在 main.go 的路由定义部分添加
-    router.GET("/auth/github", handlers.GitHubLogin)
-    router.GET("/auth/github/callback", handlers.GitHubCallback)
-    router.GET("/auth/google", handlers.GoogleLogin)
-    router.GET("/auth/google/callback", handlers.GoogleCallback)
-
-
-	// 在 main.go 的路由部分添加
-	router.GET("/api/online/count", handlers.GetOnlineUserCount)
-	// 在路由定义部分添加
-    router.POST("/api/auth/forgot-password", handlers.ForgotPassword)
-    router.GET("/reset-password", handlers.ResetPassword)
-    router.POST("/api/auth/reset-password", handlers.ProcessResetPassword)
-
-
-	// 在 main.go 的路由定义部分添加评论路由
-	commentRoutes := router.Group("/api/comments")
-	{
-		commentRoutes.POST("/", handlers.CreateComment)
-		commentRoutes.GET("/", handlers.GetComments)
-		commentRoutes.GET("/:id", handlers.GetComment)
-		commentRoutes.PUT("/:id", handlers.UpdateComment)
-		commentRoutes.DELETE("/:id", handlers.DeleteComment)
-		commentRoutes.POST("/:id/like", handlers.LikeComment)
-	}
-
-	userRoutes := router.Group("/api/users")
-	{
-		userRoutes.POST("/", handlers.CreateUser)                 // 创建用户
-		userRoutes.GET("/", handlers.GetUsers)                    // 获取所有用户
-		userRoutes.GET("/:id", handlers.GetUser)                  // 获取单个用户
-		userRoutes.PUT("/:id", handlers.UpdateUser)               // 更新用户
-		userRoutes.DELETE("/:id", handlers.DeleteUser)            // 删除用户（软删除）
-		userRoutes.DELETE("/:id/force", handlers.ForceDeleteUser) // 强制删除
-		userRoutes.PUT("/profile", handlers.UpdateUserProfile)    // 更新用户资料
-        userRoutes.PUT("/password", handlers.UpdateUserPassword) // 修改用户密码
-	}
-
-	postRoutes := router.Group("/api/posts")
-	{
-		postRoutes.POST("/", handlers.CreatePost)                 // 创建文章
-		postRoutes.GET("/", handlers.GetPosts)                    // 获取所有文章
-		postRoutes.GET("/:id", handlers.GetPost)                  // 获取单个文章
-		postRoutes.PUT("/:id", handlers.UpdatePost)               // 更新文章
-		postRoutes.DELETE("/:id", handlers.DeletePost)            // 删除文章（软删除）
-		postRoutes.POST("/:id/like", handlers.LikePost)           // 文章点赞
-		postRoutes.DELETE("/:id/force", handlers.ForceDeletePost) // 强制删除
-		postRoutes.POST("/:id/favorite", handlers.FavoritePost)   // 文章收藏
-	}
-
-    router.NoRoute(func(c *gin.Context) {
-        c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
-            "Message": "页面未找到",
-        })
-    })
-
-	// 启动定时清理任务
-	go func() {
-		ticker := time.NewTicker(10 * time.Minute) // 每10分钟清理一次
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				handlers.CleanupExpiredOnlineStatus()
+		"timeAgo": func(t time.Time) string {
+			return utils.GetTimeAgo(t)
+		},
+		"global": func() GlobalConfig {
+			// 站点信息和推荐分类每次读取，修改配置（SIGHUP）或分类后无需重启即可生效
+			site := config.Current().Site
+			global := GlobalConfig{SiteName: site.Name, Theme: site.Theme, Version: appVersion}
+			if categories, err := categories.CachedRecommended(); err == nil {
+				global.Categories = categories
+			} else {
+				slog.Error("获取推荐分类失败", "error", err)
 			}
-		}
-	}()
-	router.Run(":8080")
-}
-
-
-func homeHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		fmt.Println("未获取到用户信息")
-	}
-	// 获取页码参数，默认为第1页
-	pageStr := c.Query("page")
-	// 获取路由categories/:type中type参数
-	categoryType := c.Param("type")
-	page := 1
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	// 每页显示的帖子数量
-	limit := 10
-	offset := (page - 1) * limit
-
-	// 查询总记录数
-	var total int64
-	dbQuery := database.DB.Model(&models.Post{})
-
-	var categoryId uint
-	if categoryType != "" {
-		var category models.Category
-	    if err := database.DB.Where("alias = ?", categoryType).First(&category).Error; err != nil {
-            // 当找不到分类时，返回404页面而不是继续执行
-            c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
-                "Message": "分类未找到",
-            })
-            return
-        } else {
-            categoryId = category.ID
-            dbQuery = dbQuery.Where("category_id = ?", categoryId)
-        }
-	}
-	dbQuery.Count(&total)
-
-	// 查询当前页的帖子
-	var posts []models.Post
-	postQuery := database.DB.Where("category_id > ?", 0).Order("created_at DESC").Offset(offset).Limit(limit)
-
-	if categoryId > 0 {
-		postQuery = postQuery.Where("category_id = ?", categoryId)
-	}
-	postQuery.Find(&posts)
-
-	// 计算总页数
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-
-	// 创建带有友好时间的帖子结构
-	type PostWithFriendlyTime struct {
-		models.Post
-		TimeAgo string
-	}
-
-	var postsWithTimeAgo []PostWithFriendlyTime
-	for _, post := range posts {
-		timeAgo := utils.GetTimeAgo(post.CreatedAt)
-		postsWithTimeAgo = append(postsWithTimeAgo, PostWithFriendlyTime{
-			Post:    post,
-			TimeAgo: timeAgo,
-		})
-	}
-
-	// 获取在线用户数
-	var onlineCount int64
-	cutoffTime := time.Now().Add(-30 * time.Minute)
-	database.DB.Model(&models.UserOnlineStatus{}).
-		Where("last_active_time > ?", cutoffTime).
-		Count(&onlineCount)
-
-	// 获取站点统计信息
-	var userCount, postCount, commentCount int64
-	database.DB.Model(&models.User{}).Count(&userCount)
-	database.DB.Model(&models.Post{}).Count(&postCount)
-	database.DB.Model(&models.Comment{}).Count(&commentCount)
-
-	// 获取所有分类
-	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
-
-	data := gin.H{
-		"CurrentTime":  time.Now().Format("2006-01-02 15:04:05"),
-		"posts":        postsWithTimeAgo,
-		"currentPage":  page,
-		"totalPages":   totalPages,
-		"hasPrev":      page > 1,
-		"hasNext":      page < totalPages,
-		"prevPage":     page - 1,
-		"nextPage":     page + 1,
-		"user":         user,
-		"userCount":    userCount,
-		"postCount":    postCount,
-		"commentCount": commentCount,
-		"onlineCount":  onlineCount,
-		"categories":   categories,
+			return global
+		},
+		// CSRF令牌：页面<head>中输出meta标签供static/js读取，表单中输出隐藏字段
+		"csrfMeta": func(token string) template.HTML {
+			return template.HTML(`<meta name="csrf-token" content="` + template.HTMLEscapeString(token) + `">`)
+		},
+		"csrfField": func(token string) template.HTML {
+			return template.HTML(`<input type="hidden" name="_csrf" value="` + template.HTMLEscapeString(token) + `">`)
+		},
 	}
-
-	c.HTML(http.StatusOK, "home.tmpl", data)
 }
 
-func aboutHandler(c *gin.Context) {
-	data := gin.H{
-		"TeamMembers": []struct {
-			Name string
-			Role string
-		}{
-			{"张三", "开发工程师"},
-			{"李四", "UI设计师"},
-			{"王五", "产品经理"},
-		},
-	}
-	c.HTML(http.StatusOK, "about.tmpl", data)
+// exportOptions 全站导出使用与线上页面相同的模板函数、热门文章和相关文章
+func exportOptions(svc *services.Services) exporter.Options {
+	return exporter.Options{Funcs: templateFuncs(svc.Categories), HotPosts: svc.Posts.Hot, RelatedPosts: svc.Posts.Related}
 }
 
-func detailHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
+func main() {
+	// 加载配置（默认值 < 配置文件 < 环境变量 < 命令行参数），有误时直接退出
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		slog.Error("加载配置失败", "error", err)
+		os.Exit(1)
 	}
-	// 从路由中获取文章ID
-	idWithSuffix := c.Param("id-1") // 获取 "29-1"
-	// 分割字符串获取真正的ID
-	idParts := strings.Split(idWithSuffix, "-")
-	var id string
-	if len(idParts) > 0 {
-		id = idParts[0] // 获取 "29"
+	config.Set(cfg)
+	// 结构化日志（默认JSON），日志级别可通过SIGHUP热加载
+	if err := logging.Setup(os.Stdout, cfg.Log.Level, cfg.Log.Format); err != nil {
+		slog.Error("初始化日志失败", "error", err)
+		os.Exit(1)
 	}
-
-    UserId := handlers.UserIDFromContext(c)
-    postId, err := strconv.ParseUint(id, 10, 32)
-    if err != nil {
-        postId = 0
-    }
-    // 发送浏览事件
-    viewEvent := workers.ViewEvent{
-        PostID:     uint(postId),
-        UserID:    UserId,
-        IP:        c.ClientIP(),
-        UserAgent: c.Request.UserAgent(),
-        Timestamp: time.Now(),
-    }
-
-    select {
-    case viewEventChan <- viewEvent:
-    default:
-        fmt.Println("浏览事件通道已满")
-    }
-
-	// 查询数据库获取文章详情，并预加载用户信息
-	var post models.Post
-	if err := database.DB.Preload("User").First(&post, id).Error; err != nil {
-		c.HTML(http.StatusNotFound, "404.tmpl", gin.H{
-			"Message": "文章未找到",
-		})
-		return
+	if cfg.Session.SecretGenerated {
+		slog.Warn("未设置 SESSION_SECRET，已生成临时会话密钥，重启后需要重新登录")
 	}
 
-	// 创建带有友好时间和回复评论的评论结构
-	type CommentWithReplies struct {
-		models.Comment
-		TimeAgo string
-		Replies []CommentWithReplies
-		Content template.HTML
+	if err := database.InitDB(cfg.Database); err != nil {
+		slog.Error("初始化数据库失败", "error", err)
+		os.Exit(1)
 	}
+	// 服务、worker和批处理使用的数据库连接统一从这里注入
+	db := database.DB
 
-	// 获取评论页码参数
-	commentPageStr := c.Query("page")
-	commentPage := 1
-	if commentPageStr != "" {
-		if p, err := strconv.Atoi(commentPageStr); err == nil && p > 0 {
-			commentPage = p
-		}
-	}
-
-	// 每页显示的评论数量
-	commentLimit := 4
-	commentOffset := (commentPage - 1) * commentLimit
-
-	// 查询总评论数
-	var totalComments int64
-	database.DB.Model(&models.Comment{}).Where("post_id = ? AND parent_id = 0", id).Count(&totalComments)
-
-	// 计算总页数
-	totalCommentPages := int((totalComments + int64(commentLimit) - 1) / int64(commentLimit))
-
-	// 查询该文章的评论（仅顶级评论），并预加载用户信息
-	var comments []models.Comment
-	database.DB.Where("post_id = ? AND parent_id = 0", id).Preload("User").
-		Offset(commentOffset).Limit(commentLimit).Order("created_at DESC").Find(&comments)
-
-	// 创建带有回复和友好时间的评论列表
-	var commentsWithReplies []CommentWithReplies
-	for _, comment := range comments {
-		// 处理主评论的友好时间
-		timeAgo := utils.GetTimeAgo(comment.CreatedAt)
-
-		// 查询该评论的回复
-		var replies []models.Comment
-		database.DB.Where("parent_id = ?", comment.ID).Preload("User").Find(&replies)
-
-		// 处理回复评论的友好时间
-		var repliesWithTime []CommentWithReplies
-		for _, reply := range replies {
-			replyTimeAgo := utils.GetTimeAgo(reply.CreatedAt)
-			repliesWithTime = append(repliesWithTime, CommentWithReplies{
-				Comment: reply,
-				TimeAgo: replyTimeAgo,
-				Content: template.HTML(reply.Content),
-			})
+	// 子命令：migrate 管理数据库迁移，不检查待执行的迁移
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrate.Command(db, args[1:], os.Stdout); err != nil {
+			slog.Error("migrate 失败", "error", err)
+			os.Exit(1)
 		}
-
-		commentsWithReplies = append(commentsWithReplies, CommentWithReplies{
-			Comment: comment,
-			TimeAgo: timeAgo,
-			Replies: repliesWithTime,
-			Content: template.HTML(comment.Content),
-		})
-	}
-
-	// 将标签字符串分割成数组
-	// 使用工具方法处理标签
-	tags := utils.ParseTags(post.Tags)
-	// 将文章详情数据和用户信息传递给模板
-	var postCount, replyCount, likeCount int64
-	database.DB.Model(&models.Post{}).Where("user_id = ?", post.User.ID).Count(&postCount)
-	database.DB.Model(&models.Post{}).Where("user_id = ?", post.User.ID).Select("SUM(replies)").Row().Scan(&replyCount)
-	database.DB.Model(&models.Post{}).Where("user_id = ?", post.User.ID).Select("SUM(likes)").Row().Scan(&likeCount)
-	// 将评论分页信息添加到模板数据
-    fmt.Printf("当前文章ID: %s, 分类ID: %d\n", id, post.CategoryId)
-	// 搜索3条相关的文章数据
-	var relatedPosts []models.Post
-    database.DB.Where("id != ? AND category_id = ?", id, post.CategoryId).
-        Order("RAND()").
-        Limit(3).
-        Find(&relatedPosts)
-	data := gin.H{
-		"Post":               post,
-		"User":               post.User,
-		"Content":            template.HTML(post.Content),
-		"Tags":               tags,
-		"user":               user,
-		"Comments":           commentsWithReplies,
-		"commentCurrentPage": commentPage,
-		"commentTotalPages":  totalCommentPages,
-		"commentHasPrev":     commentPage > 1,
-		"commentHasNext":     commentPage < totalCommentPages,
-		"commentPrevPage":    commentPage - 1,
-		"commentNextPage":    commentPage + 1,
-		"commentTotalCount":  totalComments,
-		"postCount":          postCount,
-		"replyCount":         replyCount,
-		"likeCount":          likeCount,
-		"RelatedPosts":       relatedPosts,
-	}
-
-	c.HTML(http.StatusOK, "detail.tmpl", data)
-}
-
-func profileHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
 		return
 	}
-
-    var postCount int64
-    database.DB.Model(&models.Post{}).Where("category_id > ?", 0).Where("user_id = ?", user.ID).Count(&postCount)
-
-	data := gin.H{
-		"user":        user,
-		"profileUser": user,
-		"postCount":   postCount,
-	}
-	c.HTML(http.StatusOK, "profile.tmpl", data)
-}
-
-func articleHandler(c *gin.Context) {
-    // 从上下文获取用户信息
-    userObj, exists := c.Get("user")
-    var user *models.User
-    if exists && userObj != nil {
-        user = userObj.(*models.User)
-    } else {
-        // 用户未登录，重定向到登录页面
-        c.Redirect(http.StatusFound, "/login")
-        return
-    }
-
-    // 获取tab参数
-    tab := c.Query("tab")
-    if tab == "" {
-        tab = "articles" // 默认tab
-    }
-
-    // 获取页码参数，默认为第1页
-    pageStr := c.Query("page")
-    page := 1
-    if pageStr != "" {
-        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-            page = p
-        }
-    }
-
-    // 每页显示的数量
-    limit := 5
-    offset := (page - 1) * limit
-
-    // 1. 根据用户id，查询用户的帖子，从posts表查
-    var userPosts []models.Post
-    var totalUserPosts int64
-    database.DB.Model(&models.Post{}).Where("category_id > ?", 0).Where("user_id = ?", user.ID).Count(&totalUserPosts)
-    database.DB.Where("category_id > ?", 0).Where("user_id = ?", user.ID).Order("created_at DESC").Offset(offset).Limit(limit).Find(&userPosts)
-
-    // 2. 根据用户id，查询用户的评论，从comments表查,并通过post_id关联查出posts表中的title
-    type CommentWithPostTitle struct {
-        models.Comment
-        PostTitle string
-        TimeAgo   string
-    }
-
-    var userComments []CommentWithPostTitle
-    var totalUserComments int64
-
-    // 先查询总数
-    database.DB.Table("comments c").
-        Joins("LEFT JOIN posts p ON c.post_id = p.id").
-        Where("c.user_id = ?", user.ID).
-        Count(&totalUserComments)
-
-    // 查询评论及关联的文章标题
-    database.DB.Table("comments c").
-        Select("c.*, p.title as post_title").
-        Joins("LEFT JOIN posts p ON c.post_id = p.id").
-        Where("c.user_id = ?", user.ID).
-        Order("c.created_at DESC").
-        Offset(offset).
-        Limit(limit).
-        Scan(&userComments)
-
-    // 处理评论的时间显示
-    for i := range userComments {
-        userComments[i].TimeAgo = utils.GetTimeAgo(userComments[i].CreatedAt)
-    }
-
-    // 3. 根据用户id，查询用户收藏的帖子，从post_favorites表查,并通过post_id关联查出posts表中的title
-    type FavoritePost struct {
-        models.Post
-        TimeAgo string
-    }
-
-    var favoritePosts []FavoritePost
-    var totalFavoritePosts int64
-
-    // 先查询总数
-    database.DB.Table("post_favorites pf").
-        Joins("LEFT JOIN posts p ON pf.post_id = p.id").
-        Where("pf.user_id = ?", user.ID).
-        Count(&totalFavoritePosts)
-
-    // 查询收藏的文章
-    database.DB.Table("post_favorites pf").
-        Select("p.*, pf.created_at as favorite_time").
-        Joins("LEFT JOIN posts p ON pf.post_id = p.id").
-        Where("pf.user_id = ?", user.ID).
-        Order("pf.created_at DESC").
-        Offset(offset).
-        Limit(limit).
-        Scan(&favoritePosts)
-
-    // 处理收藏文章的时间显示
-    for i := range favoritePosts {
-        favoritePosts[i].TimeAgo = utils.GetTimeAgo(favoritePosts[i].CreatedAt)
-    }
-
-    // 计算总页数
-    totalPostPages := int((totalUserPosts + int64(limit) - 1) / int64(limit))
-    totalCommentPages := int((totalUserComments + int64(limit) - 1) / int64(limit))
-    totalFavoritePages := int((totalFavoritePosts + int64(limit) - 1) / int64(limit))
-
-    data := gin.H{
-        "user":              user,
-        "profileUser":       user,
-        "articles":          userPosts,
-        "comments":          userComments,
-        "favorites":         favoritePosts,
-        "currentPage":       page,
-        "currentTab":        tab, // 添加当前tab信息
-        "totalUserPosts":    totalUserPosts,
-        "totalUserComments": totalUserComments,
-        "totalFavoritePosts":totalFavoritePosts,
-        "totalPostPages":    totalPostPages,
-        "totalCommentPages": totalCommentPages,
-        "totalFavoritePages": totalFavoritePages,
-        "hasPrev":           page > 1,
-        "hasNext":           page < getTotalPagesForTab(tab, totalPostPages, totalCommentPages, totalFavoritePages),
-        "prevPage":          page - 1,
-        "nextPage":          page + 1,
-    }
-    c.HTML(http.StatusOK, "article-list.tmpl", data)
-}
-
-// 辅助函数：根据tab获取对应的总页数
-func getTotalPagesForTab(tab string, postPages, commentPages, favoritePages int) int {
-    switch tab {
-    case "articles":
-        return postPages
-    case "comments":
-        return commentPages
-    case "favorites":
-        return favoritePages
-    default:
-        return postPages
-    }
-}
-
-
-
-func settingsHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
-		return
+	// 有待执行的迁移时拒绝启动（database.auto_migrate=true 时自动执行）
+	applied, err := migrate.CheckOnStartup(db, cfg.Database.AutoMigrate)
+	if err != nil {
+		slog.Error("检查数据库迁移失败", "error", err)
+		os.Exit(1)
 	}
-
-	data := gin.H{
-		"user": user,
+	for _, migration := range applied {
+		slog.Info("已执行数据库迁移", "version", migration.Version, "name", migration.Name)
 	}
-	c.HTML(http.StatusOK, "settings.tmpl", data)
-}
 
-func publishHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
+	// 初始化缓存（cache.driver=redis 时使用Redis兼容服务，默认进程内LRU）
+	if cfg.Cache.Driver == "redis" {
+		conn := caches.DialRedis(cfg.Cache.Redis.Addr, cfg.Cache.Redis.Password, cfg.Cache.Redis.DB)
+		caches.Init(caches.NewRedisStore(conn, "doniai:"))
 	} else {
-		// 用户未登录，重定向到登录页面
-		c.Redirect(http.StatusFound, "/login")
+		caches.Init(caches.NewMemoryStore(cfg.Cache.Size))
+	}
+	// 分类、文章等表写入后自动失效相关缓存
+	if err := caches.RegisterInvalidationHooks(db); err != nil {
+		slog.Error("注册缓存失效钩子失败", "error", err)
+	}
+	// Prometheus指标：进程、缓存命中率（请求耗时和SQL耗时由中间件和GORM插件记录）
+	metrics.RegisterRuntime()
+	caches.RegisterMetrics()
+
+	// 业务服务，页面、JSON接口和GraphQL共用；webhook事件先落库，由投递worker异步发送
+	repos := repositories.NewGorm(db)
+	hooks := webhooks.NewService(repos.Webhooks, repos.WebhookDeliveries)
+	svc := services.New(repos, caches.Default(), hooks.Dispatch, config.Current)
+
+	// 子命令：import 导入文章、export 导出全站，执行完直接退出
+	if len(args) > 0 && (args[0] == "import" || args[0] == "export") {
+		if args[0] == "import" {
+			err = importer.Command(db, args[1:], os.Stdout)
+		} else {
+			err = exporter.Command(db, args[1:], os.Stdout, exportOptions(svc))
+		}
+		if err != nil {
+			slog.Error(args[0]+" 失败", "error", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	// 获取所有分类
-	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
-
-	data := gin.H{
-		"user":       user,
-		"categories": categories,
+	// 社区统计：启动时从数据库加载，之后由钩子增量维护、worker定期校准
+	if err := stats.Default().Reconcile(db); err != nil {
+		slog.Error("加载社区统计失败", "error", err)
 	}
-	c.HTML(http.StatusOK, "publish.tmpl", data)
-}
-
-func registerHandler(c *gin.Context) {
-	data := gin.H{
-		"CurrentPath": "/register",
+	if err := stats.RegisterHooks(db, stats.Default()); err != nil {
+		slog.Error("注册统计钩子失败", "error", err)
 	}
-	c.HTML(http.StatusOK, "auth.tmpl", data)
-}
-
-func loginHandler(c *gin.Context) {
-	data := gin.H{
-		"CurrentPath": "/login",
-	}
-	c.HTML(http.StatusOK, "auth.tmpl", data)
-}
-
-func logoutHandler(c *gin.Context) {
-	// 获取session
-	session := sessions.Default(c)
 
-	// 清除session中的用户信息
-	session.Clear()
-
-	// 保存session更改
-	if err := session.Save(); err != nil {
-		fmt.Printf("登出时Session保存失败: %v\n", err)
-	} else {
-		fmt.Println("用户已成功登出")
+	// 链路追踪（tracing.exporter=otlp 发送到collector，stdout 打印到标准输出），退出时写出剩余的span
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, appVersion, os.Stdout)
+	if err != nil {
+		slog.Error("初始化链路追踪失败", "error", err)
+		os.Exit(1)
 	}
 
-	// 重定向到首页
-	c.Redirect(http.StatusFound, "/")
-}
-
-func loginSubmit(c *gin.Context) {
+	gin.SetMode(cfg.Server.Mode)
 
-	// 测试密码：xZ3(Uq)sDQ6qYEY]
-	// 获取表单提交的数据
-	identifier := c.PostForm("email") // 可以是邮箱或用户名
-	password := c.PostForm("password")
-	remember := c.PostForm("remember")
+	// 后台worker统一由 app 管理，退出时取消 ctx 并等待它们处理完剩余数据
+	app := lifecycle.New()
 
-	// 基本验证
-	if identifier == "" || password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "用户名/邮箱和密码不能为空",
-		})
-		return
-	}
+	// 初始化在线状态更新通道
+	onlineStatusChan = make(chan workers.OnlineStatusUpdate, 1000) // 缓冲1000个消息
+	// 启动在线状态更新处理器
+	app.Go("online-status", func(ctx context.Context) {
+		workers.HandleOnlineStatusUpdates(ctx, svc.Online, onlineStatusChan)
+	})
+	// 启动过期在线状态清理
+	app.Go("online-status-cleanup", func(ctx context.Context) {
+		workers.HandleOnlineStatusCleanup(ctx, svc.Online)
+	})
 
-	// 查询用户（支持邮箱或用户名登录）
-	var user models.User
-	if err := database.DB.Where("email = ? OR name = ?", identifier, identifier).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "用户不存在",
-		})
-		return
-	}
+	viewEventChan = make(chan workers.ViewEvent, 1000) // 缓冲1000个消息
 
-	// 验证密码
-	if !utils.CheckPassword(password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"status":  "error",
-			"message": "密码错误",
-		})
-		return
-	}
+	// 启动浏览事件处理器（批量写入浏览数，退出前写入剩余部分）
+	app.Go("view-count", func(ctx context.Context) {
+		workers.HandleViewNumUpdates(ctx, db, viewEventChan)
+	})
 
-	// 获取session
-	session := sessions.Default(c)
+	// 启动用户数据导出处理器
+	workers.ExportDir = cfg.Export.Dir
+	dataExportChan = make(chan uint, 100)
+	app.Go("data-export", func(ctx context.Context) {
+		workers.HandleDataExports(ctx, svc.Accounts, dataExportChan)
+	})
+	// 队列长度和容量指标
+	workers.RegisterQueueMetrics(onlineStatusChan, viewEventChan, dataExportChan)
 
-	// 设置用户信息到session
-	session.Set("user_id", user.ID)
+	// 启动账户注销处理器（冷静期结束后执行）
+	app.Go("account-deletion", func(ctx context.Context) {
+		workers.HandleAccountDeletions(ctx, svc)
+	})
 
-	// 根据"记住密码"选项设置过期时间
-	if remember == "on" {
-		// 设置30天过期
-		session.Options(sessions.Options{
-			MaxAge: 30 * 24 * 60 * 60, // 30天
-		})
-	} else {
-		// 设置会话结束时失效（浏览器关闭时）
-		session.Options(sessions.Options{
-			MaxAge: 0, // 浏览器会话期间有效
-		})
-	}
+	// 启动社区统计校准处理器
+	app.Go("stats", func(ctx context.Context) {
+		workers.HandleStatsReconciliation(ctx, stats.Default(), db)
+	})
 
-	// 保存session
+	// 启动点赞、收藏、回复计数校准处理器
+	app.Go("counters", func(ctx context.Context) {
+		workers.HandleCounterReconciliation(ctx, svc.Counters)
+	})
 
-	// 保存session
-	if err := session.Save(); err != nil {
-		fmt.Printf("Session保存失败: %v\n", err)
-	} else {
-		fmt.Println("Session保存成功")
-	}
+	// 启动热门/Top榜单计算处理器
+	app.Go("ranking", func(ctx context.Context) {
+		workers.HandleRankingUpdates(ctx, ranking.Default(), db)
+	})
 
-	// 登录成功
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "登录成功",
-		"data": gin.H{
-			"user_id": user.ID,
-			"email":   user.Email,
-			"name":    user.Name,
-		},
+	// 启动相关文章计算处理器
+	app.Go("related-posts", func(ctx context.Context) {
+		workers.HandleRelatedPostsUpdates(ctx, db)
 	})
 
-}
+	// 启动webhook投递处理器
+	app.Go("webhooks", func(ctx context.Context) {
+		workers.HandleWebhookDeliveries(ctx, hooks)
+	})
 
-func registerSubmit(c *gin.Context) {
-	// 获取表单提交的数据
-	username := c.PostForm("username")
-	email := c.PostForm("email")
-	password := c.PostForm("password")
-	confirmPassword := c.PostForm("confirmPassword")
-	agreeTerms := c.PostForm("agreeTerms")
+	router := newRouter(cfg, db, svc, hooks, app.Ready)
 
-	// 基本验证
-	if username == "" || email == "" || password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "用户名、邮箱和密码不能为空",
-		})
-		return
+	srv := &http.Server{
+		Addr:    cfg.Server.Addr(),
+		Handler: router,
 	}
-
-	if password != confirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "两次输入的密码不一致",
-		})
-		return
+	// 先监听端口，成功后才标记为就绪
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		slog.Error("服务启动失败", "error", err)
+		os.Exit(1)
 	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("服务启动失败", "error", err)
+			os.Exit(1)
+		}
+	}()
+	app.SetReady(true)
+	slog.Info("服务已启动", "addr", srv.Addr, "version", appVersion)
 
-	// 处理用户协议同意状态
-	isAgreeTerms := false
-	if agreeTerms == "on" {
-		isAgreeTerms = true
-	}
+	// SIGHUP（systemctl reload）重新加载站点信息、SMTP和第三方登录配置
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			next, err := config.Reload()
+			if err != nil {
+				slog.Error("重新加载配置失败，继续使用原配置", "error", err)
+				continue
+			}
+			logging.SetLevel(next.Log.Level)
+			slog.Info("配置已重新加载")
+		}
+	}()
 
-	if !isAgreeTerms {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "请同意用户协议",
-		})
-		return
-	}
+	// 收到退出信号后按顺序关闭：
+	// 1. 标记为未就绪，负载均衡不再转发新请求
+	// 2. 停止接收请求，等待进行中的请求完成
+	// 3. 通知worker退出，等待它们写入通道中剩余的数据（浏览数、在线状态等）
+	// 4. 写出尚未发送的链路追踪数据
+	// 5. 关闭数据库连接
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	slog.Info("正在关闭服务")
+	app.SetReady(false)
 
-	// 检查用户是否已存在
-	var existingUser models.User
-	if err := database.DB.Where("email = ?", email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "该邮箱已被注册",
-		})
-		return
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("关闭服务失败", "error", err)
 	}
 
-	// 密码加密
-	hashedPassword, err := utils.HashPassword(password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "密码加密失败",
-		})
-		return
+	if err := app.Stop(15 * time.Second); err != nil {
+		slog.Error("关闭后台任务失败", "error", err)
 	}
-	// 随机一个avatar图像
-	// 生成基于用户名的随机头像
-	avatarURL := fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", username)
 
-	// 创建新用户
-	newUser := models.User{
-		Name:       username,
-		Email:      email,
-		Password:   hashedPassword,
-		AgreeTerms: isAgreeTerms,
-		Avatar:     avatarURL,
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("写出链路追踪数据失败", "error", err)
 	}
 
-	// 保存到数据库
-	if err := database.DB.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "用户注册失败",
-		})
-		return
+	if err := database.Close(); err != nil {
+		slog.Error("关闭数据库连接失败", "error", err)
 	}
+	slog.Info("服务已退出")
+}
 
-	// 返回响应
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Login data received",
-		"data": gin.H{
-			"user_id": newUser.ID,
-			"email":   newUser.Email,
+func aboutHandler(c *gin.Context) {
+	data := gin.H{
+		"TeamMembers": []struct {
+			Name string
+			Role string
+		}{
+			{"张三", "开发工程师"},
+			{"李四", "UI设计师"},
+			{"王五", "产品经理"},
 		},
-	})
+	}
+	handlers.RenderHTML(c, http.StatusOK, "about.tmpl", data)
 }
 
-func searchPostsHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		fmt.Println("未获取到用户信息")
-	}
-	// 获取页码参数，默认为第1页
-	pageStr := c.Query("page")
-	// 获取路由categories/:type中type参数
-	qStr := c.Query("q")
-	page := 1
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+// settingsHandler 设置页：API令牌、数据导出记录和注销申请
+func settingsHandler(svc *services.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 从上下文获取用户信息
+		userObj, exists := c.Get("user")
+		var user *models.User
+		if exists && userObj != nil {
+			user = userObj.(*models.User)
+		} else {
+			// 用户未登录，重定向到登录页面
+			c.Redirect(http.StatusFound, "/login")
+			return
 		}
-	}
-
-	// 每页显示的帖子数量
-	limit := 10
-	offset := (page - 1) * limit
-
-	// 查询总记录数
-	var total int64
-	database.DB.Model(&models.Post{}).Count(&total)
-
-	// 查询当前页的帖子
-	var posts []models.Post
-	postQuery := database.DB.Order("created_at DESC").Offset(offset).Limit(limit)
+		svc := svc.WithContext(c.Request.Context())
 
-	// 修改查询条件为模糊搜索
-	postQuery = postQuery.Where("title LIKE ?", "%"+qStr+"%")
-
-	postQuery.Find(&posts)
-
-	// 计算总页数
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+		// 获取用户的API令牌
+		apiTokens, err := svc.Tokens.List(user.ID)
+		if err != nil {
+			logging.FromContext(c).Error("获取API令牌失败", "error", err)
+		}
 
-	// 创建带有友好时间的帖子结构
-	type PostWithFriendlyTime struct {
-		models.Post
-		TimeAgo string
-	}
+		// 获取数据导出记录和注销申请
+		dataExports, err := svc.Accounts.Exports(user.ID)
+		if err != nil {
+			logging.FromContext(c).Error("获取数据导出记录失败", "error", err)
+		}
+		pendingDeletion, err := svc.Accounts.PendingDeletion(user.ID)
+		if err != nil && !errors.Is(err, services.ErrNoPendingDeletion) {
+			logging.FromContext(c).Error("获取注销申请失败", "error", err)
+		}
 
-	var postsWithTimeAgo []PostWithFriendlyTime
-	for _, post := range posts {
-		timeAgo := utils.GetTimeAgo(post.CreatedAt)
-		postsWithTimeAgo = append(postsWithTimeAgo, PostWithFriendlyTime{
-			Post:    post,
-			TimeAgo: timeAgo,
-		})
+		data := gin.H{
+			"user":            user,
+			"apiTokens":       apiTokens,
+			"apiScopes":       models.AllScopes,
+			"dataExports":     dataExports,
+			"pendingDeletion": pendingDeletion,
+		}
+		handlers.RenderHTML(c, http.StatusOK, "settings.tmpl", data)
 	}
+}
 
-	// 获取在线用户数
-	var onlineCount int64
-	cutoffTime := time.Now().Add(-30 * time.Minute)
-	database.DB.Model(&models.UserOnlineStatus{}).
-		Where("last_active_time > ?", cutoffTime).
-		Count(&onlineCount)
-
-	// 获取站点统计信息
-	var userCount, postCount, commentCount int64
-	database.DB.Model(&models.User{}).Count(&userCount)
-	database.DB.Model(&models.Post{}).Count(&postCount)
-	database.DB.Model(&models.Comment{}).Count(&commentCount)
+// newRouter 注册中间件、模板和全部路由，db 供管理员批量导入导出使用，ready 为 /readyz 的就绪状态
+// 依赖的后台通道（onlineStatusChan 等）需在调用前创建
+func newRouter(cfg *config.Config, db *gorm.DB, svc *services.Services, hooks *webhooks.Service, ready func() bool) *gin.Engine {
+	router := gin.New()
+	// 请求ID、链路追踪、访问日志和请求耗时指标、panic恢复，最先执行以覆盖全部请求
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.TracingMiddleware())
+	router.Use(middlewares.AccessLogMiddleware())
+	router.Use(middlewares.RecoveryMiddleware())
+	// 健康检查（docker-compose healthcheck 等使用）和Prometheus指标，注册在会话等中间件之前
+	router.GET("/healthz", handlers.Liveness)
+	router.GET("/readyz", handlers.Readiness(ready, func(ctx context.Context) error {
+		return database.Ping(ctx, db)
+	}))
+	router.GET("/metrics", gin.WrapH(metrics.Handler(cfg.Metrics.Token)))
+
+	router.SetFuncMap(templateFuncs(svc.Categories))
+	// 设置session存储
+	store := cookie.NewStore([]byte(cfg.Session.Secret))
+	sessionOptions := middlewares.SessionOptions(cfg.Session)
+	store.Options(sessionOptions(0))
+	router.Use(sessions.Sessions("mysession", store))
+	// 安全响应头（CSP默认只上报不拦截，security.csp_mode=enforce 后正式拦截）
+	securityConfig := middlewares.DefaultSecurityConfig()
+	securityConfig.CSPReportOnly = cfg.Security.CSPMode != "enforce"
+	router.Use(middlewares.SecurityHeadersMiddleware(securityConfig))
+	// 在路由定义之前应用用户中间件
+	router.Use(middlewares.UserAndOnlineStatusMiddleware(svc, onlineStatusChan))
+	// API令牌认证（Authorization: Bearer），会覆盖session中的用户
+	router.Use(middlewares.APITokenMiddleware(svc.Tokens))
+	// CSRF防护（Bearer令牌请求除外）
+	router.Use(middlewares.CSRFMiddleware("/api/csp-report"))
 
-	// 获取所有分类
-	var categories []models.Category
-	database.DB.Where("status_code = ?", 1).Find(&categories)
+	// 加载模板文件
+	router.LoadHTMLGlob("templates/**/*")
 
-	// 1、统计注册用户
-	// 2、统计文章数量
-	// 3、统计回复评论数量
-	// 4、数据表categories查询所有分类
+	// 静态文件服务
+	router.Static("/static", "./static")
 
-	data := gin.H{
-		"CurrentTime":  time.Now().Format("2006-01-02 15:04:05"),
-		"posts":        postsWithTimeAgo,
-		"currentPage":  page,
-		"totalPages":   totalPages,
-		"hasPrev":      page > 1,
-		"hasNext":      page < totalPages,
-		"prevPage":     page - 1,
-		"nextPage":     page + 1,
-		"user":         user,
-		"userCount":    userCount,
-		"postCount":    postCount,
-		"commentCount": commentCount,
-		"onlineCount":  onlineCount,
-		"categories":   categories,
-	}
+	// 路由定义
+	pages := handlers.NewPageHandler(svc, recordView)
+	sessionHandler := handlers.NewSessionHandler(svc.Users, svc.Emails, sessionOptions)
+	router.GET("/", pages.Home)
+	router.GET("/categories/:type", pages.Home)
+	router.GET("/about", aboutHandler)
+	router.GET("/post-:id-1", pages.Detail)
+	router.GET("/register", sessionHandler.RegisterPage)
+	router.POST("/register", sessionHandler.Register)
+	router.GET("/login", sessionHandler.LoginPage)
+	router.POST("/login", sessionHandler.Login)
+	router.GET("/logout", sessionHandler.Logout)
+	router.GET("/profile", pages.Profile)
+	router.GET("/posts", pages.Articles)
+	router.GET("/publish", pages.Publish)
+	router.GET("/settings", settingsHandler(svc))
+	router.GET("/rss", pages.RSS)
+	// 添加搜索路由
+	router.GET("/search", pages.SearchPosts)
+	router.GET("/member", pages.SearchUsers)
 
-	c.HTML(http.StatusOK, "search.tmpl", data)
-}
+	// 在 main.go 的路由定义部分添加
+	oauthHandler := handlers.NewOAuthHandler(svc.Users, config.Current)
+	router.GET("/auth/github", oauthHandler.GitHubLogin)
+	router.GET("/auth/github/callback", oauthHandler.GitHubCallback)
+	router.GET("/auth/google", oauthHandler.GoogleLogin)
+	router.GET("/auth/google/callback", oauthHandler.GoogleCallback)
 
-func searchUsersHandler(c *gin.Context) {
-	// 从上下文获取用户信息
-	userObj, exists := c.Get("user")
-	var user *models.User
-	if exists && userObj != nil {
-		user = userObj.(*models.User)
-	} else {
-		fmt.Println("未获取到用户信息")
-	}
+	// 在 main.go 的路由部分添加
+	router.GET("/api/online/count", handlers.NewOnlineHandler(svc.Online).GetOnlineUserCount)
+	// CSP违规报告收集
+	router.POST("/api/csp-report", handlers.ReportCSPViolation)
+	// 社区统计（?days=30 附带每日趋势）
+	router.GET("/api/stats", handlers.NewStatsHandler(svc.Stats).GetSiteStats)
+	// 缓存命中率统计（仅管理员）
+	router.GET("/api/admin/cache/stats", middlewares.AdminRequired(), handlers.GetCacheStats)
+	// webhook订阅管理和投递记录（仅管理员）
+	webhookRoutes := router.Group("/api/admin/webhooks", middlewares.RequireTokenScope(models.ScopeAdmin), middlewares.AdminRequired())
+	{
+		webhookHandler := handlers.NewWebhookHandler(hooks)
+		webhookRoutes.GET("", webhookHandler.GetWebhooks)                                                    // webhook列表
+		webhookRoutes.POST("", webhookHandler.CreateWebhook)                                                 // 创建webhook
+		webhookRoutes.PUT("/:id", webhookHandler.UpdateWebhook)                                              // 修改webhook
+		webhookRoutes.DELETE("/:id", webhookHandler.DeleteWebhook)                                           // 删除webhook
+		webhookRoutes.GET("/:id/deliveries", webhookHandler.GetWebhookDeliveries)                            // 投递记录
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverWebhookDelivery) // 重新投递
+	}
+	// 从Markdown/WordPress导出文件批量导入文章（仅管理员，dry_run=true 时只返回报告）
+	importHandler := handlers.NewImportHandler(func(items []importer.Item, opts importer.Options, report *importer.Report) {
+		importer.Run(db, items, opts, report)
+	})
+	router.POST("/api/admin/import", middlewares.RequireTokenScope(models.ScopeAdmin), middlewares.AdminRequired(), importHandler.ImportPosts)
+	// 下载全站导出包（仅管理员，format=markdown 为Markdown+JSON，format=html 为静态镜像）
+	router.GET("/api/admin/export", middlewares.RequireTokenScope(models.ScopeAdmin), middlewares.AdminRequired(), exporter.Handler(db, exportOptions(svc)))
+	// 在路由定义部分添加
+	passwordResetHandler := handlers.NewPasswordResetHandler(svc.Emails)
+	emailHandler := handlers.NewEmailHandler(svc.Emails)
+	router.POST("/api/auth/forgot-password", passwordResetHandler.ForgotPassword)
+	router.GET("/reset-password", passwordResetHandler.ResetPassword)
+	router.POST("/api/auth/reset-password", passwordResetHandler.ProcessResetPassword)
+	router.GET("/verify-email", emailHandler.VerifyEmail)
+	router.POST("/api/auth/verify-email/resend", emailHandler.ResendVerificationEmail)
 
-	// 获取页码参数和搜索关键字
-	pageStr := c.Query("page")
-	qStr := c.Query("q")
-	page := 1
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	// 在 main.go 的路由定义部分添加评论路由
+	commentRoutes := router.Group("/api/comments", middlewares.RequireTokenScope(models.ScopeWriteComments))
+	{
+		commentHandler := handlers.NewCommentHandler(svc.Comments)
+		commentRoutes.POST("/", commentHandler.Create)
+		commentRoutes.GET("/", commentHandler.List)
+		commentRoutes.GET("/:id", commentHandler.Get)
+		commentRoutes.PUT("/:id", commentHandler.Update)
+		commentRoutes.DELETE("/:id", commentHandler.Delete)
+		commentRoutes.POST("/:id/like", commentHandler.Like)
 	}
 
-	// 每页显示的用户数量
-	limit := 10
-	offset := (page - 1) * limit
-
-	// 查询总记录数
-	var total int64
-	dbQuery := database.DB.Model(&models.User{})
-	if qStr != "" {
-		dbQuery = dbQuery.Where("name LIKE ? OR email LIKE ?", "%"+qStr+"%", "%"+qStr+"%")
+	// 令牌权限按路由区分：管理操作需要 admin，修改自己的资料、密码、邮箱需要任意一种写权限
+	userRoutes := router.Group("/api/users")
+	{
+		userHandler := handlers.NewUserHandler(svc.Users, svc.Emails)
+		adminScope := middlewares.RequireTokenScope(models.ScopeAdmin)
+		selfScope := middlewares.RequireTokenScope(models.ScopeWritePosts, models.ScopeWriteComments)
+		userRoutes.POST("/", adminScope, middlewares.AdminRequired(), userHandler.Create)                 // 创建用户（仅管理员）
+		userRoutes.GET("/", middlewares.RequireTokenScope(models.ScopeRead), userHandler.List)            // 获取所有用户
+		userRoutes.GET("/:id", middlewares.RequireTokenScope(models.ScopeRead), userHandler.Get)          // 获取单个用户
+		userRoutes.PUT("/:id", adminScope, middlewares.AdminRequired(), userHandler.Update)               // 更新用户（仅管理员）
+		userRoutes.DELETE("/:id", adminScope, middlewares.AdminRequired(), userHandler.Delete)            // 删除用户（软删除，仅管理员）
+		userRoutes.DELETE("/:id/force", adminScope, middlewares.AdminRequired(), userHandler.ForceDelete) // 强制删除（仅管理员）
+		userRoutes.PUT("/profile", selfScope, userHandler.UpdateProfile)                                  // 更新用户资料
+		userRoutes.PUT("/password", selfScope, userHandler.UpdatePassword)                                // 修改用户密码
+		userRoutes.PUT("/email", selfScope, emailHandler.ChangeUserEmail)                                 // 修改邮箱（需验证新邮箱）
+	}
+
+	postRoutes := router.Group("/api/posts", middlewares.RequireTokenScope(models.ScopeWritePosts))
+	{
+		postHandler := handlers.NewPostHandler(svc.Posts)
+		postRoutes.POST("/", postHandler.Create)                 // 创建文章
+		postRoutes.GET("/", postHandler.List)                    // 获取所有文章
+		postRoutes.GET("/:id", postHandler.Get)                  // 获取单个文章
+		postRoutes.PUT("/:id", postHandler.Update)               // 更新文章（作者或管理员）
+		postRoutes.DELETE("/:id", postHandler.Delete)            // 删除文章（软删除，作者或管理员）
+		postRoutes.POST("/:id/like", postHandler.Like)           // 文章点赞
+		postRoutes.DELETE("/:id/force", postHandler.ForceDelete) // 强制删除（仅管理员）
+		postRoutes.POST("/:id/favorite", postHandler.Favorite)   // 文章收藏
+		postRoutes.GET("/:id/analytics", postHandler.Analytics)  // 文章浏览数据（仅作者）
+		postRoutes.GET("/:id/related", postHandler.Related)      // 相关文章及推荐理由
+	}
+	router.GET("/posts/:id/analytics", pages.PostAnalytics)
+
+	// 账户数据导出与注销（仅限网页登录）
+	accountHandler := handlers.NewAccountHandler(svc.Accounts, dataExportChan, svc.Emails.Notify)
+	accountRoutes := router.Group("/api/account")
+	{
+		accountRoutes.POST("/export", accountHandler.RequestDataExport)            // 申请导出个人数据
+		accountRoutes.GET("/exports", accountHandler.GetDataExports)               // 导出记录
+		accountRoutes.POST("/delete", accountHandler.RequestAccountDeletion)       // 申请注销账户
+		accountRoutes.POST("/delete/cancel", accountHandler.CancelAccountDeletion) // 撤销注销申请
 	}
-	dbQuery.Count(&total)
+	router.GET("/account/exports/:id/download", accountHandler.DownloadDataExport)
 
-	// 查询当前页的用户
-	var users []models.User
-	userQuery := database.DB.Order("created_at DESC").Offset(offset).Limit(limit)
-	if qStr != "" {
-		userQuery = userQuery.Where("name LIKE ? OR email LIKE ?", "%"+qStr+"%", "%"+qStr+"%")
-	}
-	userQuery.Find(&users)
+	// 版本化的REST API，文档见 /api/v1/openapi.json
+	apiv1.Register(router.Group("/api/v1"), apiv1.New(svc))
 
-	// 计算总页数
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	// GraphQL接口（查询支持GET和POST，mutation只能POST）
+	graphqlHandler := gql.Handler(svc)
+	router.GET("/graphql", graphqlHandler)
+	router.POST("/graphql", graphqlHandler)
 
-	// 创建带有友好时间的用户结构
-	type UserWithFriendlyTime struct {
-		models.User
-		TimeAgo string
+	// 个人API令牌管理（仅限网页登录）
+	tokenRoutes := router.Group("/api/tokens")
+	{
+		tokenHandler := handlers.NewAPITokenHandler(svc.Tokens)
+		tokenRoutes.GET("/", tokenHandler.List)         // 令牌列表
+		tokenRoutes.POST("/", tokenHandler.Create)      // 创建令牌
+		tokenRoutes.DELETE("/:id", tokenHandler.Delete) // 吊销令牌
 	}
 
-	var usersWithTimeAgo []UserWithFriendlyTime
-	for _, user := range users {
-		timeAgo := utils.GetTimeAgo(user.CreatedAt)
-		usersWithTimeAgo = append(usersWithTimeAgo, UserWithFriendlyTime{
-			User:    user,
-			TimeAgo: timeAgo,
+	router.NoRoute(func(c *gin.Context) {
+		handlers.RenderHTML(c, http.StatusNotFound, "404.tmpl", gin.H{
+			"Message": "页面未找到",
 		})
-	}
-
-	data := gin.H{
-		"users":         usersWithTimeAgo,
-		"currentPage":   page,
-		"totalPages":    totalPages,
-		"hasPrev":       page > 1,
-		"hasNext":       page < totalPages,
-		"prevPage":      page - 1,
-		"nextPage":      page + 1,
-		"user":          user,
-		"searchKeyword": qStr,
-	}
+	})
 
-	c.HTML(http.StatusOK, "member.tmpl", data)
+	return router
 }
 
-func rssHandler(c *gin.Context) {
-    // 查询最新的帖子
-    var posts []models.Post
-    database.DB.Order("created_at DESC").Limit(20).Find(&posts)
-
-    // 获取当前域名
-    scheme := "http"
-    if c.Request.TLS != nil {
-        scheme = "https"
-    }
-    currentDomain := scheme + "://" + c.Request.Host
-
-    // 构造RSS数据结构
-    type RSSItem struct {
-        Title       string    `xml:"title"`
-        Link        string    `xml:"link"`
-        Description string    `xml:"description"`
-        PubDate     time.Time `xml:"pubDate"`
-        GUID        string    `xml:"guid"`
-    }
-
-    type RSSChannel struct {
-        XMLName     xml.Name  `xml:"rss"`
-        Version     string    `xml:"version,attr"`
-        NSDC        string    `xml:"xmlns:dc,attr"`
-        NSContent   string    `xml:"xmlns:content,attr"`
-        NSAtom      string    `xml:"xmlns:atom,attr"`
-        Channel     struct {
-            Title       string    `xml:"title"`
-            Link        string    `xml:"link"`
-            Description string    `xml:"description"`
-            Language    string    `xml:"language"`
-            LastBuildDate string  `xml:"lastBuildDate"`
-            Items       []RSSItem `xml:"item"`
-        } `xml:"channel"`
-    }
-
-    rss := RSSChannel{
-        Version:   "2.0",
-        NSDC:      "http://purl.org/dc/elements/1.1/",
-        NSContent: "http://purl.org/rss/1.0/modules/content/",
-        NSAtom:    "http://www.w3.org/2005/Atom",
-    }
-
-    rss.Channel.Title = "Doniai技术社区"
-    rss.Channel.Link = currentDomain
-    rss.Channel.Description = "技术社区最新帖子"
-    rss.Channel.Language = "zh-CN"
-    rss.Channel.LastBuildDate = time.Now().Format(time.RFC1123Z)
-
-    // 添加帖子项目
-    for _, post := range posts {
-        postLink := currentDomain + "/post-" + fmt.Sprintf("%d", post.ID) + "-1"
-        rss.Channel.Items = append(rss.Channel.Items, RSSItem{
-            Title:       post.Title,
-            Link:        postLink,
-            Description: fmt.Sprintf("<![CDATA[%s]]>", post.Content),
-            PubDate:     post.CreatedAt,
-            GUID:        postLink,
-        })
-    }
-
-    c.XML(http.StatusOK, rss)
+// recordView 文章详情页被访问时发送浏览事件，由 view-count worker 批量写入
+func recordView(c *gin.Context, postID uint) {
+	workers.EnqueueViewEvent(viewEventChan, workers.ViewEvent{
+		PostID:    postID,
+		UserID:    handlers.UserIDFromContext(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Timestamp: time.Now(),
+		Trace:     trace.SpanContextFromContext(c.Request.Context()),
+	})
 }
-
-
-
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ContentType Prometheus文本格式的响应类型
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var startTime = time.Now()
+
+// RegisterRuntime 注册进程级指标：goroutine数、堆内存和运行时长
+func RegisterRuntime() {
+	GaugeFunc("go_goroutines", "当前goroutine数", nil, func() []Sample {
+		return []Sample{{Value: float64(runtime.NumGoroutine())}}
+	})
+	GaugeFunc("go_memstats_heap_alloc_bytes", "堆上已分配且仍在使用的字节数", nil, func() []Sample {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return []Sample{{Value: float64(m.HeapAlloc)}}
+	})
+	GaugeFunc("process_uptime_seconds", "进程已运行的秒数", nil, func() []Sample {
+		return []Sample{{Value: time.Since(startTime).Seconds()}}
+	})
+}
+
+// Handler 输出默认注册表的全部指标；token 不为空时要求 Authorization: Bearer <token>
+func Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "未授权", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var b strings.Builder
+		Default().Write(&b)
+		w.Header().Set("Content-Type", ContentType)
+		w.Write([]byte(b.String()))
+	})
+}
@@ -0,0 +1,311 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 指标类型，对应Prometheus文本格式中的 # TYPE
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefBuckets 默认的耗时分桶（秒），覆盖1毫秒到10秒
+var DefBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample 一个标签组合的取值，Labels 与指标声明的标签名一一对应
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// collector 可以输出为文本格式的指标
+type collector interface {
+	describe() (name, help, typ string)
+	write(b *strings.Builder)
+}
+
+// Registry 指标注册表，按名称排序输出
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局默认注册表，/metrics 输出的就是它
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// register 注册指标，同名指标重复注册时替换原有的（如测试中多次初始化）
+func (r *Registry) register(c collector) {
+	name, _, _ := c.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors[name] = c
+}
+
+// Write 按Prometheus文本格式（0.0.4）输出全部指标
+func (r *Registry) Write(b *strings.Builder) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, len(names))
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		name, help, typ := c.describe()
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+		c.write(b)
+	}
+}
+
+// desc 指标的名称、说明和标签名
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) describe() (string, string, string) {
+	return d.name, d.help, d.typ
+}
+
+// checkLabels 标签值个数必须与声明一致，否则是调用方的编程错误
+func (d *desc) checkLabels(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s 需要 %d 个标签值，传入了 %d 个", d.name, len(d.labels), len(values)))
+	}
+}
+
+// CounterVec 带标签的累加计数器
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec 创建计数器并注册到默认注册表
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, TypeCounter, labels}, values: make(map[string]*counterValue)}
+	defaultRegistry.register(c)
+	return c
+}
+
+// Inc 计数加1
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add 计数增加delta，delta 不能为负
+func (c *CounterVec) Add(delta float64, labels ...string) {
+	c.checkLabels(labels)
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: 计数器 %s 不能减少", c.name))
+	}
+	key := strings.Join(labels, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: append([]string(nil), labels...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+// Value 返回某个标签组合的当前计数
+func (c *CounterVec) Value(labels ...string) float64 {
+	c.checkLabels(labels)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.values[strings.Join(labels, "\xff")]; ok {
+		return v.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		writeSample(b, c.name, c.labels, v.labels, "", "", v.value)
+	}
+}
+
+// HistogramVec 带标签的直方图，用于请求耗时等分布
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // 与 buckets 对应，每个桶只记录落在该区间的次数，输出时再累加
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec 创建直方图并注册到默认注册表，buckets 为空时使用 DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{desc: desc{name, help, TypeHistogram, labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	defaultRegistry.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	h.checkLabels(labels)
+	key := strings.Join(labels, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: append([]string(nil), labels...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += value
+}
+
+// Count 返回某个标签组合的观测次数
+func (h *HistogramVec) Count(labels ...string) uint64 {
+	h.checkLabels(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if v, ok := h.values[strings.Join(labels, "\xff")]; ok {
+		return v.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += v.counts[i]
+			writeSample(b, h.name+"_bucket", h.labels, v.labels, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(b, h.name+"_bucket", h.labels, v.labels, "le", "+Inf", float64(v.count))
+		writeSample(b, h.name+"_sum", h.labels, v.labels, "", "", v.sum)
+		writeSample(b, h.name+"_count", h.labels, v.labels, "", "", float64(v.count))
+	}
+}
+
+// funcCollector 采集时调用函数取值，用于队列长度、缓存命中数等已有统计
+type funcCollector struct {
+	desc
+	collect func() []Sample
+}
+
+func (f *funcCollector) write(b *strings.Builder) {
+	for _, s := range f.collect() {
+		f.checkLabels(s.Labels)
+		writeSample(b, f.name, f.labels, s.Labels, "", "", s.Value)
+	}
+}
+
+// GaugeFunc 注册采集时取值的仪表盘指标
+func GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	defaultRegistry.register(&funcCollector{desc{name, help, TypeGauge, labels}, collect})
+}
+
+// CounterFunc 注册采集时取值的计数器指标，collect 返回的值应只增不减
+func CounterFunc(name, help string, labels []string, collect func() []Sample) {
+	defaultRegistry.register(&funcCollector{desc{name, help, TypeCounter, labels}, collect})
+}
+
+func writeSample(b *strings.Builder, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	b.WriteString(name)
+	if len(labelNames) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, label := range labelNames {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escapeLabel(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteTextFormat(t *testing.T) {
+	r := NewRegistry()
+	counter := &CounterVec{desc: desc{"test_events_total", "事件数", TypeCounter, []string{"kind"}}, values: map[string]*counterValue{}}
+	histogram := &HistogramVec{desc: desc{"test_duration_seconds", "耗时", TypeHistogram, []string{"route"}},
+		buckets: []float64{0.1, 1}, values: map[string]*histogramValue{}}
+	r.register(counter)
+	r.register(histogram)
+	r.register(&funcCollector{desc{"test_queue_length", "队列长度", TypeGauge, []string{"queue"}}, func() []Sample {
+		return []Sample{{Labels: []string{`a"b`}, Value: 3}}
+	}})
+
+	counter.Inc("drop")
+	counter.Add(2, "drop")
+	histogram.Observe(0.05, "/posts/:id")
+	histogram.Observe(0.1, "/posts/:id")
+	histogram.Observe(5, "/posts/:id")
+
+	var b strings.Builder
+	r.Write(&b)
+	want := `# HELP test_duration_seconds 耗时
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/posts/:id",le="0.1"} 2
+test_duration_seconds_bucket{route="/posts/:id",le="1"} 2
+test_duration_seconds_bucket{route="/posts/:id",le="+Inf"} 3
+test_duration_seconds_sum{route="/posts/:id"} 5.15
+test_duration_seconds_count{route="/posts/:id"} 3
+# HELP test_events_total 事件数
+# TYPE test_events_total counter
+test_events_total{kind="drop"} 3
+# HELP test_queue_length 队列长度
+# TYPE test_queue_length gauge
+test_queue_length{queue="a\"b"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("输出 =\n%s\n期望 =\n%s", got, want)
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	handler := Handler("secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("未带令牌 status = %d, 期望 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Errorf("带令牌 status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
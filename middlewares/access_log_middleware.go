@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+	"gin-doniai/logging"
+	"gin-doniai/metrics"
+	"gin-doniai/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 请求耗时，route 为路由模板（如 /post-:id-1），未匹配到路由时为 unmatched，避免标签数量无限增长
+var httpRequestDuration = metrics.NewHistogramVec("http_request_duration_seconds", "HTTP请求处理耗时（秒）", nil, "method", "route", "status")
+
+// 这些路径请求频繁且不重要，访问日志只在debug级别输出
+var quietPathPrefixes = []string{"/static/", "/healthz", "/readyz", "/metrics"}
+
+// AccessLogMiddleware 记录请求耗时指标，并按状态码输出结构化访问日志：5xx为error，4xx为warn，其余为info
+// 需放在 RequestIDMiddleware 之后
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		httpRequestDuration.Observe(elapsed.Seconds(), c.Request.Method, route, strconv.Itoa(status))
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case quietPath(c.Request.URL.Path):
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Int64("elapsed_ms", elapsed.Milliseconds()),
+			slog.String("ip", c.ClientIP()),
+			slog.Int("size", c.Writer.Size()),
+		}
+		if user, ok := c.Get("user"); ok {
+			if u, ok := user.(*models.User); ok {
+				attrs = append(attrs, slog.Uint64("user_id", uint64(u.ID)))
+			}
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		logging.FromContext(c).LogAttrs(c, level, "请求完成", attrs...)
+	}
+}
+
+// RecoveryMiddleware 捕获处理请求时的panic，记录堆栈后返回500
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err interface{}) {
+		logging.FromContext(c).Error("处理请求时发生panic", "panic", err, "stack", string(debug.Stack()))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+func quietPath(path string) bool {
+	for _, prefix := range quietPathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+	"gin-doniai/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRequired 只允许管理员访问
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userObj, exists := c.Get("user")
+		if !exists || userObj == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "用户未登录",
+			})
+			return
+		}
+
+		if user, ok := userObj.(*models.User); !ok || !user.IsAdmin() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "需要管理员权限",
+			})
+			return
+		}
+		c.Next()
+	}
+}
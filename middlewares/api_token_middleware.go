@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APITokenMiddleware 支持 Authorization: Bearer <token> 方式访问 /api 接口和 /graphql
+// 认证成功后与session登录一样设置 c.Set("user", ...)，并额外设置 c.Set("api_token", ...)
+func APITokenMiddleware(tokens *services.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		path := c.Request.URL.Path
+		if (!strings.HasPrefix(path, "/api/") && path != "/graphql") || authHeader == "" {
+			c.Next()
+			return
+		}
+
+		raw, found := strings.CutPrefix(authHeader, "Bearer ")
+		raw = strings.TrimSpace(raw)
+		if !found || raw == "" {
+			abortUnauthorized(c, "无效的Authorization头")
+			return
+		}
+
+		token, user, err := tokens.Authenticate(raw, c.ClientIP())
+		switch {
+		case errors.Is(err, services.ErrTokenExpired), errors.Is(err, services.ErrTokenUserMissing):
+			abortUnauthorized(c, err.Error())
+			return
+		case err != nil:
+			abortUnauthorized(c, services.ErrInvalidToken.Error())
+			return
+		}
+
+		// 令牌认证优先于session中的用户
+		c.Set("user", user)
+		c.Set("api_token", token)
+		c.Next()
+	}
+}
+
+// RequireTokenScope 校验API令牌权限
+// 读请求需要 read 权限，写请求需要 writeScopes 中任意一个权限；session登录的请求不受影响
+func RequireTokenScope(writeScopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenObj, exists := c.Get("api_token")
+		if !exists {
+			c.Next()
+			return
+		}
+		token := tokenObj.(*models.APIToken)
+
+		scopes := writeScopes
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scopes = []string{models.ScopeRead}
+		}
+
+		for _, scope := range scopes {
+			if token.HasScope(scope) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "API令牌缺少权限: " + strings.Join(scopes, " 或 "),
+		})
+	}
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"success": false,
+		"message": message,
+	})
+}
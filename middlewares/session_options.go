@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"gin-doniai/config"
+
+	"github.com/gin-contrib/sessions"
+)
+
+// SessionOptions 按会话配置返回生成session cookie选项的函数
+// maxAge 为0时cookie在浏览器关闭后失效；SameSite=Lax 阻止跨站POST携带cookie
+func SessionOptions(cfg config.SessionConfig) func(maxAge int) sessions.Options {
+	return func(maxAge int) sessions.Options {
+		return sessions.Options{
+			Path:     "/",
+			MaxAge:   maxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   cfg.Secure, // 全站HTTPS时开启（session.secure）
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package middlewares
 
 import (
-	"fmt"
 	"strings"
-	"gin-doniai/database"
+	"gin-doniai/logging"
 	"gin-doniai/models"
+	"gin-doniai/services"
 	"gin-doniai/workers"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -12,7 +12,8 @@ import (
 
 
 // UserAndOnlineStatusMiddleware 合并的用户信息和在线状态中间件
-func UserAndOnlineStatusMiddleware(onlineStatusChan chan workers.OnlineStatusUpdate) gin.HandlerFunc {
+// svc 按session中的 user_id 加载用户，查询随请求的 ctx 记录到链路中
+func UserAndOnlineStatusMiddleware(svc *services.Services, onlineStatusChan chan workers.OnlineStatusUpdate) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
 		userID := session.Get("user_id")
@@ -20,21 +21,20 @@ func UserAndOnlineStatusMiddleware(onlineStatusChan chan workers.OnlineStatusUpd
 		var user *models.User
 		if userID != nil {
 			// 确保 userID 是有效的数字类型
+			users := svc.WithContext(c.Request.Context()).Users
 			if userIDVal, ok := userID.(uint); ok && userIDVal > 0 {
-				var currentUser models.User
-				if err := database.DB.First(&currentUser, userIDVal).Error; err == nil {
-					user = &currentUser
+				if currentUser, err := users.Get(userIDVal); err == nil {
+					user = currentUser
 				}
 			} else if userIDVal, ok := userID.(int); ok && userIDVal > 0 {
-				var currentUser models.User
-				if err := database.DB.First(&currentUser, uint(userIDVal)).Error; err == nil {
-					user = &currentUser
+				if currentUser, err := users.Get(uint(userIDVal)); err == nil {
+					user = currentUser
 				}
 			}
 		} else {
 			// 当没有user_id时，不进行重定向以避免循环重定向
 			// 只是简单地设置user为nil
-			fmt.Println("Middleware - Session中没有user_id")
+			logging.FromContext(c).Debug("Session中没有user_id")
 		}
 
 		// 设置用户信息到上下文
@@ -53,7 +53,8 @@ func UserAndOnlineStatusMiddleware(onlineStatusChan chan workers.OnlineStatusUpd
 			}:
 			default:
 				// 队列满时丢弃，避免阻塞
-				fmt.Println("在线状态队列已满，丢弃更新")
+				workers.QueueDropped.Inc(workers.QueueOnlineStatus)
+				logging.FromContext(c).Warn("在线状态队列已满，丢弃更新", "user_id", user.ID)
 			}
 		}
 
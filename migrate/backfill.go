@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"gin-doniai/utils"
+	"gorm.io/gorm"
+)
+
+// backfills 需要用Go代码转换数据的迁移，按版本号注册，在该版本的升级SQL之后执行
+var backfills = map[int]func(tx *gorm.DB) error{
+	4: backfillPostTagsJSON,
+}
+
+// 每批转换的文章数
+const backfillBatchSize = 500
+
+// backfillPostTagsJSON 把逗号分隔保存的文章标签改写为JSON数组（如 ["go","gin"]），
+// 列表接口按数组元素过滤标签，旧格式的文章会被漏掉；包括已软删除的文章
+func backfillPostTagsJSON(tx *gorm.DB) error {
+	type postTags struct {
+		ID   uint
+		Tags string
+	}
+	var lastID uint
+	for {
+		var batch []postTags
+		err := tx.Table("posts").Select("id, tags").Where("id > ?", lastID).
+			Order("id ASC").Limit(backfillBatchSize).Find(&batch).Error
+		if err != nil {
+			return err
+		}
+		for _, post := range batch {
+			if formatted := utils.FormatTags(post.Tags); formatted != post.Tags {
+				if err := tx.Table("posts").Where("id = ?", post.ID).UpdateColumn("tags", formatted).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(batch) < backfillBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// openSQLite 打开一个独立的内存SQLite数据库
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	name := strings.ReplaceAll(t.Name(), "/", "_")
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestBackfillPostTagsJSON(t *testing.T) {
+	db := openSQLite(t)
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(3); err != nil {
+		t.Fatalf("执行迁移到 0003 失败: %v", err)
+	}
+
+	db.Exec("INSERT INTO users (id, name, email, password, avatar) VALUES (1, 'a', 'a@example.com', 'x', '')")
+	cases := []struct {
+		stored, want string
+		deleted      bool
+	}{
+		{"go, gin", `["go","gin"]`, false},
+		{"数据库，SQL", `["数据库","SQL"]`, false},
+		{`["already","json"]`, `["already","json"]`, false},
+		{"", `[]`, false},
+		{"R&D,<b>", `["R&D","<b>"]`, false},
+		{"deleted,post", `["deleted","post"]`, true},
+	}
+	for i, c := range cases {
+		var deletedAt interface{}
+		if c.deleted {
+			deletedAt = "2024-01-01 00:00:00"
+		}
+		err := db.Exec("INSERT INTO posts (id, title, user_id, author, category, content, tags, deleted_at) VALUES (?, 't', 1, 'a', 'c', 'x', ?, ?)",
+			i+1, c.stored, deletedAt).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done, err := m.Up(4)
+	if err != nil {
+		t.Fatalf("执行 0004 失败: %v", err)
+	}
+	if len(done) != 1 || done[0].Version != 4 {
+		t.Fatalf("执行的迁移 = %+v, 期望只有 0004", done)
+	}
+
+	for i, c := range cases {
+		var tags string
+		db.Raw("SELECT tags FROM posts WHERE id = ?", i+1).Scan(&tags)
+		if tags != c.want {
+			t.Errorf("标签 %q 转换为 %q, 期望 %q", c.stored, tags, c.want)
+		}
+	}
+
+	// 回滚不修改数据
+	if _, err := m.Down(1); err != nil {
+		t.Fatalf("回滚 0004 失败: %v", err)
+	}
+	var tags string
+	db.Raw("SELECT tags FROM posts WHERE id = 1").Scan(&tags)
+	if tags != `["go","gin"]` {
+		t.Errorf("回滚后标签 = %q", tags)
+	}
+}
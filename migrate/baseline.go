@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// baselineModels 基线迁移包含的表，被外键引用的表在前
+func baselineModels() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.Category{},
+		&models.Post{},
+		&models.Comment{},
+		&models.PostLike{},
+		&models.PostFavorite{},
+		&models.UserOnlineStatus{},
+		&models.PasswordReset{},
+		&models.EmailVerification{},
+		&models.APIToken{},
+		&models.DataExport{},
+		&models.AccountDeletion{},
+		&models.SiteStatSnapshot{},
+		&models.PostViewStat{},
+		&models.PostViewVisitor{},
+		&models.PostRelation{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.PostImport{},
+	}
+}
+
+// sqlRecorder 记录GORM生成的SQL而不执行（配合 DryRun 使用）
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// dryRunDialector 不连接数据库的方言，只用于生成SQL
+func dryRunDialector(dialect string) (gorm.Dialector, error) {
+	switch dialect {
+	case "mysql":
+		return mysql.New(mysql.Config{DSN: "dry:run@tcp(127.0.0.1:3306)/dry?parseTime=True", SkipInitializeWithVersion: true}), nil
+	case "postgres":
+		return postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=dry dbname=dry"}), nil
+	case "sqlite":
+		return sqlite.Open(":memory:"), nil
+	}
+	return nil, fmt.Errorf("不支持的数据库方言: %s", dialect)
+}
+
+// GenerateBaseline 按当前模型定义生成指定方言的建表SQL（与旧版本 AutoMigrate 创建的结构一致）
+// 返回 up 和 down 两个文件的内容，用于生成 0001_baseline 迁移
+func GenerateBaseline(dialect string) (up, down string, err error) {
+	dialector, err := dryRunDialector(dialect)
+	if err != nil {
+		return "", "", err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return "", "", err
+	}
+
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	session := db.Session(&gorm.Session{DryRun: true, Logger: recorder})
+	tables := baselineModels()
+	if err := session.Migrator().CreateTable(tables...); err != nil {
+		return "", "", err
+	}
+
+	var upSQL strings.Builder
+	fmt.Fprintf(&upSQL, "-- 由 gin-doniai migrate baseline -sql -driver %s 生成，与旧版本 AutoMigrate 创建的表结构一致\n", dialect)
+	for _, statement := range recorder.statements {
+		upSQL.WriteString(statement)
+		upSQL.WriteString(";\n")
+	}
+
+	// 按建表的相反顺序删除，先删除引用其他表的表
+	var downSQL strings.Builder
+	for i := len(tables) - 1; i >= 0; i-- {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(tables[i]); err != nil {
+			return "", "", err
+		}
+		fmt.Fprintf(&downSQL, "DROP TABLE IF EXISTS %s;\n", stmt.Schema.Table)
+	}
+	return upSQL.String(), downSQL.String(), nil
+}
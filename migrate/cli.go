@@ -0,0 +1,199 @@
+package migrate
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/tabwriter"
+	"gorm.io/gorm"
+)
+
+const usage = `用法: gin-doniai migrate <命令> [选项]
+
+命令:
+  status              列出迁移及执行状态（默认）
+  up [-to 版本]        执行尚未执行的迁移
+  down [-steps N]     回滚最近执行的 N 个迁移（默认1个）
+  baseline [-version 版本]
+                      把已有数据库（旧版本 AutoMigrate 建表）标记为已执行基线
+  baseline -sql [-driver mysql|postgres|sqlite] [-dir 目录]
+                      按当前模型生成基线迁移文件，不指定 -dir 时输出到终端
+  new <名称> [-dir 目录] 新建一对空的迁移文件
+  unlock              强制释放迁移锁
+`
+
+// Command 命令行入口：gin-doniai migrate <命令> [选项]
+func Command(db *gorm.DB, args []string, out io.Writer) error {
+	command := "status"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprint(out, usage)
+	}
+
+	switch command {
+	case "status":
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		m, err := New(db)
+		if err != nil {
+			return err
+		}
+		statuses, err := m.Status()
+		PrintStatus(out, statuses)
+		return err
+
+	case "up":
+		to := flags.Int("to", 0, "只执行到该版本，0表示全部")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		m, err := New(db)
+		if err != nil {
+			return err
+		}
+		done, err := m.Up(*to)
+		printDone(out, "已执行", done)
+		return err
+
+	case "down":
+		steps := flags.Int("steps", 1, "回滚的迁移个数")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		m, err := New(db)
+		if err != nil {
+			return err
+		}
+		done, err := m.Down(*steps)
+		printDone(out, "已回滚", done)
+		return err
+
+	case "baseline":
+		version := flags.Int("version", 1, "标记为已执行的最高版本")
+		generate := flags.Bool("sql", false, "生成基线迁移文件而不是标记数据库")
+		driver := flags.String("driver", db.Dialector.Name(), "生成SQL使用的数据库方言")
+		dir := flags.String("dir", "", "基线迁移文件的输出目录，如 migrate/sql")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *generate {
+			return writeBaseline(out, *driver, *dir)
+		}
+		m, err := New(db)
+		if err != nil {
+			return err
+		}
+		done, err := m.Baseline(*version)
+		printDone(out, "已标记", done)
+		return err
+
+	case "new":
+		dir := flags.String("dir", filepath.Join("migrate", "sql"), "迁移文件目录")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return fmt.Errorf("需要指定迁移名称")
+		}
+		m, err := New(db)
+		if err != nil {
+			return err
+		}
+		return createFiles(out, *dir, flags.Arg(0), m.Migrations())
+
+	case "unlock":
+		if err := Unlock(db); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "迁移锁已释放")
+		return nil
+	}
+
+	flags.Usage()
+	return fmt.Errorf("未知的迁移命令 %q", command)
+}
+
+// PrintStatus 以表格形式输出迁移状态
+func PrintStatus(out io.Writer, statuses []Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+	pending := 0
+	for _, status := range statuses {
+		state, appliedAt := "待执行", "-"
+		switch {
+		case status.Modified:
+			state = "已修改"
+		case status.Applied:
+			state = "已执行"
+		default:
+			pending++
+		}
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n共 %d 个迁移，待执行 %d 个\n", len(statuses), pending)
+}
+
+func printDone(out io.Writer, action string, migrations []Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(out, "没有需要处理的迁移")
+		return
+	}
+	for _, migration := range migrations {
+		fmt.Fprintf(out, "%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+func writeBaseline(out io.Writer, driver, dir string) error {
+	up, down, err := GenerateBaseline(driver)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		fmt.Fprint(out, up)
+		return nil
+	}
+	upFile := filepath.Join(dir, fmt.Sprintf("0001_baseline.%s.up.sql", driver))
+	downFile := filepath.Join(dir, "0001_baseline.down.sql")
+	if err := os.WriteFile(upFile, []byte(up), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(downFile, []byte(down), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "已生成 %s 和 %s\n", upFile, downFile)
+	return nil
+}
+
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// createFiles 新建下一个版本的迁移文件，生成后需要重新编译才会生效（迁移文件嵌入在程序中）
+func createFiles(out io.Writer, dir, name string, existing []Migration) error {
+	if !migrationNamePattern.MatchString(name) {
+		return fmt.Errorf("迁移名称只能包含小写字母、数字和下划线")
+	}
+	next := 1
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+		if err := os.WriteFile(file, []byte(fmt.Sprintf("-- %04d_%s %s\n", next, name, direction)), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "已创建 %s\n", file)
+	}
+	return nil
+}
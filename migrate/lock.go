@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"time"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 锁表只有一行（ID=1），插入成功即获得锁；多个实例同时启动时只有一个执行迁移
+type lock struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:200;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (lock) TableName() string {
+	return "schema_migration_lock"
+}
+
+const lockID = 1
+
+func defaultOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// withLock 持有迁移锁执行 fn，锁已被占用时直接返回错误而不是等待
+func (m *Migrator) withLock(fn func() error) error {
+	if err := m.db.AutoMigrate(&lock{}); err != nil {
+		return fmt.Errorf("创建迁移锁表失败: %v", err)
+	}
+	// 锁被占用时插入会因主键冲突失败，属于预期情况，不输出SQL错误日志
+	quiet := m.db.Session(&gorm.Session{Logger: m.db.Logger.LogMode(logger.Silent)})
+	if err := quiet.Create(&lock{ID: lockID, Owner: m.Owner, LockedAt: time.Now()}).Error; err != nil {
+		var holder lock
+		if m.db.Limit(1).Find(&holder, lockID).RowsAffected == 0 {
+			return fmt.Errorf("获取迁移锁失败: %v", err)
+		}
+		return fmt.Errorf("迁移锁被 %s 于 %s 持有：确认没有其他实例在执行迁移后，可执行 gin-doniai migrate unlock 强制释放",
+			holder.Owner, holder.LockedAt.Format("2006-01-02 15:04:05"))
+	}
+	defer m.db.Delete(&lock{}, lockID)
+	return fn()
+}
+
+// Unlock 强制释放迁移锁，用于迁移进程异常退出后锁未释放的情况
+func Unlock(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&lock{}) {
+		return nil
+	}
+	return db.Delete(&lock{}, lockID).Error
+}
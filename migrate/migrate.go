@@ -0,0 +1,393 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"gorm.io/gorm"
+)
+
+// 迁移文件命名：<版本>_<名称>[.<方言>].<up|down>.sql，如 0002_unique_post_likes.up.sql
+// 带方言的文件（mysql / postgres / sqlite）优先于通用文件，用于各数据库语法不同的迁移
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)(?:\.(mysql|postgres|sqlite))?\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string // 升级SQL
+	Down     string // 回滚SQL，为空表示不可回滚
+	Checksum string // 升级SQL的sha256，已执行的迁移被修改时拒绝继续
+	// Backfill 升级SQL之后在同一事务中执行的数据转换，SQL难以表达时使用，见 backfill.go
+	Backfill func(tx *gorm.DB) error
+}
+
+// record 已执行的迁移
+type record struct {
+	Version     int       `gorm:"primaryKey;autoIncrement:false"`
+	Name        string    `gorm:"size:200;not null"`
+	Checksum    string    `gorm:"size:64;not null"`
+	AppliedAt   time.Time `gorm:"not null"`
+	ExecutionMs int64     // 执行耗时，通过 baseline 标记的为0
+}
+
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // 执行后迁移文件又被修改过
+}
+
+// Migrator 按版本顺序执行迁移，每个迁移在一个事务中执行
+// 注意MySQL的DDL会隐式提交事务，执行失败时可能需要手动清理已执行的部分
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	Owner      string // 锁的持有者，默认为 主机名:进程号
+}
+
+// New 加载与数据库方言对应的迁移
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(files, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations {
+		migrations[i].Backfill = backfills[migrations[i].Version]
+	}
+	return &Migrator{db: db, migrations: migrations, Owner: defaultOwner()}, nil
+}
+
+// load 读取 sql 目录下的迁移文件，同一版本的方言文件优先
+func load(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	type sqlFile struct {
+		name, content string
+		dialect       bool
+	}
+	type versionFiles struct {
+		name     string
+		up, down *sqlFile
+	}
+	byVersion := make(map[int]*versionFiles)
+
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", entry.Name())
+		}
+		if match[3] != "" && match[3] != dialect {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, "sql/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		v := byVersion[version]
+		if v == nil {
+			v = &versionFiles{name: match[2]}
+			byVersion[version] = v
+		} else if v.name != match[2] {
+			return nil, fmt.Errorf("迁移版本 %d 重复: %s 和 %s", version, v.name, match[2])
+		}
+		file := &sqlFile{name: entry.Name(), content: string(data), dialect: match[3] != ""}
+		target := &v.up
+		if match[4] == "down" {
+			target = &v.down
+		}
+		// 方言文件覆盖通用文件
+		if *target == nil || file.dialect {
+			*target = file
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, v := range byVersion {
+		if v.up == nil {
+			return nil, fmt.Errorf("迁移 %04d_%s 缺少适用于 %s 的 up 文件", version, v.name, dialect)
+		}
+		m := Migration{Version: version, Name: v.name, Up: v.up.content, Checksum: checksum(v.up.content)}
+		if v.down != nil {
+			m.Down = v.down.content
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// statements 把SQL文件拆分为单条语句：以分号结尾的行结束一条语句，忽略 -- 开头的注释行
+func statements(content string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// Migrations 全部迁移，按版本升序
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+func (m *Migrator) ensureHistory() error {
+	return m.db.AutoMigrate(&record{})
+}
+
+func (m *Migrator) applied() (map[int]record, error) {
+	if err := m.ensureHistory(); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+	var records []record
+	if err := m.db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int]record, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// Status 返回每个迁移的执行状态；数据库中有但文件中没有的版本会返回错误
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool, len(m.migrations))
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := Status{Migration: migration}
+		if r, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = r.AppliedAt
+			status.Modified = r.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	for version, r := range applied {
+		if !known[version] {
+			return statuses, fmt.Errorf("数据库中已执行的迁移 %04d_%s 在当前版本中不存在，请确认程序版本", version, r.Name)
+		}
+	}
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的迁移；已执行的迁移被修改过时返回错误
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if status.Modified {
+			return nil, fmt.Errorf("迁移 %04d_%s 执行后被修改过（校验和不一致），已执行的迁移不能修改，请新增迁移", status.Version, status.Name)
+		}
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up 按顺序执行尚未执行的迁移，to 大于0时只执行到该版本
+func (m *Migrator) Up(to int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		pending, err := m.Pending()
+		if err != nil {
+			return err
+		}
+		if m.legacySchema(pending) {
+			return errLegacySchema
+		}
+		for _, migration := range pending {
+			if to > 0 && migration.Version > to {
+				break
+			}
+			if err := m.apply(migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	start := time.Now()
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(migration.Up) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if migration.Backfill != nil {
+			if err := migration.Backfill(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&record{
+			Version:     migration.Version,
+			Name:        migration.Name,
+			Checksum:    migration.Checksum,
+			AppliedAt:   time.Now(),
+			ExecutionMs: time.Since(start).Milliseconds(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %04d_%s 失败: %v", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// Down 按倒序回滚最近执行的 steps 个迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := statuses[i].Migration
+			if !statuses[i].Applied {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("迁移 %04d_%s 不可回滚", migration.Version, migration.Name)
+			}
+			err := m.db.Transaction(func(tx *gorm.DB) error {
+				for _, statement := range statements(migration.Down) {
+					if err := tx.Exec(statement).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Delete(&record{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("回滚迁移 %04d_%s 失败: %v", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline 把 version 及之前的迁移标记为已执行而不实际执行
+// 用于升级前由 AutoMigrate 建表的已有数据库，只能在没有任何迁移记录时使用
+func (m *Migrator) Baseline(version int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			return fmt.Errorf("数据库已有迁移记录，不能再标记基线")
+		}
+		if !m.db.Migrator().HasTable("users") {
+			return fmt.Errorf("数据库中没有数据表，新数据库请直接执行 migrate up")
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			err := m.db.Create(&record{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+var errLegacySchema = errors.New("数据库由旧版本自动建表创建，还没有迁移记录：请先执行 gin-doniai migrate baseline 标记基线，再执行 gin-doniai migrate up")
+
+// legacySchema 旧版本由 AutoMigrate 建表的数据库没有迁移记录，直接执行基线会因表已存在而失败
+func (m *Migrator) legacySchema(pending []Migration) bool {
+	return len(pending) > 0 && len(pending) == len(m.migrations) && m.db.Migrator().HasTable("users")
+}
+
+// CheckOnStartup 启动时检查迁移：没有待执行的迁移时直接返回；
+// auto 为 true 时自动执行，否则拒绝启动并提示执行 migrate up
+func CheckOnStartup(db *gorm.DB, auto bool) ([]Migration, error) {
+	m, err := New(db)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	if m.legacySchema(pending) {
+		return nil, errLegacySchema
+	}
+	if !auto {
+		names := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			names = append(names, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+		return nil, fmt.Errorf("有 %d 个待执行的数据库迁移（%s）：请先执行 gin-doniai migrate up，或设置 database.auto_migrate: true 启动时自动执行",
+			len(pending), strings.Join(names, ", "))
+	}
+	return m.Up(0)
+}
@@ -0,0 +1,223 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"空文件", "", nil},
+		{"只有注释", "-- 说明\n  -- 缩进的注释\n", nil},
+		{"单条语句", "DROP TABLE a;", []string{"DROP TABLE a"}},
+		{
+			name:    "多行语句和注释",
+			content: "-- 建表\nCREATE TABLE a (\n  id INT\n);\n\n-- 索引\nCREATE INDEX idx ON a (id);\n",
+			want:    []string{"CREATE TABLE a (\n  id INT\n)", "CREATE INDEX idx ON a (id)"},
+		},
+		{"最后一条没有分号", "DROP TABLE a;\nDROP TABLE b", []string{"DROP TABLE a", "DROP TABLE b"}},
+		{"行尾空白", "DROP TABLE a;   \r\nDROP TABLE b;\t", []string{"DROP TABLE a", "DROP TABLE b"}},
+		// 只在行尾的分号处拆分，字符串中的分号不受影响
+		{"行内分号", "INSERT INTO a VALUES ('x;y');", []string{"INSERT INTO a VALUES ('x;y')"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statements(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements(%q) = %q, 期望 %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_init.up.sql":                   {Data: []byte("CREATE TABLE a (id INT);")},
+		"sql/0001_init.down.sql":                 {Data: []byte("DROP TABLE a;")},
+		"sql/0002_index.up.sql":                  {Data: []byte("CREATE INDEX generic;")},
+		"sql/0002_index.mysql.up.sql":            {Data: []byte("CREATE INDEX mysql;")},
+		"sql/0002_index.sqlite.up.sql":           {Data: []byte("CREATE INDEX sqlite;")},
+		"sql/0002_index.down.sql":                {Data: []byte("DROP INDEX generic;")},
+		"sql/0002_index.mysql.down.sql":          {Data: []byte("DROP INDEX mysql;")},
+		"sql/0010_postgres_only.postgres.up.sql": {Data: []byte("CREATE EXTENSION x;")},
+		"sql/0010_postgres_only.up.sql":          {Data: []byte("SELECT 1;")},
+	}
+
+	tests := []struct {
+		dialect string
+		up      []string
+		down    []string
+	}{
+		{"sqlite", []string{"CREATE TABLE a (id INT);", "CREATE INDEX sqlite;", "SELECT 1;"}, []string{"DROP TABLE a;", "DROP INDEX generic;", ""}},
+		{"mysql", []string{"CREATE TABLE a (id INT);", "CREATE INDEX mysql;", "SELECT 1;"}, []string{"DROP TABLE a;", "DROP INDEX mysql;", ""}},
+		{"postgres", []string{"CREATE TABLE a (id INT);", "CREATE INDEX generic;", "CREATE EXTENSION x;"}, []string{"DROP TABLE a;", "DROP INDEX generic;", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			migrations, err := load(fsys, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versions []int
+			var up, down []string
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+				up = append(up, m.Up)
+				down = append(down, m.Down)
+				if m.Checksum != checksum(m.Up) {
+					t.Errorf("迁移 %d 的校验和 = %s, 期望为 up 文件的校验和", m.Version, m.Checksum)
+				}
+			}
+			if !reflect.DeepEqual(versions, []int{1, 2, 10}) || !reflect.DeepEqual(up, tt.up) || !reflect.DeepEqual(down, tt.down) {
+				t.Errorf("load(%s) 版本 %v\nup %q\ndown %q", tt.dialect, versions, up, down)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{"文件名格式错误", fstest.MapFS{"sql/init.sql": {}}, "文件名格式错误"},
+		{"大写名称", fstest.MapFS{"sql/0001_Init.up.sql": {}}, "文件名格式错误"},
+		{"未知方言", fstest.MapFS{"sql/0001_init.oracle.up.sql": {}}, "文件名格式错误"},
+		{"版本重复", fstest.MapFS{"sql/0001_a.up.sql": {}, "sql/0001_b.up.sql": {}}, "重复"},
+		{"缺少up文件", fstest.MapFS{"sql/0001_a.down.sql": {}}, "缺少适用于 sqlite 的 up 文件"},
+		{"方言文件只有down", fstest.MapFS{"sql/0001_a.up.sql": {}, "sql/0002_b.sqlite.down.sql": {}}, "0002_b 缺少适用于 sqlite 的 up 文件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.files, "sqlite")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("load() 错误 = %v, 期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	db := openSQLite(t)
+	migrations, err := load(fstest.MapFS{
+		"sql/0001_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"sql/0001_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"sql/0002_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);")},
+	}, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Migrator{db: db, migrations: migrations, Owner: "test"}
+	if done, err := m.Up(1); err != nil || len(done) != 1 {
+		t.Fatalf("Up(1) = %d 个, %v", len(done), err)
+	}
+
+	// 修改已执行的迁移后拒绝继续
+	m.migrations[0].Up = "CREATE TABLE a (id INTEGER, name TEXT);"
+	m.migrations[0].Checksum = checksum(m.migrations[0].Up)
+	statuses, err := m.Status()
+	if err != nil || !statuses[0].Modified || statuses[1].Modified {
+		t.Errorf("Status() = %+v, %v, 期望只有0001被修改", statuses, err)
+	}
+	if _, err := m.Up(0); err == nil || !strings.Contains(err.Error(), "校验和不一致") {
+		t.Errorf("Up() 错误 = %v, 期望校验和不一致", err)
+	}
+	if db.Migrator().HasTable("b") {
+		t.Error("校验和不一致时不应执行后续迁移")
+	}
+
+	// 数据库中有但文件中没有的版本
+	m.migrations = m.migrations[1:]
+	if _, err := m.Pending(); err == nil || !strings.Contains(err.Error(), "在当前版本中不存在") {
+		t.Errorf("Pending() 错误 = %v, 期望提示迁移不存在", err)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	db := openSQLite(t)
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := m.Up(0)
+	if err != nil || len(done) != len(m.Migrations()) {
+		t.Fatalf("Up() = %d 个, %v", len(done), err)
+	}
+	if pending, err := m.Pending(); err != nil || len(pending) != 0 {
+		t.Errorf("Pending() = %v, %v, 期望全部已执行", pending, err)
+	}
+
+	if _, err := m.Down(len(done)); err != nil {
+		t.Fatalf("Down() 失败: %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("回滚后 users 表仍然存在")
+	}
+	if pending, _ := m.Pending(); len(pending) != len(done) {
+		t.Errorf("回滚后待执行 %d 个, 期望 %d 个", len(pending), len(done))
+	}
+}
+
+func TestGenerateBaseline(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		up, down, err := GenerateBaseline(dialect)
+		if err != nil {
+			t.Fatalf("GenerateBaseline(%s) 失败: %v", dialect, err)
+		}
+		for _, model := range []string{"users", "posts", "webhook_deliveries", "post_imports"} {
+			if !strings.Contains(up, model) || !strings.Contains(down, "DROP TABLE IF EXISTS "+model+";") {
+				t.Errorf("%s 基线中缺少 %s 表", dialect, model)
+			}
+		}
+		// 被引用的 users 表最先创建、最后删除
+		if !strings.HasSuffix(down, "DROP TABLE IF EXISTS users;\n") {
+			t.Errorf("%s 基线的 down 最后应删除 users 表:\n%s", dialect, down)
+		}
+	}
+	if _, _, err := GenerateBaseline("oracle"); err == nil {
+		t.Error("GenerateBaseline(oracle) 期望返回错误")
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	db := openSQLite(t)
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Baseline(1); err == nil {
+		t.Error("空数据库标记基线期望返回错误")
+	}
+
+	// 模拟旧版本由 AutoMigrate 建表的数据库
+	up, _, err := GenerateBaseline("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range statements(up) {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("执行基线SQL失败: %v\n%s", err, statement)
+		}
+	}
+	if _, err := m.Up(0); !errors.Is(err, errLegacySchema) {
+		t.Fatalf("Up() 错误 = %v, 期望提示先标记基线", err)
+	}
+
+	done, err := m.Baseline(1)
+	if err != nil || len(done) != 1 || done[0].Version != 1 {
+		t.Fatalf("Baseline(1) = %+v, %v", done, err)
+	}
+	if _, err := m.Baseline(1); err == nil {
+		t.Error("重复标记基线期望返回错误")
+	}
+	if done, err := m.Up(0); err != nil || len(done) != len(m.Migrations())-1 {
+		t.Errorf("标记基线后 Up() = %d 个, %v", len(done), err)
+	}
+}
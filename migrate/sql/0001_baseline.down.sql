@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS post_imports;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS post_relations;
+DROP TABLE IF EXISTS post_view_visitors;
+DROP TABLE IF EXISTS post_view_stats;
+DROP TABLE IF EXISTS site_stat_snapshots;
+DROP TABLE IF EXISTS account_deletions;
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS email_verification;
+DROP TABLE IF EXISTS password_reset;
+DROP TABLE IF EXISTS user_online_status;
+DROP TABLE IF EXISTS post_favorites;
+DROP TABLE IF EXISTS post_likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
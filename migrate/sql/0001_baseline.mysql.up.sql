@@ -0,0 +1,20 @@
+-- 由 gin-doniai migrate baseline -sql -driver mysql 生成，与旧版本 AutoMigrate 创建的表结构一致
+CREATE TABLE `users` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(100) NOT NULL,`email` varchar(100) NOT NULL,`password` varchar(255) NOT NULL,`avatar` varchar(255) NOT NULL,`age` bigint DEFAULT 0,`level` bigint DEFAULT 1,`agree_terms` boolean DEFAULT false,`email_status` bigint DEFAULT 1,`role` bigint DEFAULT 1,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,`motto` longtext,`github` longtext,`google_account` longtext,PRIMARY KEY (`id`),UNIQUE INDEX `idx_users_email` (`email`),INDEX `idx_users_deleted_at` (`deleted_at`));
+CREATE TABLE `categories` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(40) NOT NULL,`alias` varchar(40),`is_recommended` boolean DEFAULT false,`recommend_rank` bigint DEFAULT 0,`status_code` bigint DEFAULT 1,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_categories_deleted_at` (`deleted_at`));
+CREATE TABLE `posts` (`id` bigint unsigned AUTO_INCREMENT,`title` varchar(200) NOT NULL,`user_id` bigint unsigned NOT NULL,`author` varchar(40) NOT NULL,`category` varchar(100) NOT NULL,`category_id` bigint DEFAULT 0,`content` text NOT NULL,`tags` varchar(255) NOT NULL,`views` bigint DEFAULT 0,`replies` bigint DEFAULT 0,`favorites` bigint DEFAULT 0,`likes` bigint DEFAULT 0,`read_limit` bigint DEFAULT 1,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_posts_deleted_at` (`deleted_at`),CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE TABLE `comments` (`id` bigint unsigned AUTO_INCREMENT,`content` text NOT NULL,`post_id` bigint unsigned NOT NULL,`user_id` bigint unsigned NOT NULL,`parent_id` bigint unsigned DEFAULT 0,`is_recommended` boolean DEFAULT false,`recommend_rank` bigint DEFAULT 0,`status_code` bigint DEFAULT 1,`like_count` bigint DEFAULT 0,`dislike_count` bigint DEFAULT 0,`reply_count` bigint DEFAULT 0,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_comments_deleted_at` (`deleted_at`),CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE TABLE `post_likes` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint NOT NULL,`post_id` bigint NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`));
+CREATE TABLE `post_favorites` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint NOT NULL,`post_id` bigint NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`));
+CREATE TABLE `user_online_status` (`user_id` bigint unsigned AUTO_INCREMENT,`last_active_time` datetime(3) NULL DEFAULT CURRENT_TIMESTAMP,`session_id` varchar(255),`ip_address` varchar(45),`user_agent` text,PRIMARY KEY (`user_id`),CONSTRAINT `fk_user_online_status_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE TABLE `password_reset` (`id` bigint unsigned AUTO_INCREMENT,`email` longtext NOT NULL,`token` longtext NOT NULL,`expires_at` datetime(3) NOT NULL,`used` boolean DEFAULT false,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_password_reset_token` (`token`),INDEX `idx_password_reset_deleted_at` (`deleted_at`));
+CREATE TABLE `email_verification` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`email` varchar(100) NOT NULL,`purpose` varchar(20) NOT NULL,`token` longtext NOT NULL,`expires_at` datetime(3) NOT NULL,`used` boolean DEFAULT false,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_email_verification_user_id` (`user_id`),UNIQUE INDEX `idx_email_verification_token` (`token`),INDEX `idx_email_verification_deleted_at` (`deleted_at`));
+CREATE TABLE `api_tokens` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`name` varchar(100) NOT NULL,`token_hash` varchar(64) NOT NULL,`prefix` varchar(16) NOT NULL,`scopes` varchar(255) NOT NULL,`expires_at` datetime(3) NULL,`last_used_at` datetime(3) NULL,`last_used_ip` varchar(45),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_api_tokens_user_id` (`user_id`),UNIQUE INDEX `idx_api_tokens_token_hash` (`token_hash`),INDEX `idx_api_tokens_deleted_at` (`deleted_at`));
+CREATE TABLE `data_exports` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`status` bigint DEFAULT 1,`file_path` varchar(255),`file_size` bigint DEFAULT 0,`error` varchar(255),`expires_at` datetime(3) NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_data_exports_user_id` (`user_id`));
+CREATE TABLE `account_deletions` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`status` bigint DEFAULT 1,`scheduled_at` datetime(3) NOT NULL,`completed_at` datetime(3) NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_account_deletions_user_id` (`user_id`));
+CREATE TABLE `site_stat_snapshots` (`id` bigint unsigned AUTO_INCREMENT,`date` varchar(10) NOT NULL,`user_count` bigint DEFAULT 0,`post_count` bigint DEFAULT 0,`comment_count` bigint DEFAULT 0,`online_peak` bigint DEFAULT 0,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_site_stat_snapshots_date` (`date`));
+CREATE TABLE `post_view_stats` (`id` bigint unsigned AUTO_INCREMENT,`post_id` bigint unsigned NOT NULL,`date` varchar(10) NOT NULL,`views` bigint DEFAULT 0,`unique_visitors` bigint DEFAULT 0,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_post_view_stat_day` (`post_id`,`date`));
+CREATE TABLE `post_view_visitors` (`id` bigint unsigned AUTO_INCREMENT,`post_id` bigint unsigned NOT NULL,`date` varchar(10) NOT NULL,`visitor_hash` varchar(64) NOT NULL,`last_view_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_post_view_visitor` (`post_id`,`date`,`visitor_hash`));
+CREATE TABLE `post_relations` (`id` bigint unsigned AUTO_INCREMENT,`post_id` bigint unsigned NOT NULL,`related_id` bigint unsigned NOT NULL,`score` double,`reasons` text,`position` bigint,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_post_relations_post_id` (`post_id`));
+CREATE TABLE `webhooks` (`id` bigint unsigned AUTO_INCREMENT,`url` varchar(500) NOT NULL,`secret` varchar(100) NOT NULL,`events` varchar(255) NOT NULL,`description` varchar(255),`active` boolean DEFAULT true,`created_by` bigint unsigned,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,`deleted_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_webhooks_deleted_at` (`deleted_at`));
+CREATE TABLE `webhook_deliveries` (`id` bigint unsigned AUTO_INCREMENT,`webhook_id` bigint unsigned NOT NULL,`event` varchar(50) NOT NULL,`delivery_id` varchar(64) NOT NULL,`payload` text NOT NULL,`status` bigint DEFAULT 1,`attempts` bigint DEFAULT 0,`next_attempt_at` datetime(3) NULL,`response_status` bigint,`response_body` text,`error` varchar(500),`duration_ms` bigint,`redelivery_of` bigint unsigned DEFAULT 0,`delivered_at` datetime(3) NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),UNIQUE INDEX `idx_webhook_deliveries_delivery_id` (`delivery_id`),INDEX `idx_delivery_due` (`status`,`next_attempt_at`));
+CREATE TABLE `post_imports` (`id` bigint unsigned AUTO_INCREMENT,`source` varchar(20) NOT NULL,`source_key` varchar(191) NOT NULL,`post_id` bigint unsigned NOT NULL,`content_hash` varchar(64) NOT NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_import_source_key` (`source`,`source_key`),INDEX `idx_post_imports_post_id` (`post_id`));
@@ -0,0 +1,45 @@
+-- 由 gin-doniai migrate baseline -sql -driver postgres 生成，与旧版本 AutoMigrate 创建的表结构一致
+CREATE TABLE "users" ("id" bigserial,"name" varchar(100) NOT NULL,"email" varchar(100) NOT NULL,"password" varchar(255) NOT NULL,"avatar" varchar(255) NOT NULL,"age" bigint DEFAULT 0,"level" bigint DEFAULT 1,"agree_terms" boolean DEFAULT false,"email_status" bigint DEFAULT 1,"role" bigint DEFAULT 1,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,"motto" text,"github" text,"google_account" text,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE TABLE "categories" ("id" bigserial,"name" varchar(40) NOT NULL,"alias" varchar(40),"is_recommended" boolean DEFAULT false,"recommend_rank" bigint DEFAULT 0,"status_code" bigint DEFAULT 1,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_categories_deleted_at" ON "categories" ("deleted_at");
+CREATE TABLE "posts" ("id" bigserial,"title" varchar(200) NOT NULL,"user_id" bigint NOT NULL,"author" varchar(40) NOT NULL,"category" varchar(100) NOT NULL,"category_id" bigint DEFAULT 0,"content" text NOT NULL,"tags" varchar(255) NOT NULL,"views" bigint DEFAULT 0,"replies" bigint DEFAULT 0,"favorites" bigint DEFAULT 0,"likes" bigint DEFAULT 0,"read_limit" bigint DEFAULT 1,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_posts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"));
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+CREATE TABLE "comments" ("id" bigserial,"content" text NOT NULL,"post_id" bigint NOT NULL,"user_id" bigint NOT NULL,"parent_id" bigint DEFAULT 0,"is_recommended" boolean DEFAULT false,"recommend_rank" bigint DEFAULT 0,"status_code" bigint DEFAULT 1,"like_count" bigint DEFAULT 0,"dislike_count" bigint DEFAULT 0,"reply_count" bigint DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_comments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"));
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE TABLE "post_likes" ("id" bigserial,"user_id" bigint NOT NULL,"post_id" bigint NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE TABLE "post_favorites" ("id" bigserial,"user_id" bigint NOT NULL,"post_id" bigint NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE TABLE "user_online_status" ("user_id" bigserial,"last_active_time" timestamptz DEFAULT CURRENT_TIMESTAMP,"session_id" varchar(255),"ip_address" varchar(45),"user_agent" text,PRIMARY KEY ("user_id"),CONSTRAINT "fk_user_online_status_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"));
+CREATE TABLE "password_reset" ("id" bigserial,"email" text NOT NULL,"token" text NOT NULL,"expires_at" timestamptz NOT NULL,"used" boolean DEFAULT false,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_password_reset_deleted_at" ON "password_reset" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_token" ON "password_reset" ("token");
+CREATE TABLE "email_verification" ("id" bigserial,"user_id" bigint NOT NULL,"email" varchar(100) NOT NULL,"purpose" varchar(20) NOT NULL,"token" text NOT NULL,"expires_at" timestamptz NOT NULL,"used" boolean DEFAULT false,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_email_verification_deleted_at" ON "email_verification" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_verification_token" ON "email_verification" ("token");
+CREATE INDEX IF NOT EXISTS "idx_email_verification_user_id" ON "email_verification" ("user_id");
+CREATE TABLE "api_tokens" ("id" bigserial,"user_id" bigint NOT NULL,"name" varchar(100) NOT NULL,"token_hash" varchar(64) NOT NULL,"prefix" varchar(16) NOT NULL,"scopes" varchar(255) NOT NULL,"expires_at" timestamptz,"last_used_at" timestamptz,"last_used_ip" varchar(45),"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_deleted_at" ON "api_tokens" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_tokens_token_hash" ON "api_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_user_id" ON "api_tokens" ("user_id");
+CREATE TABLE "data_exports" ("id" bigserial,"user_id" bigint NOT NULL,"status" bigint DEFAULT 1,"file_path" varchar(255),"file_size" bigint DEFAULT 0,"error" varchar(255),"expires_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_data_exports_user_id" ON "data_exports" ("user_id");
+CREATE TABLE "account_deletions" ("id" bigserial,"user_id" bigint NOT NULL,"status" bigint DEFAULT 1,"scheduled_at" timestamptz NOT NULL,"completed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_account_deletions_user_id" ON "account_deletions" ("user_id");
+CREATE TABLE "site_stat_snapshots" ("id" bigserial,"date" varchar(10) NOT NULL,"user_count" bigint DEFAULT 0,"post_count" bigint DEFAULT 0,"comment_count" bigint DEFAULT 0,"online_peak" bigint DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_site_stat_snapshots_date" ON "site_stat_snapshots" ("date");
+CREATE TABLE "post_view_stats" ("id" bigserial,"post_id" bigint NOT NULL,"date" varchar(10) NOT NULL,"views" bigint DEFAULT 0,"unique_visitors" bigint DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_view_stat_day" ON "post_view_stats" ("post_id","date");
+CREATE TABLE "post_view_visitors" ("id" bigserial,"post_id" bigint NOT NULL,"date" varchar(10) NOT NULL,"visitor_hash" varchar(64) NOT NULL,"last_view_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_view_visitor" ON "post_view_visitors" ("post_id","date","visitor_hash");
+CREATE TABLE "post_relations" ("id" bigserial,"post_id" bigint NOT NULL,"related_id" bigint NOT NULL,"score" decimal,"reasons" text,"position" bigint,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_post_relations_post_id" ON "post_relations" ("post_id");
+CREATE TABLE "webhooks" ("id" bigserial,"url" varchar(500) NOT NULL,"secret" varchar(100) NOT NULL,"events" varchar(255) NOT NULL,"description" varchar(255),"active" boolean DEFAULT true,"created_by" bigint,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_webhooks_deleted_at" ON "webhooks" ("deleted_at");
+CREATE TABLE "webhook_deliveries" ("id" bigserial,"webhook_id" bigint NOT NULL,"event" varchar(50) NOT NULL,"delivery_id" varchar(64) NOT NULL,"payload" text NOT NULL,"status" bigint DEFAULT 1,"attempts" bigint DEFAULT 0,"next_attempt_at" timestamptz,"response_status" bigint,"response_body" text,"error" varchar(500),"duration_ms" bigint,"redelivery_of" bigint DEFAULT 0,"delivered_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_deliveries_delivery_id" ON "webhook_deliveries" ("delivery_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+CREATE TABLE "post_imports" ("id" bigserial,"source" varchar(20) NOT NULL,"source_key" varchar(191) NOT NULL,"post_id" bigint NOT NULL,"content_hash" varchar(64) NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_post_imports_post_id" ON "post_imports" ("post_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_import_source_key" ON "post_imports" ("source","source_key");
@@ -0,0 +1,45 @@
+-- 由 gin-doniai migrate baseline -sql -driver sqlite 生成，与旧版本 AutoMigrate 创建的表结构一致
+CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`email` text NOT NULL,`password` text NOT NULL,`avatar` text NOT NULL,`age` integer DEFAULT 0,`level` integer DEFAULT 1,`agree_terms` numeric DEFAULT false,`email_status` integer DEFAULT 1,`role` integer DEFAULT 1,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`motto` text,`github` text,`google_account` text);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_users_email` ON `users`(`email`);
+CREATE TABLE `categories` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`alias` text,`is_recommended` numeric DEFAULT false,`recommend_rank` integer DEFAULT 0,`status_code` integer DEFAULT 1,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_categories_deleted_at` ON `categories`(`deleted_at`);
+CREATE TABLE `posts` (`id` integer PRIMARY KEY AUTOINCREMENT,`title` text NOT NULL,`user_id` integer NOT NULL,`author` text NOT NULL,`category` text NOT NULL,`category_id` integer DEFAULT 0,`content` text NOT NULL,`tags` text NOT NULL,`views` integer DEFAULT 0,`replies` integer DEFAULT 0,`favorites` integer DEFAULT 0,`likes` integer DEFAULT 0,`read_limit` integer DEFAULT 1,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_posts_deleted_at` ON `posts`(`deleted_at`);
+CREATE TABLE `comments` (`id` integer PRIMARY KEY AUTOINCREMENT,`content` text NOT NULL,`post_id` integer NOT NULL,`user_id` integer NOT NULL,`parent_id` integer DEFAULT 0,`is_recommended` numeric DEFAULT false,`recommend_rank` integer DEFAULT 0,`status_code` integer DEFAULT 1,`like_count` integer DEFAULT 0,`dislike_count` integer DEFAULT 0,`reply_count` integer DEFAULT 0,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+CREATE TABLE `post_likes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`post_id` integer NOT NULL,`created_at` datetime);
+CREATE TABLE `post_favorites` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`post_id` integer NOT NULL,`created_at` datetime);
+CREATE TABLE `user_online_status` (`user_id` integer PRIMARY KEY AUTOINCREMENT,`last_active_time` datetime DEFAULT CURRENT_TIMESTAMP,`session_id` text,`ip_address` text,`user_agent` text,CONSTRAINT `fk_user_online_status_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE TABLE `password_reset` (`id` integer PRIMARY KEY AUTOINCREMENT,`email` text NOT NULL,`token` text NOT NULL,`expires_at` datetime NOT NULL,`used` numeric DEFAULT false,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_password_reset_deleted_at` ON `password_reset`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_password_reset_token` ON `password_reset`(`token`);
+CREATE TABLE `email_verification` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`email` text NOT NULL,`purpose` text NOT NULL,`token` text NOT NULL,`expires_at` datetime NOT NULL,`used` numeric DEFAULT false,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_email_verification_deleted_at` ON `email_verification`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_email_verification_token` ON `email_verification`(`token`);
+CREATE INDEX `idx_email_verification_user_id` ON `email_verification`(`user_id`);
+CREATE TABLE `api_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`name` text NOT NULL,`token_hash` text NOT NULL,`prefix` text NOT NULL,`scopes` text NOT NULL,`expires_at` datetime,`last_used_at` datetime,`last_used_ip` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_api_tokens_deleted_at` ON `api_tokens`(`deleted_at`);
+CREATE UNIQUE INDEX `idx_api_tokens_token_hash` ON `api_tokens`(`token_hash`);
+CREATE INDEX `idx_api_tokens_user_id` ON `api_tokens`(`user_id`);
+CREATE TABLE `data_exports` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`status` integer DEFAULT 1,`file_path` text,`file_size` integer DEFAULT 0,`error` text,`expires_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_data_exports_user_id` ON `data_exports`(`user_id`);
+CREATE TABLE `account_deletions` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`status` integer DEFAULT 1,`scheduled_at` datetime NOT NULL,`completed_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_account_deletions_user_id` ON `account_deletions`(`user_id`);
+CREATE TABLE `site_stat_snapshots` (`id` integer PRIMARY KEY AUTOINCREMENT,`date` text NOT NULL,`user_count` integer DEFAULT 0,`post_count` integer DEFAULT 0,`comment_count` integer DEFAULT 0,`online_peak` integer DEFAULT 0,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX `idx_site_stat_snapshots_date` ON `site_stat_snapshots`(`date`);
+CREATE TABLE `post_view_stats` (`id` integer PRIMARY KEY AUTOINCREMENT,`post_id` integer NOT NULL,`date` text NOT NULL,`views` integer DEFAULT 0,`unique_visitors` integer DEFAULT 0,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX `idx_post_view_stat_day` ON `post_view_stats`(`post_id`,`date`);
+CREATE TABLE `post_view_visitors` (`id` integer PRIMARY KEY AUTOINCREMENT,`post_id` integer NOT NULL,`date` text NOT NULL,`visitor_hash` text NOT NULL,`last_view_at` datetime,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_post_view_visitor` ON `post_view_visitors`(`post_id`,`date`,`visitor_hash`);
+CREATE TABLE `post_relations` (`id` integer PRIMARY KEY AUTOINCREMENT,`post_id` integer NOT NULL,`related_id` integer NOT NULL,`score` real,`reasons` text,`position` integer,`created_at` datetime);
+CREATE INDEX `idx_post_relations_post_id` ON `post_relations`(`post_id`);
+CREATE TABLE `webhooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`url` text NOT NULL,`secret` text NOT NULL,`events` text NOT NULL,`description` text,`active` numeric DEFAULT true,`created_by` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX `idx_webhooks_deleted_at` ON `webhooks`(`deleted_at`);
+CREATE TABLE `webhook_deliveries` (`id` integer PRIMARY KEY AUTOINCREMENT,`webhook_id` integer NOT NULL,`event` text NOT NULL,`delivery_id` text NOT NULL,`payload` text NOT NULL,`status` integer DEFAULT 1,`attempts` integer DEFAULT 0,`next_attempt_at` datetime,`response_status` integer,`response_body` text,`error` text,`duration_ms` integer,`redelivery_of` integer DEFAULT 0,`delivered_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_delivery_due` ON `webhook_deliveries`(`status`,`next_attempt_at`);
+CREATE UNIQUE INDEX `idx_webhook_deliveries_delivery_id` ON `webhook_deliveries`(`delivery_id`);
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+CREATE TABLE `post_imports` (`id` integer PRIMARY KEY AUTOINCREMENT,`source` text NOT NULL,`source_key` text NOT NULL,`post_id` integer NOT NULL,`content_hash` text NOT NULL,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_post_imports_post_id` ON `post_imports`(`post_id`);
+CREATE UNIQUE INDEX `idx_import_source_key` ON `post_imports`(`source`,`source_key`);
@@ -0,0 +1,2 @@
+DROP INDEX idx_post_likes_user_post;
+DROP INDEX idx_post_favorites_user_post;
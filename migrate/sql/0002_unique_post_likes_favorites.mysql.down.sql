@@ -0,0 +1,2 @@
+DROP INDEX idx_post_likes_user_post ON post_likes;
+DROP INDEX idx_post_favorites_user_post ON post_favorites;
@@ -0,0 +1,9 @@
+-- 每个用户对每篇文章只能点赞、收藏一次：先删除重复记录（保留最早的一条），再加唯一索引
+DELETE FROM post_likes WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM post_likes GROUP BY user_id, post_id) AS keep_likes);
+DELETE FROM post_favorites WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM post_favorites GROUP BY user_id, post_id) AS keep_favorites);
+CREATE UNIQUE INDEX idx_post_likes_user_post ON post_likes (user_id, post_id);
+CREATE UNIQUE INDEX idx_post_favorites_user_post ON post_favorites (user_id, post_id);
+
+-- 按去重后的记录重新计算文章的点赞数和收藏数
+UPDATE posts SET likes = (SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id);
+UPDATE posts SET favorites = (SELECT COUNT(*) FROM post_favorites WHERE post_favorites.post_id = posts.id);
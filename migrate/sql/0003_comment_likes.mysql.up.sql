@@ -0,0 +1,2 @@
+-- 评论点赞记录，每个用户对每条评论只能点赞一次
+CREATE TABLE `comment_likes` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned NOT NULL,`comment_id` bigint unsigned NOT NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_comment_likes_user_comment` (`user_id`,`comment_id`),INDEX `idx_comment_likes_comment_id` (`comment_id`));
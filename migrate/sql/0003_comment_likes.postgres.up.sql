@@ -0,0 +1,4 @@
+-- 评论点赞记录，每个用户对每条评论只能点赞一次
+CREATE TABLE "comment_likes" ("id" bigserial,"user_id" bigint NOT NULL,"comment_id" bigint NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_comment_likes_user_comment" ON "comment_likes" ("user_id","comment_id");
+CREATE INDEX "idx_comment_likes_comment_id" ON "comment_likes" ("comment_id");
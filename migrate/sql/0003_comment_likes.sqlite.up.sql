@@ -0,0 +1,4 @@
+-- 评论点赞记录，每个用户对每条评论只能点赞一次
+CREATE TABLE `comment_likes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`comment_id` integer NOT NULL,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_comment_likes_user_comment` ON `comment_likes`(`user_id`,`comment_id`);
+CREATE INDEX `idx_comment_likes_comment_id` ON `comment_likes`(`comment_id`);
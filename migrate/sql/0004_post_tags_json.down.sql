@@ -0,0 +1 @@
+-- JSON数组格式的标签旧版本同样可以解析，回滚时保持不变
@@ -0,0 +1 @@
+-- 文章标签统一保存为JSON数组，逗号分隔的旧数据由 backfill.go 中的 backfillPostTagsJSON 转换
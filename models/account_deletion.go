@@ -0,0 +1,30 @@
+package models
+
+import (
+    "time"
+)
+
+// 账户注销状态
+const (
+    DeletionStatusPending   = 1 // 冷静期中
+    DeletionStatusCanceled  = 2 // 已撤销
+    DeletionStatusCompleted = 3 // 已完成
+)
+
+// AccountDeletionGracePeriod 注销冷静期，期间可以撤销
+const AccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+type AccountDeletion struct {
+    ID          uint       `json:"id" gorm:"primaryKey"`
+    UserID      uint       `json:"user_id" gorm:"not null;index"`
+    Status      int        `json:"status" gorm:"default:1"` // 1:冷静期中 2:已撤销 3:已完成
+    ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null"` // 到期后执行删除
+    CompletedAt *time.Time `json:"completed_at"`
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// 表名
+func (AccountDeletion) TableName() string {
+    return "account_deletions"
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// API令牌权限范围
+const (
+    ScopeRead          = "read"
+    ScopeWritePosts    = "write:posts"
+    ScopeWriteComments = "write:comments"
+    ScopeAdmin         = "admin"
+)
+
+// AllScopes 全部可用的权限范围
+var AllScopes = []string{ScopeRead, ScopeWritePosts, ScopeWriteComments, ScopeAdmin}
+
+type APIToken struct {
+    ID         uint           `json:"id" gorm:"primaryKey"`
+    UserID     uint           `json:"user_id" gorm:"not null;index"`
+    Name       string         `json:"name" gorm:"size:100;not null"`
+    TokenHash  string         `json:"-" gorm:"size:64;not null;uniqueIndex"` // 只保存令牌的SHA-256摘要
+    Prefix     string         `json:"prefix" gorm:"size:16;not null"`        // 令牌前缀，用于在列表中辨认
+    Scopes     string         `json:"scopes" gorm:"size:255;not null"`       // 逗号分隔的权限范围
+    ExpiresAt  *time.Time     `json:"expires_at"`                            // 为空表示永不过期
+    LastUsedAt *time.Time     `json:"last_used_at"`
+    LastUsedIP string         `json:"last_used_ip" gorm:"size:45"`
+    CreatedAt  time.Time      `json:"created_at"`
+    UpdatedAt  time.Time      `json:"updated_at"`
+    DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// 表名
+func (APIToken) TableName() string {
+    return "api_tokens"
+}
+
+// ScopeList 返回权限范围列表
+func (t *APIToken) ScopeList() []string {
+    var scopes []string
+    for _, scope := range strings.Split(t.Scopes, ",") {
+        if scope = strings.TrimSpace(scope); scope != "" {
+            scopes = append(scopes, scope)
+        }
+    }
+    return scopes
+}
+
+// HasScope 判断令牌是否拥有指定权限，admin 拥有全部权限
+func (t *APIToken) HasScope(scope string) bool {
+    for _, s := range t.ScopeList() {
+        if s == scope || s == ScopeAdmin {
+            return true
+        }
+    }
+    return false
+}
+
+// IsExpired 令牌是否已过期
+func (t *APIToken) IsExpired() bool {
+    return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+    "time"
+)
+
+// CommentLike 评论点赞记录，(user_id, comment_id) 的唯一索引由迁移 0003 创建
+// 迁移之前的评论点赞没有记录，已有的 like_count 保持不变
+type CommentLike struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null" json:"user_id"`
+    CommentID uint      `gorm:"not null" json:"comment_id"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CommentLike) TableName() string {
+    return "comment_likes"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+    "time"
+)
+
+// 数据导出状态
+const (
+    ExportStatusPending    = 1 // 排队中
+    ExportStatusProcessing = 2 // 生成中
+    ExportStatusDone       = 3 // 已完成
+    ExportStatusFailed     = 4 // 失败
+)
+
+type DataExport struct {
+    ID        uint       `json:"id" gorm:"primaryKey"`
+    UserID    uint       `json:"user_id" gorm:"not null;index"`
+    Status    int        `json:"status" gorm:"default:1"` // 1:排队中 2:生成中 3:已完成 4:失败
+    FilePath  string     `json:"-" gorm:"size:255"`
+    FileSize  int64      `json:"file_size" gorm:"default:0"`
+    Error     string     `json:"error" gorm:"size:255"`
+    ExpiresAt *time.Time `json:"expires_at"` // 导出文件过期时间，过期后删除文件
+    CreatedAt time.Time  `json:"created_at"`
+    UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// 表名
+func (DataExport) TableName() string {
+    return "data_exports"
+}
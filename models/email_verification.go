@@ -0,0 +1,31 @@
+// models/email_verification.go
+package models
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+// 邮箱验证用途
+const (
+    EmailVerifyPurposeRegister = "register"     // 注册时验证邮箱
+    EmailVerifyPurposeChange   = "change_email" // 修改邮箱时验证新邮箱
+)
+
+type EmailVerification struct {
+    ID        uint           `gorm:"primaryKey" json:"id"`
+    UserID    uint           `gorm:"not null;index" json:"user_id"`
+    Email     string         `gorm:"size:100;not null" json:"email"`  // 待验证的邮箱
+    Purpose   string         `gorm:"size:20;not null" json:"purpose"` // register / change_email
+    Token     string         `gorm:"not null;uniqueIndex" json:"token"`
+    ExpiresAt time.Time      `gorm:"not null" json:"expires_at"`
+    Used      bool           `gorm:"default:false" json:"used"`
+    CreatedAt time.Time      `json:"created_at"`
+    UpdatedAt time.Time      `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// 表名
+func (EmailVerification) TableName() string {
+    return "email_verification"
+}
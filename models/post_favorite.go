@@ -4,6 +4,7 @@ import (
     "time"
 )
 
+// PostFavorite 收藏记录，(user_id, post_id) 的唯一索引由迁移 0002 创建
 type PostFavorite struct {
     ID        uint      `gorm:"primaryKey" json:"id"`
     UserID    int       `gorm:"not null" json:"user_id"`
@@ -0,0 +1,21 @@
+package models
+
+import (
+    "time"
+)
+
+// PostImport 导入来源与文章的对应关系，重复导入同一来源时据此更新或跳过
+type PostImport struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    Source      string    `json:"source" gorm:"size:20;not null;uniqueIndex:idx_import_source_key"` // markdown 或 wxr
+    SourceKey   string    `json:"source_key" gorm:"size:191;not null;uniqueIndex:idx_import_source_key"`
+    PostID      uint      `json:"post_id" gorm:"not null;index"`
+    ContentHash string    `json:"content_hash" gorm:"size:64;not null"` // 导入内容的摘要，未变化时跳过
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// 表名
+func (PostImport) TableName() string {
+    return "post_imports"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+    "time"
+)
+
+// PostRelation 相关文章推荐结果，由后台worker定期计算
+type PostRelation struct {
+    ID        uint      `json:"id" gorm:"primaryKey"`
+    PostID    uint      `json:"post_id" gorm:"not null;index"`
+    RelatedID uint      `json:"related_id" gorm:"not null"`
+    Score     float64   `json:"score"`
+    Reasons   []string  `json:"reasons" gorm:"type:text;serializer:json"` // 推荐理由，如共同标签、内容相似
+    Position  int       `json:"position"`                                   // 在该文章推荐列表中的位置，从0开始
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// 表名
+func (PostRelation) TableName() string {
+    return "post_relations"
+}
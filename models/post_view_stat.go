@@ -0,0 +1,36 @@
+package models
+
+import (
+    "time"
+)
+
+// PostViewStat 文章每日浏览统计
+type PostViewStat struct {
+    ID             uint      `json:"id" gorm:"primaryKey"`
+    PostID         uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_view_stat_day"`
+    Date           string    `json:"date" gorm:"size:10;not null;uniqueIndex:idx_post_view_stat_day"` // 2006-01-02
+    Views          int64     `json:"views" gorm:"default:0"`                                          // 浏览次数（同一访客60秒内只计一次）
+    UniqueVisitors int64     `json:"unique_visitors" gorm:"default:0"`                                // 当日独立访客数
+    CreatedAt      time.Time `json:"created_at"`
+    UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// 表名
+func (PostViewStat) TableName() string {
+    return "post_view_stats"
+}
+
+// PostViewVisitor 文章每日访客记录，用于独立访客去重和跨重启的浏览防刷
+type PostViewVisitor struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    PostID      uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_view_visitor"`
+    Date        string    `json:"date" gorm:"size:10;not null;uniqueIndex:idx_post_view_visitor"`
+    VisitorHash string    `json:"-" gorm:"size:64;not null;uniqueIndex:idx_post_view_visitor"` // 登录用户为用户ID，游客为IP+UA的哈希
+    LastViewAt  time.Time `json:"last_view_at"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// 表名
+func (PostViewVisitor) TableName() string {
+    return "post_view_visitors"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+    "time"
+)
+
+// SiteStatSnapshot 每日社区统计快照，用于趋势图
+type SiteStatSnapshot struct {
+    ID           uint      `json:"id" gorm:"primaryKey"`
+    Date         string    `json:"date" gorm:"size:10;not null;uniqueIndex"` // 2006-01-02
+    UserCount    int64     `json:"user_count" gorm:"default:0"`
+    PostCount    int64     `json:"post_count" gorm:"default:0"`
+    CommentCount int64     `json:"comment_count" gorm:"default:0"`
+    OnlinePeak   int64     `json:"online_peak" gorm:"default:0"` // 当日在线人数峰值
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 表名
+func (SiteStatSnapshot) TableName() string {
+    return "site_stat_snapshots"
+}
@@ -14,6 +14,8 @@ type User struct {
     Age       int            `json:"age" gorm:"default:0"`
     Level     int            `json:"level" gorm:"default:1"`
     AgreeTerms bool          `json:"agree_terms" gorm:"default:false"` // 修改为布尔类型
+    EmailStatus int          `json:"email_status" gorm:"default:1"`    // 1:已验证 2:待验证
+    Role      int            `json:"role" gorm:"default:1"`            // 1:普通用户 2:管理员
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
     DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -27,4 +29,32 @@ type User struct {
 // 表名
 func (User) TableName() string {
     return "users"
-}
\ No newline at end of file
+}
+
+// 邮箱状态
+const (
+    EmailStatusVerified   = 1
+    EmailStatusUnverified = 2
+)
+
+// 账户注销后用于匿名化的展示内容
+const (
+    DeletedUserName       = "已注销用户"
+    DeletedCommentContent = "<p>该评论已随账户注销删除</p>"
+)
+
+// 用户角色
+const (
+    RoleMember = 1
+    RoleAdmin  = 2
+)
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+    return u.Role == RoleAdmin
+}
+
+// IsEmailVerified 邮箱是否已验证（历史用户默认视为已验证）
+func (u *User) IsEmailVerified() bool {
+    return u.EmailStatus != EmailStatusUnverified
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// Webhook事件
+const (
+    EventPostCreated    = "post.created"
+    EventPostUpdated    = "post.updated"
+    EventCommentCreated = "comment.created"
+    EventUserRegistered = "user.registered"
+)
+
+// AllWebhookEvents 全部可订阅的事件
+var AllWebhookEvents = []string{EventPostCreated, EventPostUpdated, EventCommentCreated, EventUserRegistered}
+
+// Webhook 管理员配置的事件订阅
+type Webhook struct {
+    ID          uint           `json:"id" gorm:"primaryKey"`
+    URL         string         `json:"url" gorm:"size:500;not null"`
+    Secret      string         `json:"-" gorm:"size:100;not null"`     // 用于HMAC签名，只在创建时返回一次
+    Events      string         `json:"events" gorm:"size:255;not null"` // 逗号分隔的事件列表
+    Description string         `json:"description" gorm:"size:255"`
+    Active      bool           `json:"active" gorm:"default:true"`
+    CreatedBy   uint           `json:"created_by"`
+    CreatedAt   time.Time      `json:"created_at"`
+    UpdatedAt   time.Time      `json:"updated_at"`
+    DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// 表名
+func (Webhook) TableName() string {
+    return "webhooks"
+}
+
+// EventList 返回订阅的事件列表
+func (w *Webhook) EventList() []string {
+    var events []string
+    for _, event := range strings.Split(w.Events, ",") {
+        if event = strings.TrimSpace(event); event != "" {
+            events = append(events, event)
+        }
+    }
+    return events
+}
+
+// Subscribes 是否订阅了指定事件
+func (w *Webhook) Subscribes(event string) bool {
+    for _, e := range w.EventList() {
+        if e == event {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+    "time"
+)
+
+// 投递状态
+const (
+    DeliveryStatusPending   = 1 // 等待投递（包括等待重试）
+    DeliveryStatusSucceeded = 2 // 对方返回2xx
+    DeliveryStatusFailed    = 3 // 重试次数用完
+)
+
+// WebhookDelivery 一次事件投递及最近一次请求的结果
+type WebhookDelivery struct {
+    ID             uint       `json:"id" gorm:"primaryKey"`
+    WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+    Event          string     `json:"event" gorm:"size:50;not null"`
+    DeliveryID     string     `json:"delivery_id" gorm:"size:64;not null;uniqueIndex"` // 随 X-Doniai-Delivery 请求头发送，每次重新投递都会生成新的ID
+    Payload        string     `json:"payload" gorm:"type:text;not null"`
+    Status         int        `json:"status" gorm:"default:1;index:idx_delivery_due,priority:1"`
+    Attempts       int        `json:"attempts" gorm:"default:0"`
+    NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_delivery_due,priority:2"`
+    ResponseStatus int        `json:"response_status"`
+    ResponseBody   string     `json:"response_body" gorm:"type:text"` // 截断保存
+    Error          string     `json:"error" gorm:"size:500"`
+    DurationMs     int64      `json:"duration_ms"`
+    RedeliveryOf   uint       `json:"redelivery_of" gorm:"default:0"` // 手动重新投递时指向原投递记录
+    DeliveredAt    *time.Time `json:"delivered_at"`
+    CreatedAt      time.Time  `json:"created_at"`
+    UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// 表名
+func (WebhookDelivery) TableName() string {
+    return "webhook_deliveries"
+}
@@ -0,0 +1,75 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// cursor 上一页最后一条记录的排序字段值和ID，对客户端不透明
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// formatValue 把排序字段值转换为游标中保存的字符串
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// PageInfo 列表响应中的分页信息
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Paginate 截掉多查的一条记录并生成下一页游标
+// key 返回记录的排序字段值（与Spec中字段类型一致）和ID
+func Paginate[T any](q *Query, items []T, key func(T) (interface{}, uint)) ([]T, PageInfo) {
+	info := PageInfo{Limit: q.Limit, Sort: q.SortName}
+	if len(items) <= q.Limit {
+		return items, info
+	}
+
+	items = items[:q.Limit]
+	value, id := key(items[len(items)-1])
+	info.HasMore = true
+	info.NextCursor = cursor{Sort: q.SortName, Value: formatValue(value), ID: id}.encode()
+	return items, info
+}
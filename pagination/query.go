@@ -0,0 +1,224 @@
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"gorm.io/gorm"
+)
+
+// Kind 字段值类型，决定查询参数和游标值如何解析
+type Kind int
+
+const (
+	KindInt Kind = iota
+	KindString
+	KindTime
+	KindBool
+)
+
+// Field 允许排序的字段
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// 过滤操作
+const (
+	OpEq       = "="
+	OpContains = "contains" // LIKE %value%
+	OpElement  = "element"  // JSON字符串数组字段中包含该元素，如 tags 为 ["go","gin"] 时 go 匹配、g 不匹配
+	OpGte      = ">="
+	OpLt       = "<"
+)
+
+// Filter 允许的过滤条件：查询参数 Param 映射到数据库字段 Column
+type Filter struct {
+	Param  string
+	Column string
+	Kind   Kind
+	Op     string
+}
+
+// Spec 某个列表接口的分页、排序和过滤规则
+type Spec struct {
+	Sorts        map[string]Field // 排序字段白名单，键为 sort 参数中的名称
+	DefaultSort  string           // 默认排序，"-" 前缀表示降序，如 "-created_at"
+	Filters      []Filter
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// 常用的时间范围过滤
+func CreatedAtRange() []Filter {
+	return []Filter{
+		{Param: "created_after", Column: "created_at", Kind: KindTime, Op: OpGte},
+		{Param: "created_before", Column: "created_at", Kind: KindTime, Op: OpLt},
+	}
+}
+
+type condition struct {
+	clause string
+	value  interface{}
+}
+
+// Query 解析后的列表查询
+type Query struct {
+	Limit    int
+	SortName string // 带方向的排序名称，如 "-created_at"
+	sort     Field
+	desc     bool
+	cursor   *cursor
+	after    interface{} // 游标中按排序字段类型解析后的值
+	where    []condition
+}
+
+// Error 查询参数错误，应返回400
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func badRequest(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse 按Spec解析 limit、sort、cursor 和过滤参数
+func Parse(values url.Values, spec Spec) (*Query, error) {
+	q := &Query{Limit: spec.DefaultLimit}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return nil, badRequest("limit 必须为正整数")
+		}
+		q.Limit = limit
+	}
+	if spec.MaxLimit > 0 && q.Limit > spec.MaxLimit {
+		q.Limit = spec.MaxLimit
+	}
+
+	q.SortName = values.Get("sort")
+	if q.SortName == "" {
+		q.SortName = spec.DefaultSort
+	}
+	name := strings.TrimPrefix(q.SortName, "-")
+	field, ok := spec.Sorts[name]
+	if !ok {
+		allowed := make([]string, 0, len(spec.Sorts))
+		for key := range spec.Sorts {
+			allowed = append(allowed, key)
+		}
+		return nil, badRequest("不支持按 %s 排序，可选: %s", name, strings.Join(allowed, ", "))
+	}
+	q.sort = field
+	q.desc = strings.HasPrefix(q.SortName, "-")
+
+	if raw := values.Get("cursor"); raw != "" {
+		cur, err := decodeCursor(raw)
+		if err != nil {
+			return nil, badRequest("无效的游标")
+		}
+		if cur.Sort != q.SortName {
+			return nil, badRequest("游标与当前排序方式不一致")
+		}
+		// 游标内容可被客户端篡改，解析失败时拒绝，不能当作零值继续查询
+		if field.Column != "id" {
+			if q.after, err = parseValue(cur.Value, field.Kind); err != nil {
+				return nil, badRequest("无效的游标")
+			}
+		}
+		q.cursor = cur
+	}
+
+	for _, filter := range spec.Filters {
+		raw := strings.TrimSpace(values.Get(filter.Param))
+		if raw == "" {
+			continue
+		}
+		value, err := parseValue(raw, filter.Kind)
+		if err != nil {
+			return nil, badRequest("参数 %s 格式错误", filter.Param)
+		}
+		switch filter.Op {
+		case OpContains:
+			q.where = append(q.where, condition{"LOWER(" + filter.Column + ") LIKE LOWER(?) ESCAPE '!'", "%" + escapeLike(raw) + "%"})
+		case OpElement:
+			// 按带引号的JSON字符串匹配，值中的引号等字符经过转义，不会匹配到其他元素或JSON标点
+			q.where = append(q.where, condition{"LOWER(" + filter.Column + ") LIKE LOWER(?) ESCAPE '!'", "%" + escapeLike(jsonString(raw)) + "%"})
+		case OpGte, OpLt:
+			q.where = append(q.where, condition{filter.Column + " " + filter.Op + " ?", value})
+		default:
+			q.where = append(q.where, condition{filter.Column + " = ?", value})
+		}
+	}
+	return q, nil
+}
+
+// SortColumn 当前排序的数据库字段
+func (q *Query) SortColumn() string {
+	return q.sort.Column
+}
+
+// Apply 在查询上追加过滤、游标、排序和limit（多取一条用于判断是否还有下一页）
+func (q *Query) Apply(db *gorm.DB) *gorm.DB {
+	for _, cond := range q.where {
+		db = db.Where(cond.clause, cond.value)
+	}
+
+	direction, compare := "ASC", ">"
+	if q.desc {
+		direction, compare = "DESC", "<"
+	}
+	column := q.sort.Column
+
+	if q.cursor != nil {
+		if column == "id" {
+			db = db.Where("id "+compare+" ?", q.cursor.ID)
+		} else {
+			db = db.Where("("+column+" "+compare+" ? OR ("+column+" = ? AND id "+compare+" ?))", q.after, q.after, q.cursor.ID)
+		}
+	}
+
+	db = db.Order(column + " " + direction)
+	if column != "id" {
+		db = db.Order("id " + direction)
+	}
+	return db.Limit(q.Limit + 1)
+}
+
+// parseValue 按字段类型解析查询参数；时间支持 RFC3339 和 2006-01-02
+func parseValue(raw string, kind Kind) (interface{}, error) {
+	switch kind {
+	case KindInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case KindBool:
+		return strconv.ParseBool(raw)
+	case KindTime:
+		if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			return t, nil
+		}
+		return time.ParseInLocation("2006-01-02", raw, time.Local)
+	}
+	return raw, nil
+}
+
+// jsonString 编码为JSON字符串（不转义HTML字符，与浏览器 JSON.stringify 的结果一致）
+func jsonString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// escapeLike 转义LIKE通配符，使用 ! 作为转义符以兼容不同数据库
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
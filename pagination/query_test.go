@@ -0,0 +1,215 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID        uint
+	Title     string
+	Tags      string
+	Views     int
+	Pinned    bool
+	CreatedAt time.Time
+}
+
+var testSpec = Spec{
+	Sorts: map[string]Field{
+		"id":         {Column: "id", Kind: KindInt},
+		"title":      {Column: "title", Kind: KindString},
+		"views":      {Column: "views", Kind: KindInt},
+		"created_at": {Column: "created_at", Kind: KindTime},
+	},
+	DefaultSort: "-created_at",
+	Filters: append([]Filter{
+		{Param: "q", Column: "title", Kind: KindString, Op: OpContains},
+		{Param: "tag", Column: "tags", Kind: KindString, Op: OpElement},
+		{Param: "views", Column: "views", Kind: KindInt, Op: OpEq},
+		{Param: "pinned", Column: "pinned", Kind: KindBool, Op: OpEq},
+	}, CreatedAtRange()...),
+	DefaultLimit: 2,
+	MaxLimit:     50,
+}
+
+// openItems 打开内存SQLite并写入测试数据，id 1-6 的创建时间依次晚一小时
+func openItems(t *testing.T) *gorm.DB {
+	t.Helper()
+	name := strings.ReplaceAll(t.Name(), "/", "_")
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	items := []item{
+		{ID: 1, Title: "Go 入门", Tags: `["go","gin"]`, Views: 10},
+		{ID: 2, Title: "100% 覆盖率", Tags: `["test"]`, Views: 30, Pinned: true},
+		{ID: 3, Title: "gin 中间件", Tags: `["gin"]`, Views: 10},
+		{ID: 4, Title: "数据库_索引", Tags: `["db","go"]`, Views: 20},
+		{ID: 5, Title: "引号", Tags: `["say \"hi\"","R&D"]`, Views: 20},
+		{ID: 6, Title: "golang", Tags: `["golang"]`, Views: 5},
+	}
+	for i := range items {
+		items[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func ids(items []item) []uint {
+	result := []uint{}
+	for _, it := range items {
+		result = append(result, it.ID)
+	}
+	return result
+}
+
+func TestPaginateRoundTrip(t *testing.T) {
+	db := openItems(t)
+	keys := map[string]func(item) (interface{}, uint){
+		"id":         func(it item) (interface{}, uint) { return it.ID, it.ID },
+		"title":      func(it item) (interface{}, uint) { return it.Title, it.ID },
+		"views":      func(it item) (interface{}, uint) { return it.Views, it.ID },
+		"created_at": func(it item) (interface{}, uint) { return it.CreatedAt, it.ID },
+	}
+	tests := []struct {
+		sort string
+		want []uint
+	}{
+		{"-created_at", []uint{6, 5, 4, 3, 2, 1}},
+		{"created_at", []uint{1, 2, 3, 4, 5, 6}},
+		{"-id", []uint{6, 5, 4, 3, 2, 1}},
+		// 相同的值按ID排序，分页时不会重复或遗漏
+		{"views", []uint{6, 1, 3, 4, 5, 2}},
+		{"-views", []uint{2, 5, 4, 3, 1, 6}},
+		{"title", []uint{2, 1, 3, 6, 5, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			key := keys[strings.TrimPrefix(tt.sort, "-")]
+			values := url.Values{"sort": {tt.sort}}
+			var got []uint
+			for page := 0; page < 10; page++ {
+				q, err := Parse(values, testSpec)
+				if err != nil {
+					t.Fatalf("Parse(%v) 失败: %v", values, err)
+				}
+				var items []item
+				if err := q.Apply(db.Model(&item{})).Find(&items).Error; err != nil {
+					t.Fatal(err)
+				}
+				items, info := Paginate(q, items, key)
+				got = append(got, ids(items)...)
+				if !info.HasMore {
+					break
+				}
+				values.Set("cursor", info.NextCursor)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("分页结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name   string
+		values url.Values
+	}{
+		{"limit不是数字", url.Values{"limit": {"abc"}}},
+		{"limit为负数", url.Values{"limit": {"-1"}}},
+		{"不支持的排序", url.Values{"sort": {"password"}}},
+		{"游标不是base64", url.Values{"cursor": {"!!!"}}},
+		{"游标不是JSON", url.Values{"cursor": {encode("1:2")}}},
+		{"游标排序不一致", url.Values{"sort": {"title"}, "cursor": {cursor{Sort: "-created_at", Value: "2024-03-01T00:00:00Z", ID: 1}.encode()}}},
+		{"游标时间被篡改", url.Values{"cursor": {cursor{Sort: "-created_at", Value: "yesterday", ID: 1}.encode()}}},
+		{"游标数字被篡改", url.Values{"sort": {"views"}, "cursor": {cursor{Sort: "views", Value: "1 OR 1=1", ID: 1}.encode()}}},
+		{"过滤参数格式错误", url.Values{"created_after": {"2024/03/01"}}},
+		{"布尔参数格式错误", url.Values{"pinned": {"maybe"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.values, testSpec)
+			var paramErr *Error
+			if !errors.As(err, &paramErr) {
+				t.Errorf("Parse(%v) = %+v, %v, 期望参数错误", tt.values, q, err)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+	}{
+		{"", 2},
+		{"5", 5},
+		{"1000", 50},
+	}
+	for _, tt := range tests {
+		q, err := Parse(url.Values{"limit": {tt.raw}}, testSpec)
+		if err != nil || q.Limit != tt.want {
+			t.Errorf("limit=%q: Limit = %v, %v, 期望 %d", tt.raw, q, err, tt.want)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	db := openItems(t)
+	tests := []struct {
+		name   string
+		values url.Values
+		want   []uint
+	}{
+		{"等于", url.Values{"views": {"20"}}, []uint{4, 5}},
+		{"布尔", url.Values{"pinned": {"true"}}, []uint{2}},
+		{"包含忽略大小写", url.Values{"q": {"GIN"}}, []uint{3}},
+		{"包含转义百分号", url.Values{"q": {"%"}}, []uint{2}},
+		{"包含转义下划线", url.Values{"q": {"_"}}, []uint{4}},
+		{"元素完整匹配", url.Values{"tag": {"go"}}, []uint{1, 4}},
+		{"元素不匹配前缀", url.Values{"tag": {"g"}}, []uint{}},
+		{"元素忽略大小写", url.Values{"tag": {"GIN"}}, []uint{1, 3}},
+		{"元素包含引号", url.Values{"tag": {`say "hi"`}}, []uint{5}},
+		{"元素不转义HTML字符", url.Values{"tag": {"R&D"}}, []uint{5}},
+		{"元素不匹配JSON标点", url.Values{"tag": {`go","gin`}}, []uint{}},
+		{"时间大于等于", url.Values{"created_after": {"2024-03-01T03:00:00Z"}}, []uint{4, 5, 6}},
+		{"时间小于", url.Values{"created_before": {"2024-03-01T02:00:00Z"}}, []uint{1, 2}},
+		{"组合条件", url.Values{"views": {"10"}, "tag": {"gin"}}, []uint{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.values.Set("sort", "id")
+			tt.values.Set("limit", "50")
+			q, err := Parse(tt.values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse(%v) 失败: %v", tt.values, err)
+			}
+			var items []item
+			if err := q.Apply(db.Model(&item{})).Find(&items).Error; err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("过滤结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package ranking
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cursor 分页游标
+// 热门/Top列表记录榜单版本和偏移量，翻页期间榜单重算也不会重复或遗漏；
+// 最新列表记录上一页最后一篇文章的发布时间和ID
+type Cursor struct {
+	Generation int64
+	Offset     int
+	CreatedAt  time.Time
+	LastID     uint
+}
+
+// Encode 编码为URL安全的字符串
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d:%d:%d", c.Generation, c.Offset, c.CreatedAt.UnixNano(), c.LastID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Ranked 是否为热门/Top榜单的游标（最新列表的游标没有榜单版本）
+func (c Cursor) Ranked() bool {
+	return c.Generation != 0
+}
+
+// DecodeCursor 解析游标，空字符串或格式错误时返回 ok=false（从第一页开始）
+func DecodeCursor(s string) (Cursor, bool) {
+	if s == "" {
+		return Cursor{}, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, false
+	}
+
+	var c Cursor
+	var createdAt int64
+	if _, err := fmt.Sscanf(strings.ReplaceAll(string(raw), ":", " "), "%d %d %d %d",
+		&c.Generation, &c.Offset, &createdAt, &c.LastID); err != nil || c.Offset < 0 {
+		return Cursor{}, false
+	}
+	c.CreatedAt = time.Unix(0, createdAt)
+	return c, true
+}
@@ -0,0 +1,47 @@
+package ranking
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Generation: 1709294400000000000, Offset: 20, LastID: 42},
+		{CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123, time.UTC), LastID: 7},
+		{},
+	}
+	for _, want := range tests {
+		got, ok := DecodeCursor(want.Encode())
+		// 热门/Top游标不使用发布时间，零值不要求还原
+		sameTime := want.CreatedAt.IsZero() || got.CreatedAt.Equal(want.CreatedAt)
+		if !ok || got.Generation != want.Generation || got.Offset != want.Offset || got.LastID != want.LastID || !sameTime {
+			t.Errorf("DecodeCursor(Encode(%+v)) = %+v, %v", want, got, ok)
+		}
+		if got.Ranked() != want.Ranked() {
+			t.Errorf("DecodeCursor(Encode(%+v)).Ranked() = %v", want, got.Ranked())
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "空字符串", input: ""},
+		{name: "不是base64", input: "!!!"},
+		{name: "字段不足", input: encode("1:2")},
+		{name: "不是数字", input: encode("a:b:c:d")},
+		{name: "负偏移量", input: encode("1:-5:0:3")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, ok := DecodeCursor(tt.input); ok {
+				t.Errorf("DecodeCursor(%q) = %+v, 期望解析失败", tt.input, c)
+			}
+		})
+	}
+}
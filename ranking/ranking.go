@@ -0,0 +1,243 @@
+package ranking
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// Sort 首页排序方式
+type Sort string
+
+const (
+	SortHot Sort = "hot" // 热门（随时间衰减）
+	SortNew Sort = "new" // 最新
+	SortTop Sort = "top" // 一段时间内互动最多
+)
+
+// Period Top排行的时间范围
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+	PeriodAll   Period = "all"
+)
+
+const (
+	maxBoardSize    = 1000                // 每个榜单最多保留的文章数
+	keepGenerations = 3                   // 保留的历史榜单数，翻页中的游标在此期间保持稳定
+	hotWindow       = 30 * 24 * time.Hour // 超过30天的文章热度已衰减到接近0，不参与热门榜
+)
+
+// ParseSort 解析排序参数，未知值按热门处理
+func ParseSort(s string) Sort {
+	switch Sort(s) {
+	case SortNew, SortTop:
+		return Sort(s)
+	}
+	return SortHot
+}
+
+// ParsePeriod 解析时间范围参数，未知值按一周处理
+func ParsePeriod(s string) Period {
+	switch Period(s) {
+	case PeriodDay, PeriodMonth, PeriodAll:
+		return Period(s)
+	}
+	return PeriodWeek
+}
+
+// since 时间范围的起点，全部时间返回零值
+func (p Period) since(now time.Time) time.Time {
+	switch p {
+	case PeriodDay:
+		return now.Add(-24 * time.Hour)
+	case PeriodWeek:
+		return now.AddDate(0, 0, -7)
+	case PeriodMonth:
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
+}
+
+type listKey struct {
+	Sort       Sort
+	Period     Period
+	CategoryID int // 0 表示全部分类
+}
+
+// board 某一次计算得到的全部榜单
+type board struct {
+	generation int64
+	lists      map[listKey][]uint
+}
+
+// Service 定期计算热门和Top榜单，保存在内存中供首页和分类页读取
+type Service struct {
+	mu     sync.RWMutex
+	boards []*board // 按计算时间升序，最后一个为当前榜单
+}
+
+var defaultService = &Service{}
+
+// Default 返回全局排行服务
+func Default() *Service {
+	return defaultService
+}
+
+type rankedPost struct {
+	ID         uint
+	CategoryID int
+	CreatedAt  time.Time
+	score      float64
+	hot        float64
+}
+
+// Recompute 从数据库加载文章互动计数并重新计算全部榜单
+func (s *Service) Recompute(db *gorm.DB) error {
+	now := time.Now()
+
+	type postRow struct {
+		ID         uint
+		CategoryId int
+		Views      int
+		Likes      int
+		Replies    int
+		Favorites  int
+		CreatedAt  time.Time
+	}
+	var posts []rankedPost
+	var batch []postRow
+	err := db.Model(&models.Post{}).
+		Select("id, category_id, views, likes, replies, favorites, created_at").
+		Where("category_id > ?", 0).
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for _, row := range batch {
+				e := Engagement{Views: row.Views, Likes: row.Likes, Replies: row.Replies, Favorites: row.Favorites}
+				posts = append(posts, rankedPost{
+					ID:         row.ID,
+					CategoryID: row.CategoryId,
+					CreatedAt:  row.CreatedAt,
+					score:      Score(e),
+					hot:        HotScore(e, row.CreatedAt, now),
+				})
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	b := &board{generation: now.UnixNano(), lists: make(map[listKey][]uint)}
+
+	hotCutoff := now.Add(-hotWindow)
+	b.addLists(SortHot, "", posts, func(p rankedPost) bool { return p.CreatedAt.After(hotCutoff) },
+		func(p rankedPost) float64 { return p.hot })
+
+	for _, period := range []Period{PeriodDay, PeriodWeek, PeriodMonth, PeriodAll} {
+		since := period.since(now)
+		b.addLists(SortTop, period, posts, func(p rankedPost) bool { return !p.CreatedAt.Before(since) },
+			func(p rankedPost) float64 { return p.score })
+	}
+
+	s.publish(b)
+	return nil
+}
+
+// publish 把新榜单设为当前榜单，只保留最近 keepGenerations 个历史榜单
+func (s *Service) publish(b *board) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.boards = append(s.boards, b)
+	if len(s.boards) > keepGenerations {
+		s.boards = s.boards[len(s.boards)-keepGenerations:]
+	}
+}
+
+// addLists 按score降序（同分按ID降序）生成全站榜单和各分类榜单
+func (b *board) addLists(sortBy Sort, period Period, posts []rankedPost, include func(rankedPost) bool, score func(rankedPost) float64) {
+	var candidates []rankedPost
+	for _, p := range posts {
+		if include(p) {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		si, sj := score(candidates[i]), score(candidates[j])
+		if si != sj {
+			return si > sj
+		}
+		return candidates[i].ID > candidates[j].ID
+	})
+
+	for _, p := range candidates {
+		for _, key := range []listKey{{sortBy, period, 0}, {sortBy, period, p.CategoryID}} {
+			if len(b.lists[key]) < maxBoardSize {
+				b.lists[key] = append(b.lists[key], p.ID)
+			}
+		}
+	}
+}
+
+// Ready 是否已经计算过榜单
+func (s *Service) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.boards) > 0
+}
+
+// Page 读取热门或Top榜单的一页文章ID，cursor为nil时从第一页开始
+// 游标对应的榜单仍保留时沿用该榜单，否则在当前榜单中从上一页最后一篇文章之后继续
+func (s *Service) Page(sortBy Sort, period Period, categoryID int, cursor *Cursor, limit int) ([]uint, *Cursor) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.boards) == 0 {
+		return nil, nil
+	}
+	if sortBy == SortHot {
+		period = ""
+	}
+	key := listKey{sortBy, period, categoryID}
+
+	b := s.boards[len(s.boards)-1]
+	offset := 0
+	if cursor != nil {
+		offset = cursor.Offset
+		found := false
+		for _, old := range s.boards {
+			if old.generation == cursor.Generation {
+				b, found = old, true
+				break
+			}
+		}
+		if !found {
+			for i, id := range b.lists[key] {
+				if id == cursor.LastID {
+					offset = i + 1
+					break
+				}
+			}
+		}
+	}
+
+	list := b.lists[key]
+	if offset >= len(list) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(list) {
+		end = len(list)
+	}
+	ids := list[offset:end]
+
+	var next *Cursor
+	if end < len(list) {
+		next = &Cursor{Generation: b.generation, Offset: end, LastID: ids[len(ids)-1]}
+	}
+	return ids, next
+}
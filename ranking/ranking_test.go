@@ -0,0 +1,86 @@
+package ranking
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAddListsOrder(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	posts := []rankedPost{
+		{ID: 1, CategoryID: 1, CreatedAt: now.Add(-48 * time.Hour), score: 10},
+		{ID: 2, CategoryID: 2, CreatedAt: now.Add(-time.Hour), score: 30},
+		{ID: 3, CategoryID: 1, CreatedAt: now.Add(-2 * time.Hour), score: 10},
+		{ID: 4, CategoryID: 2, CreatedAt: now.Add(-3 * time.Hour), score: 20},
+	}
+
+	b := &board{lists: make(map[listKey][]uint)}
+	since := PeriodDay.since(now)
+	b.addLists(SortTop, PeriodDay, posts, func(p rankedPost) bool { return !p.CreatedAt.Before(since) },
+		func(p rankedPost) float64 { return p.score })
+	b.addLists(SortTop, PeriodAll, posts, func(rankedPost) bool { return true },
+		func(p rankedPost) float64 { return p.score })
+
+	tests := []struct {
+		key  listKey
+		want []uint
+	}{
+		{listKey{SortTop, PeriodDay, 0}, []uint{2, 4, 3}},    // 文章1超过一天
+		{listKey{SortTop, PeriodAll, 0}, []uint{2, 4, 3, 1}}, // 同分时ID大的在前
+		{listKey{SortTop, PeriodAll, 1}, []uint{3, 1}},
+		{listKey{SortTop, PeriodAll, 2}, []uint{2, 4}},
+		{listKey{SortTop, PeriodDay, 1}, []uint{3}},
+	}
+	for _, tt := range tests {
+		if got := b.lists[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("榜单 %+v = %v, 期望 %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+// hotBoard 只包含全站热门榜的榜单
+func hotBoard(generation int64, ids ...uint) *board {
+	return &board{generation: generation, lists: map[listKey][]uint{{SortHot, "", 0}: ids}}
+}
+
+func TestPageGenerationCursor(t *testing.T) {
+	s := &Service{}
+	if ids, next := s.Page(SortHot, "", 0, nil, 2); ids != nil || next != nil {
+		t.Fatalf("未计算榜单时 Page = %v, %v, 期望为空", ids, next)
+	}
+
+	s.publish(hotBoard(1, 5, 4, 3, 2, 1))
+	ids, next := s.Page(SortHot, PeriodWeek, 0, nil, 2) // 热门榜忽略时间范围
+	if !reflect.DeepEqual(ids, []uint{5, 4}) || next == nil || *next != (Cursor{Generation: 1, Offset: 2, LastID: 4}) {
+		t.Fatalf("第一页 = %v, %+v", ids, next)
+	}
+
+	// 榜单重算后，旧游标继续读取它所在的榜单，不会重复或遗漏
+	s.publish(hotBoard(2, 9, 3, 5, 4, 2, 1))
+	if ids, _ := s.Page(SortHot, "", 0, next, 2); !reflect.DeepEqual(ids, []uint{3, 2}) {
+		t.Errorf("重算后第二页 = %v, 期望沿用旧榜单 [3 2]", ids)
+	}
+	if ids, _ := s.Page(SortHot, "", 0, nil, 2); !reflect.DeepEqual(ids, []uint{9, 3}) {
+		t.Errorf("新的第一页 = %v, 期望 [9 3]", ids)
+	}
+
+	// 旧榜单超出保留数量后，在当前榜单中从上一页最后一篇文章之后继续
+	s.publish(hotBoard(3, 1, 2, 3, 4, 5, 6))
+	s.publish(hotBoard(4, 8, 4, 7, 6, 2))
+	if len(s.boards) != keepGenerations {
+		t.Fatalf("保留榜单数 = %d, 期望 %d", len(s.boards), keepGenerations)
+	}
+	ids, last := s.Page(SortHot, "", 0, next, 2)
+	if !reflect.DeepEqual(ids, []uint{7, 6}) || last == nil || *last != (Cursor{Generation: 4, Offset: 4, LastID: 6}) {
+		t.Errorf("旧榜单过期后的第二页 = %v, %+v, 期望 [7 6]", ids, last)
+	}
+
+	// 最后一页没有下一页游标，超出范围返回空
+	if ids, end := s.Page(SortHot, "", 0, last, 2); !reflect.DeepEqual(ids, []uint{2}) || end != nil {
+		t.Errorf("最后一页 = %v, %+v, 期望 [2] 且没有下一页", ids, end)
+	}
+	if ids, _ := s.Page(SortHot, "", 0, &Cursor{Generation: 4, Offset: 10}, 2); ids != nil {
+		t.Errorf("偏移量超出榜单时 = %v, 期望为空", ids)
+	}
+}
@@ -0,0 +1,42 @@
+package ranking
+
+import (
+	"math"
+	"time"
+)
+
+// 互动权重：收藏和回复比点赞更能说明内容价值，浏览权重最低
+const (
+	viewWeight     = 0.1
+	likeWeight     = 2.0
+	replyWeight    = 3.0
+	favoriteWeight = 4.0
+
+	// 热度随时间衰减的指数，越大新文章越容易上榜
+	gravity = 1.5
+)
+
+// Engagement 文章的互动计数
+type Engagement struct {
+	Views     int
+	Likes     int
+	Replies   int
+	Favorites int
+}
+
+// Score 互动得分（不随时间衰减），用于Top排行
+func Score(e Engagement) float64 {
+	return float64(e.Views)*viewWeight +
+		float64(e.Likes)*likeWeight +
+		float64(e.Replies)*replyWeight +
+		float64(e.Favorites)*favoriteWeight
+}
+
+// HotScore 随时间衰减的热度：score / (发布小时数 + 2)^gravity
+func HotScore(e Engagement, createdAt, now time.Time) float64 {
+	hours := now.Sub(createdAt).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return Score(e) / math.Pow(hours+2, gravity)
+}
@@ -0,0 +1,63 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Engagement
+		want float64
+	}{
+		{name: "没有互动", e: Engagement{}, want: 0},
+		{name: "只有浏览", e: Engagement{Views: 10}, want: 1},
+		{name: "各项互动", e: Engagement{Views: 10, Likes: 3, Replies: 2, Favorites: 1}, want: 1 + 6 + 6 + 4},
+		{name: "收藏权重最高", e: Engagement{Favorites: 5}, want: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.e); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score(%+v) = %v, 期望 %v", tt.e, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHotScore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := Engagement{Views: 10, Likes: 3, Replies: 2, Favorites: 1} // 得分 17
+
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		want      float64
+	}{
+		// 17 / (0+2)^1.5 = 17 / 2√2
+		{name: "刚发布", createdAt: now, want: 17 / (2 * math.Sqrt2)},
+		// 17 / (2+2)^1.5 = 17 / 8
+		{name: "发布2小时", createdAt: now.Add(-2 * time.Hour), want: 2.125},
+		// 17 / (7+2)^1.5 = 17 / 27
+		{name: "发布7小时", createdAt: now.Add(-7 * time.Hour), want: 17.0 / 27},
+		// 17 / (14+2)^1.5 = 17 / 64
+		{name: "发布14小时", createdAt: now.Add(-14 * time.Hour), want: 17.0 / 64},
+		// 发布时间晚于当前时间（时钟偏差）按刚发布计算
+		{name: "发布时间在未来", createdAt: now.Add(time.Hour), want: 17 / (2 * math.Sqrt2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HotScore(e, tt.createdAt, now); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("HotScore = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	// 互动相同时越新的文章越热；得分高很多的旧文章仍可能排在前面
+	fresh := HotScore(Engagement{Likes: 1}, now.Add(-time.Hour), now)           // 2 / 3^1.5 ≈ 0.385
+	popular := HotScore(Engagement{Favorites: 10}, now.Add(-10*time.Hour), now) // 40 / 12^1.5 ≈ 0.962
+	if !(popular > fresh) {
+		t.Errorf("HotScore 旧的热门文章 = %v, 新文章 = %v, 期望旧的热门文章更高", popular, fresh)
+	}
+}
@@ -0,0 +1,74 @@
+package recommend
+
+import (
+	"gin-doniai/models"
+	"gin-doniai/utils"
+	"gorm.io/gorm"
+)
+
+// Rebuild 加载全部文章和点赞/收藏记录，重新计算相关文章并替换 post_relations 表中的结果
+func Rebuild(db *gorm.DB) (int, error) {
+	var docs []Document
+	var batch []models.Post
+	err := db.Select("id, category_id, title, content, tags").
+		Where("category_id > ?", 0).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, post := range batch {
+				docs = append(docs, Document{
+					ID:         post.ID,
+					CategoryID: post.CategoryId,
+					Title:      post.Title,
+					Content:    post.Content,
+					Tags:       utils.ParseTags(post.Tags),
+				})
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	type engagement struct {
+		UserID int
+		PostID int
+	}
+	var rows []engagement
+	if err := db.Model(&models.PostLike{}).Select("user_id, post_id").Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	var favorites []engagement
+	if err := db.Model(&models.PostFavorite{}).Select("user_id, post_id").Find(&favorites).Error; err != nil {
+		return 0, err
+	}
+	engaged := make(map[uint][]uint)
+	for _, row := range append(rows, favorites...) {
+		engaged[uint(row.UserID)] = append(engaged[uint(row.UserID)], uint(row.PostID))
+	}
+
+	results := Compute(docs, engaged)
+
+	var relations []models.PostRelation
+	for _, related := range results {
+		for rank, r := range related {
+			relations = append(relations, models.PostRelation{
+				PostID:    r.PostID,
+				RelatedID: r.RelatedID,
+				Score:     r.Score,
+				Reasons:   r.Reasons,
+				Position:  rank,
+			})
+		}
+	}
+
+	// 整表替换，读取方在事务提交前仍能看到上一次的结果
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.PostRelation{}).Error; err != nil {
+			return err
+		}
+		if len(relations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&relations, 500).Error
+	})
+	return len(relations), err
+}
@@ -0,0 +1,267 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	maxRelated        = 5    // 每篇文章保留的相关文章数
+	maxContentRunes   = 5000 // 参与相似度计算的正文长度上限
+	maxTermsPerDoc    = 50   // 每篇文章只保留权重最高的词项
+	maxEngagedPerUser = 200  // 点赞/收藏过多的用户不参与共同互动统计，避免刷量账号干扰
+	titleRepeat       = 3    // 标题词项的权重倍数
+
+	tagWeight        = 0.5
+	textWeight       = 0.3
+	engagementWeight = 0.2
+	categoryBonus    = 0.05
+	minScore         = 0.05
+
+	textReasonThreshold = 0.2
+)
+
+// Document 参与推荐的文章
+type Document struct {
+	ID         uint
+	CategoryID int
+	Title      string
+	Content    string
+	Tags       []string
+}
+
+// Relation 一条相关文章推荐
+type Relation struct {
+	PostID    uint
+	RelatedID uint
+	Score     float64
+	Reasons   []string
+}
+
+type candidate struct {
+	sharedTags []string
+	text       float64
+	coEngaged  int
+	engagement float64
+}
+
+// Compute 为每篇文章计算相关文章，得分由标签重合度（Jaccard）、标题和正文的TF-IDF余弦相似度
+// 以及共同点赞/收藏的用户数加权得到；engaged 为每个用户点赞或收藏过的文章ID
+func Compute(docs []Document, engaged map[uint][]uint) map[uint][]Relation {
+	index := make(map[uint]int, len(docs))
+	for i, doc := range docs {
+		index[doc.ID] = i
+	}
+
+	tagSets, tagPostings := buildTagIndex(docs)
+	vectors, termPostings := buildTFIDF(docs)
+	coCounts, engagedCounts := buildCoEngagement(engaged, index)
+
+	result := make(map[uint][]Relation, len(docs))
+	for i, doc := range docs {
+		candidates := make(map[int]*candidate)
+		get := func(j int) *candidate {
+			c, ok := candidates[j]
+			if !ok {
+				c = &candidate{}
+				candidates[j] = c
+			}
+			return c
+		}
+
+		for _, tag := range docTags(doc) {
+			for _, j := range tagPostings[strings.ToLower(tag)] {
+				if j != i {
+					get(j).sharedTags = append(get(j).sharedTags, tag)
+				}
+			}
+		}
+		for term, weight := range vectors[i] {
+			for _, posting := range termPostings[term] {
+				if posting.doc != i {
+					get(posting.doc).text += weight * posting.weight
+				}
+			}
+		}
+		for j, count := range coCounts[i] {
+			c := get(j)
+			c.coEngaged = count
+			c.engagement = float64(count) / math.Sqrt(float64(engagedCounts[i]*engagedCounts[j]))
+		}
+
+		var relations []Relation
+		for j, c := range candidates {
+			other := docs[j]
+			var score float64
+			var reasons []string
+
+			if shared := len(c.sharedTags); shared > 0 {
+				union := len(tagSets[i]) + len(tagSets[j]) - shared
+				score += tagWeight * float64(shared) / float64(union)
+				tags := c.sharedTags
+				if len(tags) > 3 {
+					tags = tags[:3]
+				}
+				reasons = append(reasons, "共同标签："+strings.Join(tags, "、"))
+			}
+			score += textWeight * c.text
+			if c.text >= textReasonThreshold {
+				reasons = append(reasons, "内容相似")
+			}
+			if c.coEngaged > 0 {
+				score += engagementWeight * c.engagement
+				reasons = append(reasons, "喜欢这篇文章的用户也喜欢")
+			}
+			if other.CategoryID == doc.CategoryID {
+				score += categoryBonus
+				reasons = append(reasons, "同一分类")
+			}
+
+			if score >= minScore {
+				relations = append(relations, Relation{PostID: doc.ID, RelatedID: other.ID, Score: score, Reasons: reasons})
+			}
+		}
+
+		sort.Slice(relations, func(a, b int) bool {
+			if relations[a].Score != relations[b].Score {
+				return relations[a].Score > relations[b].Score
+			}
+			return relations[a].RelatedID > relations[b].RelatedID
+		})
+		if len(relations) > maxRelated {
+			relations = relations[:maxRelated]
+		}
+		result[doc.ID] = relations
+	}
+	return result
+}
+
+// docTags 去重后的标签（保留原始大小写用于展示）
+func docTags(doc Document) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, tag := range doc.Tags {
+		key := strings.ToLower(tag)
+		if !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func buildTagIndex(docs []Document) ([]map[string]bool, map[string][]int) {
+	sets := make([]map[string]bool, len(docs))
+	postings := make(map[string][]int)
+	for i, doc := range docs {
+		sets[i] = make(map[string]bool)
+		for _, tag := range docTags(doc) {
+			key := strings.ToLower(tag)
+			sets[i][key] = true
+			postings[key] = append(postings[key], i)
+		}
+	}
+	return sets, postings
+}
+
+type termPosting struct {
+	doc    int
+	weight float64
+}
+
+// buildTFIDF 计算每篇文章归一化后的TF-IDF向量，以及词项到文章的倒排表
+func buildTFIDF(docs []Document) ([]map[string]float64, map[string][]termPosting) {
+	termCounts := make([]map[string]int, len(docs))
+	df := make(map[string]int)
+	for i, doc := range docs {
+		counts := make(map[string]int)
+		for _, term := range tokenize(doc.Title) {
+			counts[term] += titleRepeat
+		}
+		content := []rune(doc.Content)
+		if len(content) > maxContentRunes {
+			content = content[:maxContentRunes]
+		}
+		for _, term := range tokenize(string(content)) {
+			counts[term]++
+		}
+		for term := range counts {
+			df[term]++
+		}
+		termCounts[i] = counts
+	}
+
+	n := float64(len(docs))
+	vectors := make([]map[string]float64, len(docs))
+	postings := make(map[string][]termPosting)
+	for i, counts := range termCounts {
+		type weighted struct {
+			term   string
+			weight float64
+		}
+		var terms []weighted
+		for term, count := range counts {
+			// 只出现在一篇文章中的词无法产生相似度；超过半数文章都有的词区分度太低
+			if df[term] < 2 || (len(docs) >= 10 && float64(df[term]) > n/2) {
+				continue
+			}
+			idf := math.Log((n+1)/float64(df[term]+1)) + 1
+			terms = append(terms, weighted{term, (1 + math.Log(float64(count))) * idf})
+		}
+		sort.Slice(terms, func(a, b int) bool { return terms[a].weight > terms[b].weight })
+		if len(terms) > maxTermsPerDoc {
+			terms = terms[:maxTermsPerDoc]
+		}
+
+		var norm float64
+		for _, t := range terms {
+			norm += t.weight * t.weight
+		}
+		norm = math.Sqrt(norm)
+
+		vectors[i] = make(map[string]float64, len(terms))
+		for _, t := range terms {
+			weight := t.weight / norm
+			vectors[i][t.term] = weight
+			postings[t.term] = append(postings[t.term], termPosting{doc: i, weight: weight})
+		}
+	}
+	return vectors, postings
+}
+
+// buildCoEngagement 统计每两篇文章被同一用户点赞/收藏的次数，以及每篇文章的互动用户数
+func buildCoEngagement(engaged map[uint][]uint, index map[uint]int) (map[int]map[int]int, map[int]int) {
+	coCounts := make(map[int]map[int]int)
+	engagedCounts := make(map[int]int)
+
+	for _, postIDs := range engaged {
+		seen := make(map[int]bool)
+		var docs []int
+		for _, id := range postIDs {
+			if i, ok := index[id]; ok && !seen[i] {
+				seen[i] = true
+				docs = append(docs, i)
+			}
+		}
+		if len(docs) > maxEngagedPerUser {
+			continue
+		}
+
+		for _, i := range docs {
+			engagedCounts[i]++
+		}
+		for _, i := range docs {
+			for _, j := range docs {
+				if i == j {
+					continue
+				}
+				if coCounts[i] == nil {
+					coCounts[i] = make(map[int]int)
+				}
+				coCounts[i][j]++
+			}
+		}
+	}
+	return coCounts, engagedCounts
+}
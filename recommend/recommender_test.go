@@ -0,0 +1,131 @@
+package recommend
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// 以下期望值按 idf = ln((n+1)/(df+1)) + 1、tf = 1 + ln(count) 手工计算：
+// 3篇文章中 alpha 出现3次（idf = 1），beta 出现2次（idf = 1 + ln(4/3) ≈ 1.287682），gamma 只出现1次被忽略
+const (
+	alphaWeight = 0.6133555370249717 // 1 / √(1 + 1.287682²)
+	betaWeight  = 0.7898069290660905 // 1.287682 / √(1 + 1.287682²)
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestBuildTFIDF(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Content: "alpha beta"},
+		{ID: 2, Content: "alpha beta"},
+		{ID: 3, Content: "alpha gamma"},
+	}
+	vectors, postings := buildTFIDF(docs)
+
+	want := []map[string]float64{
+		{"alpha": alphaWeight, "beta": betaWeight},
+		{"alpha": alphaWeight, "beta": betaWeight},
+		{"alpha": 1},
+	}
+	for i := range want {
+		if len(vectors[i]) != len(want[i]) {
+			t.Fatalf("文章%d的向量 = %v, 期望 %v", i+1, vectors[i], want[i])
+		}
+		for term, weight := range want[i] {
+			if !approxEqual(vectors[i][term], weight) {
+				t.Errorf("文章%d %s 的权重 = %v, 期望 %v", i+1, term, vectors[i][term], weight)
+			}
+		}
+	}
+	if len(postings["alpha"]) != 3 || len(postings["beta"]) != 2 || len(postings["gamma"]) != 0 {
+		t.Errorf("倒排表 alpha = %v, beta = %v, gamma = %v", postings["alpha"], postings["beta"], postings["gamma"])
+	}
+
+	// 标题词项按 titleRepeat 次计：tf = 1 + ln(3)
+	titled := []Document{{ID: 1, Title: "alpha", Content: "beta"}, {ID: 2, Content: "alpha beta"}}
+	vectors, _ = buildTFIDF(titled)
+	tf := 1 + math.Log(titleRepeat)
+	if norm := math.Sqrt(tf*tf + 1); !approxEqual(vectors[0]["alpha"], tf/norm) || !approxEqual(vectors[0]["beta"], 1/norm) {
+		t.Errorf("标题加权后的向量 = %v, 期望 alpha %v, beta %v", vectors[0], tf/norm, 1/norm)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	docs := []Document{
+		{ID: 1, CategoryID: 1, Tags: []string{"Go", "Web"}, Content: "alpha beta"},
+		{ID: 2, CategoryID: 1, Tags: []string{"go"}, Content: "alpha beta"},
+		{ID: 3, CategoryID: 2, Tags: []string{"rust"}, Content: "alpha gamma"},
+	}
+	// 用户10、11都互动过文章1和3（重复记录只算一次），用户12只互动过文章2
+	engaged := map[uint][]uint{10: {1, 3}, 11: {1, 3, 3}, 12: {2}}
+
+	result := Compute(docs, engaged)
+
+	want := map[uint][]Relation{
+		1: {
+			// 标签 Jaccard 1/2 × 0.5 + 余弦相似度 1 × 0.3 + 同分类 0.05
+			{PostID: 1, RelatedID: 2, Score: 0.25 + 0.3 + 0.05, Reasons: []string{"共同标签：Go", "内容相似", "同一分类"}},
+			// 余弦相似度 alphaWeight × 0.3 + 共同互动 2/√(2×2) × 0.2
+			{PostID: 1, RelatedID: 3, Score: alphaWeight*0.3 + 0.2, Reasons: []string{"内容相似", "喜欢这篇文章的用户也喜欢"}},
+		},
+		2: {
+			{PostID: 2, RelatedID: 1, Score: 0.25 + 0.3 + 0.05, Reasons: []string{"共同标签：go", "内容相似", "同一分类"}},
+			{PostID: 2, RelatedID: 3, Score: alphaWeight * 0.3, Reasons: []string{"内容相似"}},
+		},
+		3: {
+			{PostID: 3, RelatedID: 1, Score: alphaWeight*0.3 + 0.2, Reasons: []string{"内容相似", "喜欢这篇文章的用户也喜欢"}},
+			{PostID: 3, RelatedID: 2, Score: alphaWeight * 0.3, Reasons: []string{"内容相似"}},
+		},
+	}
+
+	for id, relations := range want {
+		got := result[id]
+		if len(got) != len(relations) {
+			t.Errorf("文章%d的相关文章 = %+v, 期望 %+v", id, got, relations)
+			continue
+		}
+		for i, r := range relations {
+			g := got[i]
+			if g.PostID != r.PostID || g.RelatedID != r.RelatedID || !approxEqual(g.Score, r.Score) || !reflect.DeepEqual(g.Reasons, r.Reasons) {
+				t.Errorf("文章%d的第%d个相关文章 = %+v, 期望 %+v", id, i+1, g, r)
+			}
+		}
+	}
+}
+
+func TestComputeLimits(t *testing.T) {
+	// 7篇同分类、同标签的文章，每篇最多保留 maxRelated 个，同分时ID大的在前
+	var docs []Document
+	for id := uint(1); id <= 7; id++ {
+		docs = append(docs, Document{ID: id, CategoryID: 1, Tags: []string{"go"}})
+	}
+	relations := Compute(docs, nil)[1]
+	var ids []uint
+	for _, r := range relations {
+		ids = append(ids, r.RelatedID)
+	}
+	if want := []uint{7, 6, 5, 4, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("相关文章 = %v, 期望 %v", ids, want)
+	}
+
+	// 没有共同标签、内容和互动的不同分类文章得分低于 minScore，不推荐
+	unrelated := Compute([]Document{{ID: 1, CategoryID: 1, Content: "alpha"}, {ID: 2, CategoryID: 2, Content: "beta"}}, nil)
+	if len(unrelated[1]) != 0 || len(unrelated[2]) != 0 {
+		t.Errorf("无关文章的推荐 = %+v, 期望为空", unrelated)
+	}
+
+	// 互动文章过多的用户不参与共同互动统计
+	var many []uint
+	index := make(map[uint]int)
+	for id := uint(1); id <= maxEngagedPerUser+1; id++ {
+		many = append(many, id)
+		index[id] = int(id) - 1
+	}
+	coCounts, engagedCounts := buildCoEngagement(map[uint][]uint{1: many, 2: {1, 2}}, index)
+	if coCounts[0][1] != 1 || engagedCounts[0] != 1 {
+		t.Errorf("共同互动 = %v, 互动用户数 = %v, 期望只统计用户2", coCounts, engagedCounts)
+	}
+}
@@ -0,0 +1,54 @@
+package recommend
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// 常见的无意义英文词
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"are": true, "was": true, "from": true, "you": true, "your": true, "not": true,
+	"but": true, "can": true, "have": true, "has": true, "will": true, "how": true,
+	"nbsp": true, "amp": true, "quot": true,
+}
+
+// tokenize 把文本切分为词项：英文和数字按单词切分，中文按相邻两字（bigram）切分
+func tokenize(text string) []string {
+	text = strings.ToLower(htmlTagPattern.ReplaceAllString(text, " "))
+
+	var tokens []string
+	var word []rune
+	var prevHan rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			if w := string(word); !stopWords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			if prevHan != 0 {
+				tokens = append(tokens, string([]rune{prevHan, r}))
+			}
+			prevHan = r
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			prevHan = 0
+			word = append(word, r)
+		default:
+			prevHan = 0
+			flushWord()
+		}
+	}
+	flushWord()
+	return tokens
+}
@@ -0,0 +1,28 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "英文按单词切分并转小写", text: "Hello, World!", want: []string{"hello", "world"}},
+		{name: "去掉停用词和单个字母", text: "the Go and a Rust", want: []string{"go", "rust"}},
+		{name: "中文按相邻两字切分", text: "并发编程", want: []string{"并发", "发编", "编程"}},
+		{name: "中英文混排", text: "Go语言v2", want: []string{"go", "语言", "v2"}},
+		{name: "去掉HTML标签和实体", text: "<p>gin&nbsp;框架</p>", want: []string{"gin", "框架"}},
+		{name: "单个汉字不成词", text: "好 的", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, 期望 %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// UserArchive 个人数据导出包中的用户资料、文章、评论、点赞和收藏，均按创建时间正序
+type UserArchive struct {
+	User         models.User
+	Posts        []models.Post
+	Comments     []models.Comment
+	Likes        []models.PostLike
+	Favorites    []models.PostFavorite
+	CommentLikes []models.CommentLike
+}
+
+// DataExportRepository 个人数据导出任务的数据访问
+type DataExportRepository interface {
+	// Latest 用户最近一次申请的导出
+	Latest(userID uint) (*models.DataExport, error)
+	// ListByUser 用户最近的导出记录，按创建时间倒序
+	ListByUser(userID uint, limit int) ([]models.DataExport, error)
+	// FindForUser 查询属于指定用户的导出记录
+	FindForUser(id, userID uint) (*models.DataExport, error)
+	FindByID(id uint) (*models.DataExport, error)
+	// Unfinished 排队中和生成中的导出任务
+	Unfinished() ([]models.DataExport, error)
+	// Expired 保留期在 now 之前结束的已完成导出
+	Expired(now time.Time) ([]models.DataExport, error)
+	// Archive 读取生成导出包需要的用户数据
+	Archive(userID uint) (*UserArchive, error)
+	Create(export *models.DataExport) error
+	// Update 按列更新导出任务的状态和文件信息
+	Update(export *models.DataExport, changes map[string]interface{}) error
+	Delete(export *models.DataExport) error
+}
+
+type gormDataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository 基于GORM的数据导出仓储
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &gormDataExportRepository{db: db}
+}
+
+func (r *gormDataExportRepository) Latest(userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &export, nil
+}
+
+func (r *gormDataExportRepository) ListByUser(userID uint, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormDataExportRepository) FindForUser(id, userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&export).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &export, nil
+}
+
+func (r *gormDataExportRepository) FindByID(id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.First(&export, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &export, nil
+}
+
+func (r *gormDataExportRepository) Unfinished() ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("status IN ?", []int{models.ExportStatusPending, models.ExportStatusProcessing}).Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormDataExportRepository) Expired(now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("status = ? AND expires_at < ?", models.ExportStatusDone, now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormDataExportRepository) Archive(userID uint) (*UserArchive, error) {
+	var archive UserArchive
+	if err := r.db.First(&archive.User, userID).Error; err != nil {
+		return nil, translate(err)
+	}
+	lists := []interface{}{&archive.Posts, &archive.Comments, &archive.Likes, &archive.Favorites, &archive.CommentLikes}
+	for _, list := range lists {
+		if err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(list).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &archive, nil
+}
+
+func (r *gormDataExportRepository) Create(export *models.DataExport) error {
+	return translate(r.db.Create(export).Error)
+}
+
+func (r *gormDataExportRepository) Update(export *models.DataExport, changes map[string]interface{}) error {
+	return r.db.Model(export).Updates(changes).Error
+}
+
+func (r *gormDataExportRepository) Delete(export *models.DataExport) error {
+	return r.db.Delete(export).Error
+}
+
+// AccountDeletionRepository 账户注销申请的数据访问
+type AccountDeletionRepository interface {
+	// FindPending 用户处于冷静期中的注销申请
+	FindPending(userID uint) (*models.AccountDeletion, error)
+	// Due 冷静期在 now 之前结束、尚未执行的注销申请
+	Due(now time.Time) ([]models.AccountDeletion, error)
+	Create(deletion *models.AccountDeletion) error
+	UpdateStatus(deletion *models.AccountDeletion, status int) error
+	// Complete 标记注销已执行
+	Complete(deletion *models.AccountDeletion, at time.Time) error
+	// PurgeUser 在一个事务中匿名化用户发布的内容、撤回点赞收藏并永久删除个人数据，
+	// 返回被删除的导出记录，导出文件由调用方在事务成功后删除
+	PurgeUser(userID uint) ([]models.DataExport, error)
+}
+
+type gormAccountDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountDeletionRepository 基于GORM的账户注销仓储
+func NewAccountDeletionRepository(db *gorm.DB) AccountDeletionRepository {
+	return &gormAccountDeletionRepository{db: db}
+}
+
+func (r *gormAccountDeletionRepository) FindPending(userID uint) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	if err := r.db.Where("user_id = ? AND status = ?", userID, models.DeletionStatusPending).First(&deletion).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &deletion, nil
+}
+
+func (r *gormAccountDeletionRepository) Due(now time.Time) ([]models.AccountDeletion, error) {
+	var due []models.AccountDeletion
+	err := r.db.Where("status = ? AND scheduled_at <= ?", models.DeletionStatusPending, now).Find(&due).Error
+	return due, err
+}
+
+func (r *gormAccountDeletionRepository) Create(deletion *models.AccountDeletion) error {
+	return translate(r.db.Create(deletion).Error)
+}
+
+func (r *gormAccountDeletionRepository) UpdateStatus(deletion *models.AccountDeletion, status int) error {
+	return r.db.Model(deletion).Update("status", status).Error
+}
+
+func (r *gormAccountDeletionRepository) Complete(deletion *models.AccountDeletion, at time.Time) error {
+	return r.db.Model(deletion).Updates(map[string]interface{}{
+		"status":       models.DeletionStatusCompleted,
+		"completed_at": at,
+	}).Error
+}
+
+func (r *gormAccountDeletionRepository) PurgeUser(userID uint) ([]models.DataExport, error) {
+	var user models.User
+	if err := r.db.Unscoped().First(&user, userID).Error; err != nil {
+		return nil, translate(err)
+	}
+
+	var exports []models.DataExport
+	if err := r.db.Where("user_id = ?", userID).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 文章保留，作者匿名化
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"user_id": 0, "author": models.DeletedUserName}).Error; err != nil {
+			return err
+		}
+
+		// 评论保留楼层结构，内容替换为墓碑
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"user_id": 0, "content": models.DeletedCommentContent}).Error; err != nil {
+			return err
+		}
+
+		// 撤回点赞和收藏，并同步文章和评论计数
+		if err := tx.Model(&models.Post{}).
+			Where("id IN (?) AND likes > 0", tx.Model(&models.PostLike{}).Select("post_id").Where("user_id = ?", userID)).
+			UpdateColumn("likes", gorm.Expr("likes - ?", 1)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Post{}).
+			Where("id IN (?) AND favorites > 0", tx.Model(&models.PostFavorite{}).Select("post_id").Where("user_id = ?", userID)).
+			UpdateColumn("favorites", gorm.Expr("favorites - ?", 1)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Comment{}).
+			Where("id IN (?) AND like_count > 0", tx.Model(&models.CommentLike{}).Select("comment_id").Where("user_id = ?", userID)).
+			UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CommentLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PostLike{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PostFavorite{}).Error; err != nil {
+			return err
+		}
+
+		// 删除其余个人数据
+		personal := []struct {
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{&models.UserOnlineStatus{}, "user_id = ?", userID},
+			{&models.APIToken{}, "user_id = ?", userID},
+			{&models.EmailVerification{}, "user_id = ?", userID},
+			{&models.PasswordReset{}, "email = ?", user.Email},
+			{&models.DataExport{}, "user_id = ?", userID},
+		}
+		for _, item := range personal {
+			if err := tx.Unscoped().Where(item.query, item.arg).Delete(item.model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&models.User{}, userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
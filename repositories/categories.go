@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// CategoryRepository 分类的数据访问
+type CategoryRepository interface {
+	FindByID(id uint) (*models.Category, error)
+	// FindByIDs 按ID批量查询，不存在的ID忽略
+	FindByIDs(ids []uint) ([]models.Category, error)
+	FindByAlias(alias string) (*models.Category, error)
+	// Recommended 正常状态的推荐分类（导航栏）
+	Recommended() ([]models.Category, error)
+	// Active 全部正常状态的分类
+	Active() ([]models.Category, error)
+}
+
+type gormCategoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository 基于GORM的分类仓储
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &gormCategoryRepository{db: db}
+}
+
+func (r *gormCategoryRepository) FindByID(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.First(&category, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) FindByIDs(ids []uint) ([]models.Category, error) {
+	var categories []models.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) FindByAlias(alias string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Where("alias = ?", alias).First(&category).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) Recommended() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Where("is_recommended = ? AND status_code = ?", true, 1).Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) Active() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Where("status_code = ?", 1).Find(&categories).Error
+	return categories, err
+}
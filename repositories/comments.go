@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"fmt"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gorm.io/gorm"
+)
+
+// 评论上的计数字段，只能通过 AddCounter 原子增减
+const (
+	CounterCommentLikes   = "like_count"
+	CounterCommentReplies = "reply_count"
+)
+
+var commentCounters = map[string]bool{
+	CounterCommentLikes:   true,
+	CounterCommentReplies: true,
+}
+
+// UserComment 用户发表的评论及所属文章标题（个人文章页）
+type UserComment struct {
+	models.Comment
+	PostTitle string
+}
+
+// CommentRepository 评论的数据访问
+type CommentRepository interface {
+	FindByID(id uint) (*models.Comment, error)
+	// List 按分页参数查询并加载评论者，多查一条用于判断是否有下一页
+	List(query *pagination.Query) ([]models.Comment, error)
+	// TopLevel 文章的顶级评论并加载评论者，按时间倒序
+	TopLevel(postID uint, offset, limit int) (Page[models.Comment], error)
+	// TopLevelOf 多篇文章的全部顶级评论，按时间倒序
+	TopLevelOf(postIDs []uint) ([]models.Comment, error)
+	// Replies 一批评论的回复并加载评论者，按时间正序
+	Replies(parentIDs []uint) ([]models.Comment, error)
+	// ListByUser 用户发表的评论，按时间倒序
+	ListByUser(userID uint, offset, limit int) (Page[UserComment], error)
+	Create(comment *models.Comment) error
+	UpdateContent(comment *models.Comment, content string) error
+	// Delete 软删除，评论不存在或已被其他请求删除时返回 ErrNotFound
+	Delete(comment *models.Comment) error
+	// AddCounter 原子增减计数字段（不会减到0以下），返回最新值
+	AddCounter(id uint, column string, delta int) (int, error)
+	// ReconcileReplies 按未删除的回复重新计算 reply_count，返回修正的评论数
+	// 评论点赞数不校准：迁移 0003 之前的点赞没有记录，无法重新计算
+	ReconcileReplies() (int64, error)
+}
+
+type gormCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 基于GORM的评论仓储
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &gormCommentRepository{db: db}
+}
+
+func (r *gormCommentRepository) FindByID(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.First(&comment, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormCommentRepository) List(query *pagination.Query) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := query.Apply(r.db.Model(&models.Comment{}).Preload("User")).Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormCommentRepository) TopLevel(postID uint, offset, limit int) (Page[models.Comment], error) {
+	var page Page[models.Comment]
+	scope := r.db.Model(&models.Comment{}).Where("post_id = ? AND parent_id = 0", postID)
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Preload("User").Order("created_at DESC").Offset(offset).Limit(limit).Find(&page.Items).Error
+	return page, err
+}
+
+func (r *gormCommentRepository) TopLevelOf(postIDs []uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if len(postIDs) == 0 {
+		return comments, nil
+	}
+	err := r.db.Where("post_id IN ? AND parent_id = 0", postIDs).Order("created_at DESC, id DESC").Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormCommentRepository) Replies(parentIDs []uint) ([]models.Comment, error) {
+	var replies []models.Comment
+	if len(parentIDs) == 0 {
+		return replies, nil
+	}
+	err := r.db.Where("parent_id IN ?", parentIDs).Preload("User").Order("created_at ASC, id ASC").Find(&replies).Error
+	return replies, err
+}
+
+func (r *gormCommentRepository) ListByUser(userID uint, offset, limit int) (Page[UserComment], error) {
+	var page Page[UserComment]
+	scope := r.db.Table("comments c").
+		Joins("LEFT JOIN posts p ON c.post_id = p.id").
+		Where("c.user_id = ? AND c.deleted_at IS NULL", userID)
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Select("c.*, p.title as post_title").
+		Order("c.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&page.Items).Error
+	return page, err
+}
+
+func (r *gormCommentRepository) Create(comment *models.Comment) error {
+	return translate(r.db.Create(comment).Error)
+}
+
+func (r *gormCommentRepository) UpdateContent(comment *models.Comment, content string) error {
+	return r.db.Model(comment).Update("content", content).Error
+}
+
+func (r *gormCommentRepository) Delete(comment *models.Comment) error {
+	result := r.db.Delete(comment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormCommentRepository) AddCounter(id uint, column string, delta int) (int, error) {
+	if !commentCounters[column] {
+		return 0, fmt.Errorf("不支持的计数字段: %s", column)
+	}
+	scope := r.db.Model(&models.Comment{}).Where("id = ?", id)
+	if delta < 0 {
+		scope = scope.Where(column+" >= ?", -delta)
+	}
+	if err := scope.UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error; err != nil {
+		return 0, err
+	}
+
+	var value int
+	err := r.db.Model(&models.Comment{}).Where("id = ?", id).Select(column).Row().Scan(&value)
+	return value, translate(err)
+}
+
+// 评论的未删除回复数；MySQL 不允许 UPDATE 的子查询直接读取被更新的表，需要包一层派生表
+const commentRepliesSQL = `COALESCE((SELECT r.total FROM (SELECT parent_id, COUNT(*) AS total FROM comments
+	WHERE parent_id > 0 AND deleted_at IS NULL GROUP BY parent_id) r WHERE r.parent_id = comments.id), 0)`
+
+func (r *gormCommentRepository) ReconcileReplies() (int64, error) {
+	result := r.db.Exec("UPDATE comments SET reply_count = " + commentRepliesSQL +
+		" WHERE reply_count <> " + commentRepliesSQL)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// OnlineStatusRepository 用户在线状态的数据访问
+type OnlineStatusRepository interface {
+	// Save 写入用户的在线状态，已有记录时覆盖
+	Save(status *models.UserOnlineStatus) error
+	// CountActiveSince since 之后活跃过的用户数
+	CountActiveSince(since time.Time) (int64, error)
+	// DeleteInactiveBefore 删除 before 之前最后活跃的记录
+	DeleteInactiveBefore(before time.Time) error
+}
+
+type gormOnlineStatusRepository struct {
+	db *gorm.DB
+}
+
+// NewOnlineStatusRepository 基于GORM的在线状态仓储
+func NewOnlineStatusRepository(db *gorm.DB) OnlineStatusRepository {
+	return &gormOnlineStatusRepository{db: db}
+}
+
+func (r *gormOnlineStatusRepository) Save(status *models.UserOnlineStatus) error {
+	return r.db.Where("user_id = ?", status.UserID).Assign(*status).FirstOrCreate(status).Error
+}
+
+func (r *gormOnlineStatusRepository) CountActiveSince(since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserOnlineStatus{}).Where("last_active_time > ?", since).Count(&count).Error
+	return count, err
+}
+
+func (r *gormOnlineStatusRepository) DeleteInactiveBefore(before time.Time) error {
+	return r.db.Where("last_active_time < ?", before).Delete(&models.UserOnlineStatus{}).Error
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/ranking"
+	"gorm.io/gorm"
+)
+
+// 文章上的计数字段，只能通过 AddCounter 原子增减
+const (
+	CounterLikes     = "likes"
+	CounterFavorites = "favorites"
+	CounterReplies   = "replies"
+)
+
+var postCounters = map[string]bool{
+	CounterLikes:     true,
+	CounterFavorites: true,
+	CounterReplies:   true,
+}
+
+// AuthorStats 作者的发帖数、获得的回复数和点赞数（文章详情页侧栏）
+type AuthorStats struct {
+	PostCount  int64
+	ReplyCount int64
+	LikeCount  int64
+}
+
+// PostRepository 文章的数据访问
+type PostRepository interface {
+	FindByID(id uint) (*models.Post, error)
+	// FindByIDs 按ID批量查询，不存在的ID忽略
+	FindByIDs(ids []uint) ([]models.Post, error)
+	// FindWithAuthor 查询文章并加载作者
+	FindWithAuthor(id uint) (*models.Post, error)
+	// List 按分页参数查询，多查一条用于判断是否有下一页
+	List(query *pagination.Query) ([]models.Post, error)
+	// Search 按标题模糊搜索，按发布时间倒序
+	Search(keyword string, offset, limit int) (Page[models.Post], error)
+	// ListByUser 用户发表的文章（不含未分类的文章），按发布时间倒序
+	ListByUser(userID uint, offset, limit int) (Page[models.Post], error)
+	// Hot since 之后发布的浏览最多的文章
+	Hot(since time.Time, limit int) ([]models.Post, error)
+	// Latest 最新发布的文章
+	Latest(limit int) ([]models.Post, error)
+	// Newest 首页按最新排序的一页文章（不含未分类的文章），categoryID 为0表示全部分类，
+	// before 不为空时只查询游标之后的文章
+	Newest(categoryID int, before *ranking.Cursor, limit int) ([]models.Post, error)
+	// SameCategory 同一分类下除 excludeID 外最新发布的文章
+	SameCategory(categoryID int, excludeID uint, limit int) ([]models.Post, error)
+	AuthorStats(userID uint) (AuthorStats, error)
+	Create(post *models.Post) error
+	// Update 更新非零值字段
+	Update(post *models.Post, changes models.Post) error
+	Delete(post *models.Post) error
+	ForceDelete(id uint) error
+	// AddCounter 原子增减计数字段（不会减到0以下），返回最新值
+	AddCounter(id uint, column string, delta int) (int, error)
+	// ReconcileCounters 按点赞、收藏和未删除的评论记录重新计算文章计数，返回修正的计数个数
+	ReconcileCounters() (int64, error)
+}
+
+type gormPostRepository struct {
+	db *gorm.DB
+}
+
+// NewPostRepository 基于GORM的文章仓储
+func NewPostRepository(db *gorm.DB) PostRepository {
+	return &gormPostRepository{db: db}
+}
+
+func (r *gormPostRepository) FindByID(id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.First(&post, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) FindByIDs(ids []uint) ([]models.Post, error) {
+	var posts []models.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) FindWithAuthor(id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.Preload("User").First(&post, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) List(query *pagination.Query) ([]models.Post, error) {
+	var posts []models.Post
+	err := query.Apply(r.db.Model(&models.Post{})).Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) Search(keyword string, offset, limit int) (Page[models.Post], error) {
+	var page Page[models.Post]
+	scope := r.db.Model(&models.Post{}).Where(database.LikeFold("title"), "%"+keyword+"%")
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Order("created_at DESC").Offset(offset).Limit(limit).Find(&page.Items).Error
+	return page, err
+}
+
+func (r *gormPostRepository) ListByUser(userID uint, offset, limit int) (Page[models.Post], error) {
+	var page Page[models.Post]
+	scope := r.db.Model(&models.Post{}).Where("category_id > ?", 0).Where("user_id = ?", userID)
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Order("created_at DESC").Offset(offset).Limit(limit).Find(&page.Items).Error
+	return page, err
+}
+
+func (r *gormPostRepository) Hot(since time.Time, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.Select("id", "title", "author", "category", "views", "replies", "likes", "created_at").
+		Where("category_id > ? AND created_at > ?", 0, since).
+		Order("views DESC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) Latest(limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.Order("created_at DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) Newest(categoryID int, before *ranking.Cursor, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	query := r.db.Where("category_id > ?", 0)
+	if categoryID > 0 {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	if before != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", before.CreatedAt, before.CreatedAt, before.LastID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) SameCategory(categoryID int, excludeID uint, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.Select("id, title, author, category, replies, created_at").
+		Where("id != ? AND category_id = ?", excludeID, categoryID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPostRepository) AuthorStats(userID uint) (AuthorStats, error) {
+	var stats AuthorStats
+	err := r.db.Model(&models.Post{}).Where("user_id = ?", userID).
+		Select("COUNT(*), COALESCE(SUM(replies), 0), COALESCE(SUM(likes), 0)").
+		Row().Scan(&stats.PostCount, &stats.ReplyCount, &stats.LikeCount)
+	return stats, err
+}
+
+func (r *gormPostRepository) Create(post *models.Post) error {
+	return translate(r.db.Create(post).Error)
+}
+
+func (r *gormPostRepository) Update(post *models.Post, changes models.Post) error {
+	return translate(r.db.Model(post).Updates(changes).Error)
+}
+
+func (r *gormPostRepository) Delete(post *models.Post) error {
+	return r.db.Delete(post).Error
+}
+
+func (r *gormPostRepository) ForceDelete(id uint) error {
+	return r.db.Unscoped().Delete(&models.Post{}, id).Error
+}
+
+func (r *gormPostRepository) AddCounter(id uint, column string, delta int) (int, error) {
+	if !postCounters[column] {
+		return 0, fmt.Errorf("不支持的计数字段: %s", column)
+	}
+	scope := r.db.Model(&models.Post{}).Where("id = ?", id)
+	if delta < 0 {
+		scope = scope.Where(column+" >= ?", -delta)
+	}
+	if err := scope.UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error; err != nil {
+		return 0, err
+	}
+
+	var value int
+	err := r.db.Model(&models.Post{}).Where("id = ?", id).Select(column).Row().Scan(&value)
+	return value, translate(err)
+}
+
+// 文章计数对应的记录数
+var postCounterSources = map[string]string{
+	CounterLikes:     "(SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id)",
+	CounterFavorites: "(SELECT COUNT(*) FROM post_favorites WHERE post_favorites.post_id = posts.id)",
+	CounterReplies:   "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)",
+}
+
+func (r *gormPostRepository) ReconcileCounters() (int64, error) {
+	var total int64
+	for _, column := range []string{CounterLikes, CounterFavorites, CounterReplies} {
+		source := postCounterSources[column]
+		result := r.db.Exec("UPDATE posts SET " + column + " = " + source + " WHERE " + column + " <> " + source)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+	}
+	return total, nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"gin-doniai/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionRepository 文章点赞、收藏和评论点赞记录的数据访问
+// 同一用户对同一文章（评论）只有一条记录（唯一索引），Add/Remove 返回记录是否实际发生了变化
+type ReactionRepository interface {
+	AddLike(userID, postID uint) (bool, error)
+	RemoveLike(userID, postID uint) (bool, error)
+	AddFavorite(userID, postID uint) (bool, error)
+	RemoveFavorite(userID, postID uint) (bool, error)
+	AddCommentLike(userID, commentID uint) (bool, error)
+	RemoveCommentLike(userID, commentID uint) (bool, error)
+	// Favorites 用户收藏的文章，按收藏时间倒序
+	Favorites(userID uint, offset, limit int) (Page[models.Post], error)
+}
+
+type gormReactionRepository struct {
+	db *gorm.DB
+}
+
+// NewReactionRepository 基于GORM的点赞收藏仓储
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &gormReactionRepository{db: db}
+}
+
+func (r *gormReactionRepository) AddLike(userID, postID uint) (bool, error) {
+	return r.add(&models.PostLike{UserID: int(userID), PostID: int(postID)})
+}
+
+func (r *gormReactionRepository) RemoveLike(userID, postID uint) (bool, error) {
+	return r.remove(&models.PostLike{}, "post_id", userID, postID)
+}
+
+func (r *gormReactionRepository) AddFavorite(userID, postID uint) (bool, error) {
+	return r.add(&models.PostFavorite{UserID: int(userID), PostID: int(postID)})
+}
+
+func (r *gormReactionRepository) RemoveFavorite(userID, postID uint) (bool, error) {
+	return r.remove(&models.PostFavorite{}, "post_id", userID, postID)
+}
+
+func (r *gormReactionRepository) AddCommentLike(userID, commentID uint) (bool, error) {
+	return r.add(&models.CommentLike{UserID: userID, CommentID: commentID})
+}
+
+func (r *gormReactionRepository) RemoveCommentLike(userID, commentID uint) (bool, error) {
+	return r.remove(&models.CommentLike{}, "comment_id", userID, commentID)
+}
+
+// add 插入记录，已存在时（唯一索引冲突）忽略
+// 使用 ON CONFLICT DO NOTHING 而不是捕获冲突错误，PostgreSQL 事务中的语句出错后整个事务都无法继续
+func (r *gormReactionRepository) add(record interface{}) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormReactionRepository) remove(model interface{}, column string, userID, targetID uint) (bool, error) {
+	result := r.db.Where("user_id = ? AND "+column+" = ?", userID, targetID).Delete(model)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormReactionRepository) Favorites(userID uint, offset, limit int) (Page[models.Post], error) {
+	var page Page[models.Post]
+	scope := r.db.Table("post_favorites pf").
+		Joins("JOIN posts p ON pf.post_id = p.id AND p.deleted_at IS NULL").
+		Where("pf.user_id = ?", userID)
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Select("p.*").
+		Order("pf.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&page.Items).Error
+	return page, err
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// PostRelationRepository worker预先计算的相关文章的数据访问
+type PostRelationRepository interface {
+	// ListByPost 文章的相关文章记录，按推荐位置排序
+	ListByPost(postID uint) ([]models.PostRelation, error)
+}
+
+type gormPostRelationRepository struct {
+	db *gorm.DB
+}
+
+// NewPostRelationRepository 基于GORM的相关文章仓储
+func NewPostRelationRepository(db *gorm.DB) PostRelationRepository {
+	return &gormPostRelationRepository{db: db}
+}
+
+func (r *gormPostRelationRepository) ListByPost(postID uint) ([]models.PostRelation, error) {
+	var relations []models.PostRelation
+	err := r.db.Where("post_id = ?", postID).Order("position ASC").Find(&relations).Error
+	return relations, err
+}
@@ -0,0 +1,104 @@
+// Package repositories 按聚合（文章、评论、用户、分类、点赞收藏、账户、webhook等）封装数据访问，
+// 业务规则由 services 包实现，handlers 和 workers 通过构造函数注入服务；
+// 统计、榜单、推荐和导入导出等批处理包使用 main 传入的 *gorm.DB，都不读取全局的 database.DB
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// 仓储层统一返回的错误，调用方不需要依赖GORM
+var (
+	ErrNotFound  = errors.New("记录不存在")
+	ErrDuplicate = errors.New("记录已存在")
+)
+
+// translate 把GORM错误转换为仓储错误（需要开启 TranslateError 才能识别唯一索引冲突）
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	}
+	return err
+}
+
+// Page 按页码分页的一页结果
+type Page[T any] struct {
+	Items []T
+	Total int64
+}
+
+// Repositories 全部仓储
+type Repositories struct {
+	Posts      PostRepository
+	Comments   CommentRepository
+	Users      UserRepository
+	Categories CategoryRepository
+	Reactions  ReactionRepository
+	Relations  PostRelationRepository
+	ViewStats  PostViewStatRepository
+	SiteStats  SiteStatRepository
+
+	APITokens          APITokenRepository
+	EmailVerifications EmailVerificationRepository
+	PasswordResets     PasswordResetRepository
+	DataExports        DataExportRepository
+	AccountDeletions   AccountDeletionRepository
+	OnlineStatus       OnlineStatusRepository
+	Webhooks           WebhookRepository
+	WebhookDeliveries  WebhookDeliveryRepository
+
+	db *gorm.DB
+}
+
+// NewGorm 基于GORM的仓储实现
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Posts:      NewPostRepository(db),
+		Comments:   NewCommentRepository(db),
+		Users:      NewUserRepository(db),
+		Categories: NewCategoryRepository(db),
+		Reactions:  NewReactionRepository(db),
+		Relations:  NewPostRelationRepository(db),
+		ViewStats:  NewPostViewStatRepository(db),
+		SiteStats:  NewSiteStatRepository(db),
+
+		APITokens:          NewAPITokenRepository(db),
+		EmailVerifications: NewEmailVerificationRepository(db),
+		PasswordResets:     NewPasswordResetRepository(db),
+		DataExports:        NewDataExportRepository(db),
+		AccountDeletions:   NewAccountDeletionRepository(db),
+		OnlineStatus:       NewOnlineStatusRepository(db),
+		Webhooks:           NewWebhookRepository(db),
+		WebhookDeliveries:  NewWebhookDeliveryRepository(db),
+
+		db: db,
+	}
+}
+
+// WithContext 返回绑定 ctx 的仓储，查询会随 ctx 取消并记录到 ctx 中的链路
+// 没有绑定数据库（直接构造的内存实现）时返回自身
+func (r *Repositories) WithContext(ctx context.Context) *Repositories {
+	if r.db == nil {
+		return r
+	}
+	return NewGorm(r.db.WithContext(ctx))
+}
+
+// Transaction 在同一个数据库事务中执行 fn，fn 使用参数中的仓储读写，返回错误时回滚
+// 没有绑定数据库（直接构造的内存实现）时在当前仓储上执行
+func (r *Repositories) Transaction(fn func(tx *Repositories) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGorm(tx))
+	})
+}
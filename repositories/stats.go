@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// PostViewStatRepository 文章每日浏览统计的数据访问
+type PostViewStatRepository interface {
+	// ListSince 文章从 date（2006-01-02）起的每日统计
+	ListSince(postID uint, date string) ([]models.PostViewStat, error)
+}
+
+type gormPostViewStatRepository struct {
+	db *gorm.DB
+}
+
+// NewPostViewStatRepository 基于GORM的文章浏览统计仓储
+func NewPostViewStatRepository(db *gorm.DB) PostViewStatRepository {
+	return &gormPostViewStatRepository{db: db}
+}
+
+func (r *gormPostViewStatRepository) ListSince(postID uint, date string) ([]models.PostViewStat, error) {
+	var stats []models.PostViewStat
+	err := r.db.Where("post_id = ? AND date >= ?", postID, date).Find(&stats).Error
+	return stats, err
+}
+
+// SiteStatRepository 社区每日统计快照的数据访问
+type SiteStatRepository interface {
+	// ListAfter date（2006-01-02）之后的每日快照，按日期升序
+	ListAfter(date string) ([]models.SiteStatSnapshot, error)
+}
+
+type gormSiteStatRepository struct {
+	db *gorm.DB
+}
+
+// NewSiteStatRepository 基于GORM的社区统计快照仓储
+func NewSiteStatRepository(db *gorm.DB) SiteStatRepository {
+	return &gormSiteStatRepository{db: db}
+}
+
+func (r *gormSiteStatRepository) ListAfter(date string) ([]models.SiteStatSnapshot, error) {
+	var snapshots []models.SiteStatSnapshot
+	err := r.db.Where("date > ?", date).Order("date ASC").Find(&snapshots).Error
+	return snapshots, err
+}
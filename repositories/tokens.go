@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// APITokenRepository 个人API令牌的数据访问
+type APITokenRepository interface {
+	// ListByUser 用户的全部令牌，按创建时间倒序
+	ListByUser(userID uint) ([]models.APIToken, error)
+	// FindByHash 按令牌摘要查询（认证）
+	FindByHash(hash string) (*models.APIToken, error)
+	// FindForUser 查询属于指定用户的令牌
+	FindForUser(id, userID uint) (*models.APIToken, error)
+	Create(token *models.APIToken) error
+	Delete(token *models.APIToken) error
+	// Touch 记录最近使用时间和IP
+	Touch(token *models.APIToken, at time.Time, ip string) error
+}
+
+type gormAPITokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAPITokenRepository 基于GORM的API令牌仓储
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &gormAPITokenRepository{db: db}
+}
+
+func (r *gormAPITokenRepository) ListByUser(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *gormAPITokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r *gormAPITokenRepository) FindForUser(id, userID uint) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r *gormAPITokenRepository) Create(token *models.APIToken) error {
+	return translate(r.db.Create(token).Error)
+}
+
+func (r *gormAPITokenRepository) Delete(token *models.APIToken) error {
+	return r.db.Delete(token).Error
+}
+
+func (r *gormAPITokenRepository) Touch(token *models.APIToken, at time.Time, ip string) error {
+	return r.db.Model(token).UpdateColumns(map[string]interface{}{
+		"last_used_at": at,
+		"last_used_ip": ip,
+	}).Error
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"gin-doniai/database"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gorm.io/gorm"
+)
+
+// UserRepository 用户的数据访问
+type UserRepository interface {
+	FindByID(id uint) (*models.User, error)
+	// FindByIDs 按ID批量查询，不存在的ID忽略
+	FindByIDs(ids []uint) ([]models.User, error)
+	// FindByLogin 按邮箱或用户名查询（登录）
+	FindByLogin(identifier string) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	FindByName(name string) (*models.User, error)
+	// List 按分页参数查询，多查一条用于判断是否有下一页
+	List(query *pagination.Query) ([]models.User, error)
+	// Search 按用户名或邮箱模糊搜索，关键字为空时返回全部，按注册时间倒序
+	Search(keyword string, offset, limit int) (Page[models.User], error)
+	Create(user *models.User) error
+	// Update 更新非零值字段
+	Update(user *models.User, changes models.User) error
+	Delete(user *models.User) error
+	ForceDelete(id uint) error
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 基于GORM的用户仓储
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r *gormUserRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepository) FindByLogin(identifier string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ? OR name = ?", identifier, identifier).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByName(name string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("name = ?", name).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) List(query *pagination.Query) ([]models.User, error) {
+	var users []models.User
+	err := query.Apply(r.db.Model(&models.User{})).Find(&users).Error
+	return users, err
+}
+
+func (r *gormUserRepository) Search(keyword string, offset, limit int) (Page[models.User], error) {
+	var page Page[models.User]
+	scope := r.db.Model(&models.User{})
+	if keyword != "" {
+		scope = scope.Where(database.LikeFold("name")+" OR "+database.LikeFold("email"), "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	err := scope.Order("created_at DESC").Offset(offset).Limit(limit).Find(&page.Items).Error
+	return page, err
+}
+
+func (r *gormUserRepository) Create(user *models.User) error {
+	return translate(r.db.Create(user).Error)
+}
+
+func (r *gormUserRepository) Update(user *models.User, changes models.User) error {
+	return translate(r.db.Model(user).Updates(changes).Error)
+}
+
+func (r *gormUserRepository) Delete(user *models.User) error {
+	return r.db.Delete(user).Error
+}
+
+func (r *gormUserRepository) ForceDelete(id uint) error {
+	return r.db.Unscoped().Delete(&models.User{}, id).Error
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationRepository 邮箱验证令牌的数据访问
+type EmailVerificationRepository interface {
+	Create(verification *models.EmailVerification) error
+	// InvalidatePending 把用户在该用途下未使用的令牌全部标记为已使用
+	InvalidatePending(userID uint, purpose string) error
+	// Latest 用户在该用途下最近一次发送的令牌
+	Latest(userID uint, purpose string) (*models.EmailVerification, error)
+	// CountSince 用户在该用途下 since 之后发送的次数
+	CountSince(userID uint, purpose string, since time.Time) (int64, error)
+	// FindUnused 按令牌查询未使用的记录
+	FindUnused(token string) (*models.EmailVerification, error)
+	// MarkUsed 标记为已使用，已被使用过（并发请求）时返回 ErrNotFound
+	MarkUsed(verification *models.EmailVerification) error
+}
+
+type gormEmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository 基于GORM的邮箱验证仓储
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &gormEmailVerificationRepository{db: db}
+}
+
+func (r *gormEmailVerificationRepository) Create(verification *models.EmailVerification) error {
+	return translate(r.db.Create(verification).Error)
+}
+
+func (r *gormEmailVerificationRepository) InvalidatePending(userID uint, purpose string) error {
+	return r.db.Model(&models.EmailVerification{}).
+		Where("user_id = ? AND purpose = ? AND used = ?", userID, purpose, false).
+		Update("used", true).Error
+}
+
+func (r *gormEmailVerificationRepository) Latest(userID uint, purpose string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.Where("user_id = ? AND purpose = ?", userID, purpose).Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &verification, nil
+}
+
+func (r *gormEmailVerificationRepository) CountSince(userID uint, purpose string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.EmailVerification{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormEmailVerificationRepository) FindUnused(token string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	if err := r.db.Where("token = ? AND used = ?", token, false).First(&verification).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &verification, nil
+}
+
+func (r *gormEmailVerificationRepository) MarkUsed(verification *models.EmailVerification) error {
+	result := r.db.Model(verification).Where("used = ?", false).Update("used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PasswordResetRepository 密码重置令牌的数据访问
+type PasswordResetRepository interface {
+	Create(reset *models.PasswordReset) error
+	// FindUnused 按令牌查询未使用的记录
+	FindUnused(token string) (*models.PasswordReset, error)
+	// MarkUsed 标记为已使用，已被使用过（并发请求）时返回 ErrNotFound
+	MarkUsed(reset *models.PasswordReset) error
+}
+
+type gormPasswordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 基于GORM的密码重置仓储
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &gormPasswordResetRepository{db: db}
+}
+
+func (r *gormPasswordResetRepository) Create(reset *models.PasswordReset) error {
+	return translate(r.db.Create(reset).Error)
+}
+
+func (r *gormPasswordResetRepository) FindUnused(token string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	if err := r.db.Where("token = ? AND used = ?", token, false).First(&reset).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &reset, nil
+}
+
+func (r *gormPasswordResetRepository) MarkUsed(reset *models.PasswordReset) error {
+	result := r.db.Model(reset).Where("used = ?", false).Update("used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository webhook订阅的数据访问
+type WebhookRepository interface {
+	// List 全部webhook，按创建时间倒序
+	List() ([]models.Webhook, error)
+	// Active 全部启用中的webhook
+	Active() ([]models.Webhook, error)
+	FindByID(id uint) (*models.Webhook, error)
+	// FindWithDeleted 按ID查询，包括已删除的webhook（查看历史投递记录）
+	FindWithDeleted(id uint) (*models.Webhook, error)
+	Create(hook *models.Webhook) error
+	// Update 按列更新并重新读取，changes 中的false等零值也会写入
+	Update(hook *models.Webhook, changes map[string]interface{}) error
+	Delete(hook *models.Webhook) error
+}
+
+type gormWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 基于GORM的webhook仓储
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &gormWebhookRepository{db: db}
+}
+
+func (r *gormWebhookRepository) List() ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.Order("created_at DESC").Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *gormWebhookRepository) Active() ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.Where("active = ?", true).Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *gormWebhookRepository) FindByID(id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.db.First(&hook, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &hook, nil
+}
+
+func (r *gormWebhookRepository) FindWithDeleted(id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.db.Unscoped().First(&hook, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &hook, nil
+}
+
+func (r *gormWebhookRepository) Create(hook *models.Webhook) error {
+	active := hook.Active
+	if err := r.db.Create(hook).Error; err != nil {
+		return translate(err)
+	}
+	// active 字段有默认值，创建时false会被忽略，需要单独写入
+	if !active {
+		hook.Active = false
+		return r.db.Model(hook).Update("active", false).Error
+	}
+	return nil
+}
+
+func (r *gormWebhookRepository) Update(hook *models.Webhook, changes map[string]interface{}) error {
+	if err := r.db.Model(hook).Updates(changes).Error; err != nil {
+		return err
+	}
+	return r.db.First(hook, hook.ID).Error
+}
+
+func (r *gormWebhookRepository) Delete(hook *models.Webhook) error {
+	return r.db.Delete(hook).Error
+}
+
+// WebhookDeliveryRepository webhook投递记录的数据访问
+type WebhookDeliveryRepository interface {
+	FindByID(id uint) (*models.WebhookDelivery, error)
+	// FindForWebhook 查询属于指定webhook的投递记录
+	FindForWebhook(id, webhookID uint) (*models.WebhookDelivery, error)
+	// ListForWebhook 按分页参数查询webhook的投递记录，多查一条用于判断是否有下一页
+	ListForWebhook(webhookID uint, query *pagination.Query) ([]models.WebhookDelivery, error)
+	// Due 到期待投递的记录，按计划时间先后，最多 limit 条
+	Due(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	Create(delivery *models.WebhookDelivery) error
+	// Save 保存投递结果
+	Save(delivery *models.WebhookDelivery) error
+}
+
+type gormWebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepository 基于GORM的webhook投递记录仓储
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &gormWebhookDeliveryRepository{db: db}
+}
+
+func (r *gormWebhookDeliveryRepository) FindByID(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &delivery, nil
+}
+
+func (r *gormWebhookDeliveryRepository) FindForWebhook(id, webhookID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.Where("id = ? AND webhook_id = ?", id, webhookID).First(&delivery).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &delivery, nil
+}
+
+func (r *gormWebhookDeliveryRepository) ListForWebhook(webhookID uint, query *pagination.Query) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	scope := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	err := query.Apply(scope).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *gormWebhookDeliveryRepository) Due(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryStatusPending, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *gormWebhookDeliveryRepository) Create(delivery *models.WebhookDelivery) error {
+	return translate(r.db.Create(delivery).Error)
+}
+
+func (r *gormWebhookDeliveryRepository) Save(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
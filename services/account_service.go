@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+// 两次数据导出申请的最小间隔
+const dataExportInterval = 24 * time.Hour
+
+// 设置页展示的数据导出记录条数
+const dataExportListLimit = 10
+
+// 导出文件保留时间
+const dataExportRetention = 7 * 24 * time.Hour
+
+// 数据导出和账户注销的业务错误
+var (
+	ErrExportInProgress  = errors.New("已有导出任务正在处理中")
+	ErrExportTooSoon     = errors.New("每24小时只能申请一次数据导出")
+	ErrExportNotFound    = errors.New("导出记录不存在")
+	ErrExportFinished    = errors.New("导出已完成")
+	ErrDeletionPending   = errors.New("已提交注销申请")
+	ErrNoPendingDeletion = errors.New("没有待处理的注销申请")
+)
+
+// AccountService 个人数据导出和账户注销申请
+type AccountService struct {
+	exports   repositories.DataExportRepository
+	deletions repositories.AccountDeletionRepository
+}
+
+// NewAccountService 创建账户服务
+func NewAccountService(exports repositories.DataExportRepository, deletions repositories.AccountDeletionRepository) *AccountService {
+	return &AccountService{exports: exports, deletions: deletions}
+}
+
+// RequestExport 创建排队中的数据导出任务，导出包由后台worker异步生成
+// 已有任务处理中时返回 ErrExportInProgress，24小时内已导出过时返回 ErrExportTooSoon
+func (s *AccountService) RequestExport(userID uint) (*models.DataExport, error) {
+	latest, err := s.exports.Latest(userID)
+	switch {
+	case err == nil:
+		if latest.Status == models.ExportStatusPending || latest.Status == models.ExportStatusProcessing {
+			return nil, ErrExportInProgress
+		}
+		if latest.Status == models.ExportStatusDone && time.Since(latest.CreatedAt) < dataExportInterval {
+			return nil, ErrExportTooSoon
+		}
+	case !errors.Is(err, repositories.ErrNotFound):
+		return nil, err
+	}
+
+	export := &models.DataExport{
+		UserID: userID,
+		Status: models.ExportStatusPending,
+	}
+	if err := s.exports.Create(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Exports 用户最近的数据导出记录
+func (s *AccountService) Exports(userID uint) ([]models.DataExport, error) {
+	return s.exports.ListByUser(userID, dataExportListLimit)
+}
+
+// Export 获取用户自己的数据导出记录
+func (s *AccountService) Export(id, userID uint) (*models.DataExport, error) {
+	export, err := s.exports.FindForUser(id, userID)
+	return export, notFound(err, ErrExportNotFound)
+}
+
+// UnfinishedExports 排队中和生成中的导出任务（worker启动时继续处理）
+func (s *AccountService) UnfinishedExports() ([]models.DataExport, error) {
+	return s.exports.Unfinished()
+}
+
+// StartExport 把导出任务标记为生成中，任务不存在时返回 ErrExportNotFound，已完成时返回 ErrExportFinished
+func (s *AccountService) StartExport(id uint) (*models.DataExport, error) {
+	export, err := s.exports.FindByID(id)
+	if err != nil {
+		return nil, notFound(err, ErrExportNotFound)
+	}
+	if export.Status == models.ExportStatusDone {
+		return nil, ErrExportFinished
+	}
+	if err := s.exports.Update(export, map[string]interface{}{"status": models.ExportStatusProcessing}); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ExportArchive 生成导出包需要的用户资料、文章、评论、点赞和收藏
+func (s *AccountService) ExportArchive(userID uint) (*repositories.UserArchive, error) {
+	archive, err := s.exports.Archive(userID)
+	return archive, notFound(err, ErrUserNotFound)
+}
+
+// FinishExport 记录生成好的导出包，文件保留7天
+func (s *AccountService) FinishExport(export *models.DataExport, filePath string, size int64) error {
+	return s.exports.Update(export, map[string]interface{}{
+		"status":     models.ExportStatusDone,
+		"file_path":  filePath,
+		"file_size":  size,
+		"expires_at": time.Now().Add(dataExportRetention),
+	})
+}
+
+// FailExport 记录导出失败的原因
+func (s *AccountService) FailExport(export *models.DataExport, cause error) error {
+	return s.exports.Update(export, map[string]interface{}{
+		"status": models.ExportStatusFailed,
+		"error":  utils.Truncate(cause.Error(), 255),
+	})
+}
+
+// ExpiredExports 保留期已过的导出
+func (s *AccountService) ExpiredExports() ([]models.DataExport, error) {
+	return s.exports.Expired(time.Now())
+}
+
+// DeleteExport 删除导出记录，导出文件由调用方删除
+func (s *AccountService) DeleteExport(export *models.DataExport) error {
+	return s.exports.Delete(export)
+}
+
+// PendingDeletion 用户处于冷静期中的注销申请，没有时返回 ErrNoPendingDeletion
+func (s *AccountService) PendingDeletion(userID uint) (*models.AccountDeletion, error) {
+	deletion, err := s.deletions.FindPending(userID)
+	return deletion, notFound(err, ErrNoPendingDeletion)
+}
+
+// RequestDeletion 申请注销账户，冷静期结束后由worker执行删除；调用方负责确认用户身份
+// 已有申请时返回该申请和 ErrDeletionPending
+func (s *AccountService) RequestDeletion(user *models.User) (*models.AccountDeletion, error) {
+	pending, err := s.PendingDeletion(user.ID)
+	if err == nil {
+		return pending, ErrDeletionPending
+	}
+	if !errors.Is(err, ErrNoPendingDeletion) {
+		return nil, err
+	}
+
+	deletion := &models.AccountDeletion{
+		UserID:      user.ID,
+		Status:      models.DeletionStatusPending,
+		ScheduledAt: time.Now().Add(models.AccountDeletionGracePeriod),
+	}
+	if err := s.deletions.Create(deletion); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// CancelDeletion 撤销冷静期中的注销申请
+func (s *AccountService) CancelDeletion(userID uint) error {
+	pending, err := s.PendingDeletion(userID)
+	if err != nil {
+		return err
+	}
+	return s.deletions.UpdateStatus(pending, models.DeletionStatusCanceled)
+}
+
+// DueDeletions 冷静期已结束、等待执行的注销申请
+func (s *AccountService) DueDeletions() ([]models.AccountDeletion, error) {
+	return s.deletions.Due(time.Now())
+}
+
+// Purge 执行注销：匿名化用户发布的内容并永久删除个人数据，然后标记申请已完成
+// 返回被删除的导出记录，调用方负责删除对应的文件
+func (s *AccountService) Purge(deletion *models.AccountDeletion) ([]models.DataExport, error) {
+	exports, err := s.deletions.PurgeUser(deletion.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return exports, s.deletions.Complete(deletion, time.Now())
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+)
+
+// 分类缓存时间，分类写入时通过缓存失效钩子立即刷新
+const categoryCacheTTL = 10 * time.Minute
+
+// CategoryService 分类查询，导航栏和分类列表带缓存
+type CategoryService struct {
+	categories repositories.CategoryRepository
+	cache      *caches.Cache
+}
+
+// NewCategoryService 创建分类服务
+func NewCategoryService(categories repositories.CategoryRepository, cache *caches.Cache) *CategoryService {
+	return &CategoryService{categories: categories, cache: cache}
+}
+
+// ByAlias 按别名获取分类（分类页URL）
+func (s *CategoryService) ByAlias(alias string) (*models.Category, error) {
+	category, err := s.categories.FindByAlias(alias)
+	return category, notFound(err, ErrCategoryNotFound)
+}
+
+// ByIDs 按ID批量获取分类，正常状态的分类从缓存读取，其余的查询数据库，不存在的ID忽略
+func (s *CategoryService) ByIDs(ids []uint) ([]models.Category, error) {
+	active, err := s.CachedActive()
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var categories []models.Category
+	for _, category := range active {
+		if wanted[category.ID] {
+			categories = append(categories, category)
+			delete(wanted, category.ID)
+		}
+	}
+	if len(wanted) == 0 {
+		return categories, nil
+	}
+
+	missing := make([]uint, 0, len(wanted))
+	for id := range wanted {
+		missing = append(missing, id)
+	}
+	rest, err := s.categories.FindByIDs(missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(categories, rest...), nil
+}
+
+// Recommended 推荐分类
+func (s *CategoryService) Recommended() ([]models.Category, error) {
+	return s.categories.Recommended()
+}
+
+// Active 全部正常状态的分类
+func (s *CategoryService) Active() ([]models.Category, error) {
+	return s.categories.Active()
+}
+
+// CachedRecommended 缓存的推荐分类（导航栏）
+func (s *CategoryService) CachedRecommended() ([]models.Category, error) {
+	return caches.Remember(s.cache, caches.KeyRecommendedCategories, categoryCacheTTL, s.Recommended)
+}
+
+// CachedActive 缓存的全部分类
+func (s *CategoryService) CachedActive() ([]models.Category, error) {
+	return caches.Remember(s.cache, caches.KeyActiveCategories, categoryCacheTTL, s.Active)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"gin-doniai/dto"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/repositories"
+)
+
+// CommentInput 发表评论的参数
+type CommentInput struct {
+	Content  string `json:"content" binding:"required"`
+	PostID   uint   `json:"post_id" binding:"required"`
+	ParentID uint   `json:"parent_id" binding:"omitempty"`
+}
+
+// CommentThread 顶级评论及其回复
+type CommentThread struct {
+	models.Comment
+	Replies []models.Comment
+}
+
+// CommentService 评论的发表、修改、删除、点赞和查询
+type CommentService struct {
+	repos    *repositories.Repositories // 评论和文章、父评论上的计数需要在同一事务中更新
+	comments repositories.CommentRepository
+	posts    repositories.PostRepository
+	dispatch Dispatcher
+}
+
+// NewCommentService 创建评论服务
+func NewCommentService(repos *repositories.Repositories, dispatch Dispatcher) *CommentService {
+	return &CommentService{repos: repos, comments: repos.Comments, posts: repos.Posts, dispatch: dispatch}
+}
+
+// Create 以指定用户身份发表评论，同一事务中增加文章回复数（回复时还增加父评论的回复数）
+// 调用方负责校验登录；邮箱未验证时返回 ErrEmailNotVerified
+func (s *CommentService) Create(user *models.User, input CommentInput) (*models.Comment, error) {
+	if !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+	if _, err := s.posts.FindByID(input.PostID); err != nil {
+		return nil, notFound(err, ErrPostNotFound)
+	}
+	if input.ParentID > 0 {
+		parent, err := s.Get(input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != input.PostID {
+			return nil, ErrCommentNotFound
+		}
+	}
+
+	// 内容经过解析和转换
+	comment := &models.Comment{
+		Content:  processCommentContent(input.Content),
+		PostID:   input.PostID,
+		UserID:   user.ID,
+		ParentID: input.ParentID,
+	}
+	err := s.repos.Transaction(func(tx *repositories.Repositories) error {
+		if err := tx.Comments.Create(comment); err != nil {
+			return err
+		}
+		return adjustReplyCounters(tx, comment, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	withAuthor := *comment
+	withAuthor.User = *user
+	s.dispatch(models.EventCommentCreated, dto.NewComment(withAuthor))
+	return comment, nil
+}
+
+// Get 获取评论
+func (s *CommentService) Get(id uint) (*models.Comment, error) {
+	comment, err := s.comments.FindByID(id)
+	return comment, notFound(err, ErrCommentNotFound)
+}
+
+// TopLevelOf 多篇文章的全部顶级评论，按时间倒序
+func (s *CommentService) TopLevelOf(postIDs []uint) ([]models.Comment, error) {
+	return s.comments.TopLevelOf(postIDs)
+}
+
+// Replies 一批评论的回复，按时间正序
+func (s *CommentService) Replies(parentIDs []uint) ([]models.Comment, error) {
+	return s.comments.Replies(parentIDs)
+}
+
+// List 按分页参数查询评论，返回当前页和分页信息
+func (s *CommentService) List(query *pagination.Query) ([]models.Comment, pagination.PageInfo, error) {
+	comments, err := s.comments.List(query)
+	if err != nil {
+		return nil, pagination.PageInfo{}, err
+	}
+	comments, info := pagination.Paginate(query, comments, func(comment models.Comment) (interface{}, uint) {
+		return CommentSortValue(comment, query.SortColumn()), comment.ID
+	})
+	return comments, info, nil
+}
+
+// Update 修改评论内容（仅评论作者）
+func (s *CommentService) Update(user *models.User, id uint, content string) (*models.Comment, error) {
+	comment, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || comment.UserID != user.ID {
+		return nil, ErrForbidden
+	}
+	if err := s.comments.UpdateContent(comment, content); err != nil {
+		return nil, err
+	}
+	comment.Content = content
+	return comment, nil
+}
+
+// Delete 删除评论（评论作者或管理员）
+func (s *CommentService) Delete(user *models.User, id uint) error {
+	comment, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !canModify(user, comment.UserID) {
+		return ErrForbidden
+	}
+	// 并发删除同一条评论时只有真正删除了记录的请求调整计数，其余请求返回评论不存在
+	err = s.repos.Transaction(func(tx *repositories.Repositories) error {
+		if err := tx.Comments.Delete(comment); err != nil {
+			return err
+		}
+		return adjustReplyCounters(tx, comment, -1)
+	})
+	return notFound(err, ErrCommentNotFound)
+}
+
+// adjustReplyCounters 评论增加或删除后同步文章的回复数，回复还要同步父评论的回复数
+func adjustReplyCounters(tx *repositories.Repositories, comment *models.Comment, delta int) error {
+	if _, err := tx.Posts.AddCounter(comment.PostID, repositories.CounterReplies, delta); err != nil {
+		return fmt.Errorf("更新文章回复数失败: %w", err)
+	}
+	if comment.ParentID == 0 {
+		return nil
+	}
+	if _, err := tx.Comments.AddCounter(comment.ParentID, repositories.CounterCommentReplies, delta); err != nil {
+		return fmt.Errorf("更新评论回复数失败: %w", err)
+	}
+	return nil
+}
+
+// SetLike 设置用户对评论的点赞状态（重复点赞或重复取消不生效），返回最新点赞数；不能给自己的评论点赞
+func (s *CommentService) SetLike(user *models.User, id uint, like bool) (int, error) {
+	comment, err := s.Get(id)
+	if err != nil {
+		return 0, err
+	}
+	if like && comment.UserID == user.ID {
+		return comment.LikeCount, ErrSelfLike
+	}
+
+	apply, delta := repositories.ReactionRepository.AddCommentLike, 1
+	if !like {
+		apply, delta = repositories.ReactionRepository.RemoveCommentLike, -1
+	}
+	count := comment.LikeCount
+	err = s.repos.Transaction(func(tx *repositories.Repositories) error {
+		changed, err := apply(tx.Reactions, user.ID, comment.ID)
+		if err != nil || !changed {
+			return err
+		}
+		count, err = tx.Comments.AddCounter(comment.ID, repositories.CounterCommentLikes, delta)
+		return err
+	})
+	return count, err
+}
+
+// Threads 文章的一页顶级评论（按时间倒序）及其全部回复，page 从1开始
+func (s *CommentService) Threads(postID uint, page, limit int) ([]CommentThread, int64, error) {
+	top, err := s.comments.TopLevel(postID, pageOffset(page, limit), limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, 0, len(top.Items))
+	for _, comment := range top.Items {
+		ids = append(ids, comment.ID)
+	}
+	replies, err := s.comments.Replies(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	byParent := make(map[uint][]models.Comment, len(ids))
+	for _, reply := range replies {
+		byParent[reply.ParentID] = append(byParent[reply.ParentID], reply)
+	}
+
+	threads := make([]CommentThread, 0, len(top.Items))
+	for _, comment := range top.Items {
+		threads = append(threads, CommentThread{Comment: comment, Replies: byParent[comment.ID]})
+	}
+	return threads, top.Total, nil
+}
+
+// ListByUser 用户发表的评论及所属文章标题，page 从1开始
+func (s *CommentService) ListByUser(userID uint, page, limit int) (repositories.Page[repositories.UserComment], error) {
+	return s.comments.ListByUser(userID, pageOffset(page, limit), limit)
+}
+
+// CommentListSpec 评论列表的排序和过滤规则
+var CommentListSpec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"id":         {Column: "id", Kind: pagination.KindInt},
+		"created_at": {Column: "created_at", Kind: pagination.KindTime},
+		"like_count": {Column: "like_count", Kind: pagination.KindInt},
+	},
+	DefaultSort: "created_at",
+	Filters: append([]pagination.Filter{
+		{Param: "post_id", Column: "post_id", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "user_id", Column: "user_id", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "parent_id", Column: "parent_id", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "status", Column: "status_code", Kind: pagination.KindInt, Op: pagination.OpEq},
+	}, pagination.CreatedAtRange()...),
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// CommentSortValue 评论在排序字段上的值，用于生成下一页游标
+func CommentSortValue(comment models.Comment, column string) interface{} {
+	switch column {
+	case "created_at":
+		return comment.CreatedAt
+	case "like_count":
+		return comment.LikeCount
+	}
+	return comment.ID
+}
+
+var (
+	// 匹配 @用户名 (假设用户名不包含空格)
+	mentionPattern = regexp.MustCompile(`@(\S+)`)
+	// 匹配 #ID (数字)
+	commentRefPattern = regexp.MustCompile(`#(\d+)`)
+)
+
+// processCommentContent 把 @用户名 和 #评论ID 转换为链接，并包装在 <p> 标签中
+func processCommentContent(content string) string {
+	content = mentionPattern.ReplaceAllString(content, `<a href="/user/$1" target="_blank">@$1</a>`)
+	content = commentRefPattern.ReplaceAllString(content, `<a href="#comment-$1">#$1</a>`)
+	return fmt.Sprintf("<p>%s</p>", content)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"gin-doniai/repositories"
+)
+
+// CounterReport 一次校准修正的计数
+type CounterReport struct {
+	Posts    int64 // 文章的点赞数、收藏数和回复数
+	Comments int64 // 评论的回复数
+}
+
+// CounterService 按点赞、收藏和评论记录校准文章、评论上的冗余计数
+// 正常情况下计数在事务中与记录同步更新，校准用于修复直接改库、历史数据等造成的偏差
+type CounterService struct {
+	posts    repositories.PostRepository
+	comments repositories.CommentRepository
+}
+
+// NewCounterService 创建计数校准服务
+func NewCounterService(posts repositories.PostRepository, comments repositories.CommentRepository) *CounterService {
+	return &CounterService{posts: posts, comments: comments}
+}
+
+// Reconcile 重新计算全部计数，只更新与记录不一致的行
+func (s *CounterService) Reconcile() (CounterReport, error) {
+	var report CounterReport
+	var err error
+	if report.Posts, err = s.posts.ReconcileCounters(); err != nil {
+		return report, err
+	}
+	report.Comments, err = s.comments.ReconcileReplies()
+	return report, err
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+const (
+	emailVerifyExpiry     = 24 * time.Hour // 验证链接有效期
+	emailVerifyResendGap  = time.Minute    // 两次发送的最小间隔
+	emailVerifyMaxPerHour = 5              // 每小时最多发送次数
+	passwordResetExpiry   = time.Hour      // 重置密码链接有效期
+)
+
+// 邮箱验证和重置密码的业务错误，错误信息直接展示给用户
+var (
+	ErrSendTooFrequent   = errors.New("发送过于频繁，请稍后再试")
+	ErrSendTooMany       = errors.New("发送次数过多，请一小时后再试")
+	ErrSameEmail         = errors.New("新邮箱不能与当前邮箱相同")
+	ErrVerifyLinkInvalid = errors.New("验证链接无效或已被使用")
+	ErrVerifyLinkExpired = errors.New("验证链接已过期，请重新发送验证邮件")
+	ErrVerifyLinkStale   = errors.New("验证链接已失效")
+	ErrEmailInUse        = errors.New("该邮箱已被其他账户使用")
+	ErrResetLinkInvalid  = errors.New("重置链接无效或已过期")
+	ErrResetLinkExpired  = errors.New("重置链接已过期")
+	ErrMailNotSent       = errors.New("邮件发送失败")
+)
+
+// EmailChange 邮箱验证通过后的结果，修改邮箱时 OldEmail 为原邮箱
+type EmailChange struct {
+	Purpose  string
+	OldEmail string
+	NewEmail string
+}
+
+// EmailService 邮箱验证、修改邮箱和重置密码
+type EmailService struct {
+	repos         *repositories.Repositories // 更新用户和标记令牌需要在同一事务中完成
+	users         repositories.UserRepository
+	verifications repositories.EmailVerificationRepository
+	resets        repositories.PasswordResetRepository
+	settings      Settings
+}
+
+// NewEmailService 创建邮箱服务，邮件中的链接基于 settings 中的站点地址生成
+func NewEmailService(repos *repositories.Repositories, settings Settings) *EmailService {
+	return &EmailService{repos: repos, users: repos.Users, verifications: repos.EmailVerifications, resets: repos.PasswordResets, settings: settings}
+}
+
+// Notify 按当前的SMTP配置发送邮件，处理器发送邮箱变更、账户注销等通知时也使用它
+func (s *EmailService) Notify(to, subject, body string) error {
+	return utils.SendMail(s.settings().SMTP, to, subject, body)
+}
+
+// SendVerification 生成验证令牌并发送验证邮件，同一用途下旧的未使用令牌全部作废
+func (s *EmailService) SendVerification(userID uint, email, purpose string) error {
+	if err := s.verifications.InvalidatePending(userID, purpose); err != nil {
+		return err
+	}
+
+	verification := &models.EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		Purpose:   purpose,
+		Token:     randomToken(),
+		ExpiresAt: time.Now().Add(emailVerifyExpiry),
+	}
+	if err := s.verifications.Create(verification); err != nil {
+		return err
+	}
+
+	verifyLink := s.settings().Site.Link("/verify-email?token=" + verification.Token)
+
+	subject := "请验证您的邮箱"
+	body := fmt.Sprintf("欢迎加入，请在24小时内点击以下链接完成邮箱验证：\n%s", verifyLink)
+	if purpose == models.EmailVerifyPurposeChange {
+		subject = "请确认您的新邮箱"
+		body = fmt.Sprintf("您正在将账户邮箱修改为 %s，请在24小时内点击以下链接确认：\n%s\n如非本人操作，请忽略此邮件。", email, verifyLink)
+	}
+	return s.Notify(email, subject, body)
+}
+
+// CheckThrottle 检查验证邮件发送频率，超出限制时返回 ErrSendTooFrequent 或 ErrSendTooMany
+func (s *EmailService) CheckThrottle(userID uint, purpose string) error {
+	latest, err := s.verifications.Latest(userID, purpose)
+	if err == nil && time.Since(latest.CreatedAt) < emailVerifyResendGap {
+		return ErrSendTooFrequent
+	}
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+
+	count, err := s.verifications.CountSince(userID, purpose, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= emailVerifyMaxPerHour {
+		return ErrSendTooMany
+	}
+	return nil
+}
+
+// Verify 校验验证链接中的令牌，注册验证时标记邮箱已验证，修改邮箱时替换为新邮箱
+func (s *EmailService) Verify(token string) (*EmailChange, error) {
+	verification, err := s.verifications.FindUnused(token)
+	if err != nil {
+		return nil, notFound(err, ErrVerifyLinkInvalid)
+	}
+	if time.Now().After(verification.ExpiresAt) {
+		return nil, ErrVerifyLinkExpired
+	}
+
+	owner, err := s.users.FindByID(verification.UserID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+
+	result := &EmailChange{Purpose: verification.Purpose, OldEmail: owner.Email, NewEmail: verification.Email}
+	changes := models.User{EmailStatus: models.EmailStatusVerified}
+	switch verification.Purpose {
+	case models.EmailVerifyPurposeRegister:
+		// 注册后邮箱又被修改过，旧链接不再有效
+		if !strings.EqualFold(verification.Email, owner.Email) {
+			return nil, ErrVerifyLinkStale
+		}
+	case models.EmailVerifyPurposeChange:
+		if other, err := s.users.FindByEmail(verification.Email); err == nil && other.ID != owner.ID {
+			return nil, ErrEmailInUse
+		} else if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+		changes.Email = verification.Email
+	default:
+		return nil, ErrVerifyLinkInvalid
+	}
+
+	err = s.repos.Transaction(func(tx *repositories.Repositories) error {
+		// 先标记令牌已使用，同一链接的并发请求只有一个能继续
+		if err := tx.EmailVerifications.MarkUsed(verification); err != nil {
+			return notFound(err, ErrVerifyLinkInvalid)
+		}
+		return tx.Users.Update(owner, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RequestChange 校验当前密码后向新邮箱发送确认链接，验证通过后才真正修改
+// 密码为空时要求刚刚重新登录过（reauthenticated），第三方登录创建的账户没有可用的密码
+func (s *EmailService) RequestChange(user *models.User, password, newEmail string, reauthenticated bool) error {
+	if password != "" || !reauthenticated {
+		if !utils.CheckPassword(password, user.Password) {
+			return ErrWrongPassword
+		}
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+	if _, err := s.users.FindByEmail(newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	if err := s.CheckThrottle(user.ID, models.EmailVerifyPurposeChange); err != nil {
+		return err
+	}
+	if err := s.SendVerification(user.ID, newEmail, models.EmailVerifyPurposeChange); err != nil {
+		return fmt.Errorf("%w: %v", ErrMailNotSent, err)
+	}
+	return nil
+}
+
+// RequestPasswordReset 生成重置令牌并发送重置邮件，邮箱未注册时返回 ErrUserNotFound，
+// 邮件发送失败时返回 ErrMailNotSent（令牌已保存）
+func (s *EmailService) RequestPasswordReset(email string) error {
+	if _, err := s.users.FindByEmail(email); err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+
+	reset := &models.PasswordReset{
+		Email:     email,
+		Token:     randomUUID(),
+		ExpiresAt: time.Now().Add(passwordResetExpiry),
+	}
+	if err := s.resets.Create(reset); err != nil {
+		return err
+	}
+
+	// 使用配置的站点地址而不是请求的Host头，防止伪造Host把令牌发到别的域名
+	resetLink := s.settings().Site.Link("/reset-password?token=" + reset.Token)
+	body := fmt.Sprintf("请在1小时内点击以下链接重置密码：\n%s\n如非本人操作，请忽略此邮件。", resetLink)
+	if err := s.Notify(email, "重置密码", body); err != nil {
+		return fmt.Errorf("%w: %v", ErrMailNotSent, err)
+	}
+	return nil
+}
+
+// CheckResetToken 校验重置密码令牌未使用且未过期
+func (s *EmailService) CheckResetToken(token string) (*models.PasswordReset, error) {
+	reset, err := s.resets.FindUnused(token)
+	if err != nil {
+		return nil, notFound(err, ErrResetLinkInvalid)
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		return nil, ErrResetLinkExpired
+	}
+	return reset, nil
+}
+
+// ResetPassword 使用重置令牌设置新密码，令牌同时标记为已使用
+func (s *EmailService) ResetPassword(token, password string) error {
+	reset, err := s.CheckResetToken(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.FindByEmail(reset.Email)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	return s.repos.Transaction(func(tx *repositories.Repositories) error {
+		if err := tx.PasswordResets.MarkUsed(reset); err != nil {
+			return notFound(err, ErrResetLinkInvalid)
+		}
+		return tx.Users.Update(user, models.User{Password: hashedPassword})
+	})
+}
+
+// randomToken 生成URL安全的随机令牌
+func randomToken() string {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return randomUUID()
+	}
+	return base64.URLEncoding.EncodeToString(bytes)
+}
+
+// randomUUID 生成随机的UUID（v4）
+func randomUUID() string {
+	uuid := make([]byte, 16)
+	rand.Read(uuid)
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x",
+		uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+)
+
+// 最近多长时间内有请求的用户视为在线
+const onlineWindow = 30 * time.Minute
+
+// OnlineService 用户在线状态
+type OnlineService struct {
+	statuses repositories.OnlineStatusRepository
+}
+
+// NewOnlineService 创建在线状态服务
+func NewOnlineService(statuses repositories.OnlineStatusRepository) *OnlineService {
+	return &OnlineService{statuses: statuses}
+}
+
+// Touch 记录用户最近一次活跃（由在线状态worker批量调用）
+func (s *OnlineService) Touch(userID uint, clientIP, userAgent string) error {
+	now := time.Now()
+	return s.statuses.Save(&models.UserOnlineStatus{
+		UserID:         userID,
+		LastActiveTime: now,
+		IPAddress:      clientIP,
+		SessionID:      fmt.Sprintf("batch-%d-%s", userID, now.Format("20060102150405")),
+		UserAgent:      userAgent,
+	})
+}
+
+// Count 最近30分钟内活跃的用户数
+func (s *OnlineService) Count() (int64, error) {
+	return s.statuses.CountActiveSince(time.Now().Add(-onlineWindow))
+}
+
+// Cleanup 删除超过30分钟没有活跃的在线状态
+func (s *OnlineService) Cleanup() error {
+	return s.statuses.DeleteInactiveBefore(time.Now().Add(-onlineWindow))
+}
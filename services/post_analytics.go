@@ -0,0 +1,79 @@
+package services
+
+import (
+	"time"
+	"gin-doniai/models"
+)
+
+// DailyViewStat 某天的浏览统计，Percent为相对区间内最高浏览数的百分比，用于绘制柱状图
+type DailyViewStat struct {
+	Date           string `json:"date"`
+	Views          int64  `json:"views"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+	Percent        int    `json:"-"`
+}
+
+// PostAnalytics 文章浏览分析
+type PostAnalytics struct {
+	PostID         uint            `json:"post_id"`
+	Days           int             `json:"days"`
+	TotalViews     int64           `json:"total_views"`     // 文章累计浏览数
+	PeriodViews    int64           `json:"period_views"`    // 区间内浏览数
+	PeriodVisitors int64           `json:"period_visitors"` // 区间内每日独立访客数之和
+	Daily          []DailyViewStat `json:"daily"`
+}
+
+// GetOwn 获取文章并校验当前用户是作者或管理员，否则返回 ErrForbidden
+func (s *PostService) GetOwn(user *models.User, id uint) (*models.Post, error) {
+	post, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canModify(user, uint(post.UserId)) {
+		return nil, ErrForbidden
+	}
+	return post, nil
+}
+
+// Analytics 查询文章最近 days 天的每日浏览统计，没有数据的日期补零
+func (s *PostService) Analytics(post *models.Post, days int) (PostAnalytics, error) {
+	analytics := PostAnalytics{
+		PostID:     post.ID,
+		Days:       days,
+		TotalViews: int64(post.Views),
+	}
+
+	start := time.Now().AddDate(0, 0, -(days - 1))
+	stats, err := s.viewStats.ListSince(post.ID, start.Format("2006-01-02"))
+	if err != nil {
+		return analytics, err
+	}
+
+	byDate := make(map[string]models.PostViewStat, len(stats))
+	for _, stat := range stats {
+		byDate[stat.Date] = stat
+	}
+
+	var maxViews int64
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		stat := byDate[date]
+		analytics.Daily = append(analytics.Daily, DailyViewStat{
+			Date:           date,
+			Views:          stat.Views,
+			UniqueVisitors: stat.UniqueVisitors,
+		})
+		analytics.PeriodViews += stat.Views
+		analytics.PeriodVisitors += stat.UniqueVisitors
+		if stat.Views > maxViews {
+			maxViews = stat.Views
+		}
+	}
+
+	if maxViews > 0 {
+		for i := range analytics.Daily {
+			analytics.Daily[i].Percent = int(analytics.Daily[i].Views * 100 / maxViews)
+		}
+	}
+	return analytics, nil
+}
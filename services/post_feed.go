@@ -0,0 +1,82 @@
+package services
+
+import (
+	"gin-doniai/models"
+	"gin-doniai/ranking"
+)
+
+// FeedOptions 首页/分类页文章列表参数
+type FeedOptions struct {
+	Sort       ranking.Sort
+	Period     ranking.Period // 仅 Top 排序使用
+	CategoryID int            // 0 表示全部分类
+	Cursor     string
+	Limit      int
+}
+
+// FeedPage 一页文章及下一页游标，没有下一页时 NextCursor 为空
+type FeedPage struct {
+	Sort       ranking.Sort
+	Period     ranking.Period
+	Posts      []models.Post
+	NextCursor string
+}
+
+// Feed 按热门、最新或Top排序读取一页文章
+// 热门和Top读取worker计算好的榜单，榜单尚未就绪时按最新排序
+func (s *PostService) Feed(opts FeedOptions) (FeedPage, error) {
+	page := FeedPage{Sort: opts.Sort, Period: opts.Period}
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	if page.Sort != ranking.SortNew && !ranking.Default().Ready() {
+		page.Sort = ranking.SortNew
+	}
+
+	// 游标属于另一种排序时从第一页开始，例如翻页期间榜单未就绪回退为最新排序，
+	// 热门/Top游标中没有发布时间，按最新排序使用会查不到任何文章
+	var cursor *ranking.Cursor
+	if decoded, ok := ranking.DecodeCursor(opts.Cursor); ok && decoded.Ranked() == (page.Sort != ranking.SortNew) {
+		cursor = &decoded
+	}
+
+	if page.Sort == ranking.SortNew {
+		// 多查一条用于判断是否还有下一页
+		posts, err := s.posts.Newest(opts.CategoryID, cursor, opts.Limit+1)
+		if err != nil {
+			return page, err
+		}
+		page.Posts = posts
+		if len(page.Posts) > opts.Limit {
+			page.Posts = page.Posts[:opts.Limit]
+			last := page.Posts[len(page.Posts)-1]
+			page.NextCursor = ranking.Cursor{CreatedAt: last.CreatedAt, LastID: last.ID}.Encode()
+		}
+		return page, nil
+	}
+
+	ids, next := ranking.Default().Page(page.Sort, page.Period, opts.CategoryID, cursor, opts.Limit)
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+	if len(ids) == 0 {
+		return page, nil
+	}
+
+	posts, err := s.posts.FindByIDs(ids)
+	if err != nil {
+		return page, err
+	}
+
+	// 按榜单顺序排列，榜单计算后被删除的文章直接跳过
+	byID := make(map[uint]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			page.Posts = append(page.Posts, post)
+		}
+	}
+	return page, nil
+}
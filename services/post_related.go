@@ -0,0 +1,83 @@
+package services
+
+import (
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/models"
+)
+
+// 相关文章缓存时间，worker重新计算后会主动失效
+const relatedPostsCacheTTL = 30 * time.Minute
+
+// 没有预先计算结果时退化为同分类最新文章的数量
+const relatedPostsLimit = 3
+
+// RelatedPost 相关文章及推荐理由
+type RelatedPost struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	Category  string    `json:"category"`
+	Replies   int       `json:"replies"`
+	CreatedAt time.Time `json:"created_at"`
+	Score     float64   `json:"score"`
+	Reasons   []string  `json:"reasons"`
+}
+
+// Related 读取worker预先计算的相关文章；尚未计算时退化为同分类的最新文章
+func (s *PostService) Related(post *models.Post) ([]RelatedPost, error) {
+	relations, err := s.relations.ListByPost(post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var related []RelatedPost
+	if len(relations) == 0 {
+		posts, err := s.posts.SameCategory(post.CategoryId, post.ID, relatedPostsLimit)
+		for _, p := range posts {
+			related = append(related, relatedPostFrom(p, 0, []string{"同一分类"}))
+		}
+		return related, err
+	}
+
+	ids := make([]uint, 0, len(relations))
+	for _, relation := range relations {
+		ids = append(ids, relation.RelatedID)
+	}
+	posts, err := s.posts.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+	}
+
+	// 计算后被删除的文章直接跳过
+	for _, relation := range relations {
+		if p, ok := byID[relation.RelatedID]; ok {
+			related = append(related, relatedPostFrom(p, relation.Score, relation.Reasons))
+		}
+	}
+	return related, nil
+}
+
+// CachedRelated 缓存的相关文章
+func (s *PostService) CachedRelated(post *models.Post) ([]RelatedPost, error) {
+	return caches.Remember(s.cache, caches.RelatedPostsKey(post.ID), relatedPostsCacheTTL, func() ([]RelatedPost, error) {
+		return s.Related(post)
+	})
+}
+
+func relatedPostFrom(post models.Post, score float64, reasons []string) RelatedPost {
+	return RelatedPost{
+		ID:        post.ID,
+		Title:     post.Title,
+		Author:    post.Author,
+		Category:  post.Category,
+		Replies:   post.Replies,
+		CreatedAt: post.CreatedAt,
+		Score:     score,
+		Reasons:   reasons,
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/dto"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+// 热门文章的统计范围、数量和缓存时间
+const (
+	hotPostsDays     = 30
+	hotPostsLimit    = 10
+	hotPostsCacheTTL = 10 * time.Minute
+)
+
+// PostInput 创建文章的参数
+type PostInput struct {
+	Title      string `json:"title" binding:"required"`
+	CategoryId int    `json:"category_id" binding:"required"`
+	Content    string `json:"content" binding:"required"`
+	Tags       string `json:"tags"`
+	ReadLimit  int    `json:"read_limit"`
+}
+
+// PostUpdate 修改文章的参数，未提供（零值）的字段保持不变
+type PostUpdate struct {
+	Title      string `json:"title"`
+	CategoryId int    `json:"category_id"`
+	Content    string `json:"content"`
+	Tags       string `json:"tags"`
+	ReadLimit  int    `json:"read_limit"`
+}
+
+// PostService 文章的发布、修改、删除、点赞收藏和查询
+type PostService struct {
+	repos      *repositories.Repositories // 点赞收藏需要在事务中同时写记录和计数
+	posts      repositories.PostRepository
+	categories repositories.CategoryRepository
+	reactions  repositories.ReactionRepository
+	relations  repositories.PostRelationRepository
+	viewStats  repositories.PostViewStatRepository
+	cache      *caches.Cache
+	dispatch   Dispatcher
+}
+
+// NewPostService 创建文章服务
+func NewPostService(repos *repositories.Repositories, cache *caches.Cache, dispatch Dispatcher) *PostService {
+	return &PostService{repos: repos, posts: repos.Posts, categories: repos.Categories, reactions: repos.Reactions,
+		relations: repos.Relations, viewStats: repos.ViewStats, cache: cache, dispatch: dispatch}
+}
+
+// Create 以指定用户身份创建文章，调用方负责校验登录；邮箱未验证时返回 ErrEmailNotVerified
+func (s *PostService) Create(user *models.User, input PostInput) (*models.Post, error) {
+	if !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+	category, err := s.categories.FindByID(uint(input.CategoryId))
+	if err != nil {
+		return nil, notFound(err, ErrInvalidCategory)
+	}
+
+	post := &models.Post{
+		Title:      input.Title,
+		Category:   category.Name,
+		CategoryId: input.CategoryId,
+		Content:    input.Content,
+		Tags:       utils.FormatTags(input.Tags),
+		UserId:     int(user.ID),
+		Author:     user.Name,
+		ReadLimit:  input.ReadLimit,
+	}
+	if err := s.posts.Create(post); err != nil {
+		return nil, err
+	}
+
+	s.dispatch(models.EventPostCreated, dto.NewPost(*post, true))
+	return post, nil
+}
+
+// Get 获取文章
+func (s *PostService) Get(id uint) (*models.Post, error) {
+	post, err := s.posts.FindByID(id)
+	return post, notFound(err, ErrPostNotFound)
+}
+
+// ByIDs 按ID批量获取文章，不存在的ID忽略
+func (s *PostService) ByIDs(ids []uint) ([]models.Post, error) {
+	return s.posts.FindByIDs(ids)
+}
+
+// GetWithAuthor 获取文章及作者信息
+func (s *PostService) GetWithAuthor(id uint) (*models.Post, error) {
+	post, err := s.posts.FindWithAuthor(id)
+	return post, notFound(err, ErrPostNotFound)
+}
+
+// List 按分页参数查询文章，返回当前页和分页信息
+func (s *PostService) List(query *pagination.Query) ([]models.Post, pagination.PageInfo, error) {
+	posts, err := s.posts.List(query)
+	if err != nil {
+		return nil, pagination.PageInfo{}, err
+	}
+	posts, info := pagination.Paginate(query, posts, func(post models.Post) (interface{}, uint) {
+		return PostSortValue(post, query.SortColumn()), post.ID
+	})
+	return posts, info, nil
+}
+
+// Update 修改文章（作者或管理员），修改分类时同步分类名称
+func (s *PostService) Update(user *models.User, id uint, input PostUpdate) (*models.Post, error) {
+	post, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canModify(user, uint(post.UserId)) {
+		return nil, ErrForbidden
+	}
+
+	changes := models.Post{
+		Title:      input.Title,
+		CategoryId: input.CategoryId,
+		Content:    input.Content,
+		ReadLimit:  input.ReadLimit,
+	}
+	if input.Tags != "" {
+		changes.Tags = utils.FormatTags(input.Tags)
+	}
+	if input.CategoryId != 0 && input.CategoryId != post.CategoryId {
+		category, err := s.categories.FindByID(uint(input.CategoryId))
+		if err != nil {
+			return nil, notFound(err, ErrInvalidCategory)
+		}
+		changes.Category = category.Name
+	}
+	if err := s.posts.Update(post, changes); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	s.dispatch(models.EventPostUpdated, dto.NewPost(*updated, true))
+	return updated, nil
+}
+
+// Delete 软删除文章（作者或管理员）
+func (s *PostService) Delete(user *models.User, id uint) error {
+	post, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if !canModify(user, uint(post.UserId)) {
+		return ErrForbidden
+	}
+	return s.posts.Delete(post)
+}
+
+// ForceDelete 永久删除文章（仅管理员）
+func (s *PostService) ForceDelete(user *models.User, id uint) error {
+	if user == nil || !user.IsAdmin() {
+		return ErrForbidden
+	}
+	return s.posts.ForceDelete(id)
+}
+
+// SetLike 设置用户对文章的点赞状态（重复点赞或重复取消不生效），返回最新点赞数
+func (s *PostService) SetLike(user *models.User, id uint, like bool) (int, error) {
+	if like {
+		return s.setReaction(user, id, repositories.ReactionRepository.AddLike, repositories.CounterLikes, 1, "点赞失败")
+	}
+	return s.setReaction(user, id, repositories.ReactionRepository.RemoveLike, repositories.CounterLikes, -1, "取消点赞失败")
+}
+
+// SetFavorite 设置用户对文章的收藏状态（重复收藏或重复取消不生效），返回最新收藏数
+func (s *PostService) SetFavorite(user *models.User, id uint, favorite bool) (int, error) {
+	if favorite {
+		return s.setReaction(user, id, repositories.ReactionRepository.AddFavorite, repositories.CounterFavorites, 1, "收藏失败")
+	}
+	return s.setReaction(user, id, repositories.ReactionRepository.RemoveFavorite, repositories.CounterFavorites, -1, "取消收藏失败")
+}
+
+// setReaction 在同一事务中写入或删除点赞/收藏记录，记录实际发生变化时才原子更新文章上的计数
+// 并发的重复请求由唯一索引保证只有一个生效，计数不会多加或少减
+func (s *PostService) setReaction(user *models.User, id uint, apply func(repositories.ReactionRepository, uint, uint) (bool, error),
+	counter string, delta int, failure string) (int, error) {
+	post, err := s.Get(id)
+	if err != nil {
+		return 0, err
+	}
+
+	count := post.Likes
+	if counter == repositories.CounterFavorites {
+		count = post.Favorites
+	}
+	err = s.repos.Transaction(func(tx *repositories.Repositories) error {
+		changed, err := apply(tx.Reactions, user.ID, post.ID)
+		if err != nil || !changed {
+			return err
+		}
+		count, err = tx.Posts.AddCounter(post.ID, counter, delta)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", failure, err)
+	}
+	return count, nil
+}
+
+// Hot 最近30天浏览最多的文章
+func (s *PostService) Hot() ([]models.Post, error) {
+	return s.posts.Hot(time.Now().AddDate(0, 0, -hotPostsDays), hotPostsLimit)
+}
+
+// CachedHot 缓存的热门文章
+func (s *PostService) CachedHot() ([]models.Post, error) {
+	return caches.Remember(s.cache, caches.KeyHotPosts, hotPostsCacheTTL, s.Hot)
+}
+
+// Latest 最新发布的文章
+func (s *PostService) Latest(limit int) ([]models.Post, error) {
+	return s.posts.Latest(limit)
+}
+
+// Search 按标题搜索文章，page 从1开始
+func (s *PostService) Search(keyword string, page, limit int) (repositories.Page[models.Post], error) {
+	return s.posts.Search(keyword, pageOffset(page, limit), limit)
+}
+
+// ListByUser 用户发表的文章，page 从1开始
+func (s *PostService) ListByUser(userID uint, page, limit int) (repositories.Page[models.Post], error) {
+	return s.posts.ListByUser(userID, pageOffset(page, limit), limit)
+}
+
+// Favorites 用户收藏的文章，page 从1开始
+func (s *PostService) Favorites(userID uint, page, limit int) (repositories.Page[models.Post], error) {
+	return s.reactions.Favorites(userID, pageOffset(page, limit), limit)
+}
+
+// AuthorStats 作者的发帖数、获得的回复数和点赞数
+func (s *PostService) AuthorStats(userID uint) (repositories.AuthorStats, error) {
+	return s.posts.AuthorStats(userID)
+}
+
+// PostListSpec 文章列表的排序和过滤规则
+var PostListSpec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"id":         {Column: "id", Kind: pagination.KindInt},
+		"created_at": {Column: "created_at", Kind: pagination.KindTime},
+		"updated_at": {Column: "updated_at", Kind: pagination.KindTime},
+		"views":      {Column: "views", Kind: pagination.KindInt},
+		"likes":      {Column: "likes", Kind: pagination.KindInt},
+		"replies":    {Column: "replies", Kind: pagination.KindInt},
+		"favorites":  {Column: "favorites", Kind: pagination.KindInt},
+	},
+	DefaultSort: "-created_at",
+	Filters: append([]pagination.Filter{
+		{Param: "category_id", Column: "category_id", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "category", Column: "category", Kind: pagination.KindString, Op: pagination.OpEq},
+		{Param: "author", Column: "author", Kind: pagination.KindString, Op: pagination.OpEq},
+		{Param: "author_id", Column: "user_id", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "tag", Column: "tags", Kind: pagination.KindString, Op: pagination.OpElement},
+		{Param: "read_limit", Column: "read_limit", Kind: pagination.KindInt, Op: pagination.OpEq},
+	}, pagination.CreatedAtRange()...),
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// PostSortValue 文章在排序字段上的值，用于生成下一页游标
+func PostSortValue(post models.Post, column string) interface{} {
+	switch column {
+	case "created_at":
+		return post.CreatedAt
+	case "updated_at":
+		return post.UpdatedAt
+	case "views":
+		return post.Views
+	case "likes":
+		return post.Likes
+	case "replies":
+		return post.Replies
+	case "favorites":
+		return post.Favorites
+	}
+	return post.ID
+}
@@ -0,0 +1,100 @@
+// Package services 实现文章、评论、用户、分类、账户相关的业务规则（权限、计数、缓存、事件），
+// HTML页面、JSON接口和GraphQL共用同一套逻辑；数据访问通过 repositories 中的接口注入，测试时可以替换为内存实现
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-doniai/caches"
+	"gin-doniai/config"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+)
+
+// 业务错误，由调用方转换为对应的HTTP状态码或GraphQL错误码
+var (
+	ErrPostNotFound     = errors.New("文章不存在")
+	ErrCommentNotFound  = errors.New("评论未找到")
+	ErrUserNotFound     = errors.New("用户不存在")
+	ErrCategoryNotFound = errors.New("分类未找到")
+	ErrInvalidCategory  = errors.New("无效的分类ID")
+	ErrSelfLike         = errors.New("不能给自己的评论点赞")
+	ErrForbidden        = errors.New("无权限执行此操作")
+	ErrEmailTaken       = errors.New("该邮箱已被注册")
+	ErrWrongPassword    = errors.New("密码错误")
+	ErrPasswordTooShort = errors.New("新密码长度至少6位")
+	ErrEmailNotVerified = errors.New("请先验证邮箱后再进行此操作")
+)
+
+// Dispatcher 发送webhook事件，通常为 (*webhooks.Service).Dispatch
+type Dispatcher func(event string, data interface{})
+
+// Settings 返回当前生效的配置，通常为 config.Current，配置热加载后立即生效
+type Settings func() *config.Config
+
+// Services 全部服务
+type Services struct {
+	Posts      *PostService
+	Comments   *CommentService
+	Users      *UserService
+	Categories *CategoryService
+	Counters   *CounterService
+	Emails     *EmailService
+	Tokens     *TokenService
+	Accounts   *AccountService
+	Online     *OnlineService
+	Stats      *StatsService
+
+	repos    *repositories.Repositories
+	cache    *caches.Cache
+	dispatch Dispatcher
+	settings Settings
+}
+
+// New 基于仓储创建全部服务，cache 缓存热门文章和分类，dispatch 发送webhook事件，
+// settings 提供邮件中的站点地址和SMTP配置
+func New(repos *repositories.Repositories, cache *caches.Cache, dispatch Dispatcher, settings Settings) *Services {
+	return &Services{
+		Posts:      NewPostService(repos, cache, dispatch),
+		Comments:   NewCommentService(repos, dispatch),
+		Users:      NewUserService(repos.Users, dispatch),
+		Categories: NewCategoryService(repos.Categories, cache),
+		Counters:   NewCounterService(repos.Posts, repos.Comments),
+		Emails:     NewEmailService(repos, settings),
+		Tokens:     NewTokenService(repos.APITokens, repos.Users),
+		Accounts:   NewAccountService(repos.DataExports, repos.AccountDeletions),
+		Online:     NewOnlineService(repos.OnlineStatus),
+		Stats:      NewStatsService(repos.SiteStats),
+		repos:      repos,
+		cache:      cache,
+		dispatch:   dispatch,
+		settings:   settings,
+	}
+}
+
+// WithContext 返回查询时使用 ctx 的一组服务，请求处理时传入 c.Request.Context()，
+// SQL会作为请求span的子span记录；缓存与原服务共用
+func (s *Services) WithContext(ctx context.Context) *Services {
+	return New(s.repos.WithContext(ctx), s.cache, s.dispatch, s.settings)
+}
+
+// canModify 作者本人或管理员可以修改、删除内容
+func canModify(user *models.User, ownerID uint) bool {
+	return user != nil && (user.ID == ownerID || user.IsAdmin())
+}
+
+// pageOffset 页码（从1开始）对应的偏移量
+func pageOffset(page, limit int) int {
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * limit
+}
+
+// notFound 把仓储的 ErrNotFound 转换为业务错误，其他错误原样返回
+func notFound(err, target error) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return target
+	}
+	return err
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/config"
+	"gin-doniai/models"
+	"gin-doniai/ranking"
+	"gin-doniai/repositories"
+)
+
+// 以下为内存实现的仓储，只实现测试用到的方法，其余方法调用时会因嵌入的nil接口而panic
+
+type fakePosts struct {
+	repositories.PostRepository
+	posts map[uint]*models.Post
+}
+
+func (f *fakePosts) FindByID(id uint) (*models.Post, error) {
+	post, ok := f.posts[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *post
+	return &copied, nil
+}
+
+func (f *fakePosts) Newest(categoryID int, before *ranking.Cursor, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	for _, post := range f.posts {
+		if before != nil && !post.CreatedAt.Before(before.CreatedAt) && !(post.CreatedAt.Equal(before.CreatedAt) && post.ID < before.LastID) {
+			continue
+		}
+		posts = append(posts, *post)
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (f *fakePosts) Create(post *models.Post) error {
+	post.ID = uint(len(f.posts) + 1)
+	f.posts[post.ID] = post
+	return nil
+}
+
+func (f *fakePosts) Delete(post *models.Post) error {
+	delete(f.posts, post.ID)
+	return nil
+}
+
+func (f *fakePosts) AddCounter(id uint, column string, delta int) (int, error) {
+	post := f.posts[id]
+	switch column {
+	case repositories.CounterLikes:
+		post.Likes += delta
+		return post.Likes, nil
+	case repositories.CounterFavorites:
+		post.Favorites += delta
+		return post.Favorites, nil
+	case repositories.CounterReplies:
+		post.Replies += delta
+		return post.Replies, nil
+	}
+	return 0, errors.New("unknown counter " + column)
+}
+
+type fakeCategories struct {
+	repositories.CategoryRepository
+	categories map[uint]*models.Category
+}
+
+func (f *fakeCategories) FindByID(id uint) (*models.Category, error) {
+	category, ok := f.categories[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return category, nil
+}
+
+type reactionKey struct{ user, target uint }
+
+type fakeReactions struct {
+	repositories.ReactionRepository
+	likes        map[reactionKey]bool
+	commentLikes map[reactionKey]bool
+}
+
+func toggle(records map[reactionKey]bool, key reactionKey, on bool) (bool, error) {
+	if records[key] == on {
+		return false, nil
+	}
+	if on {
+		records[key] = true
+	} else {
+		delete(records, key)
+	}
+	return true, nil
+}
+
+func (f *fakeReactions) AddLike(userID, postID uint) (bool, error) {
+	return toggle(f.likes, reactionKey{userID, postID}, true)
+}
+
+func (f *fakeReactions) RemoveLike(userID, postID uint) (bool, error) {
+	return toggle(f.likes, reactionKey{userID, postID}, false)
+}
+
+func (f *fakeReactions) AddCommentLike(userID, commentID uint) (bool, error) {
+	return toggle(f.commentLikes, reactionKey{userID, commentID}, true)
+}
+
+func (f *fakeReactions) RemoveCommentLike(userID, commentID uint) (bool, error) {
+	return toggle(f.commentLikes, reactionKey{userID, commentID}, false)
+}
+
+type fakeComments struct {
+	repositories.CommentRepository
+	comments map[uint]*models.Comment
+	onDelete func(id uint) // 删除前调用，用于模拟并发的请求
+}
+
+func (f *fakeComments) FindByID(id uint) (*models.Comment, error) {
+	comment, ok := f.comments[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (f *fakeComments) Create(comment *models.Comment) error {
+	comment.ID = uint(len(f.comments) + 1)
+	f.comments[comment.ID] = comment
+	return nil
+}
+
+func (f *fakeComments) Delete(comment *models.Comment) error {
+	if f.onDelete != nil {
+		f.onDelete(comment.ID)
+	}
+	if _, ok := f.comments[comment.ID]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(f.comments, comment.ID)
+	return nil
+}
+
+func (f *fakeComments) AddCounter(id uint, column string, delta int) (int, error) {
+	comment, ok := f.comments[id]
+	if !ok {
+		return 0, repositories.ErrNotFound
+	}
+	switch column {
+	case repositories.CounterCommentLikes:
+		comment.LikeCount += delta
+		return comment.LikeCount, nil
+	case repositories.CounterCommentReplies:
+		comment.ReplyCount += delta
+		return comment.ReplyCount, nil
+	}
+	return 0, errors.New("unknown counter " + column)
+}
+
+// recorder 记录分发的事件
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) dispatch(event string, data interface{}) {
+	r.events = append(r.events, event)
+}
+
+type fixture struct {
+	posts    *fakePosts
+	comments *fakeComments
+	events   *recorder
+	svc      *Services
+	author   *models.User
+	other    *models.User
+	admin    *models.User
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		posts:    &fakePosts{posts: map[uint]*models.Post{}},
+		comments: &fakeComments{comments: map[uint]*models.Comment{}},
+		events:   &recorder{},
+		author:   &models.User{ID: 1, Name: "author", Role: models.RoleMember},
+		other:    &models.User{ID: 2, Name: "other", Role: models.RoleMember},
+		admin:    &models.User{ID: 3, Name: "admin", Role: models.RoleAdmin},
+	}
+	repos := &repositories.Repositories{
+		Posts:      f.posts,
+		Comments:   f.comments,
+		Categories: &fakeCategories{categories: map[uint]*models.Category{1: {ID: 1, Name: "Go", Alias: "go"}}},
+		Reactions:  &fakeReactions{likes: map[reactionKey]bool{}, commentLikes: map[reactionKey]bool{}},
+	}
+	f.svc = New(repos, caches.New(caches.NewMemoryStore(16)), f.events.dispatch, config.Default)
+	return f
+}
+
+func (f *fixture) createPost(t *testing.T) *models.Post {
+	t.Helper()
+	post, err := f.svc.Posts.Create(f.author, PostInput{Title: "Hello", CategoryId: 1, Content: "<p>正文</p>"})
+	if err != nil {
+		t.Fatalf("创建文章失败: %v", err)
+	}
+	return post
+}
+
+func TestCreatePost(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+	if post.Category != "Go" || post.UserId != int(f.author.ID) || post.Author != f.author.Name {
+		t.Errorf("文章的分类或作者不正确: %+v", post)
+	}
+	if len(f.events.events) != 1 || f.events.events[0] != models.EventPostCreated {
+		t.Errorf("事件 = %v, 期望 [%s]", f.events.events, models.EventPostCreated)
+	}
+
+	_, err := f.svc.Posts.Create(f.author, PostInput{Title: "x", CategoryId: 99, Content: "x"})
+	if !errors.Is(err, ErrInvalidCategory) {
+		t.Errorf("无效分类 err = %v, 期望 ErrInvalidCategory", err)
+	}
+}
+
+func TestCreateRequiresVerifiedEmail(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+	unverified := &models.User{ID: 4, Name: "new", Role: models.RoleMember, EmailStatus: models.EmailStatusUnverified}
+
+	if _, err := f.svc.Posts.Create(unverified, PostInput{Title: "x", CategoryId: 1, Content: "x"}); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("未验证邮箱发帖 err = %v, 期望 ErrEmailNotVerified", err)
+	}
+	if _, err := f.svc.Comments.Create(unverified, CommentInput{PostID: post.ID, Content: "x"}); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("未验证邮箱评论 err = %v, 期望 ErrEmailNotVerified", err)
+	}
+	if len(f.posts.posts) != 1 || len(f.comments.comments) != 0 {
+		t.Errorf("未验证邮箱时不应写入数据，文章 %d 篇，评论 %d 条", len(f.posts.posts), len(f.comments.comments))
+	}
+}
+
+func TestDeletePostPermissions(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+
+	if err := f.svc.Posts.Delete(f.other, post.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("非作者删除 err = %v, 期望 ErrForbidden", err)
+	}
+	if err := f.svc.Posts.ForceDelete(f.author, post.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("非管理员永久删除 err = %v, 期望 ErrForbidden", err)
+	}
+	if err := f.svc.Posts.Delete(f.admin, post.ID); err != nil {
+		t.Errorf("管理员删除失败: %v", err)
+	}
+	if err := f.svc.Posts.Delete(f.author, post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("重复删除 err = %v, 期望 ErrPostNotFound", err)
+	}
+}
+
+func TestSetLikeIsIdempotent(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+
+	steps := []struct {
+		user  *models.User
+		like  bool
+		likes int
+	}{
+		{f.other, true, 1},
+		{f.other, true, 1}, // 重复点赞不计数
+		{f.admin, true, 2},
+		{f.other, false, 1},
+		{f.other, false, 1}, // 重复取消不计数
+	}
+	for i, step := range steps {
+		likes, err := f.svc.Posts.SetLike(step.user, post.ID, step.like)
+		if err != nil {
+			t.Fatalf("第%d步失败: %v", i+1, err)
+		}
+		if likes != step.likes {
+			t.Errorf("第%d步点赞数 = %d, 期望 %d", i+1, likes, step.likes)
+		}
+	}
+
+	if _, err := f.svc.Posts.SetLike(f.other, 99, true); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("不存在的文章 err = %v, 期望 ErrPostNotFound", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+
+	comment, err := f.svc.Comments.Create(f.other, CommentInput{PostID: post.ID, Content: "<p>评论</p>"})
+	if err != nil {
+		t.Fatalf("创建评论失败: %v", err)
+	}
+	if f.posts.posts[post.ID].Replies != 1 {
+		t.Errorf("回复数 = %d, 期望 1", f.posts.posts[post.ID].Replies)
+	}
+	if _, err := f.svc.Comments.Create(f.other, CommentInput{PostID: 99, Content: "x"}); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("不存在的文章 err = %v, 期望 ErrPostNotFound", err)
+	}
+
+	if _, err := f.svc.Comments.SetLike(f.other, comment.ID, true); !errors.Is(err, ErrSelfLike) {
+		t.Errorf("给自己点赞 err = %v, 期望 ErrSelfLike", err)
+	}
+	for i, want := range []int{1, 1} { // 重复点赞不计数
+		if likes, err := f.svc.Comments.SetLike(f.author, comment.ID, true); err != nil || likes != want {
+			t.Errorf("第%d次点赞 = (%d, %v), 期望 (%d, nil)", i+1, likes, err, want)
+		}
+	}
+	if likes, err := f.svc.Comments.SetLike(f.author, comment.ID, false); err != nil || likes != 0 {
+		t.Errorf("取消点赞 = (%d, %v), 期望 (0, nil)", likes, err)
+	}
+
+	// 回复增加父评论和文章的回复数，删除时减回
+	reply, err := f.svc.Comments.Create(f.author, CommentInput{PostID: post.ID, ParentID: comment.ID, Content: "回复"})
+	if err != nil {
+		t.Fatalf("回复失败: %v", err)
+	}
+	if f.comments.comments[comment.ID].ReplyCount != 1 || f.posts.posts[post.ID].Replies != 2 {
+		t.Errorf("回复后评论/文章回复数 = %d/%d, 期望 1/2", f.comments.comments[comment.ID].ReplyCount, f.posts.posts[post.ID].Replies)
+	}
+	if _, err := f.svc.Comments.Create(f.author, CommentInput{PostID: post.ID, ParentID: 99, Content: "x"}); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("回复不存在的评论 err = %v, 期望 ErrCommentNotFound", err)
+	}
+	if err := f.svc.Comments.Delete(f.author, reply.ID); err != nil {
+		t.Fatalf("删除回复失败: %v", err)
+	}
+	if f.comments.comments[comment.ID].ReplyCount != 0 || f.posts.posts[post.ID].Replies != 1 {
+		t.Errorf("删除后评论/文章回复数 = %d/%d, 期望 0/1", f.comments.comments[comment.ID].ReplyCount, f.posts.posts[post.ID].Replies)
+	}
+
+	if _, err := f.svc.Comments.Update(f.author, comment.ID, "改"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("非作者修改 err = %v, 期望 ErrForbidden", err)
+	}
+}
+
+func TestDeleteCommentOnlyOnce(t *testing.T) {
+	f := newFixture()
+	post := f.createPost(t)
+	comment, err := f.svc.Comments.Create(f.other, CommentInput{PostID: post.ID, Content: "评论"})
+	if err != nil {
+		t.Fatalf("创建评论失败: %v", err)
+	}
+	reply, err := f.svc.Comments.Create(f.author, CommentInput{PostID: post.ID, ParentID: comment.ID, Content: "回复"})
+	if err != nil {
+		t.Fatalf("回复失败: %v", err)
+	}
+
+	// 读取评论之后、删除之前，另一个请求已经删除了这条回复（并调整了计数）
+	f.comments.onDelete = func(id uint) {
+		delete(f.comments.comments, id)
+		f.comments.comments[comment.ID].ReplyCount--
+		f.posts.posts[post.ID].Replies--
+	}
+	if err := f.svc.Comments.Delete(f.author, reply.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("并发删除 err = %v, 期望 ErrCommentNotFound", err)
+	}
+	if f.comments.comments[comment.ID].ReplyCount != 0 || f.posts.posts[post.ID].Replies != 1 {
+		t.Errorf("删除后评论/文章回复数 = %d/%d, 期望 0/1（只减一次）", f.comments.comments[comment.ID].ReplyCount, f.posts.posts[post.ID].Replies)
+	}
+
+	f.comments.onDelete = nil
+	if err := f.svc.Comments.Delete(f.author, reply.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("重复删除 err = %v, 期望 ErrCommentNotFound", err)
+	}
+}
+
+func TestPageOffset(t *testing.T) {
+	cases := []struct{ page, limit, offset int }{
+		{1, 10, 0},
+		{3, 10, 20},
+		{0, 10, 0},
+		{-1, 5, 0},
+	}
+	for _, tc := range cases {
+		if got := pageOffset(tc.page, tc.limit); got != tc.offset {
+			t.Errorf("pageOffset(%d, %d) = %d, 期望 %d", tc.page, tc.limit, got, tc.offset)
+		}
+	}
+}
+
+func TestFeedFallbackIgnoresRankedCursor(t *testing.T) {
+	f := newFixture()
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		post := f.createPost(t)
+		post.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+	if ranking.Default().Ready() {
+		t.Skip("榜单已就绪，无法测试回退")
+	}
+
+	ids := func(page FeedPage) []uint {
+		var result []uint
+		for _, post := range page.Posts {
+			result = append(result, post.ID)
+		}
+		return result
+	}
+
+	// 热门第一页的游标在榜单未就绪时按最新排序从第一页开始，而不是返回空页
+	hot := ranking.Cursor{Generation: base.UnixNano(), Offset: 2, LastID: 2}.Encode()
+	page, err := f.svc.Posts.Feed(FeedOptions{Sort: ranking.SortHot, Cursor: hot, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Sort != ranking.SortNew || len(page.Posts) != 2 || page.Posts[0].ID != 3 || page.NextCursor == "" {
+		t.Fatalf("回退后第一页 = %v, sort=%s next=%q, 期望最新的两篇 [3 2]", ids(page), page.Sort, page.NextCursor)
+	}
+
+	// 回退后生成的是最新排序的游标，可以继续翻页
+	page, err = f.svc.Posts.Feed(FeedOptions{Sort: ranking.SortHot, Cursor: page.NextCursor, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].ID != 1 || page.NextCursor != "" {
+		t.Errorf("回退后第二页 = %v, next=%q, 期望 [1]", ids(page), page.NextCursor)
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+)
+
+// StatsService 社区统计的历史快照（实时计数由 stats 包在内存中维护）
+type StatsService struct {
+	snapshots repositories.SiteStatRepository
+}
+
+// NewStatsService 创建社区统计服务
+func NewStatsService(snapshots repositories.SiteStatRepository) *StatsService {
+	return &StatsService{snapshots: snapshots}
+}
+
+// Daily 最近 days 天的每日快照，按日期升序
+func (s *StatsService) Daily(days int) ([]models.SiteStatSnapshot, error) {
+	return s.snapshots.ListAfter(time.Now().AddDate(0, 0, -days).Format("2006-01-02"))
+}
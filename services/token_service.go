@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+// 明文令牌的固定前缀
+const apiTokenPrefix = "dn_"
+
+// API令牌的业务错误
+var (
+	ErrTokenNotFound    = errors.New("令牌不存在")
+	ErrInvalidScope     = errors.New("无效的权限范围")
+	ErrAdminScope       = errors.New("只有管理员可以创建admin权限的令牌")
+	ErrInvalidToken     = errors.New("无效的API令牌")
+	ErrTokenExpired     = errors.New("API令牌已过期")
+	ErrTokenUserMissing = errors.New("令牌所属用户不存在")
+)
+
+// TokenInput 创建API令牌的参数
+type TokenInput struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"` // 0 表示永不过期
+}
+
+// TokenService 个人API令牌的创建、吊销和认证
+type TokenService struct {
+	tokens repositories.APITokenRepository
+	users  repositories.UserRepository
+}
+
+// NewTokenService 创建API令牌服务
+func NewTokenService(tokens repositories.APITokenRepository, users repositories.UserRepository) *TokenService {
+	return &TokenService{tokens: tokens, users: users}
+}
+
+// List 用户的全部API令牌
+func (s *TokenService) List(userID uint) ([]models.APIToken, error) {
+	return s.tokens.ListByUser(userID)
+}
+
+// Create 创建API令牌，返回只展示一次的明文令牌；admin 权限只有管理员可以申请
+func (s *TokenService) Create(user *models.User, input TokenInput) (string, *models.APIToken, error) {
+	for _, scope := range input.Scopes {
+		valid := false
+		for _, s := range models.AllScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if scope == models.ScopeAdmin && !user.IsAdmin() {
+			return "", nil, ErrAdminScope
+		}
+	}
+
+	raw := apiTokenPrefix + strings.TrimRight(randomToken(), "=")
+	token := &models.APIToken{
+		UserID:    user.ID,
+		Name:      input.Name,
+		TokenHash: utils.HashToken(raw),
+		Prefix:    raw[:len(apiTokenPrefix)+6],
+		Scopes:    strings.Join(input.Scopes, ","),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokens.Create(token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// Revoke 吊销用户自己的API令牌
+func (s *TokenService) Revoke(user *models.User, id uint) error {
+	token, err := s.tokens.FindForUser(id, user.ID)
+	if err != nil {
+		return notFound(err, ErrTokenNotFound)
+	}
+	return s.tokens.Delete(token)
+}
+
+// Authenticate 校验明文令牌，返回令牌和所属用户；最近使用时间一分钟内只写一次库
+func (s *TokenService) Authenticate(raw, clientIP string) (*models.APIToken, *models.User, error) {
+	token, err := s.tokens.FindByHash(utils.HashToken(raw))
+	if err != nil {
+		return nil, nil, notFound(err, ErrInvalidToken)
+	}
+	if token.IsExpired() {
+		return nil, nil, ErrTokenExpired
+	}
+
+	user, err := s.users.FindByID(token.UserID)
+	if err != nil {
+		return nil, nil, notFound(err, ErrTokenUserMissing)
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+		s.tokens.Touch(token, now, clientIP)
+	}
+	return token, user, nil
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"gin-doniai/dto"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+// 新密码最短长度
+const minPasswordLength = 6
+
+// RegisterInput 注册参数，调用方负责校验确认密码和用户协议
+type RegisterInput struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// CreateUserInput 管理员创建用户的参数
+type CreateUserInput struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateUserInput 管理员修改用户的参数，空字段保持不变；邮箱（需走验证流程）和角色不能通过该接口修改
+type UpdateUserInput struct {
+	Name          string `json:"name"`
+	Password      string `json:"password"`
+	Avatar        string `json:"avatar"`
+	Level         int    `json:"level"`
+	Motto         string `json:"motto"`
+	Github        string `json:"github"`
+	GoogleAccount string `json:"google_account"`
+}
+
+// ProfileInput 修改个人资料的参数，空字段保持不变
+type ProfileInput struct {
+	Motto         string `json:"motto"`
+	Github        string `json:"github"`
+	GoogleAccount string `json:"google_account"`
+}
+
+// UserService 用户注册、登录、资料修改和查询
+type UserService struct {
+	users    repositories.UserRepository
+	dispatch Dispatcher
+}
+
+// NewUserService 创建用户服务
+func NewUserService(users repositories.UserRepository, dispatch Dispatcher) *UserService {
+	return &UserService{users: users, dispatch: dispatch}
+}
+
+// Get 获取用户
+func (s *UserService) Get(id uint) (*models.User, error) {
+	user, err := s.users.FindByID(id)
+	return user, notFound(err, ErrUserNotFound)
+}
+
+// ByIDs 按ID批量获取用户，不存在的ID忽略
+func (s *UserService) ByIDs(ids []uint) ([]models.User, error) {
+	return s.users.FindByIDs(ids)
+}
+
+// List 按分页参数查询用户，返回当前页和分页信息
+func (s *UserService) List(query *pagination.Query) ([]models.User, pagination.PageInfo, error) {
+	users, err := s.users.List(query)
+	if err != nil {
+		return nil, pagination.PageInfo{}, err
+	}
+	users, info := pagination.Paginate(query, users, func(user models.User) (interface{}, uint) {
+		return UserSortValue(user, query.SortColumn()), user.ID
+	})
+	return users, info, nil
+}
+
+// Search 按用户名或邮箱搜索用户，page 从1开始
+func (s *UserService) Search(keyword string, page, limit int) (repositories.Page[models.User], error) {
+	return s.users.Search(keyword, pageOffset(page, limit), limit)
+}
+
+// Register 注册新用户（邮箱未验证），发送验证邮件由调用方负责
+func (s *UserService) Register(input RegisterInput) (*models.User, error) {
+	if _, err := s.users.FindByEmail(input.Email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return nil, fmt.Errorf("密码加密失败: %w", err)
+	}
+	user := &models.User{
+		Name:        input.Name,
+		Email:       input.Email,
+		Password:    hashedPassword,
+		AgreeTerms:  true,
+		Avatar:      fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", input.Name), // 基于用户名生成头像
+		EmailStatus: models.EmailStatusUnverified,
+		Role:        models.RoleMember,
+	}
+	if err := s.users.Create(user); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+
+	s.dispatch(models.EventUserRegistered, dto.NewUser(*user, false))
+	return user, nil
+}
+
+// OAuthProfile 第三方登录返回的用户信息，Identifier 为GitHub用户名或Google邮箱
+type OAuthProfile struct {
+	Identifier string
+	Email      string
+	Name       string
+	AvatarURL  string
+}
+
+// LoginOAuth 第三方登录：优先按邮箱、其次按用户名查找已有用户，都不存在时创建新用户（随机密码）
+func (s *UserService) LoginOAuth(profile OAuthProfile) (*models.User, error) {
+	if profile.Email != "" {
+		user, err := s.users.FindByEmail(profile.Email)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+	}
+	if profile.Identifier != "" {
+		user, err := s.users.FindByName(profile.Identifier)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	// 生成随机密码
+	passwordBytes := make([]byte, 32)
+	rand.Read(passwordBytes)
+	hashedPassword, err := utils.HashPassword(base64.URLEncoding.EncodeToString(passwordBytes))
+	if err != nil {
+		return nil, fmt.Errorf("密码加密失败: %w", err)
+	}
+
+	// 没有名字时使用标识符，没有头像时生成默认头像
+	name := profile.Name
+	if name == "" {
+		name = profile.Identifier
+	}
+	avatarURL := profile.AvatarURL
+	if avatarURL == "" {
+		avatarURL = fmt.Sprintf("https://ui-avatars.com/api/?name=%s&background=random", url.QueryEscape(name))
+	}
+
+	user := &models.User{
+		Name:       name,
+		Email:      profile.Email,
+		Password:   hashedPassword,
+		AgreeTerms: true, // OAuth用户默认同意条款
+		Avatar:     avatarURL,
+	}
+	if err := s.users.Create(user); err != nil {
+		return nil, err
+	}
+	s.dispatch(models.EventUserRegistered, dto.NewUser(*user, false))
+	return user, nil
+}
+
+// Authenticate 使用邮箱或用户名和密码登录
+func (s *UserService) Authenticate(identifier, password string) (*models.User, error) {
+	user, err := s.users.FindByLogin(identifier)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if !utils.CheckPassword(password, user.Password) {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+// Create 管理员直接创建用户，与注册相同：只能创建普通用户，密码加密保存，邮箱待验证
+func (s *UserService) Create(input CreateUserInput) (*models.User, error) {
+	if len(input.Password) < minPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+	return s.Register(RegisterInput{Name: input.Name, Email: input.Email, Password: input.Password})
+}
+
+// Update 管理员修改用户信息，新密码加密后保存
+func (s *UserService) Update(id uint, input UpdateUserInput) (*models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	changes := models.User{
+		Name:          input.Name,
+		Avatar:        input.Avatar,
+		Level:         input.Level,
+		Motto:         input.Motto,
+		Github:        input.Github,
+		GoogleAccount: input.GoogleAccount,
+	}
+	if input.Password != "" {
+		if len(input.Password) < minPasswordLength {
+			return nil, ErrPasswordTooShort
+		}
+		if changes.Password, err = utils.HashPassword(input.Password); err != nil {
+			return nil, fmt.Errorf("密码加密失败: %w", err)
+		}
+	}
+	if err := s.users.Update(user, changes); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateProfile 修改个人资料
+func (s *UserService) UpdateProfile(user *models.User, input ProfileInput) error {
+	return s.users.Update(user, models.User{
+		Motto:         input.Motto,
+		Github:        input.Github,
+		GoogleAccount: input.GoogleAccount,
+	})
+}
+
+// ChangePassword 校验当前密码后修改密码
+func (s *UserService) ChangePassword(user *models.User, current, next string) error {
+	if !utils.CheckPassword(current, user.Password) {
+		return ErrWrongPassword
+	}
+	if len(next) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	hashedPassword, err := utils.HashPassword(next)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+	return s.users.Update(user, models.User{Password: hashedPassword})
+}
+
+// Delete 软删除用户
+func (s *UserService) Delete(id uint) error {
+	user, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.users.Delete(user)
+}
+
+// ForceDelete 永久删除用户
+func (s *UserService) ForceDelete(id uint) error {
+	return s.users.ForceDelete(id)
+}
+
+// CanSeePrivate 当前用户是否可以查看该用户的邮箱等信息（本人或管理员）
+func CanSeePrivate(current *models.User, userID uint) bool {
+	return current != nil && (current.ID == userID || current.IsAdmin())
+}
+
+// UserListSpec 用户列表的排序和过滤规则（不支持按邮箱过滤，避免被用来探测注册邮箱）
+// 角色和邮箱验证状态只有本人和管理员可见，按它们过滤只对管理员开放，见 AdminUserListSpec
+var UserListSpec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"id":         {Column: "id", Kind: pagination.KindInt},
+		"created_at": {Column: "created_at", Kind: pagination.KindTime},
+		"level":      {Column: "level", Kind: pagination.KindInt},
+		"name":       {Column: "name", Kind: pagination.KindString},
+	},
+	DefaultSort: "-created_at",
+	Filters: append([]pagination.Filter{
+		{Param: "name", Column: "name", Kind: pagination.KindString, Op: pagination.OpContains},
+		{Param: "level", Column: "level", Kind: pagination.KindInt, Op: pagination.OpEq},
+	}, pagination.CreatedAtRange()...),
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// AdminUserListSpec 管理员查询用户列表时额外支持按角色和邮箱验证状态过滤
+var AdminUserListSpec = func() pagination.Spec {
+	spec := UserListSpec
+	spec.Filters = append([]pagination.Filter{
+		{Param: "role", Column: "role", Kind: pagination.KindInt, Op: pagination.OpEq},
+		{Param: "status", Column: "email_status", Kind: pagination.KindInt, Op: pagination.OpEq},
+	}, UserListSpec.Filters...)
+	return spec
+}()
+
+// UserListSpecFor 按当前用户选择用户列表的过滤规则，未登录时传nil
+func UserListSpecFor(current *models.User) pagination.Spec {
+	if current != nil && current.IsAdmin() {
+		return AdminUserListSpec
+	}
+	return UserListSpec
+}
+
+// UserSortValue 用户在排序字段上的值，用于生成下一页游标
+func UserSortValue(user models.User, column string) interface{} {
+	switch column {
+	case "created_at":
+		return user.CreatedAt
+	case "level":
+		return user.Level
+	case "name":
+		return user.Name
+	}
+	return user.ID
+}
@@ -1,3 +1,24 @@
+// 为所有修改状态的同源请求自动附带CSRF令牌（令牌由页面<head>中的meta标签提供）
+(function() {
+  const meta = document.querySelector('meta[name="csrf-token"]');
+  if (!meta) return;
+  const csrfToken = meta.getAttribute('content');
+  const originalFetch = window.fetch;
+
+  window.fetch = function(input, init) {
+    init = init || {};
+    const method = (init.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+    const url = new URL(input instanceof Request ? input.url : input, window.location.href);
+
+    if (url.origin === window.location.origin && !['GET', 'HEAD', 'OPTIONS'].includes(method)) {
+      const headers = new Headers(init.headers || (input instanceof Request ? input.headers : {}));
+      headers.set('X-CSRF-Token', csrfToken);
+      init.headers = headers;
+    }
+    return originalFetch.call(this, input, init);
+  };
+})();
+
 // 自定义Alert组件
 class CustomAlert {
   constructor() {
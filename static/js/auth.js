@@ -327,7 +327,7 @@ class AuthPage {
           .then(data => {
             console.log('注册响应:', data);
             if (data.status === 'success') {
-              this.showSuccess('注册成功！正在跳转...');
+              this.showSuccess('注册成功！验证邮件已发送，请查收');
               setTimeout(() => {
                 this.switchTab('login');
               }, 1500);
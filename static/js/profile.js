@@ -80,7 +80,211 @@ document.getElementById('securityForm').addEventListener('submit', function(e) {
         });
 });
 
-// 切换密码可见性
+// 重新发送验证邮件
+const resendVerifyBtn = document.getElementById('resendVerifyBtn');
+if (resendVerifyBtn) {
+    resendVerifyBtn.addEventListener('click', function() {
+        fetch('/api/auth/verify-email/resend', {
+            method: 'POST',
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 修改邮箱功能
+document.getElementById('emailForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+
+    const emailData = {
+        new_email: document.getElementById('newEmail').value,
+        password: document.getElementById('emailPassword').value
+    };
+
+    fetch('/api/users/email', {
+        method: 'PUT',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(emailData)
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                customAlert.success(data.message);
+                document.getElementById('emailForm').reset();
+            } else {
+                customAlert.error('修改邮箱失败: ' + data.message);
+            }
+        })
+        .catch(error => {
+            console.error('Error:', error);
+            customAlert.error('网络错误，请稍后重试');
+        });
+});
+
+// 创建API令牌
+document.getElementById('apiTokenForm').addEventListener('submit', function(e) {
+    e.preventDefault();
+
+    const scopes = Array.from(document.querySelectorAll('input[name="tokenScopes"]:checked'))
+        .map(input => input.value);
+    if (scopes.length === 0) {
+        customAlert.error('请至少选择一个权限');
+        return;
+    }
+
+    const tokenData = {
+        name: document.getElementById('tokenName').value,
+        scopes: scopes,
+        expires_in_days: parseInt(document.getElementById('tokenExpires').value, 10)
+    };
+
+    fetch('/api/tokens', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(tokenData)
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                document.getElementById('tokenCreatedValue').textContent = data.token;
+                document.getElementById('tokenCreated').style.display = 'block';
+                document.getElementById('apiTokenForm').reset();
+                customAlert.success(data.message);
+            } else {
+                customAlert.error('创建令牌失败: ' + data.message);
+            }
+        })
+        .catch(error => {
+            console.error('Error:', error);
+            customAlert.error('网络错误，请稍后重试');
+        });
+});
+
+// 吊销API令牌
+document.querySelectorAll('.revoke-token-btn').forEach(button => {
+    button.addEventListener('click', function() {
+        if (!confirm('确定要吊销该令牌吗？使用该令牌的脚本将立即失效。')) {
+            return;
+        }
+
+        fetch(`/api/tokens/${this.dataset.id}`, {
+            method: 'DELETE',
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    this.closest('tr').remove();
+                    customAlert.success(data.message);
+                } else {
+                    customAlert.error('吊销失败: ' + data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+});
+
+// 申请数据导出
+document.getElementById('requestExportBtn').addEventListener('click', function() {
+    fetch('/api/account/export', {
+        method: 'POST',
+    })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                customAlert.success(data.message);
+                setTimeout(() => window.location.reload(), 1500);
+            } else {
+                customAlert.error(data.message);
+            }
+        })
+        .catch(error => {
+            console.error('Error:', error);
+            customAlert.error('网络错误，请稍后重试');
+        });
+});
+
+// 申请注销账户
+const deleteAccountForm = document.getElementById('deleteAccountForm');
+if (deleteAccountForm) {
+    deleteAccountForm.addEventListener('submit', function(e) {
+        e.preventDefault();
+
+        if (!confirm('确定要注销账户吗？冷静期结束后个人数据将被永久删除。')) {
+            return;
+        }
+
+        fetch('/api/account/delete', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({
+                password: document.getElementById('deletePassword').value
+            })
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1500);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 撤销注销申请
+const cancelDeletionBtn = document.getElementById('cancelDeletionBtn');
+if (cancelDeletionBtn) {
+    cancelDeletionBtn.addEventListener('click', function() {
+        fetch('/api/account/delete/cancel', {
+            method: 'POST',
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.success) {
+                    customAlert.success(data.message);
+                    setTimeout(() => window.location.reload(), 1500);
+                } else {
+                    customAlert.error(data.message);
+                }
+            })
+            .catch(error => {
+                console.error('Error:', error);
+                customAlert.error('网络错误，请稍后重试');
+            });
+    });
+}
+
+// 切换密码可见性（不使用内联onclick，以兼容CSP）
+document.querySelectorAll('.password-toggle[data-target]').forEach(toggle => {
+    toggle.addEventListener('click', function() {
+        togglePasswordVisibility(this.dataset.target);
+    });
+});
+
 function togglePasswordVisibility(inputId) {
     const input = document.getElementById(inputId);
     const toggleIcon = input.nextElementSibling;
@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"gorm.io/gorm"
+)
+
+// RegisterHooks 注册GORM回调，在用户、文章、评论创建或删除后增量更新计数
+func RegisterHooks(db *gorm.DB, s *Service) error {
+	if err := db.Callback().Create().After("gorm:create").Register("stats:after_create", func(tx *gorm.DB) {
+		s.apply(tx, 1)
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("stats:after_delete", func(tx *gorm.DB) {
+		// 已软删除的记录再被永久删除时会重复扣减，由定期校准修正
+		s.apply(tx, -1)
+	})
+}
+
+func (s *Service) apply(tx *gorm.DB, sign int64) {
+	if tx.Error != nil || tx.Statement == nil || tx.RowsAffected <= 0 {
+		return
+	}
+	if counter := s.counter(tx.Statement.Table); counter != nil {
+		counter.Add(sign * tx.RowsAffected)
+	}
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+	"gin-doniai/models"
+	"gorm.io/gorm"
+)
+
+// 在线用户的活跃时间窗口
+const OnlineWindow = 30 * time.Minute
+
+// Service 维护社区统计计数，读取为O(1)
+// 用户、文章、评论数通过GORM钩子增量维护，并由worker定期从数据库校准；在线人数由worker定期刷新
+type Service struct {
+	users    atomic.Int64
+	posts    atomic.Int64
+	comments atomic.Int64
+	online   atomic.Int64
+
+	reconciledAt atomic.Int64 // 最近一次校准时间（Unix秒）
+}
+
+// Snapshot 当前统计值
+type Snapshot struct {
+	UserCount    int64     `json:"user_count"`
+	PostCount    int64     `json:"post_count"`
+	CommentCount int64     `json:"comment_count"`
+	OnlineCount  int64     `json:"online_count"`
+	ReconciledAt time.Time `json:"reconciled_at"`
+}
+
+var defaultService = &Service{}
+
+// Default 返回全局统计服务
+func Default() *Service {
+	return defaultService
+}
+
+// Snapshot 读取当前统计值
+func (s *Service) Snapshot() Snapshot {
+	return Snapshot{
+		UserCount:    s.users.Load(),
+		PostCount:    s.posts.Load(),
+		CommentCount: s.comments.Load(),
+		OnlineCount:  s.online.Load(),
+		ReconciledAt: time.Unix(s.reconciledAt.Load(), 0),
+	}
+}
+
+// counter 返回数据表对应的计数器
+func (s *Service) counter(table string) *atomic.Int64 {
+	switch table {
+	case "users":
+		return &s.users
+	case "posts":
+		return &s.posts
+	case "comments":
+		return &s.comments
+	}
+	return nil
+}
+
+// Reconcile 从数据库重新统计全部计数，修正增量维护产生的偏差
+func (s *Service) Reconcile(db *gorm.DB) error {
+	var users, posts, comments int64
+	if err := db.Model(&models.User{}).Count(&users).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.Post{}).Count(&posts).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.Comment{}).Count(&comments).Error; err != nil {
+		return err
+	}
+
+	s.users.Store(users)
+	s.posts.Store(posts)
+	s.comments.Store(comments)
+	s.reconciledAt.Store(time.Now().Unix())
+	return s.RefreshOnline(db)
+}
+
+// RefreshOnline 刷新在线人数（最近30分钟内活跃的用户）
+func (s *Service) RefreshOnline(db *gorm.DB) error {
+	var online int64
+	err := db.Model(&models.UserOnlineStatus{}).
+		Where("last_active_time > ?", time.Now().Add(-OnlineWindow)).
+		Count(&online).Error
+	if err != nil {
+		return err
+	}
+	s.online.Store(online)
+	return nil
+}
+
+// SaveDailySnapshot 保存当天的统计快照，同一天多次保存时覆盖计数并保留在线峰值
+func (s *Service) SaveDailySnapshot(db *gorm.DB) error {
+	current := s.Snapshot()
+	today := time.Now().Format("2006-01-02")
+
+	var snapshot models.SiteStatSnapshot
+	err := db.Where("date = ?", today).First(&snapshot).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	snapshot.Date = today
+	snapshot.UserCount = current.UserCount
+	snapshot.PostCount = current.PostCount
+	snapshot.CommentCount = current.CommentCount
+	if current.OnlineCount > snapshot.OnlinePeak {
+		snapshot.OnlinePeak = current.OnlineCount
+	}
+	return db.Save(&snapshot).Error
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"gin-doniai/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetupStdout(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	var out bytes.Buffer
+	shutdown, err := Setup(config.TracingConfig{Exporter: "stdout", SampleRatio: 1, ServiceName: "doniai-test"}, "1.0.0", &out)
+	if err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("查询失败"))
+	End(parent, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{`"Name":"parent"`, `"Name":"child"`, `"Description":"查询失败"`, "doniai-test", parent.SpanContext().TraceID().String()} {
+		if !strings.Contains(got, want) {
+			t.Errorf("导出内容缺少 %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(config.TracingConfig{Exporter: "none"}, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("关闭失败: %v", err)
+	}
+	if _, err := Setup(config.TracingConfig{Exporter: "zipkin"}, "1.0.0", nil); err == nil {
+		t.Error("不支持的导出方式应返回错误")
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"gin-doniai/config"
+)
+
+// SendMail 按 cfg 发送邮件
+// 配置了 smtp.host（SMTP_HOST）时通过SMTP发送，否则不发送，只在debug日志中记录收件人和主题（开发环境）
+// 邮件正文包含验证、重置密码令牌，不写入日志
+func SendMail(cfg config.SMTPConfig, to, subject, body string) error {
+	if cfg.Host == "" {
+		slog.Debug("未配置SMTP，跳过发送邮件", "to", to, "subject", subject)
+		return nil
+	}
+
+	host, port := cfg.Host, strconv.Itoa(cfg.Port)
+	username, password := cfg.User, cfg.Password
+	from := cfg.From
+	if from == "" {
+		from = username
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("邮件发送失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Truncate 按字符截断字符串
+func Truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// StripHTML 去掉HTML标签并合并空白，用于生成纯文本摘要
+func StripHTML(s string) string {
+	text := html.UnescapeString(htmlTagPattern.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -39,3 +39,23 @@ func ParseTags(tagStr string) []string {
     return cleanedTags
 }
 
+
+// FormatTags 把JSON数组或逗号分隔的标签统一保存为JSON数组（如 ["go","gin"]），列表接口按元素过滤标签时依赖这一格式
+func FormatTags(tagStr string) string {
+    return EncodeTags(ParseTags(tagStr))
+}
+
+// EncodeTags 把标签列表编码为保存用的JSON数组，去掉首尾空白和空标签，标签内的逗号保持不变
+func EncodeTags(tags []string) string {
+    cleaned := make([]string, 0, len(tags))
+    for _, tag := range tags {
+        if trimmedTag := strings.TrimSpace(tag); trimmedTag != "" {
+            cleaned = append(cleaned, trimmedTag)
+        }
+    }
+    var b strings.Builder
+    enc := json.NewEncoder(&b)
+    enc.SetEscapeHTML(false) // 与浏览器 JSON.stringify 的结果一致
+    enc.Encode(cleaned)
+    return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"strings"
+)
+
+// 常见爬虫、监控和命令行工具的UA关键字（小写）
+var botUserAgentKeywords = []string{
+	"bot", "spider", "crawl", "slurp", "curl", "wget", "python-requests",
+	"go-http-client", "httpclient", "java/", "okhttp", "headless", "phantomjs",
+	"lighthouse", "pingdom", "uptime", "monitor", "facebookexternalhit", "preview",
+}
+
+// IsBotUserAgent 判断UA是否为爬虫或程序访问，空UA也视为机器访问
+func IsBotUserAgent(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, keyword := range botUserAgentKeywords {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+	return false
+}
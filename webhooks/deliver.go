@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+	"gin-doniai/utils"
+)
+
+const (
+	MaxAttempts     = 8                // 最多尝试次数，之后标记为失败，可手动重新投递
+	baseBackoff     = 30 * time.Second // 第一次重试的等待时间，之后每次翻倍
+	maxBackoff      = 6 * time.Hour
+	requestTimeout  = 10 * time.Second
+	responseBodyMax = 2000 // 保存的响应内容最大字符数
+	BatchSize       = 50   // 每轮最多处理的投递数
+)
+
+var client = &http.Client{Timeout: requestTimeout}
+
+// Backoff 第 attempts 次失败后距离下次重试的时间：30s、1m、2m…，最长6小时
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// ProcessDue 投递所有到期的记录，返回本轮处理的数量
+func (s *Service) ProcessDue() int {
+	deliveries, err := s.deliveries.Due(time.Now(), BatchSize)
+	if err != nil {
+		slog.Error("查询待投递的webhook失败", "error", err)
+		return 0
+	}
+
+	type lookup struct {
+		hook *models.Webhook
+		err  error
+	}
+	hooks := make(map[uint]lookup)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		found, ok := hooks[delivery.WebhookID]
+		if !ok {
+			found.hook, found.err = s.hooks.FindByID(delivery.WebhookID)
+			hooks[delivery.WebhookID] = found
+		}
+		switch {
+		case errors.Is(found.err, repositories.ErrNotFound):
+			s.deliver(nil, delivery)
+		case found.err != nil:
+			s.postpone(delivery, found.err)
+		default:
+			s.deliver(found.hook, delivery)
+		}
+	}
+	return len(deliveries)
+}
+
+// postpone 读取webhook失败（如数据库暂时不可用）时不计入尝试次数，按退避时间稍后再试
+func (s *Service) postpone(delivery *models.WebhookDelivery, err error) {
+	slog.Error("读取webhook失败，稍后重试投递", "webhook_id", delivery.WebhookID, "delivery_id", delivery.DeliveryID, "error", err)
+	delivery.NextAttemptAt = time.Now().Add(Backoff(delivery.Attempts + 1))
+	s.save(delivery)
+}
+
+// deliver 发送一次请求并记录结果，失败时按指数退避安排下次重试
+func (s *Service) deliver(hook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	if hook == nil || !hook.Active {
+		// webhook已删除或停用，不再重试
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = "webhook已删除或停用"
+		s.save(delivery)
+		return
+	}
+
+	start := time.Now()
+	status, body, err := send(hook, delivery)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = utils.Truncate(body, responseBodyMax)
+
+	if err == nil && status >= 200 && status < 300 {
+		now := time.Now()
+		delivery.Status = models.DeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+	} else {
+		if err != nil {
+			delivery.Error = utils.Truncate(err.Error(), 500)
+		} else {
+			delivery.Error = "响应状态码 " + strconv.Itoa(status)
+		}
+		if delivery.Attempts >= MaxAttempts {
+			delivery.Status = models.DeliveryStatusFailed
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(Backoff(delivery.Attempts))
+		}
+		slog.Warn("webhook投递失败", "attempts", delivery.Attempts, "event", delivery.Event, "url", hook.URL, "error", delivery.Error)
+	}
+	s.save(delivery)
+}
+
+func send(hook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Doniai-Webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.DeliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyMax*4))
+	return resp.StatusCode, string(respBody), nil
+}
+
+func (s *Service) save(delivery *models.WebhookDelivery) {
+	if err := s.deliveries.Save(delivery); err != nil {
+		slog.Error("保存webhook投递结果失败", "error", err)
+	}
+}
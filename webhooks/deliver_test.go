@@ -0,0 +1,192 @@
+package webhooks
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/repositories"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{"whsec_test", 1700000000, `{"id":"1"}`, "sha256=11bf4466ea17c3df3fd743af0b435368e16b7a05eb8eced85e8c4670767bdec5"},
+		{"secret", 1709294400, "hello", "sha256=5190852a30c9a073b67557bdf7c17a5f53c46ff733acafac4b3ca67f3043aed2"},
+		{"", 0, "", "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %d, %q) = %s, 期望 %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, 期望 %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// 以下为内存实现的仓储，只实现投递用到的方法，其余方法调用时会因嵌入的nil接口而panic
+
+type fakeHooks struct {
+	repositories.WebhookRepository
+	hooks map[uint]*models.Webhook
+	err   error // 不为nil时 FindByID 返回该错误，用于模拟数据库故障
+}
+
+func (f *fakeHooks) FindByID(id uint) (*models.Webhook, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	hook, ok := f.hooks[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return hook, nil
+}
+
+type fakeDeliveries struct {
+	repositories.WebhookDeliveryRepository
+	deliveries map[uint]*models.WebhookDelivery
+}
+
+func (f *fakeDeliveries) Due(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.Status == models.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *delivery)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeDeliveries) Save(delivery *models.WebhookDelivery) error {
+	copied := *delivery
+	f.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func newTestService(hook *models.Webhook, delivery *models.WebhookDelivery) (*Service, *fakeHooks, *fakeDeliveries) {
+	hooks := &fakeHooks{hooks: map[uint]*models.Webhook{hook.ID: hook}}
+	deliveries := &fakeDeliveries{deliveries: map[uint]*models.WebhookDelivery{delivery.ID: delivery}}
+	return NewService(hooks, deliveries), hooks, deliveries
+}
+
+func TestProcessDue(t *testing.T) {
+	var status int
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	newDelivery := func(attempts int) *models.WebhookDelivery {
+		return &models.WebhookDelivery{
+			ID:            1,
+			WebhookID:     1,
+			Event:         models.EventPostCreated,
+			DeliveryID:    "d-1",
+			Payload:       `{"id":"d-1"}`,
+			Status:        models.DeliveryStatusPending,
+			Attempts:      attempts,
+			NextAttemptAt: time.Now().Add(-time.Second),
+		}
+	}
+	hook := &models.Webhook{ID: 1, URL: server.URL, Secret: "whsec_test", Events: models.EventPostCreated, Active: true}
+
+	t.Run("成功", func(t *testing.T) {
+		status = http.StatusOK
+		svc, _, deliveries := newTestService(hook, newDelivery(0))
+		if n := svc.ProcessDue(); n != 1 {
+			t.Fatalf("ProcessDue() = %d, 期望 1", n)
+		}
+		got := deliveries.deliveries[1]
+		if got.Status != models.DeliveryStatusSucceeded || got.Attempts != 1 || got.DeliveredAt == nil || got.ResponseBody != "ok" {
+			t.Errorf("投递结果 = %+v, 期望成功", got)
+		}
+		if received.Header.Get(HeaderEvent) != models.EventPostCreated || received.Header.Get(HeaderDelivery) != "d-1" {
+			t.Errorf("请求头 = %v", received.Header)
+		}
+		timestamp, _ := strconv.ParseInt(received.Header.Get(HeaderTimestamp), 10, 64)
+		if want := Sign(hook.Secret, timestamp, receivedBody); received.Header.Get(HeaderSignature) != want {
+			t.Errorf("签名 = %s, 期望 %s", received.Header.Get(HeaderSignature), want)
+		}
+	})
+
+	t.Run("5xx后按退避重试", func(t *testing.T) {
+		status = http.StatusBadGateway
+		svc, _, deliveries := newTestService(hook, newDelivery(2))
+		before := time.Now()
+		svc.ProcessDue()
+		got := deliveries.deliveries[1]
+		if got.Status != models.DeliveryStatusPending || got.Attempts != 3 || got.ResponseStatus != http.StatusBadGateway {
+			t.Fatalf("投递结果 = %+v, 期望等待重试", got)
+		}
+		if got.NextAttemptAt.Before(before.Add(Backoff(3))) {
+			t.Errorf("下次投递时间 = %v, 期望不早于 %v", got.NextAttemptAt, before.Add(Backoff(3)))
+		}
+		// 未到重试时间，不会再次投递
+		if n := svc.ProcessDue(); n != 0 {
+			t.Errorf("ProcessDue() = %d, 期望 0", n)
+		}
+	})
+
+	t.Run("重试次数用完后失败", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		svc, _, deliveries := newTestService(hook, newDelivery(MaxAttempts-1))
+		svc.ProcessDue()
+		got := deliveries.deliveries[1]
+		if got.Status != models.DeliveryStatusFailed || got.Attempts != MaxAttempts || got.Error == "" {
+			t.Errorf("投递结果 = %+v, 期望失败", got)
+		}
+	})
+
+	t.Run("webhook已删除", func(t *testing.T) {
+		svc, hooks, deliveries := newTestService(hook, newDelivery(0))
+		delete(hooks.hooks, hook.ID)
+		svc.ProcessDue()
+		if got := deliveries.deliveries[1]; got.Status != models.DeliveryStatusFailed {
+			t.Errorf("投递结果 = %+v, 期望失败", got)
+		}
+	})
+
+	t.Run("读取webhook出错时保留投递", func(t *testing.T) {
+		svc, hooks, deliveries := newTestService(hook, newDelivery(0))
+		hooks.err = errors.New("database is locked")
+		before := time.Now()
+		svc.ProcessDue()
+		got := deliveries.deliveries[1]
+		if got.Status != models.DeliveryStatusPending || got.Attempts != 0 || got.NextAttemptAt.Before(before.Add(Backoff(1))) {
+			t.Errorf("投递结果 = %+v, 期望保留并稍后重试", got)
+		}
+	})
+}
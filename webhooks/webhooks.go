@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/pagination"
+	"gin-doniai/repositories"
+)
+
+// 投递请求头
+const (
+	HeaderEvent     = "X-Doniai-Event"
+	HeaderDelivery  = "X-Doniai-Delivery"
+	HeaderTimestamp = "X-Doniai-Timestamp"
+	HeaderSignature = "X-Doniai-Signature"
+)
+
+// 业务错误
+var (
+	ErrWebhookNotFound  = errors.New("webhook不存在")
+	ErrDeliveryNotFound = errors.New("投递记录不存在")
+)
+
+// Payload 发送给订阅方的请求体
+type Payload struct {
+	ID        string      `json:"id"` // 事件ID，重新投递时保持不变，接收方可据此去重
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Service webhook订阅管理、事件分发和投递
+type Service struct {
+	hooks      repositories.WebhookRepository
+	deliveries repositories.WebhookDeliveryRepository
+	// wake 有新的投递时唤醒worker，缓冲为1，多次通知会合并
+	wake chan struct{}
+}
+
+// NewService 基于仓储创建webhook服务
+func NewService(hooks repositories.WebhookRepository, deliveries repositories.WebhookDeliveryRepository) *Service {
+	return &Service{hooks: hooks, deliveries: deliveries, wake: make(chan struct{}, 1)}
+}
+
+// Wake 返回唤醒通道，供投递worker监听
+func (s *Service) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatch 为订阅了该事件的启用中的webhook创建投递记录，由worker异步发送
+// 投递记录先落库再发送，进程重启后未完成的投递会继续重试
+func (s *Service) Dispatch(event string, data interface{}) {
+	hooks, err := s.hooks.Active()
+	if err != nil {
+		slog.Error("查询webhook失败", "error", err)
+		return
+	}
+
+	created := 0
+	for _, hook := range hooks {
+		if !hook.Subscribes(event) {
+			continue
+		}
+		payload := Payload{ID: NewDeliveryID(), Event: event, CreatedAt: time.Now(), Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			slog.Error("webhook事件序列化失败", "event", event, "error", err)
+			return
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			DeliveryID:    payload.ID,
+			Payload:       string(body),
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := s.deliveries.Create(&delivery); err != nil {
+			slog.Error("创建webhook投递记录失败", "error", err)
+			continue
+		}
+		created++
+	}
+	if created > 0 {
+		s.notify()
+	}
+}
+
+// Redeliver 复制webhook的一条投递记录重新发送（生成新的投递ID），原记录保持不变
+func (s *Service) Redeliver(webhookID, id uint) (*models.WebhookDelivery, error) {
+	original, err := s.deliveries.FindForWebhook(id, webhookID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		DeliveryID:    NewDeliveryID(),
+		Payload:       original.Payload,
+		Status:        models.DeliveryStatusPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  original.ID,
+	}
+	if err := s.deliveries.Create(delivery); err != nil {
+		return nil, err
+	}
+	s.notify()
+	return delivery, nil
+}
+
+// List 全部webhook
+func (s *Service) List() ([]models.Webhook, error) {
+	return s.hooks.List()
+}
+
+// Get 获取webhook
+func (s *Service) Get(id uint) (*models.Webhook, error) {
+	hook, err := s.hooks.FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, err
+}
+
+// Create 生成签名密钥并创建webhook
+func (s *Service) Create(hook *models.Webhook) error {
+	hook.Secret = NewSecret()
+	return s.hooks.Create(hook)
+}
+
+// Update 按列修改webhook，rotateSecret 为 true 时重新生成签名密钥
+func (s *Service) Update(hook *models.Webhook, changes map[string]interface{}, rotateSecret bool) error {
+	if rotateSecret {
+		changes["secret"] = NewSecret()
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.hooks.Update(hook, changes)
+}
+
+// Delete 删除webhook，未完成的投递不再重试
+func (s *Service) Delete(hook *models.Webhook) error {
+	return s.hooks.Delete(hook)
+}
+
+// Deliveries 按分页参数查询webhook（包括已删除的）的投递记录
+func (s *Service) Deliveries(webhookID uint, query *pagination.Query) ([]models.WebhookDelivery, error) {
+	if _, err := s.hooks.FindWithDeleted(webhookID); errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.deliveries.ListForWebhook(webhookID, query)
+}
+
+// NewDeliveryID 生成投递ID
+func NewDeliveryID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewSecret 生成签名密钥
+func NewSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// Sign 计算签名：HMAC-SHA256(secret, timestamp + "." + body)，格式为 sha256=<hex>
+// 接收方应使用相同方式计算并用常量时间比较，同时拒绝时间戳过旧的请求以防重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"gin-doniai/services"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HandleAccountDeletions 定期执行冷静期已结束的账户注销
+// 每批使用新的链路，查询记录为批次span的子span
+func HandleAccountDeletions(ctx context.Context, svc *services.Services) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	processDueAccountDeletions(svc)
+	for {
+		select {
+		case <-ticker.C:
+			processDueAccountDeletions(svc)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func processDueAccountDeletions(svc *services.Services) {
+	ctx, span := startBatch("account-deletion.process")
+	defer span.End()
+	accounts := svc.WithContext(ctx).Accounts
+
+	due, err := accounts.DueDeletions()
+	if err != nil {
+		slog.Error("查询待注销账户失败", "error", err)
+		return
+	}
+	span.SetAttributes(attribute.Int("account_deletion.due", len(due)))
+
+	for _, deletion := range due {
+		// 事务成功后再删除导出文件
+		exports, err := accounts.Purge(&deletion)
+		for _, export := range exports {
+			removeExportFile(export)
+		}
+		if err != nil {
+			slog.Error("注销账户失败", "user_id", deletion.UserID, "error", err)
+			continue
+		}
+		slog.Info("账户已注销", "user_id", deletion.UserID)
+	}
+}
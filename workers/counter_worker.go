@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"gin-doniai/services"
+	"gin-doniai/tracing"
+	"time"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 冗余计数的校准间隔
+const counterReconcileInterval = time.Hour
+
+// HandleCounterReconciliation 定期按点赞、收藏和评论记录校准文章、评论上的计数
+func HandleCounterReconciliation(ctx context.Context, counters *services.CounterService) {
+	ticker := time.NewTicker(counterReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reconcileCounters(counters)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func reconcileCounters(counters *services.CounterService) {
+	_, span := startBatch("counters.reconcile")
+	report, err := counters.Reconcile()
+	span.SetAttributes(attribute.Int64("counters.posts", report.Posts), attribute.Int64("counters.comments", report.Comments))
+	tracing.End(span, err)
+	if err != nil {
+		slog.Error("校准点赞收藏回复计数失败", "error", err)
+		return
+	}
+	if report.Posts > 0 || report.Comments > 0 {
+		slog.Info("计数校准完成", "posts", report.Posts, "comments", report.Comments)
+	}
+}
@@ -0,0 +1,264 @@
+package workers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"gin-doniai/models"
+	"gin-doniai/services"
+	"gin-doniai/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExportDir 用户数据导出文件的存放目录
+var ExportDir = "exports"
+
+// HandleDataExports 异步生成用户数据导出包（ZIP，包含JSON和Markdown）
+// ctx 取消后不再接收新任务，未处理的任务保持待处理状态，下次启动时继续
+func HandleDataExports(ctx context.Context, accounts *services.AccountService, exportChan <-chan uint) {
+	// 启动时先处理上次未完成的任务
+	unfinished, err := accounts.UnfinishedExports()
+	if err != nil {
+		slog.Error("查询未完成的数据导出失败", "error", err)
+	}
+	for _, export := range unfinished {
+		processDataExport(accounts, export.ID)
+	}
+
+	cleanupTicker := time.NewTicker(time.Hour) // 定期清理过期导出文件
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case exportID := <-exportChan:
+			processDataExport(accounts, exportID)
+
+		case <-cleanupTicker.C:
+			cleanupExpiredExports(accounts)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func processDataExport(accounts *services.AccountService, exportID uint) {
+	_, span := startBatch("data-export.process", attribute.Int64("data_export.id", int64(exportID)))
+	defer span.End()
+
+	export, err := accounts.StartExport(exportID)
+	if errors.Is(err, services.ErrExportFinished) {
+		return
+	}
+	if err != nil {
+		slog.Warn("数据导出任务不存在", "export_id", exportID, "error", err)
+		return
+	}
+
+	if err := os.MkdirAll(ExportDir, 0o750); err != nil {
+		failDataExport(accounts, export, err)
+		return
+	}
+
+	filePath := filepath.Join(ExportDir, fmt.Sprintf("user-%d-export-%d-%s.zip", export.UserID, export.ID, time.Now().Format("20060102150405")))
+	if err := writeUserArchive(accounts, export.UserID, filePath); err != nil {
+		os.Remove(filePath)
+		failDataExport(accounts, export, err)
+		return
+	}
+
+	var size int64
+	if info, err := os.Stat(filePath); err == nil {
+		size = info.Size()
+	}
+
+	if err := accounts.FinishExport(export, filePath, size); err != nil {
+		slog.Error("保存数据导出结果失败", "export_id", export.ID, "error", err)
+	}
+}
+
+func failDataExport(accounts *services.AccountService, export *models.DataExport, err error) {
+	slog.Error("生成数据导出失败", "export_id", export.ID, "error", err)
+	if err := accounts.FailExport(export, err); err != nil {
+		slog.Error("保存数据导出状态失败", "export_id", export.ID, "error", err)
+	}
+}
+
+// writeUserArchive 把用户的个人资料、文章、评论、点赞（含评论点赞）和收藏写入ZIP
+func writeUserArchive(accounts *services.AccountService, userID uint, filePath string) error {
+	data, err := accounts.ExportArchive(userID)
+	if err != nil {
+		return fmt.Errorf("读取用户数据失败: %v", err)
+	}
+	user, posts, comments := data.User, data.Posts, data.Comments
+	likes, favorites, commentLikes := data.Likes, data.Favorites, data.CommentLikes
+
+	// 个人资料不包含密码等敏感字段
+	profile := map[string]interface{}{
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"avatar":         user.Avatar,
+		"age":            user.Age,
+		"level":          user.Level,
+		"motto":          user.Motto,
+		"github":         user.Github,
+		"google_account": user.GoogleAccount,
+		"created_at":     user.CreatedAt,
+		"updated_at":     user.UpdatedAt,
+	}
+
+	// 文章关联的User字段不需要导出
+	type exportPost struct {
+		ID         uint      `json:"id"`
+		Title      string    `json:"title"`
+		Category   string    `json:"category"`
+		CategoryId int       `json:"category_id"`
+		Content    string    `json:"content"`
+		Tags       []string  `json:"tags"`
+		Views      int       `json:"views"`
+		Replies    int       `json:"replies"`
+		Favorites  int       `json:"favorites"`
+		Likes      int       `json:"likes"`
+		ReadLimit  int       `json:"read_limit"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+	var postItems []exportPost
+	for _, post := range posts {
+		postItems = append(postItems, exportPost{
+			ID:         post.ID,
+			Title:      post.Title,
+			Category:   post.Category,
+			CategoryId: post.CategoryId,
+			Content:    post.Content,
+			Tags:       utils.ParseTags(post.Tags),
+			Views:      post.Views,
+			Replies:    post.Replies,
+			Favorites:  post.Favorites,
+			Likes:      post.Likes,
+			ReadLimit:  post.ReadLimit,
+			CreatedAt:  post.CreatedAt,
+			UpdatedAt:  post.UpdatedAt,
+		})
+	}
+
+	type exportComment struct {
+		ID        uint      `json:"id"`
+		PostID    uint      `json:"post_id"`
+		ParentID  uint      `json:"parent_id"`
+		Content   string    `json:"content"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	var commentItems []exportComment
+	for _, comment := range comments {
+		commentItems = append(commentItems, exportComment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			ParentID:  comment.ParentID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		})
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	jsonFiles := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"posts.json", postItems},
+		{"comments.json", commentItems},
+		{"likes.json", likes},
+		{"favorites.json", favorites},
+		{"comment_likes.json", commentLikes},
+	}
+	for _, item := range jsonFiles {
+		content, err := json.MarshalIndent(item.data, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeZipEntry(archive, item.name, content); err != nil {
+			return err
+		}
+	}
+
+	// 每篇文章额外导出为带front matter的Markdown
+	for _, post := range posts {
+		name := fmt.Sprintf("posts/%d.md", post.ID)
+		if err := writeZipEntry(archive, name, []byte(postMarkdown(post))); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeZipEntry(archive *zip.Writer, name string, content []byte) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
+
+// postMarkdown 生成带YAML front matter的Markdown文本
+func postMarkdown(post models.Post) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", post.Title)
+	fmt.Fprintf(&b, "author: %q\n", post.Author)
+	fmt.Fprintf(&b, "category: %q\n", post.Category)
+	tags := utils.ParseTags(post.Tags)
+	quoted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		quoted = append(quoted, fmt.Sprintf("%q", tag))
+	}
+	fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(quoted, ", "))
+	fmt.Fprintf(&b, "date: %s\n", post.CreatedAt.Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	b.WriteString(post.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// cleanupExpiredExports 删除过期的导出文件
+func cleanupExpiredExports(accounts *services.AccountService) {
+	expired, err := accounts.ExpiredExports()
+	if err != nil {
+		slog.Error("查询过期的数据导出失败", "error", err)
+		return
+	}
+	for _, export := range expired {
+		removeExportFile(export)
+		if err := accounts.DeleteExport(&export); err != nil {
+			slog.Error("删除导出记录失败", "export_id", export.ID, "error", err)
+		}
+	}
+}
+
+// removeExportFile 删除导出文件
+func removeExportFile(export models.DataExport) {
+	if export.FilePath == "" {
+		return
+	}
+	if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+		slog.Error("删除导出文件失败", "error", err)
+	}
+}
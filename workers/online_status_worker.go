@@ -1,8 +1,11 @@
 package workers
 
 import (
+	"context"
+	"log/slog"
 	"time"
-	"gin-doniai/handlers"
+	"gin-doniai/services"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type OnlineStatusUpdate struct {
@@ -11,7 +14,8 @@ type OnlineStatusUpdate struct {
 	UserAgent string
 }
 
-func HandleOnlineStatusUpdates(onlineStatusChan <-chan OnlineStatusUpdate) {
+// HandleOnlineStatusUpdates 批量写入在线状态，ctx 取消后写入通道中剩余的更新再退出
+func HandleOnlineStatusUpdates(ctx context.Context, online *services.OnlineService, onlineStatusChan <-chan OnlineStatusUpdate) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -25,24 +29,58 @@ func HandleOnlineStatusUpdates(onlineStatusChan <-chan OnlineStatusUpdate) {
 
 			// 达到批次大小时立即处理
 			if len(updates) >= batchSize {
-				processBatchOnlineStatus(updates)
+				processBatchOnlineStatus(online, updates)
 				updates = updates[:0] // 清空切片
 			}
 
 		case <-ticker.C:
 			// 定时处理剩余的更新
 			if len(updates) > 0 {
-				processBatchOnlineStatus(updates)
+				processBatchOnlineStatus(online, updates)
 				updates = updates[:0]
 			}
+
+		case <-ctx.Done():
+			for {
+				select {
+				case update := <-onlineStatusChan:
+					updates = append(updates, update)
+					continue
+				default:
+				}
+				break
+			}
+			if len(updates) > 0 {
+				processBatchOnlineStatus(online, updates)
+			}
+			return
 		}
 	}
 }
 
-func processBatchOnlineStatus(updates []OnlineStatusUpdate) {
+// HandleOnlineStatusCleanup 定期清理过期的在线状态
+func HandleOnlineStatusCleanup(ctx context.Context, online *services.OnlineService) {
+	ticker := time.NewTicker(10 * time.Minute) // 每10分钟清理一次
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := online.Cleanup(); err != nil {
+				slog.Error("清理过期在线状态失败", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func processBatchOnlineStatus(online *services.OnlineService, updates []OnlineStatusUpdate) {
+	_, span := startBatch("online-status.flush", attribute.Int("online_status.updates", len(updates)))
+	defer span.End()
 	for _, update := range updates {
-		// 创建模拟的 gin.Context 用于处理
-		// 或者创建新的批量处理方法
-		handlers.UpdateUserOnlineStatusWithInfo(update.UserID, update.IP, update.UserAgent)
+		if err := online.Touch(update.UserID, update.IP, update.UserAgent); err != nil {
+			slog.Error("写入在线状态失败", "user_id", update.UserID, "error", err)
+		}
 	}
 }
@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"gin-doniai/metrics"
+)
+
+// 队列名称，用作指标的 queue 标签
+const (
+	QueueOnlineStatus = "online_status"
+	QueueViewEvents   = "view_events"
+	QueueDataExports  = "data_exports"
+)
+
+// QueueDropped 各队列因通道已满被丢弃的消息数
+var QueueDropped = metrics.NewCounterVec("worker_queue_dropped_total", "后台队列已满被丢弃的消息数", "queue")
+
+// RegisterQueueMetrics 注册各后台队列的当前长度和容量指标，采集时读取通道状态
+func RegisterQueueMetrics(onlineStatus chan OnlineStatusUpdate, viewEvents chan ViewEvent, dataExports chan uint) {
+	metrics.GaugeFunc("worker_queue_length", "后台队列中等待处理的消息数", []string{"queue"}, func() []metrics.Sample {
+		return []metrics.Sample{
+			{Labels: []string{QueueOnlineStatus}, Value: float64(len(onlineStatus))},
+			{Labels: []string{QueueViewEvents}, Value: float64(len(viewEvents))},
+			{Labels: []string{QueueDataExports}, Value: float64(len(dataExports))},
+		}
+	})
+	metrics.GaugeFunc("worker_queue_capacity", "后台队列的缓冲容量", []string{"queue"}, func() []metrics.Sample {
+		return []metrics.Sample{
+			{Labels: []string{QueueOnlineStatus}, Value: float64(cap(onlineStatus))},
+			{Labels: []string{QueueViewEvents}, Value: float64(cap(viewEvents))},
+			{Labels: []string{QueueDataExports}, Value: float64(cap(dataExports))},
+		}
+	})
+}
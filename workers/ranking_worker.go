@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"gin-doniai/ranking"
+	"gin-doniai/tracing"
+	"gorm.io/gorm"
+)
+
+// HandleRankingUpdates 启动时计算一次热门和Top榜单，之后每5分钟重新计算
+func HandleRankingUpdates(ctx context.Context, service *ranking.Service, db *gorm.DB) {
+	recomputeRanking(service, db)
+
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			recomputeRanking(service, db)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func recomputeRanking(service *ranking.Service, db *gorm.DB) {
+	ctx, span := startBatch("ranking.recompute")
+	err := service.Recompute(db.WithContext(ctx))
+	if err != nil {
+		slog.Error("计算文章榜单失败", "error", err)
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"gin-doniai/caches"
+	"gin-doniai/recommend"
+	"gin-doniai/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// HandleRelatedPostsUpdates 启动时和之后每30分钟重新计算相关文章，完成后失效缓存
+func HandleRelatedPostsUpdates(ctx context.Context, db *gorm.DB) {
+	rebuildRelatedPosts(db)
+
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rebuildRelatedPosts(db)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func rebuildRelatedPosts(db *gorm.DB) {
+	start := time.Now()
+	ctx, span := startBatch("related-posts.rebuild")
+	count, err := recommend.Rebuild(db.WithContext(ctx))
+	span.SetAttributes(attribute.Int("related_posts.count", count))
+	tracing.End(span, err)
+	if err != nil {
+		slog.Error("计算相关文章失败", "error", err)
+		return
+	}
+	caches.Default().InvalidatePrefix(caches.KeyRelatedPostsPrefix)
+	slog.Info("相关文章计算完成", "count", count, "elapsed_ms", time.Since(start).Milliseconds())
+}
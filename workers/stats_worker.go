@@ -0,0 +1,45 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"gin-doniai/stats"
+	"gin-doniai/tracing"
+	"time"
+	"gorm.io/gorm"
+)
+
+// HandleStatsReconciliation 定期刷新在线人数、从数据库校准计数并保存每日快照
+func HandleStatsReconciliation(ctx context.Context, service *stats.Service, db *gorm.DB) {
+	onlineTicker := time.NewTicker(time.Minute)         // 每分钟刷新在线人数
+	reconcileTicker := time.NewTicker(10 * time.Minute) // 每10分钟校准一次计数
+	defer onlineTicker.Stop()
+	defer reconcileTicker.Stop()
+
+	for {
+		select {
+		case <-onlineTicker.C:
+			if err := service.RefreshOnline(db); err != nil {
+				slog.Error("刷新在线人数失败", "error", err)
+			}
+
+		case <-reconcileTicker.C:
+			reconcileStats(service, db)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func reconcileStats(service *stats.Service, db *gorm.DB) {
+	ctx, span := startBatch("stats.reconcile")
+	db = db.WithContext(ctx)
+	err := service.Reconcile(db)
+	if err != nil {
+		slog.Error("校准社区统计失败", "error", err)
+	} else if err = service.SaveDailySnapshot(db); err != nil {
+		slog.Error("保存统计快照失败", "error", err)
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,17 @@
+package workers
+
+import (
+	"context"
+	"gin-doniai/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startBatch 为一次批处理创建新链路的根span
+// 不继承worker的ctx：退出时ctx已取消，最后一批写入仍需要执行
+func startBatch(name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(context.Background(), name,
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...))
+}
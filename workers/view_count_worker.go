@@ -1,11 +1,16 @@
 package workers
 
 import (
+    "context"
     "fmt"
+    "log/slog"
     "time"
-    "gin-doniai/database"
     "gin-doniai/models"
+    "gin-doniai/utils"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/trace"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 )
 
 type ViewEvent struct {
@@ -14,46 +19,108 @@ type ViewEvent struct {
     IP        string
     UserAgent string
     Timestamp time.Time
+    Trace     trace.SpanContext // 产生浏览的请求所在链路，批量写入的span通过link关联回这些请求
 }
 
-func HandleViewNumUpdates(viewChan chan ViewEvent) {
-    // 使用map记录用户/IP对文章的访问时间
-    viewRecords := make(map[string]time.Time)
+const (
+    viewDedupWindow    = 60 * time.Second // 同一访客60秒内重复浏览只计一次
+    viewFlushInterval  = 10 * time.Second // 浏览数批量写入间隔
+    viewFlushBatchSize = 500              // 待写入的访客数达到该值时立即写入
+    maxViewFlushLinks  = 128              // 一次批量写入最多关联的请求链路数
+)
+
+// EnqueueViewEvent 非阻塞地投递浏览事件，爬虫访问直接忽略；通道已满时丢弃并计数
+func EnqueueViewEvent(viewChan chan<- ViewEvent, event ViewEvent) bool {
+    if event.PostID == 0 || utils.IsBotUserAgent(event.UserAgent) {
+        return false
+    }
+
+    select {
+    case viewChan <- event:
+        return true
+    default:
+        QueueDropped.Inc(QueueViewEvents)
+        slog.Warn("浏览事件通道已满，丢弃事件", "post_id", event.PostID)
+        return false
+    }
+}
+
+// viewKey 某访客某天对某篇文章的浏览，日期只用来决定计入哪一天的 PostViewStat
+type viewKey struct {
+    PostID  uint
+    Date    string
+    Visitor string
+}
+
+// dedupKey 去重时不区分日期，跨零点刷新页面同样在去重窗口内只计一次
+type dedupKey struct {
+    PostID  uint
+    Visitor string
+}
+
+// pendingView 尚未写入数据库的浏览
+type pendingView struct {
+    views     int64
+    firstView time.Time
+    lastView  time.Time
+}
+
+// visitorHash 登录用户按用户ID识别，游客按IP+UA识别，只保存哈希
+func visitorHash(event ViewEvent) string {
+    if event.UserID != nil {
+        return utils.HashToken(fmt.Sprintf("user:%d", *event.UserID))
+    }
+    return utils.HashToken("guest:" + event.IP + "|" + event.UserAgent)
+}
+
+// HandleViewNumUpdates 合并浏览事件并定期批量写入文章浏览数和每日统计
+// ctx 取消后处理完通道中剩余的事件，写入全部浏览数再退出
+func HandleViewNumUpdates(ctx context.Context, db *gorm.DB, viewChan <-chan ViewEvent) {
+    // 使用map记录访客对文章的最近计数时间
+    viewRecords := make(map[dedupKey]time.Time)
+    pending := make(map[viewKey]*pendingView)
+    var links []trace.Link // 本批浏览事件来自的请求链路
+
+    flushTicker := time.NewTicker(viewFlushInterval)
     cleanupTicker := time.NewTicker(10 * time.Minute) // 定期清理过期记录
+    defer flushTicker.Stop()
     defer cleanupTicker.Stop()
 
     for {
         select {
         case event := <-viewChan:
-            // 生成唯一的访问标识符
-            var key string
-            if event.UserID != nil {
-                key = fmt.Sprintf("user:%d:post:%d", *event.UserID, event.PostID)
-            } else {
-                key = fmt.Sprintf("ip:%s:post:%d", event.IP, event.PostID)
+            recordView(viewRecords, pending, event)
+            links = appendViewLink(links, event)
+            if len(pending) >= viewFlushBatchSize {
+                pending = flushPendingViews(db, pending, links)
+                links = nil
             }
 
-            // 检查是否在60秒内已经记录过
-            if lastViewTime, exists := viewRecords[key]; exists {
-                if time.Since(lastViewTime) < 60*time.Second {
-                    // 60秒内已记录过，跳过
+        case <-ctx.Done():
+            for {
+                select {
+                case event := <-viewChan:
+                    recordView(viewRecords, pending, event)
+                    links = appendViewLink(links, event)
                     continue
+                default:
                 }
+                break
             }
+            if failed := flushPendingViews(db, pending, links); len(failed) > 0 {
+                slog.Error("退出前写入浏览数失败，丢失访客记录", "visitors", len(failed))
+            }
+            return
 
-            // 更新记录时间
-            viewRecords[key] = event.Timestamp
-
-            // 更新文章浏览数
-            if err := database.DB.Model(&models.Post{}).
-                Where("id = ?", event.PostID).
-                Update("views", gorm.Expr("views + ?", 1)).Error; err != nil {
-                fmt.Printf("更新文章浏览数失败: %v\n", err)
+        case <-flushTicker.C:
+            if len(pending) > 0 {
+                pending = flushPendingViews(db, pending, links)
+                links = nil
             }
 
         case <-cleanupTicker.C:
             // 清理60秒前的记录
-            cutoffTime := time.Now().Add(-60 * time.Second)
+            cutoffTime := time.Now().Add(-viewDedupWindow)
             for key, viewTime := range viewRecords {
                 if viewTime.Before(cutoffTime) {
                     delete(viewRecords, key)
@@ -63,4 +130,156 @@ func HandleViewNumUpdates(viewChan chan ViewEvent) {
     }
 }
 
+// recordView 记录一次浏览，同一访客60秒内的重复浏览忽略
+func recordView(viewRecords map[dedupKey]time.Time, pending map[viewKey]*pendingView, event ViewEvent) {
+    visitor := visitorHash(event)
+
+    // 检查是否在60秒内已经记录过
+    dedup := dedupKey{PostID: event.PostID, Visitor: visitor}
+    if lastViewTime, exists := viewRecords[dedup]; exists && event.Timestamp.Sub(lastViewTime) < viewDedupWindow {
+        return
+    }
+    viewRecords[dedup] = event.Timestamp
+
+    key := viewKey{
+        PostID:  event.PostID,
+        Date:    event.Timestamp.Format("2006-01-02"),
+        Visitor: visitor,
+    }
+
+    view, exists := pending[key]
+    if !exists {
+        view = &pendingView{firstView: event.Timestamp}
+        pending[key] = view
+    }
+    view.views++
+    view.lastView = event.Timestamp
+}
+
+// appendViewLink 记录浏览事件来自的请求链路，未采样的请求和超过上限的部分忽略
+func appendViewLink(links []trace.Link, event ViewEvent) []trace.Link {
+    if !event.Trace.IsSampled() || len(links) >= maxViewFlushLinks {
+        return links
+    }
+    return append(links, trace.Link{SpanContext: event.Trace})
+}
+
+// flushPendingViews 按文章和日期分组写入数据库，返回写入失败、需要下次重试的浏览
+// 整批写入记录为一个span，links 关联产生这些浏览的请求
+func flushPendingViews(db *gorm.DB, pending map[viewKey]*pendingView, links []trace.Link) map[viewKey]*pendingView {
+    ctx, span := startBatch("view-count.flush", attribute.Int("view_count.visitors", len(pending)))
+    for _, link := range links {
+        span.AddLink(link)
+    }
+    defer span.End()
 
+    type postDay struct {
+        PostID uint
+        Date   string
+    }
+    groups := make(map[postDay]map[string]*pendingView)
+    for key, view := range pending {
+        day := postDay{PostID: key.PostID, Date: key.Date}
+        if groups[day] == nil {
+            groups[day] = make(map[string]*pendingView)
+        }
+        groups[day][key.Visitor] = view
+    }
+
+    failed := make(map[viewKey]*pendingView)
+    for day, visitors := range groups {
+        if err := flushPostDayViews(db.WithContext(ctx), day.PostID, day.Date, visitors); err != nil {
+            slog.Error("写入文章浏览统计失败", "post_id", day.PostID, "error", err)
+            span.RecordError(err)
+            for visitor, view := range visitors {
+                failed[viewKey{PostID: day.PostID, Date: day.Date, Visitor: visitor}] = view
+            }
+        }
+    }
+    return failed
+}
+
+// flushPostDayViews 在一个事务中写入某篇文章某天的访客、每日统计和文章浏览数
+func flushPostDayViews(db *gorm.DB, postID uint, date string, visitors map[string]*pendingView) error {
+    hashes := make([]string, 0, len(visitors))
+    for hash := range visitors {
+        hashes = append(hashes, hash)
+    }
+
+    return db.Transaction(func(tx *gorm.DB) error {
+        var existing []models.PostViewVisitor
+        if err := tx.Where("post_id = ? AND date = ? AND visitor_hash IN ?", postID, date, hashes).
+            Find(&existing).Error; err != nil {
+            return err
+        }
+
+        var views int64
+        for _, view := range visitors {
+            views += view.views
+        }
+
+        seen := make(map[string]bool, len(existing))
+        for _, record := range existing {
+            seen[record.VisitorHash] = true
+            view := visitors[record.VisitorHash]
+            // 重启后内存中的去重记录丢失，用数据库中的最近浏览时间补充判断
+            if view.firstView.Sub(record.LastViewAt) < viewDedupWindow {
+                views--
+            }
+            if err := tx.Model(&models.PostViewVisitor{}).Where("id = ?", record.ID).
+                Update("last_view_at", view.lastView).Error; err != nil {
+                return err
+            }
+        }
+
+        var newVisitors []models.PostViewVisitor
+        for hash, view := range visitors {
+            if !seen[hash] {
+                newVisitors = append(newVisitors, models.PostViewVisitor{
+                    PostID:      postID,
+                    Date:        date,
+                    VisitorHash: hash,
+                    LastViewAt:  view.lastView,
+                })
+            }
+        }
+
+        var uniqueVisitors int64
+        if len(newVisitors) > 0 {
+            result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&newVisitors, 100)
+            if result.Error != nil {
+                return result.Error
+            }
+            uniqueVisitors = result.RowsAffected
+        }
+
+        if views <= 0 && uniqueVisitors == 0 {
+            return nil
+        }
+
+        stat := models.PostViewStat{
+            PostID:         postID,
+            Date:           date,
+            Views:          views,
+            UniqueVisitors: uniqueVisitors,
+        }
+        if err := tx.Clauses(clause.OnConflict{
+            Columns: []clause.Column{{Name: "post_id"}, {Name: "date"}},
+            DoUpdates: clause.Assignments(map[string]interface{}{
+                "views":           gorm.Expr("post_view_stats.views + ?", views),
+                "unique_visitors": gorm.Expr("post_view_stats.unique_visitors + ?", uniqueVisitors),
+                "updated_at":      time.Now(),
+            }),
+        }).Create(&stat).Error; err != nil {
+            return err
+        }
+
+        if views <= 0 {
+            return nil
+        }
+        // 更新文章浏览数
+        return tx.Model(&models.Post{}).
+            Where("id = ?", postID).
+            Update("views", gorm.Expr("views + ?", views)).Error
+    })
+}
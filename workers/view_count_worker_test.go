@@ -0,0 +1,74 @@
+package workers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordViewDedup(t *testing.T) {
+	userID := uint(7)
+	beforeMidnight := time.Date(2024, 3, 1, 23, 59, 40, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		events []ViewEvent
+		want   map[string]int64 // 日期 -> 计入的浏览数
+	}{
+		{
+			name: "窗口内重复浏览只计一次",
+			events: []ViewEvent{
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight.Add(-30 * time.Second)},
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight},
+			},
+			want: map[string]int64{"2024-03-01": 1},
+		},
+		{
+			name: "跨零点刷新仍在窗口内",
+			events: []ViewEvent{
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight},
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight.Add(30 * time.Second)},
+			},
+			want: map[string]int64{"2024-03-01": 1},
+		},
+		{
+			name: "超过窗口后计入新的一天",
+			events: []ViewEvent{
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight},
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight.Add(viewDedupWindow)},
+			},
+			want: map[string]int64{"2024-03-01": 1, "2024-03-02": 1},
+		},
+		{
+			name: "不同访客分别计数",
+			events: []ViewEvent{
+				{PostID: 1, UserID: &userID, Timestamp: beforeMidnight},
+				{PostID: 1, IP: "10.0.0.1", UserAgent: "Firefox", Timestamp: beforeMidnight},
+				{PostID: 1, IP: "10.0.0.2", UserAgent: "Firefox", Timestamp: beforeMidnight},
+			},
+			want: map[string]int64{"2024-03-01": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viewRecords := make(map[dedupKey]time.Time)
+			pending := make(map[viewKey]*pendingView)
+			for _, event := range tt.events {
+				recordView(viewRecords, pending, event)
+			}
+
+			got := make(map[string]int64)
+			for key, view := range pending {
+				got[key.Date] += view.views
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("按日期统计 = %v, 期望 %v", got, tt.want)
+			}
+			for date, views := range tt.want {
+				if got[date] != views {
+					t.Errorf("%s 浏览数 = %d, 期望 %d", date, got[date], views)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package workers
+
+import (
+	"context"
+	"time"
+	"gin-doniai/webhooks"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HandleWebhookDeliveries 投递webhook事件：有新事件时立即处理，另外每15秒检查一次到期的重试
+func HandleWebhookDeliveries(ctx context.Context, hooks *webhooks.Service) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hooks.Wake():
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		// 一轮处理满一批时继续处理，直到没有到期的投递；退出时不再开始新的一批
+		for processWebhooks(hooks) == webhooks.BatchSize && ctx.Err() == nil {
+		}
+	}
+}
+
+func processWebhooks(hooks *webhooks.Service) int {
+	_, span := startBatch("webhooks.deliver")
+	processed := hooks.ProcessDue()
+	span.SetAttributes(attribute.Int("webhook.deliveries", processed))
+	span.End()
+	return processed
+}